@@ -1,4 +1,4 @@
 #!/bin/sh 
 set -e
-go test -cover -tags unit ./...
+go test -race -cover -tags unit ./...
 golangci-lint run
@@ -16,6 +16,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,6 +24,7 @@ import (
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
 // +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=".status.conditions[?(@.type==\"Policy\")].status",description=""
 // +kubebuilder:printcolumn:name="Key",type="string",JSONPath=".status.conditions[?(@.type==\"Key\")].status",description=""
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
@@ -53,12 +55,353 @@ type AttesterSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	// PgpSecret defines the name of the secret to use for signing. If the secret doesn't already exist it will be created.
+	// Defaults to the attester's name, unless the key is imported with PgpSecretRef or stored in Vault.
 	// +optional
-	PgpSecret string `json:"pgpSecret"`
-	// Policy defines the Rego policy that the attester will attest adherance to.
-	Policy string `json:"policy"`
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	PgpSecret string `json:"pgpSecret,omitempty"`
+
+	// PgpSecretKey is the key in the PgpSecret's data that the signing key is read from and written to. Defaults to keys.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	PgpSecretKey string `json:"pgpSecretKey,omitempty"`
+
+	// PgpSecretNamespace is the namespace of the PgpSecret. Defaults to the attester's namespace. A secret in another
+	// namespace can only be referenced by users that are allowed to get and create secrets in it, and it isn't
+	// garbage collected with the attester since owner references can't cross namespaces.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	PgpSecretNamespace string `json:"pgpSecretNamespace,omitempty"`
+
+	// PgpSecretRef references a key in a secret in the attester's namespace that contains a private key managed outside
+	// of rode, such as by a KMS pipeline. The key is used as it is, it's never generated, rotated or deleted, and the
+	// attester fails to load if the key is missing or invalid. PgpSecret must not be set with PgpSecretRef.
+	// +optional
+	PgpSecretRef *corev1.SecretKeySelector `json:"pgpSecretRef,omitempty"`
+
+	// VaultPath is the path of a secret in the controller's Vault KV version 2 mount that the attester's key is stored
+	// in instead of a Kubernetes secret. The key is generated and written to the path if it doesn't exist, and deleted
+	// when the attester is deleted. PgpSecret, PgpSecretRef and the options that only apply to a Kubernetes secret must
+	// not be set with VaultPath.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[-_.a-zA-Z0-9]+(/[-_.a-zA-Z0-9]+)*$`
+	VaultPath string `json:"vaultPath,omitempty"`
+
+	// KeyType is the type of key generated for the attester when its secret doesn't exist. Keys that already exist are
+	// used whatever their type.
+	// +optional
+	KeyType KeyType `json:"keyType,omitempty"`
+
+	// PgpKeyBits is the size of the RSA key generated for the attester when its key type is pgp-rsa. Defaults to 2048.
+	// Keys smaller than 2048 bits are rejected unless AllowInsecurePgpParameters is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=1024
+	// +kubebuilder:validation:Maximum=8192
+	PgpKeyBits int `json:"pgpKeyBits,omitempty"`
+
+	// PgpHashAlgorithm is the hash that the PGP key generated for the attester signs with. It's stored in the key as
+	// its preferred hash, so changing it only affects keys generated afterwards. Defaults to SHA256. SHA1 is rejected
+	// unless AllowInsecurePgpParameters is set.
+	// +optional
+	PgpHashAlgorithm HashAlgorithm `json:"pgpHashAlgorithm,omitempty"`
+
+	// AllowInsecurePgpParameters allows PgpKeyBits and PgpHashAlgorithm to be set to values that aren't secure, such
+	// as SHA1, for verifiers that don't support stronger ones. They're still rejected in FIPS mode.
+	// +optional
+	AllowInsecurePgpParameters bool `json:"allowInsecurePgpParameters,omitempty"`
+
+	// SignatureFormat is the format attestations are signed in. With pgp, the default, the attestation's signature is
+	// the key's signed message. With dsse it's a DSSE envelope whose signature is over the PAE encoding of the payload.
+	// +optional
+	SignatureFormat SignatureFormat `json:"signatureFormat,omitempty"`
+
+	// PgpPassphraseSecretRef references a key in a secret in the attester's namespace that contains the passphrase for
+	// an encrypted PGP private key. The passphrase is only held in memory.
+	// +optional
+	PgpPassphraseSecretRef *corev1.SecretKeySelector `json:"pgpPassphraseSecretRef,omitempty"`
+	// Policy defines the Rego policy that the attester will attest adherance to. One of Policy, Policies,
+	// PolicyConfigMapRef or PolicyGitRepository must be set.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Policy string `json:"policy,omitempty"`
+
+	// Policies are the Rego modules of the attester's policy keyed by their names, such as helpers.rego, which are
+	// compiled together so that the modules can import each other
+	// +optional
+	Policies map[string]string `json:"policies,omitempty"`
+
+	// PolicyConfigMapRef references a ConfigMap in the attester's namespace whose data entries are the Rego modules of
+	// the attester's policy, compiled together. The policy is recompiled when the ConfigMap changes.
+	// +optional
+	PolicyConfigMapRef *corev1.LocalObjectReference `json:"policyConfigMapRef,omitempty"`
+
+	// PolicyGitRepository is a Git repository whose Rego modules at a path are the attester's policy, compiled
+	// together. The repository is pulled on an interval and the policy is recompiled when its commit changes.
+	// +optional
+	PolicyGitRepository *PolicyGitRepository `json:"policyGitRepository,omitempty"`
+
+	// PolicyQuery is the rule of the policy that's evaluated for violations, such as data.shared.strict_violation, so
+	// that attesters can share a policy module that defines several rule sets. It must refer to a partial set rule
+	// defined by the policy. Defaults to the violation rule in the package named after the attester.
+	// +optional
+	PolicyQuery string `json:"policyQuery,omitempty"`
+
+	// RegoVersion is the version of the Rego syntax that the attester's policy is written in. Only v0, the syntax parsed
+	// by the bundled OPA, is supported, and v1 policies using the if and contains keywords are rejected until OPA is
+	// upgraded. Defaults to v0.
+	// +optional
+	RegoVersion RegoVersion `json:"regoVersion,omitempty"`
+
+	// Stage is embedded in the attester's attestations so that enforcers can require an attestation for a specific
+	// stage, such as prod, when the same image is promoted through several stages.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Stage string `json:"stage,omitempty"`
+
+	// NoteKinds are the kinds of occurrence that get a note of their own. Attestations triggered by an occurrence of one
+	// of the kinds are stored under the kind's note, and all other attestations under the attester's default note.
+	// +optional
+	NoteKinds []NoteKind `json:"noteKinds,omitempty"`
+
+	// Note configures the Grafeas note that the attester's attestations are stored under. Defaults to a note in the rode
+	// project named after the attester's namespace and name.
+	// +optional
+	Note *Note `json:"note,omitempty"`
+
+	// SecretDeletionGracePeriod is how long the generated key secret is kept after the attester is deleted. The secret
+	// is kept if an attester with the same name is created within the period.
+	// +optional
+	SecretDeletionGracePeriod *metav1.Duration `json:"secretDeletionGracePeriod,omitempty"`
+
+	// PolicyEvalBudget overrides the controller's default limit on the time the attester can spend evaluating its
+	// policy in each budget window
+	// +optional
+	PolicyEvalBudget *metav1.Duration `json:"policyEvalBudget,omitempty"`
+
+	// RequireSignedInput rejects the occurrences that aren't vouched for by an attestation signed by one of the
+	// InputSigners, leaving them out of the policy's input
+	// +optional
+	RequireSignedInput bool `json:"requireSignedInput,omitempty"`
+
+	// InputSigners reference keys in secrets in the attester's namespace that contain the armored PGP public keys
+	// trusted to sign input occurrences
+	// +optional
+	InputSigners []corev1.SecretKeySelector `json:"inputSigners,omitempty"`
+
+	// CoSigners reference keys in secrets in the attester's namespace that contain the armored PGP public keys of the
+	// other signers, such as other attesters, whose attestations of a resource count toward RequiredSignatures
+	// +optional
+	CoSigners []corev1.SecretKeySelector `json:"coSigners,omitempty"`
+
+	// RequiredSignatures is the number of distinct keys, counting the attester's own, that must have signed the same
+	// attestation body for one of the attester's attestations to be valid. Defaults to 1, the attester's own signature.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RequiredSignatures int `json:"requiredSignatures,omitempty"`
+
+	// KeyEscrow splits the private key generated for the attester into shares held in separate secrets, so that the
+	// key can be recovered from a quorum of the shares. Keys that already exist aren't escrowed.
+	// +optional
+	KeyEscrow *KeyEscrow `json:"keyEscrow,omitempty"`
+
+	// PolicyMigration evaluates the previous policy alongside Policy while tightening it. Until the window starts only
+	// the old policy's violations block attestation, during the window only violations reported by both policies
+	// block it, and once the window ends only Policy is used.
+	// +optional
+	PolicyMigration *PolicyMigration `json:"policyMigration,omitempty"`
+
+	// KeyRotation replaces the attester's generated key once it's older than the rotation interval
+	// +optional
+	KeyRotation *KeyRotation `json:"keyRotation,omitempty"`
+
+	// InputTransform adapts each occurrence before it's added to the policy's input, so that policies don't depend on
+	// the schema of a particular source
+	// +optional
+	InputTransform *InputTransform `json:"inputTransform,omitempty"`
+
+	// PayloadFormat is the format of the occurrences sent to the attester's verification API, which are decoded into
+	// Grafeas occurrences before they're evaluated. Defaults to json.
+	// +optional
+	PayloadFormat PayloadFormat `json:"payloadFormat,omitempty"`
+
+	// Suspend stops the attester from attesting while keeping the attester and its secret, until it's set to false
+	// again. Attestations it already made are left alone.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// KeyRotation configures rotating an attester's generated key. Keys that weren't generated by the controller aren't
+// rotated.
+type KeyRotation struct {
+	// RotateAfter is the age at which the key is replaced with a new one
+	RotateAfter metav1.Duration `json:"rotateAfter"`
+
+	// GracePeriod is how long the public key that was replaced is kept in the status, and still accepted when verifying
+	// attestations. Defaults to RotateAfter.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// InputTransform transforms an occurrence into the document the policy sees. Exactly one of Rego or Mapping must be set.
+type InputTransform struct {
+	// Rego is a module defining a transform rule, which is evaluated with the occurrence as input and must be an object
+	// +optional
+	Rego string `json:"rego,omitempty"`
+
+	// Mapping maps fields of the transformed document to dot separated paths in the occurrence, such as
+	// vulnerability.severity. Fields whose path isn't in the occurrence are left out.
+	// +optional
+	Mapping map[string]string `json:"mapping,omitempty"`
+}
+
+// PolicyMigration configures the window for migrating from an old policy to the attester's policy
+type PolicyMigration struct {
+	// OldPolicy is the Rego policy being migrated from
+	OldPolicy string `json:"oldPolicy"`
+
+	// Start is when violations of the new policy start blocking attestation if the old policy reports them too
+	Start metav1.Time `json:"start"`
+
+	// End is when the old policy stops being evaluated
+	End metav1.Time `json:"end"`
+}
+
+// KeyEscrow configures escrowing an attester's private key with Shamir's secret sharing
+type KeyEscrow struct {
+	// Threshold is the number of shares needed to recover the key
+	// +kubebuilder:validation:Minimum=2
+	Threshold int `json:"threshold"`
+
+	// Secrets are the secrets the shares are written to, one share to each secret. Secrets without a namespace are
+	// written to the attester's namespace.
+	// +kubebuilder:validation:MinItems=2
+	Secrets []corev1.SecretReference `json:"secrets"`
+}
+
+// PolicyGitRepository is a Git repository that an attester's policy is read from
+type PolicyGitRepository struct {
+	// URL is the URL of the repository, which is fetched over https or ssh
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// Ref is the branch, tag or commit that the policy is read from. Defaults to the repository's default branch.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Path is the directory of the repository whose Rego modules, including those in its subdirectories, are the
+	// policy. Modules whose names end in _test.rego are left out. Defaults to the root of the repository.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// PollInterval is how often the repository is pulled for new commits. Defaults to 5m.
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+}
+
+// Note configures an attester's Grafeas note. The notes of its note kinds are named after it. A note that already
+// exists is used as it is, rather than being recreated.
+type Note struct {
+	// Name is the ID of the note. Defaults to the attester's namespace and name joined with a dot.
+	// +optional
+	// +kubebuilder:validation:MaxLength=100
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`
+	Name string `json:"name,omitempty"`
+
+	// Project is the ID of the Grafeas project the note is created in, which is created if it doesn't exist. Defaults
+	// to rode.
+	// +optional
+	// +kubebuilder:validation:MaxLength=100
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`
+	Project string `json:"project,omitempty"`
+
+	// Description is the short description of the note
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Authority is the human readable name of the note's attestation authority. Defaults to the note's ID.
+	// +optional
+	Authority string `json:"authority,omitempty"`
 }
 
+// NoteKind is a kind of Grafeas occurrence
+// +kubebuilder:validation:Enum=VULNERABILITY;BUILD;IMAGE;PACKAGE;DEPLOYMENT;DISCOVERY
+type NoteKind string
+
+// KeyType is a type of signing key
+// +kubebuilder:validation:Enum=pgp-rsa;pgp-ecdsa-p256;ed25519
+type KeyType string
+
+const (
+	// KeyTypePGPRSA is a PGP key with an RSA primary key, the default
+	KeyTypePGPRSA KeyType = "pgp-rsa"
+
+	// KeyTypePGPECDSAP256 is a PGP key with an ECDSA primary key on the NIST P-256 curve
+	KeyTypePGPECDSAP256 KeyType = "pgp-ecdsa-p256"
+
+	// KeyTypeEd25519 is an Ed25519 key, stored as a PKCS #8 PEM block rather than a PGP key
+	KeyTypeEd25519 KeyType = "ed25519"
+)
+
+// HashAlgorithm is a hash that PGP keys sign with
+// +kubebuilder:validation:Enum=SHA1;SHA256;SHA384;SHA512
+type HashAlgorithm string
+
+const (
+	// HashAlgorithmSHA1 is SHA-1, which isn't secure and is only allowed with AllowInsecurePgpParameters
+	HashAlgorithmSHA1 HashAlgorithm = "SHA1"
+
+	// HashAlgorithmSHA256 is SHA-256, the default
+	HashAlgorithmSHA256 HashAlgorithm = "SHA256"
+
+	// HashAlgorithmSHA384 is SHA-384
+	HashAlgorithmSHA384 HashAlgorithm = "SHA384"
+
+	// HashAlgorithmSHA512 is SHA-512
+	HashAlgorithmSHA512 HashAlgorithm = "SHA512"
+)
+
+// SignatureFormat is a format that attestations are signed in
+// +kubebuilder:validation:Enum=pgp;dsse
+type SignatureFormat string
+
+const (
+	// SignatureFormatPGP signs attestations as messages signed by the key, the default
+	SignatureFormatPGP SignatureFormat = "pgp"
+
+	// SignatureFormatDSSE signs attestations as DSSE (Dead Simple Signing Envelope) envelopes
+	SignatureFormatDSSE SignatureFormat = "dsse"
+)
+
+// PayloadFormat is a format that occurrences are sent to an attester in
+// +kubebuilder:validation:Enum=json;yaml;protobuf
+type PayloadFormat string
+
+const (
+	// PayloadFormatJSON is the JSON form of a Grafeas occurrence, the default
+	PayloadFormatJSON PayloadFormat = "json"
+
+	// PayloadFormatYAML is the YAML form of a Grafeas occurrence, sent as a string
+	PayloadFormatYAML PayloadFormat = "yaml"
+
+	// PayloadFormatProtobuf is the binary protobuf encoding of a Grafeas occurrence, sent as a base64 string
+	PayloadFormatProtobuf PayloadFormat = "protobuf"
+)
+
+// RegoVersion is a version of the Rego syntax
+// +kubebuilder:validation:Enum=v0;v1
+type RegoVersion string
+
+const (
+	// RegoVersionV0 is the syntax from before OPA 1.0, where rule bodies don't need the if keyword and partial set rules
+	// are defined without the contains keyword, the default
+	RegoVersionV0 RegoVersion = "v0"
+
+	// RegoVersionV1 is the syntax of OPA 1.0, where the if and contains keywords are required
+	RegoVersionV1 RegoVersion = "v1"
+)
+
 // AttesterStatus defines the observed state of Attester
 type AttesterStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -66,6 +409,131 @@ type AttesterStatus struct {
 
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of the spec that the attester was last loaded for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// PolicyVersion is the version of the policy that the loaded attester evaluates, a hash of its modules and query.
+	// It only changes once a new policy has compiled and the attester has been loaded with it, so while a changed policy
+	// fails to compile it's the version of the policy that's still being evaluated.
+	// +optional
+	PolicyVersion string `json:"policyVersion,omitempty"`
+
+	// PolicyCommit is the commit of the policy Git repository that the loaded policy was read from
+	// +optional
+	PolicyCommit string `json:"policyCommit,omitempty"`
+
+	// NoteNames are the names of the Grafeas notes the attester stores attestations under
+	// +optional
+	NoteNames []string `json:"noteNames,omitempty"`
+
+	// AttestedSubjects is the number of subjects the attester currently has a valid attestation for
+	// +optional
+	AttestedSubjects int `json:"attestedSubjects,omitempty"`
+
+	// AttestationsSigned is the number of attestations the attester has signed. Like AttestationsRejected, it's updated
+	// periodically rather than on every attestation.
+	// +optional
+	AttestationsSigned int64 `json:"attestationsSigned,omitempty"`
+
+	// AttestationsRejected is the number of resources the attester has refused to attest because of violations of its
+	// policy
+	// +optional
+	AttestationsRejected int64 `json:"attestationsRejected,omitempty"`
+
+	// LastAttestationTime is when the attester last signed or rejected an attestation
+	// +optional
+	LastAttestationTime *metav1.Time `json:"lastAttestationTime,omitempty"`
+
+	// EvalThrottled is set while the attester has exceeded its policy evaluation budget and refuses to evaluate
+	// +optional
+	EvalThrottled bool `json:"evalThrottled,omitempty"`
+
+	// KeyID is the ID of the key the attester currently signs with
+	// +optional
+	KeyID string `json:"keyId,omitempty"`
+
+	// PgpSecret is the name of the secret that the attester's generated key was last loaded from, which is the spec's
+	// PgpSecret or the attester's name when it isn't set
+	// +optional
+	PgpSecret string `json:"pgpSecret,omitempty"`
+
+	// PublicKey is the public key the attester currently signs with, armored for PGP keys or PEM encoded for Ed25519
+	// keys, so that attestations can be verified without access to the attester's secret
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// PreviousPublicKeys are the public keys the attester signed with before its key was rotated, until their grace
+	// period has passed
+	// +optional
+	PreviousPublicKeys []PreviousPublicKey `json:"previousPublicKeys,omitempty"`
+
+	// Timings contains the duration of the most recent parse, compile and evaluation of the policy. It is only
+	// populated when the controller is started with policy timings enabled.
+	// +optional
+	Timings *PolicyTimings `json:"timings,omitempty"`
+
+	// SecretRetry is set while the attester's secret is being retried after transient API errors, and cleared once the
+	// secret is loaded
+	// +optional
+	SecretRetry *SecretRetry `json:"secretRetry,omitempty"`
+
+	// LastViolations are the violations of the most recent evaluation that rejected a resource, so that rejections can
+	// be diagnosed without tracing the policy. They're kept when later resources are attested.
+	// +optional
+	LastViolations *LastViolations `json:"lastViolations,omitempty"`
+}
+
+// LastViolations describes the violations of a policy evaluation that rejected a resource. The list of messages is
+// capped, as is the length of each message.
+type LastViolations struct {
+	// ResourceURI is the resource that was rejected
+	ResourceURI string `json:"resourceUri"`
+
+	// Time is when the resource was rejected
+	Time metav1.Time `json:"time"`
+
+	// Messages are the messages of the violations
+	Messages []string `json:"messages"`
+
+	// Omitted is the number of violations left out of Messages because of the cap
+	// +optional
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// SecretRetry describes the backoff of retrying the attester's secret after transient API errors
+type SecretRetry struct {
+	// Attempts is the number of consecutive transient errors
+	Attempts int32 `json:"attempts"`
+
+	// Backoff is how long the controller waits before retrying
+	Backoff metav1.Duration `json:"backoff"`
+
+	// LastError is the most recent transient error
+	LastError string `json:"lastError,omitempty"`
+}
+
+// PreviousPublicKey is a public key that an attester's key was rotated from
+type PreviousPublicKey struct {
+	// KeyID is the ID of the key
+	KeyID string `json:"keyId"`
+
+	// PublicKey is the armored PGP public key, or the PEM encoded public key for Ed25519 keys
+	PublicKey string `json:"publicKey"`
+
+	// RotatedAt is when the key was replaced
+	RotatedAt metav1.Time `json:"rotatedAt"`
+
+	// ExpiresAt is when the key is removed from the status and stops being accepted
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// PolicyTimings describes how long each phase of handling a policy took
+type PolicyTimings struct {
+	Parse   metav1.Duration `json:"parse,omitempty"`
+	Compile metav1.Duration `json:"compile,omitempty"`
+	Eval    metav1.Duration `json:"eval,omitempty"`
 }
 
 func init() {
@@ -75,3 +543,32 @@ func init() {
 func (a *Attester) GetConditions() []Condition {
 	return a.Status.Conditions
 }
+
+// GetPgpSecretNamespace returns the namespace of the attester's PgpSecret, defaulting to the attester's namespace when
+// unset
+func (a *Attester) GetPgpSecretNamespace() string {
+	if a.Spec.PgpSecretNamespace == "" {
+		return a.Namespace
+	}
+	return a.Spec.PgpSecretNamespace
+}
+
+// GetPgpSecret returns the name of the secret holding the attester's generated key, defaulting to the attester's name
+// when unset. Attesters whose key is imported or stored in Vault don't have one, so an empty string is returned for them.
+func (a *Attester) GetPgpSecret() string {
+	if a.Spec.PgpSecretRef != nil || a.Spec.VaultPath != "" {
+		return ""
+	}
+	if a.Spec.PgpSecret == "" {
+		return a.Name
+	}
+	return a.Spec.PgpSecret
+}
+
+// GetPgpSecretKey returns the key in the PgpSecret's data that holds the signing key, defaulting to keys when unset
+func (s AttesterSpec) GetPgpSecretKey() string {
+	if s.PgpSecretKey == "" {
+		return "keys"
+	}
+	return s.PgpSecretKey
+}
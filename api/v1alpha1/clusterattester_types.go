@@ -0,0 +1,125 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterAttesterSpec defines the desired state of ClusterAttester. It's a subset of the AttesterSpec, since a cluster
+// attester's policy and key can't be read from a namespace of its own.
+type ClusterAttesterSpec struct {
+	// Policy defines the Rego policy that the attester will attest adherance to. One of Policy or Policies must be set.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Policy string `json:"policy,omitempty"`
+
+	// Policies are the Rego modules of the attester's policy keyed by their names, such as helpers.rego, which are
+	// compiled together so that the modules can import each other
+	// +optional
+	Policies map[string]string `json:"policies,omitempty"`
+
+	// PolicyQuery is the rule of the policy that's evaluated for violations, such as data.shared.strict_violation.
+	// Defaults to the violation rule in the package named after the attester.
+	// +optional
+	PolicyQuery string `json:"policyQuery,omitempty"`
+
+	// PgpSecret is the name of the secret in the controller's cluster attester secret namespace that the signing key is
+	// read from. If the secret doesn't exist it will be created. Defaults to the attester's name.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	PgpSecret string `json:"pgpSecret,omitempty"`
+
+	// KeyType is the type of key generated for the attester when its secret doesn't exist
+	// +optional
+	KeyType KeyType `json:"keyType,omitempty"`
+
+	// PgpKeyBits is the size of the RSA key generated for the attester when its key type is pgp-rsa. Defaults to 2048.
+	// +optional
+	// +kubebuilder:validation:Minimum=1024
+	// +kubebuilder:validation:Maximum=8192
+	PgpKeyBits int `json:"pgpKeyBits,omitempty"`
+
+	// PgpHashAlgorithm is the hash that the PGP key generated for the attester signs with. Defaults to SHA256.
+	// +optional
+	PgpHashAlgorithm HashAlgorithm `json:"pgpHashAlgorithm,omitempty"`
+
+	// AllowInsecurePgpParameters allows PgpKeyBits and PgpHashAlgorithm to be set to values that aren't secure
+	// +optional
+	AllowInsecurePgpParameters bool `json:"allowInsecurePgpParameters,omitempty"`
+
+	// SignatureFormat is the format attestations are signed in. Defaults to pgp.
+	// +optional
+	SignatureFormat SignatureFormat `json:"signatureFormat,omitempty"`
+
+	// Stage is embedded in the attester's attestations so that enforcers can require an attestation for a specific
+	// stage
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Stage string `json:"stage,omitempty"`
+}
+
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+// +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=".status.conditions[?(@.type==\"Policy\")].status",description=""
+// +kubebuilder:printcolumn:name="Key",type="string",JSONPath=".status.conditions[?(@.type==\"Key\")].status",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+
+// ClusterAttester is the Schema for the clusterattesters API. It's an attester shared by every namespace, whose signing
+// key is stored in the controller's namespace.
+type ClusterAttester struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterAttesterSpec `json:"spec,omitempty"`
+	Status AttesterStatus      `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterAttesterList contains a list of ClusterAttester
+type ClusterAttesterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterAttester `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterAttester{}, &ClusterAttesterList{})
+}
+
+func (a *ClusterAttester) GetConditions() []Condition {
+	return a.Status.Conditions
+}
+
+// AttesterSpec returns the spec of a namespaced attester with the same policy and key parameters
+func (s ClusterAttesterSpec) AttesterSpec() AttesterSpec {
+	return AttesterSpec{
+		Policy:                     s.Policy,
+		Policies:                   s.Policies,
+		PolicyQuery:                s.PolicyQuery,
+		PgpSecret:                  s.PgpSecret,
+		KeyType:                    s.KeyType,
+		PgpKeyBits:                 s.PgpKeyBits,
+		PgpHashAlgorithm:           s.PgpHashAlgorithm,
+		AllowInsecurePgpParameters: s.AllowInsecurePgpParameters,
+		SignatureFormat:            s.SignatureFormat,
+		Stage:                      s.Stage,
+	}
+}
@@ -39,6 +39,11 @@ type ClusterEnforcerSpec struct {
 	Namespaces    []string            `json:"namespaces,omitempty"`
 	MatchStrategy MatchStrategy       `json:"matchStrategy,omitempty"`
 	Attesters     []*EnforcerAttester `json:"attesters"`
+
+	// RequiredWeight is the total weight of attesters that must have attested an image for it to be allowed.
+	// Defaults to the weight of all attesters, requiring every attester to pass.
+	// +optional
+	RequiredWeight int `json:"requiredWeight,omitempty"`
 }
 
 // ClusterEnforcerStatus defines the observed state of ClusterEnforcer
@@ -14,7 +14,12 @@ type Condition struct {
 	Type               ConditionType   `json:"type"`
 	Status             ConditionStatus `json:"status"`
 	LastTransitionTime *metav1.Time    `json:"lastTransitionTime,omitempty"`
-	Message            string          `json:"message,omitempty"`
+
+	// Reason is a CamelCase reason for the condition's last update
+	Reason string `json:"reason,omitempty"`
+
+	// Message describes the condition's last update, such as the error that caused it to be False
+	Message string `json:"message,omitempty"`
 }
 
 type ConditionType string
@@ -23,4 +28,10 @@ const (
 	ConditionActive   ConditionType = "Active"
 	ConditionCompiled ConditionType = "Policy"
 	ConditionSecret   ConditionType = "Key"
+
+	// ConditionReady is True when both an attester's Policy and Key conditions are True, so that it's attesting
+	ConditionReady ConditionType = "Ready"
+
+	// ConditionSuspended is True while an attester is suspended, which also keeps its Ready condition False
+	ConditionSuspended ConditionType = "Suspended"
 )
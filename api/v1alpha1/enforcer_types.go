@@ -27,6 +27,11 @@ type EnforcerSpec struct {
 
 	// Foo is an example field of Enforcer. Edit Enforcer_types.go to remove/update
 	Attesters []*EnforcerAttester `json:"attesters"`
+
+	// RequiredWeight is the total weight of attesters that must have attested an image for it to be allowed.
+	// Defaults to the weight of all attesters, requiring every attester to pass.
+	// +optional
+	RequiredWeight int `json:"requiredWeight,omitempty"`
 }
 
 // EnforcerStatus defines the observed state of Enforcer
@@ -63,8 +68,33 @@ func init() {
 type EnforcerAttester struct {
 	Namespace string `json:"namespace"`
 	Name      string `json:"name"`
+
+	// Weight is the weight this attester contributes towards the required weight when it passes. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Weight int `json:"weight,omitempty"`
+
+	// Stage requires the attestation to have been made for the stage. Attestations for any stage are accepted when unset.
+	// +optional
+	Stage string `json:"stage,omitempty"`
 }
 
 func (ea EnforcerAttester) String() string {
 	return fmt.Sprintf("%s/%s", ea.Namespace, ea.Name)
 }
+
+// Description returns the attester's name, along with the stage it's required for
+func (ea EnforcerAttester) Description() string {
+	if ea.Stage == "" {
+		return ea.String()
+	}
+	return fmt.Sprintf("%s (stage %s)", ea.String(), ea.Stage)
+}
+
+// GetWeight returns the weight of the attester, defaulting to 1 when unset
+func (ea EnforcerAttester) GetWeight() int {
+	if ea.Weight <= 0 {
+		return 1
+	}
+	return ea.Weight
+}
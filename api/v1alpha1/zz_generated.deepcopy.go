@@ -20,6 +20,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -28,7 +30,7 @@ func (in *Attester) DeepCopyInto(out *Attester) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -85,6 +87,87 @@ func (in *AttesterList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AttesterSpec) DeepCopyInto(out *AttesterSpec) {
 	*out = *in
+	if in.PgpSecretRef != nil {
+		in, out := &in.PgpSecretRef, &out.PgpSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PgpPassphraseSecretRef != nil {
+		in, out := &in.PgpPassphraseSecretRef, &out.PgpPassphraseSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PolicyConfigMapRef != nil {
+		in, out := &in.PolicyConfigMapRef, &out.PolicyConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.PolicyGitRepository != nil {
+		in, out := &in.PolicyGitRepository, &out.PolicyGitRepository
+		*out = new(PolicyGitRepository)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NoteKinds != nil {
+		in, out := &in.NoteKinds, &out.NoteKinds
+		*out = make([]NoteKind, len(*in))
+		copy(*out, *in)
+	}
+	if in.Note != nil {
+		in, out := &in.Note, &out.Note
+		*out = new(Note)
+		**out = **in
+	}
+	if in.SecretDeletionGracePeriod != nil {
+		in, out := &in.SecretDeletionGracePeriod, &out.SecretDeletionGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.PolicyEvalBudget != nil {
+		in, out := &in.PolicyEvalBudget, &out.PolicyEvalBudget
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.InputSigners != nil {
+		in, out := &in.InputSigners, &out.InputSigners
+		*out = make([]v1.SecretKeySelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CoSigners != nil {
+		in, out := &in.CoSigners, &out.CoSigners
+		*out = make([]v1.SecretKeySelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.KeyEscrow != nil {
+		in, out := &in.KeyEscrow, &out.KeyEscrow
+		*out = new(KeyEscrow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PolicyMigration != nil {
+		in, out := &in.PolicyMigration, &out.PolicyMigration
+		*out = new(PolicyMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InputTransform != nil {
+		in, out := &in.InputTransform, &out.InputTransform
+		*out = new(InputTransform)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AttesterSpec.
@@ -107,6 +190,37 @@ func (in *AttesterStatus) DeepCopyInto(out *AttesterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.NoteNames != nil {
+		in, out := &in.NoteNames, &out.NoteNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastAttestationTime != nil {
+		in, out := &in.LastAttestationTime, &out.LastAttestationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PreviousPublicKeys != nil {
+		in, out := &in.PreviousPublicKeys, &out.PreviousPublicKeys
+		*out = make([]PreviousPublicKey, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timings != nil {
+		in, out := &in.Timings, &out.Timings
+		*out = new(PolicyTimings)
+		**out = **in
+	}
+	if in.SecretRetry != nil {
+		in, out := &in.SecretRetry, &out.SecretRetry
+		*out = new(SecretRetry)
+		**out = **in
+	}
+	if in.LastViolations != nil {
+		in, out := &in.LastViolations, &out.LastViolations
+		*out = new(LastViolations)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AttesterStatus.
@@ -119,6 +233,87 @@ func (in *AttesterStatus) DeepCopy() *AttesterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAttester) DeepCopyInto(out *ClusterAttester) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAttester.
+func (in *ClusterAttester) DeepCopy() *ClusterAttester {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAttester)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAttester) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAttesterList) DeepCopyInto(out *ClusterAttesterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterAttester, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAttesterList.
+func (in *ClusterAttesterList) DeepCopy() *ClusterAttesterList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAttesterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAttesterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAttesterSpec) DeepCopyInto(out *ClusterAttesterSpec) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAttesterSpec.
+func (in *ClusterAttesterSpec) DeepCopy() *ClusterAttesterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAttesterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterEnforcer) DeepCopyInto(out *ClusterEnforcer) {
 	*out = *in
@@ -266,6 +461,21 @@ func (in *CollectorECRConfig) DeepCopy() *CollectorECRConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CollectorHarborConfig) DeepCopyInto(out *CollectorHarborConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorHarborConfig.
+func (in *CollectorHarborConfig) DeepCopy() *CollectorHarborConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CollectorHarborConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CollectorList) DeepCopyInto(out *CollectorList) {
 	*out = *in
@@ -302,6 +512,7 @@ func (in *CollectorList) DeepCopyObject() runtime.Object {
 func (in *CollectorSpec) DeepCopyInto(out *CollectorSpec) {
 	*out = *in
 	out.ECR = in.ECR
+	out.Harbor = in.Harbor
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CollectorSpec.
@@ -469,3 +680,190 @@ func (in *EnforcerStatus) DeepCopy() *EnforcerStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputTransform) DeepCopyInto(out *InputTransform) {
+	*out = *in
+	if in.Mapping != nil {
+		in, out := &in.Mapping, &out.Mapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputTransform.
+func (in *InputTransform) DeepCopy() *InputTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(InputTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyEscrow) DeepCopyInto(out *KeyEscrow) {
+	*out = *in
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]v1.SecretReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyEscrow.
+func (in *KeyEscrow) DeepCopy() *KeyEscrow {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyEscrow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRotation) DeepCopyInto(out *KeyRotation) {
+	*out = *in
+	out.RotateAfter = in.RotateAfter
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRotation.
+func (in *KeyRotation) DeepCopy() *KeyRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LastViolations) DeepCopyInto(out *LastViolations) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Messages != nil {
+		in, out := &in.Messages, &out.Messages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LastViolations.
+func (in *LastViolations) DeepCopy() *LastViolations {
+	if in == nil {
+		return nil
+	}
+	out := new(LastViolations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Note) DeepCopyInto(out *Note) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Note.
+func (in *Note) DeepCopy() *Note {
+	if in == nil {
+		return nil
+	}
+	out := new(Note)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyGitRepository) DeepCopyInto(out *PolicyGitRepository) {
+	*out = *in
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyGitRepository.
+func (in *PolicyGitRepository) DeepCopy() *PolicyGitRepository {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyGitRepository)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyMigration) DeepCopyInto(out *PolicyMigration) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyMigration.
+func (in *PolicyMigration) DeepCopy() *PolicyMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTimings) DeepCopyInto(out *PolicyTimings) {
+	*out = *in
+	out.Parse = in.Parse
+	out.Compile = in.Compile
+	out.Eval = in.Eval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTimings.
+func (in *PolicyTimings) DeepCopy() *PolicyTimings {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTimings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviousPublicKey) DeepCopyInto(out *PreviousPublicKey) {
+	*out = *in
+	in.RotatedAt.DeepCopyInto(&out.RotatedAt)
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviousPublicKey.
+func (in *PreviousPublicKey) DeepCopy() *PreviousPublicKey {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviousPublicKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretRetry) DeepCopyInto(out *SecretRetry) {
+	*out = *in
+	out.Backoff = in.Backoff
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRetry.
+func (in *SecretRetry) DeepCopy() *SecretRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretRetry)
+	in.DeepCopyInto(out)
+	return out
+}
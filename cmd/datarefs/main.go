@@ -0,0 +1,90 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// datarefs lists the Attesters whose policies reference a document under data, to assess the impact of changing it
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func main() {
+	var path string
+	var namespace string
+	flag.StringVar(&path, "path", "", "The data document to find references to, such as data.limits.high.")
+	flag.StringVar(&namespace, "namespace", "", "The namespace to list Attesters from. Defaults to all namespaces.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(func(o *zap.Options) {
+		o.Development = true
+	}))
+	log := ctrl.Log.WithName("datarefs")
+
+	if path == "" {
+		log.Error(fmt.Errorf("missing required flags"), "-path must be set")
+		os.Exit(1)
+	}
+
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		log.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	attesters := &rodev1alpha1.AttesterList{}
+	err = c.List(context.Background(), attesters, client.InNamespace(namespace))
+	if err != nil {
+		log.Error(err, "unable to list attesters")
+		os.Exit(1)
+	}
+
+	for _, line := range findReferences(attesters.Items, path) {
+		fmt.Println(line)
+	}
+}
+
+// findReferences returns a line for each reference to the data document in the attesters' policies, along with a line
+// for each policy that couldn't be analyzed
+func findReferences(attesters []rodev1alpha1.Attester, path string) []string {
+	lines := make([]string, 0)
+
+	for _, att := range attesters {
+		refs, err := attester.FindDataReferences(att.Name, att.Spec.Policy, path)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s/%s: unable to analyze policy: %v", att.Namespace, att.Name, err))
+			continue
+		}
+
+		for _, ref := range refs {
+			lines = append(lines, fmt.Sprintf("%s/%s: %s", att.Namespace, att.Name, ref))
+		}
+	}
+
+	return lines
+}
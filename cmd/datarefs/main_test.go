@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+func TestFindReferences(t *testing.T) {
+	assert := assert.New(t)
+
+	attesters := []rodev1alpha1.Attester{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "limits"},
+			Spec:       rodev1alpha1.AttesterSpec{Policy: "package limits\nviolation[{\"msg\":\"limit\"}]{\n\tcount(input.occurrences) > data.limits.max\n}"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"},
+			Spec:       rodev1alpha1.AttesterSpec{Policy: "package other\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "invalid"},
+			Spec:       rodev1alpha1.AttesterSpec{Policy: "package invalid\nviolation["},
+		},
+	}
+
+	lines := findReferences(attesters, "data.limits")
+	assert.Len(lines, 2)
+	assert.Equal("default/limits: data.limits.max (line 3)", lines[0])
+	assert.Contains(lines[1], "default/invalid: unable to analyze policy")
+}
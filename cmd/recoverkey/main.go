@@ -0,0 +1,106 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// recoverkey recovers an attester's escrowed private key from a quorum of its key shares. Each share is read from a
+// file containing the decoded "share" key of an escrow secret. The recovered key is checked before it's written, and
+// the recovery is logged with the key's ID for the audit trail.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/shamir"
+)
+
+func main() {
+	var sharePaths string
+	var out string
+	flag.StringVar(&sharePaths, "shares", "", "A comma separated list of paths to the key shares.")
+	flag.StringVar(&out, "out", "", "The path to write the recovered key to. The file must not already exist.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(func(o *zap.Options) {
+		o.Development = true
+	}))
+	log := ctrl.Log.WithName("recoverkey")
+
+	if sharePaths == "" || out == "" {
+		log.Error(fmt.Errorf("missing required flags"), "both -shares and -out must be set")
+		os.Exit(1)
+	}
+
+	paths := strings.Split(sharePaths, ",")
+	keys, keyID, err := recoverKey(paths)
+	if err != nil {
+		log.Error(err, "unable to recover key", "shares", len(paths))
+		os.Exit(1)
+	}
+
+	if err := writeKey(out, keys); err != nil {
+		log.Error(err, "unable to write recovered key", "out", out)
+		os.Exit(1)
+	}
+
+	log.Info("recovered escrowed key", "keyID", keyID, "shares", paths, "out", out)
+}
+
+// recoverKey combines the shares in the files and returns the recovered key and its ID
+func recoverKey(paths []string) ([]byte, string, error) {
+	shares := make([][]byte, len(paths))
+	for i, path := range paths {
+		share, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		shares[i] = share
+	}
+
+	keys, err := shamir.Combine(shares)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// too few shares combine to garbage rather than an error, so check that the result is a key
+	signer, err := attester.ReadSigner(bytes.NewReader(keys))
+	if err != nil {
+		return nil, "", fmt.Errorf("the shares don't recover a valid key, more may be required: %v", err)
+	}
+
+	return keys, signer.KeyID(), nil
+}
+
+// writeKey writes the key to a new file that only the current user can read
+func writeKey(path string, keys []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(keys); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
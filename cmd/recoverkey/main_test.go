@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/shamir"
+)
+
+func TestRecoverKey(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "recoverkey")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	signer, err := attester.NewSigner("escrowed")
+	assert.NoError(err)
+	buf := &bytes.Buffer{}
+	assert.NoError(signer.Serialize(buf))
+
+	shares, err := shamir.Split(buf.Bytes(), 3, 2)
+	assert.NoError(err)
+
+	var paths []string
+	for i, share := range shares {
+		path := filepath.Join(dir, fmt.Sprintf("share-%d", i))
+		assert.NoError(ioutil.WriteFile(path, share, 0600))
+		paths = append(paths, path)
+	}
+
+	keys, keyID, err := recoverKey([]string{paths[2], paths[0]})
+	assert.NoError(err)
+	assert.Equal(buf.Bytes(), keys)
+	assert.Equal(signer.KeyID(), keyID)
+
+	out := filepath.Join(dir, "recovered.asc")
+	assert.NoError(writeKey(out, keys))
+	assert.Error(writeKey(out, keys))
+
+	shares, err = shamir.Split(buf.Bytes(), 3, 3)
+	assert.NoError(err)
+	for i, share := range shares {
+		assert.NoError(ioutil.WriteFile(paths[i], share, 0600))
+	}
+
+	_, _, err = recoverKey(paths[:2])
+	assert.Error(err)
+
+	_, _, err = recoverKey([]string{filepath.Join(dir, "missing")})
+	assert.Error(err)
+}
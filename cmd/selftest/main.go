@@ -0,0 +1,50 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// selftest checks that the crypto provider can generate keys, sign and verify, and optionally that FIPS mode is active
+package main
+
+import (
+	"flag"
+	"os"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func main() {
+	var fips bool
+	flag.BoolVar(&fips, "fips", false, "Enable FIPS mode and fail if it can't be activated.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(func(o *zap.Options) {
+		o.Development = true
+	}))
+	log := ctrl.Log.WithName("selftest")
+
+	if err := attester.SetFIPSMode(fips); err != nil {
+		log.Error(err, "unable to set FIPS mode")
+		os.Exit(1)
+	}
+
+	if err := attester.SelfTest(fips); err != nil {
+		log.Error(err, "selftest failed", "fipsCapable", attester.FIPSCapable(), "fipsMode", attester.FIPSMode())
+		os.Exit(1)
+	}
+
+	log.Info("selftest passed", "fipsCapable", attester.FIPSCapable(), "fipsMode", attester.FIPSMode())
+}
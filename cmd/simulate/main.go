@@ -0,0 +1,325 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// simulate reconciles a directory of Attester manifests against an in-memory client and reports how this version of
+// the controller would handle them, without a cluster. Secrets, ConfigMaps and any other objects in the directory are
+// loaded too, so existing signer secrets and passphrases are used the same way they would be in a cluster. It exits
+// with a non-zero status if any attester fails to load, which makes it suitable for checking manifests before an
+// upgrade or in CI.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/controllers"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+const (
+	// SecretDecisionCreate means the controller would generate a new signer secret
+	SecretDecisionCreate = "create"
+	// SecretDecisionReuse means the controller would load the signer from an existing secret
+	SecretDecisionReuse = "reuse"
+	// SecretDecisionNone means the controller stopped before it reached the signer secret
+	SecretDecisionNone = "none"
+
+	// maxReconciles is the number of times an attester is reconciled before giving up on it being loaded. The
+	// controller returns after each status update, so loading an attester takes several reconciles.
+	maxReconciles = 10
+)
+
+// Outcome is the result of reconciling an Attester manifest
+type Outcome struct {
+	Attester       string                       `json:"attester"`
+	Compiled       rodev1alpha1.ConditionStatus `json:"compiled"`
+	Secret         string                       `json:"secret,omitempty"`
+	SecretStatus   rodev1alpha1.ConditionStatus `json:"secretStatus"`
+	SecretDecision string                       `json:"secretDecision"`
+	Loaded         bool                         `json:"loaded"`
+	Message        string                       `json:"message,omitempty"`
+}
+
+func main() {
+	var dir string
+	var namespace string
+	var jsonOutput bool
+	var verbose bool
+	flag.StringVar(&dir, "dir", "", "The directory containing the Attester manifests to simulate.")
+	flag.StringVar(&namespace, "namespace", "default", "The namespace of manifests that don't set one.")
+	flag.BoolVar(&jsonOutput, "json", false, "Print the outcomes as JSON.")
+	flag.BoolVar(&verbose, "v", false, "Log each reconcile.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(func(o *zap.Options) {
+		o.Development = true
+	}))
+	log := ctrl.Log.WithName("simulate")
+
+	if dir == "" {
+		log.Error(fmt.Errorf("missing required flags"), "-dir must be set")
+		os.Exit(1)
+	}
+
+	var reconcileLog logr.Logger = logf.NullLogger{}
+	if verbose {
+		reconcileLog = ctrl.Log.WithName("controllers").WithName("Attester")
+	}
+
+	outcomes, err := simulate(reconcileLog, dir, namespace)
+	if err != nil {
+		log.Error(err, "unable to simulate", "dir", dir)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		err = json.NewEncoder(os.Stdout).Encode(outcomes)
+	} else {
+		err = printOutcomes(os.Stdout, outcomes)
+	}
+	if err != nil {
+		log.Error(err, "unable to print outcomes")
+		os.Exit(1)
+	}
+
+	for _, outcome := range outcomes {
+		if !outcome.Loaded {
+			os.Exit(1)
+		}
+	}
+}
+
+// simulate loads the manifests in dir into a fake client and reconciles each Attester until it's loaded or the
+// controller gives up on it
+func simulate(log logr.Logger, dir, namespace string) ([]*Outcome, error) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = rodev1alpha1.AddToScheme(scheme)
+
+	objs, err := loadManifests(scheme, dir, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var attesters []*rodev1alpha1.Attester
+	secrets := make(map[types.NamespacedName]bool)
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *rodev1alpha1.Attester:
+			attesters = append(attesters, o)
+		case *corev1.Secret:
+			secrets[types.NamespacedName{Namespace: o.Namespace, Name: o.Name}] = true
+		}
+	}
+
+	sort.Slice(attesters, func(i, j int) bool {
+		return attesters[i].Namespace+"/"+attesters[i].Name < attesters[j].Namespace+"/"+attesters[j].Name
+	})
+
+	r := &controllers.AttesterReconciler{
+		Client:      fake.NewFakeClientWithScheme(scheme, objs...),
+		Log:         log,
+		Scheme:      scheme,
+		Attesters:   make(map[string]attester.Attester),
+		KeyStrength: &attester.DefaultKeyStrengthPolicy,
+	}
+
+	outcomes := make([]*Outcome, 0, len(attesters))
+	for _, att := range attesters {
+		outcomes = append(outcomes, reconcile(r, att, secrets))
+	}
+
+	return outcomes, nil
+}
+
+// reconcile reconciles the attester until it's loaded, the controller returns an error, or the controller asks to
+// retry later
+func reconcile(r *controllers.AttesterReconciler, att *rodev1alpha1.Attester, secrets map[types.NamespacedName]bool) *Outcome {
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: att.Namespace, Name: att.Name}
+	outcome := &Outcome{
+		Attester:       key.String(),
+		SecretDecision: SecretDecisionNone,
+	}
+
+	var reconcileErr error
+	for i := 0; i < maxReconciles; i++ {
+		result, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+		if err != nil {
+			reconcileErr = err
+			break
+		}
+
+		if _, ok := r.ListAttesters()[key.String()]; ok {
+			outcome.Loaded = true
+			break
+		}
+
+		if result.RequeueAfter > 0 {
+			reconcileErr = fmt.Errorf("retry requested after %s", result.RequeueAfter)
+			break
+		}
+	}
+
+	current := &rodev1alpha1.Attester{}
+	if err := r.Get(ctx, key, current); err != nil {
+		outcome.Message = err.Error()
+		return outcome
+	}
+
+	var compiledMessage, secretMessage string
+	for _, condition := range current.Status.Conditions {
+		switch condition.Type {
+		case rodev1alpha1.ConditionCompiled:
+			outcome.Compiled = condition.Status
+			compiledMessage = condition.Message
+		case rodev1alpha1.ConditionSecret:
+			outcome.SecretStatus = condition.Status
+			secretMessage = condition.Message
+		}
+	}
+
+	outcome.Secret = current.GetPgpSecret()
+	if ref := current.Spec.PgpSecretRef; ref != nil {
+		outcome.Secret = ref.Name
+	}
+	if outcome.Compiled == rodev1alpha1.ConditionStatusTrue {
+		if secrets[types.NamespacedName{Namespace: key.Namespace, Name: outcome.Secret}] {
+			outcome.SecretDecision = SecretDecisionReuse
+		} else if outcome.SecretStatus == rodev1alpha1.ConditionStatusTrue {
+			outcome.SecretDecision = SecretDecisionCreate
+		}
+	}
+
+	switch {
+	case reconcileErr != nil:
+		outcome.Message = reconcileErr.Error()
+	case outcome.Compiled == rodev1alpha1.ConditionStatusFalse && current.Spec.PolicyConfigMapRef != nil:
+		outcome.Message = fmt.Sprintf("unable to compile the policy in ConfigMap %s: %s", current.Spec.PolicyConfigMapRef.Name, compiledMessage)
+	case outcome.Compiled == rodev1alpha1.ConditionStatusFalse:
+		outcome.Message = compiledMessage
+	case outcome.SecretStatus == rodev1alpha1.ConditionStatusFalse && secretMessage == "":
+		outcome.Message = fmt.Sprintf("unable to load the signer from secret %s", outcome.Secret)
+	case outcome.SecretStatus == rodev1alpha1.ConditionStatusFalse:
+		outcome.Message = fmt.Sprintf("unable to load the signer from secret %s: %s", outcome.Secret, secretMessage)
+	}
+	if !outcome.Loaded && outcome.Message == "" {
+		outcome.Message = fmt.Sprintf("not loaded after %d reconciles", maxReconciles)
+	}
+
+	return outcome
+}
+
+// loadManifests decodes every object in the YAML and JSON files in dir
+func loadManifests(scheme *runtime.Scheme, dir, namespace string) ([]runtime.Object, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	var objs []runtime.Object
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		fileObjs, err := decodeManifest(decoder, path, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		objs = append(objs, fileObjs...)
+	}
+
+	return objs, nil
+}
+
+func decodeManifest(decoder runtime.Decoder, path, namespace string) ([]runtime.Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objs []runtime.Object
+	reader := yaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(string(doc)) == "" {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		if accessor.GetNamespace() == "" {
+			accessor.SetNamespace(namespace)
+		}
+
+		objs = append(objs, obj)
+	}
+}
+
+// printOutcomes prints the outcomes as a table. Messages are printed on a single line to keep the table aligned.
+func printOutcomes(w io.Writer, outcomes []*Outcome) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ATTESTER\tCOMPILED\tSECRET\tSECRET STATUS\tSECRET DECISION\tLOADED\tMESSAGE")
+	for _, o := range outcomes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n", o.Attester, o.Compiled, o.Secret, o.SecretStatus, o.SecretDecision, o.Loaded, strings.Join(strings.Fields(o.Message), " "))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+func TestSimulate(t *testing.T) {
+	assert := assert.New(t)
+
+	outcomes, err := simulate(logf.NullLogger{}, "testdata", "rode")
+	assert.NoError(err)
+	assert.Len(outcomes, 3)
+
+	existing := outcomes[0]
+	assert.Equal("rode/existing", existing.Attester)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, existing.Compiled)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, existing.SecretStatus)
+	assert.Equal(SecretDecisionReuse, existing.SecretDecision)
+	assert.False(existing.Loaded)
+	assert.Contains(existing.Message, "existing-keys")
+
+	invalid := outcomes[1]
+	assert.Equal("rode/invalid", invalid.Attester)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, invalid.Compiled)
+	assert.Equal(SecretDecisionNone, invalid.SecretDecision)
+	assert.False(invalid.Loaded)
+	assert.Contains(invalid.Message, "rego_parse_error")
+
+	valid := outcomes[2]
+	assert.Equal("rode/valid", valid.Attester)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, valid.Compiled)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, valid.SecretStatus)
+	assert.Equal("valid", valid.Secret)
+	assert.Equal(SecretDecisionCreate, valid.SecretDecision)
+	assert.True(valid.Loaded)
+
+	out := &bytes.Buffer{}
+	assert.NoError(printOutcomes(out, outcomes))
+	assert.Contains(out.String(), "rode/valid")
+}
+
+func TestSimulate_MissingDir(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := simulate(logf.NullLogger{}, "testdata/missing", "rode")
+	assert.Error(err)
+}
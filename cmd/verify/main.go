@@ -0,0 +1,202 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// verify checks that an image has an attestation signed by an attester's public key. It exits with a non-zero status
+// if no valid attestation is found, which makes it suitable for gating pipelines from an initContainer or Job.
+//
+// When -layout is set it instead verifies the in-toto links in the -links directory against the layout, which must be
+// signed by the -layout-key keys.
+//
+// When -signature is set it instead verifies a stored attestation offline, checking that the signature was made by
+// the -public-key key over the -payload, without loading attestations from Grafeas.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/intoto"
+	"github.com/liatrio/rode/pkg/occurrence"
+)
+
+func main() {
+	var image string
+	var publicKeyPath string
+	var grafeasEndpoint string
+	var stage string
+	flag.StringVar(&image, "image", "", "The image reference to verify.")
+	flag.StringVar(&publicKeyPath, "public-key", "", "The path to the attester's public key.")
+	flag.StringVar(&stage, "stage", "", "The stage the attestation must have been made for. Any stage is accepted when empty.")
+	flag.StringVar(&grafeasEndpoint, "grafeas-endpoint", os.Getenv("GRAFEAS_ENDPOINT"), "The Grafeas endpoint to load attestations from.")
+	var layoutPath string
+	var layoutKeyPaths string
+	var linksDir string
+	flag.StringVar(&layoutPath, "layout", "", "The path to an in-toto layout to verify links against instead of verifying an attestation.")
+	flag.StringVar(&layoutKeyPaths, "layout-key", "", "A comma separated list of paths to the public keys that must have signed the in-toto layout.")
+	flag.StringVar(&linksDir, "links", ".", "The directory containing the in-toto links to verify.")
+	var payloadPath string
+	var signaturePath string
+	flag.StringVar(&payloadPath, "payload", "", "The path to the signed payload of an attestation to verify offline with -signature.")
+	flag.StringVar(&signaturePath, "signature", "", "The path to the signature of an attestation to verify offline against -payload instead of loading attestations.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(func(o *zap.Options) {
+		o.Development = true
+	}))
+	log := ctrl.Log.WithName("verify")
+
+	if layoutPath != "" {
+		err := verifyLayout(layoutPath, layoutKeyPaths, linksDir, time.Now())
+		if err != nil {
+			log.Error(err, "in-toto verification failed", "layout", layoutPath)
+			os.Exit(1)
+		}
+
+		log.Info("verified in-toto layout", "layout", layoutPath, "links", linksDir)
+		return
+	}
+
+	if signaturePath != "" {
+		err := verifySignature(publicKeyPath, payloadPath, signaturePath)
+		if err != nil {
+			log.Error(err, "signature is invalid", "signature", signaturePath)
+			os.Exit(1)
+		}
+
+		log.Info("signature is valid", "signature", signaturePath, "payload", payloadPath)
+		return
+	}
+
+	if image == "" || publicKeyPath == "" {
+		log.Error(fmt.Errorf("missing required flags"), "both -image and -public-key must be set")
+		os.Exit(1)
+	}
+
+	publicKey, err := os.Open(publicKeyPath)
+	if err != nil {
+		log.Error(err, "unable to open public key")
+		os.Exit(1)
+	}
+	defer publicKey.Close()
+
+	verifier, err := attester.ReadVerifier(publicKey)
+	if err != nil {
+		log.Error(err, "unable to read public key")
+		os.Exit(1)
+	}
+
+	tlsConfig, err := occurrence.NewGrafeasTLSConfig(log)
+	if err != nil {
+		log.Error(err, "error creating grafeas TLS config")
+		os.Exit(1)
+	}
+
+	grafeasClient, err := occurrence.NewGrafeasClient(log.WithName("GrafeasClient"), tlsConfig, grafeasEndpoint)
+	if err != nil {
+		log.Error(err, "error initializing grafeas client")
+		os.Exit(1)
+	}
+
+	err = verify(context.Background(), grafeasClient, verifier, image, stage)
+	if err != nil {
+		log.Error(err, "verification failed", "image", image)
+		os.Exit(1)
+	}
+
+	log.Info("verified attestation", "image", image, "stage", stage, "keyID", verifier.KeyID())
+}
+
+// verify returns nil if any of the occurrences for the image is an attestation for the stage signed by the verifier
+func verify(ctx context.Context, lister occurrence.Lister, verifier attester.Verifier, image, stage string) error {
+	occurrenceList, err := lister.ListOccurrences(ctx, image)
+	if err != nil {
+		return err
+	}
+
+	for _, occ := range occurrenceList.GetOccurrences() {
+		if err = attester.VerifyAttestation(verifier, occ, stage); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unable to find attestation for %s signed by %s", image, verifier.KeyID())
+}
+
+// verifySignature returns nil if the signature in the file was made by the public key over the payload in the file. A
+// trailing newline is ignored in both, since attestation payloads and signatures don't end with one.
+func verifySignature(publicKeyPath, payloadPath, signaturePath string) error {
+	if publicKeyPath == "" || payloadPath == "" {
+		return fmt.Errorf("-public-key and -payload must be set to verify a signature")
+	}
+
+	publicKey, err := os.Open(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	defer publicKey.Close()
+
+	verifier, err := attester.ReadVerifier(publicKey)
+	if err != nil {
+		return fmt.Errorf("unable to read public key: %v", err)
+	}
+
+	payload, err := ioutil.ReadFile(payloadPath)
+	if err != nil {
+		return err
+	}
+	signature, err := ioutil.ReadFile(signaturePath)
+	if err != nil {
+		return err
+	}
+
+	return attester.VerifySignature(verifier, strings.TrimRight(string(payload), "\r\n"), strings.TrimRight(string(signature), "\r\n"))
+}
+
+// verifyLayout returns nil if the in-toto links in the directory satisfy the layout signed by the keys
+func verifyLayout(layoutPath, keyPaths, linksDir string, now time.Time) error {
+	if keyPaths == "" {
+		return fmt.Errorf("-layout-key must be set to verify a layout")
+	}
+
+	layout, err := intoto.LoadMetablock(layoutPath)
+	if err != nil {
+		return err
+	}
+
+	var keys []intoto.Key
+	for _, path := range strings.Split(keyPaths, ",") {
+		key, err := intoto.LoadKey(strings.TrimSpace(path))
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	links, err := intoto.LoadLinks(linksDir)
+	if err != nil {
+		return err
+	}
+
+	return intoto.Verify(layout, keys, links, now)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+type fakeLister struct {
+	occurrences []*grafeas.Occurrence
+}
+
+func (l *fakeLister) ListOccurrences(ctx context.Context, resourceURI string) (*grafeas.ListOccurrencesResponse, error) {
+	return &grafeas.ListOccurrencesResponse{Occurrences: l.occurrences}, nil
+}
+
+func TestVerify(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	image := "harbor.liatr.io/rode/app@sha256:b88ca0f4a7fe2c67fc8bdb67ad405f418ca0be6aec442c79ae32253138da2dd5"
+
+	policy, err := attester.NewPolicy(ctx, "verify", "package verify\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := attester.NewSigner("verify")
+	assert.NoError(err)
+	otherSigner, err := attester.NewSigner("other")
+	assert.NoError(err)
+
+	res, err := attester.NewAttester("verify", policy, signer).Attest(ctx, &attester.AttestRequest{ResourceURI: image})
+	assert.NoError(err)
+
+	lister := &fakeLister{occurrences: []*grafeas.Occurrence{res.Attestation}}
+
+	assert.NoError(verify(ctx, lister, signer, image, ""))
+	assert.Error(verify(ctx, lister, otherSigner, image, ""))
+	assert.Error(verify(ctx, &fakeLister{}, signer, image, ""))
+	assert.Error(verify(ctx, lister, signer, image, "prod"))
+
+	tampered := &grafeas.Occurrence{
+		Resource: &grafeas.Resource{Uri: fmt.Sprintf("%s-tampered", image)},
+		Details:  res.Attestation.Details,
+	}
+	assert.Error(verify(ctx, &fakeLister{occurrences: []*grafeas.Occurrence{tampered}}, signer, image, ""))
+
+	staged, err := attester.NewAttester("verify", policy, signer, attester.WithStage("prod")).Attest(ctx, &attester.AttestRequest{ResourceURI: image})
+	assert.NoError(err)
+
+	stagedLister := &fakeLister{occurrences: []*grafeas.Occurrence{staged.Attestation}}
+	assert.NoError(verify(ctx, stagedLister, signer, image, "prod"))
+	assert.NoError(verify(ctx, stagedLister, signer, image, ""))
+	assert.Error(verify(ctx, stagedLister, signer, image, "staging"))
+}
+
+func TestVerifyLayout(t *testing.T) {
+	assert := assert.New(t)
+	testdata := "../../pkg/intoto/testdata"
+	now := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(verifyLayout(testdata+"/root.layout", testdata+"/owner.pub", testdata+"/links", now))
+	assert.Error(verifyLayout(testdata+"/root.layout", "", testdata+"/links", now))
+	assert.Error(verifyLayout(testdata+"/root.layout", testdata+"/owner.pub", testdata, now))
+}
+
+func TestVerifySignature(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	image := "harbor.liatr.io/rode/app@sha256:b88ca0f4a7fe2c67fc8bdb67ad405f418ca0be6aec442c79ae32253138da2dd5"
+
+	dir, err := ioutil.TempDir("", "verify")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(ioutil.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	writePublicKey := func(name string, verifier attester.Verifier) string {
+		publicKey := &bytes.Buffer{}
+		assert.NoError(attester.SerializePublicKey(verifier, publicKey))
+		return writeFile(name, publicKey.String())
+	}
+
+	policy, err := attester.NewPolicy(ctx, "verify", "package verify\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := attester.NewSigner("verify")
+	assert.NoError(err)
+	otherSigner, err := attester.NewSigner("other")
+	assert.NoError(err)
+
+	publicKey := writePublicKey("verify.asc", signer)
+	otherPublicKey := writePublicKey("other.asc", otherSigner)
+	payload := writeFile("payload", image+"\n")
+	tamperedPayload := writeFile("tampered", image+"-tampered\n")
+
+	for _, format := range []rodev1alpha1.SignatureFormat{rodev1alpha1.SignatureFormatPGP, rodev1alpha1.SignatureFormatDSSE} {
+		res, err := attester.NewAttester("verify", policy, signer, attester.WithSignatureFormat(format)).Attest(ctx, &attester.AttestRequest{ResourceURI: image})
+		assert.NoError(err)
+		signature := writeFile(fmt.Sprintf("signature-%s", format), res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetSignature()+"\n")
+
+		// the signature is valid for the payload that was signed, and not for a tampered payload or another key
+		assert.NoError(verifySignature(publicKey, payload, signature), "format %s", format)
+		assert.Error(verifySignature(publicKey, tamperedPayload, signature), "format %s", format)
+		assert.Error(verifySignature(otherPublicKey, payload, signature), "format %s", format)
+	}
+
+	assert.Error(verifySignature("", payload, payload))
+	assert.Error(verifySignature(publicKey, "", payload))
+	assert.Error(verifySignature(publicKey, payload, payload))
+}
@@ -18,18 +18,42 @@ package controllers
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/liatrio/rode/api/util"
 	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/occurrence"
+	"github.com/liatrio/rode/pkg/tracing"
 )
 
 // AttesterReconciler reconciles a Attester object
@@ -38,98 +62,537 @@ type AttesterReconciler struct {
 	Log       logr.Logger
 	Scheme    *runtime.Scheme
 	Attesters map[string]attester.Attester
+
+	// PolicyTimings enables recording the duration of policy parsing, compilation and evaluation in the status
+	PolicyTimings bool
+
+	// PolicyPartialEval enables partially evaluating policies once, leaving only the input to evaluate for each resource
+	PolicyPartialEval bool
+
+	// PolicyEvalTimeout cancels evaluations of a policy that take longer, so that a policy that doesn't terminate is
+	// reported as a violation rather than hanging. Defaults to 5s, and evaluations aren't limited when it's negative.
+	PolicyEvalTimeout time.Duration
+
+	// Builtins are custom built-in functions that policies can call, such as the attester.ImageAllowlist's
+	Builtins []attester.Builtin
+
+	// EvalCache caches the results of evaluating policies when set
+	EvalCache *attester.EvalCache
+
+	// Notes creates the Grafeas notes that attestations are stored under. Notes aren't created when it isn't set.
+	Notes occurrence.NoteCreator
+
+	// PolicyRepositories fetches the Git repositories that attesters' policies are read from. The policies of attesters
+	// with a policy Git repository don't compile when it isn't set.
+	PolicyRepositories *attester.PolicyRepositories
+
+	// Subjects tracks the subjects attested by each attester. When set, changes enqueue the attester so that the count
+	// in its status is kept up to date.
+	Subjects *attester.SubjectTracker
+
+	// Violations records the violations of each attester's most recent rejection when set. Recording violations
+	// enqueues the attester so that they're shown in its status.
+	Violations *attester.ViolationTracker
+
+	// Attestations counts the attestations each attester signs and rejects when set. The counts are added to the
+	// attester's status when it's enqueued by the counter, which happens at most once per the counter's interval.
+	Attestations *attester.AttestationCounter
+
+	// EvalBudget limits the time each attester spends evaluating its policy when set. Changes to whether an attester is
+	// throttled enqueue the attester so that its status is kept up to date.
+	EvalBudget *attester.EvalBudget
+
+	// Recorder records events for attesters when set
+	Recorder record.EventRecorder
+
+	// Clock is used to decide when keys are rotated. Defaults to the real clock.
+	Clock clock.Clock
+
+	// KeyStrength is the minimum strength of keys loaded from existing secrets. Keys aren't checked when it isn't set.
+	KeyStrength *attester.KeyStrengthPolicy
+
+	// FailOnDeletedSecret stops loading an attester whose secret is deleted after its key was loaded, rather than
+	// generating a new key, so that the key can be restored
+	FailOnDeletedSecret bool
+
+	// FinalizerName is the finalizer added to attesters so that their secrets are cleaned up when they're deleted.
+	// Installations sharing a cluster need distinct names. Defaults to attester.finalizers.rode.liatr.io.
+	FinalizerName string
+
+	// NoFinalizer stops the finalizer from being added to attesters, as the attester.NoFinalizerAnnotation does for a
+	// single attester, so that attesters can be deleted while the controller is down. Their secrets are deleted by the
+	// garbage collector instead, so secrets in other namespaces are left behind and deletion grace periods are ignored.
+	NoFinalizer bool
+
+	// MaxConcurrentReconciles is the number of attesters reconciled at once, both by the controller and when loading
+	// the attesters with WarmUp. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	// ResyncPeriod is how often each attester is reconciled when it hasn't changed. The informer's resyncs, which
+	// reconcile every attester at once, are ignored when it's set, and each attester is requeued after the period
+	// instead. Attesters are only resynced by the informer when it isn't set.
+	ResyncPeriod time.Duration
+
+	// VaultStore stores the keys of attesters that set a VaultPath rather than a PgpSecret. Attesters with a VaultPath
+	// fail to load when it isn't set.
+	VaultStore attester.SignerStore
+
+	// TracerProvider records spans of reconciles, and of the policy compiles and key generation within them, when set
+	TracerProvider tracing.TracerProvider
+
+	// PublicKeysConfigMap is the ConfigMap that the public key of every loaded attester is published in, keyed by
+	// attester.PublicKeyName, so that verifiers can read the keys without access to the attesters. Keys aren't published
+	// when its name is empty.
+	PublicKeysConfigMap types.NamespacedName
+
+	// ResyncJitter spreads the resyncs of attesters that were reconciled together by requeueing each one after a random
+	// delay of up to this fraction of the ResyncPeriod on top of the period
+	ResyncJitter float64
+
+	// RateLimitBaseDelay is the delay before an attester that's requeued is reconciled again, which the controller's
+	// workqueue doubles each time the attester is requeued in a row, up to RateLimitMaxDelay. Each attester is backed
+	// off on its own, so that an attester that keeps getting requeued doesn't starve the others. The workqueue keeps
+	// controller-runtime's default rate limiter when it isn't set.
+	RateLimitBaseDelay time.Duration
+
+	// RateLimitMaxDelay is the longest delay before an attester that's requeued is reconciled again
+	RateLimitMaxDelay time.Duration
+
+	// events enqueues attesters that need to be reconciled outside of changes to the Attester objects
+	events chan event.GenericEvent
+
+	// ctx is cancelled when the manager stops, so that reconciles in progress give up on compiling policies and
+	// generating keys rather than holding up the shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// enqueueCluster queues the cluster attester with the given name to be reconciled, and is set by the
+	// ClusterAttesterReconciler so that changes to the counts of cluster attesters reach it
+	enqueueCluster func(name string)
+
+	// loadedMutex guards signers, traced, compiledVersions, policyCommits and policies, which are shared by attesters
+	// reconciled concurrently
+	loadedMutex sync.Mutex
+
+	// policies is the compiled policy of each loaded attester, which is reused until the policy changes
+	policies map[string]compiledPolicy
+
+	// signers provides the current signer of each loaded attester
+	signers map[string]*attester.RotatingSigner
+
+	// traced is whether each loaded attester's policy was compiled with tracing enabled
+	traced map[string]bool
+
+	// compiledVersions is the generation of each attester, and the resource version of its policy ConfigMap or the
+	// commit of its policy Git repository, that its policy was last compiled for, so that a compile is only recorded
+	// once for each version
+	compiledVersions map[string]string
+
+	// policyCommits is the commit that each attester's policy Git repository was last fetched at
+	policyCommits map[string]policyCommit
+
+	reloadMutex sync.Mutex
+	reloads     map[string]bool
+
+	// attestersMutex guards Attesters, which is read by the handlers serving requests while attesters are reconciled
+	attestersMutex sync.RWMutex
+
+	// warmingUp holds back the controller's reconciles until the attesters have been loaded by WarmUp, and warmedUp is
+	// set once they have
+	warmingUp sync.WaitGroup
+	warmedUp  int32
+}
+
+// compiledPolicy is a compiled policy and the version of the policy, and the options that affect compiling it, that it
+// was compiled from
+type compiledPolicy struct {
+	version string
+	policy  attester.Policy
+}
+
+// ReloadSummary describes the attesters that were queued to be reloaded by Reload
+type ReloadSummary struct {
+	Reloaded []string `json:"reloaded"`
+	Removed  []string `json:"removed"`
 }
 
-// ListAttesters returns a list of Attester objects
+// ListAttesters returns a copy of the loaded attesters, which can be used while attesters are reconciled
 func (r *AttesterReconciler) ListAttesters() map[string]attester.Attester {
-	return r.Attesters
+	r.attestersMutex.RLock()
+	defer r.attestersMutex.RUnlock()
+
+	attesters := make(map[string]attester.Attester, len(r.Attesters))
+	for key, att := range r.Attesters {
+		attesters[key] = att
+	}
+
+	return attesters
+}
+
+// ListAttestersFiltered returns a copy of the loaded attesters that are in the options' namespace and whose labels match
+// its label selector. Cluster attesters are shared by every namespace, so they're selected by their labels alone. The
+// labels are read from the cached Attesters and ClusterAttesters, so the API server isn't listed.
+func (r *AttesterReconciler) ListAttestersFiltered(opts attester.ListOptions) (map[string]attester.Attester, error) {
+	listOpts := make([]client.ListOption, 0, 2)
+	if opts.Namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(opts.Namespace))
+	}
+	if opts.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(opts.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector: %v", err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	list := &rodev1alpha1.AttesterList{}
+	if err := r.List(context.Background(), list, listOpts...); err != nil {
+		return nil, err
+	}
+
+	// cluster attesters aren't in a namespace, so only the label selector applies to them
+	clusterListOpts := listOpts
+	if opts.Namespace != "" {
+		clusterListOpts = listOpts[1:]
+	}
+	clusterList := &rodev1alpha1.ClusterAttesterList{}
+	if err := r.List(context.Background(), clusterList, clusterListOpts...); err != nil {
+		return nil, err
+	}
+
+	r.attestersMutex.RLock()
+	defer r.attestersMutex.RUnlock()
+
+	attesters := make(map[string]attester.Attester, len(list.Items)+len(clusterList.Items))
+	for _, item := range list.Items {
+		key := types.NamespacedName{Namespace: item.Namespace, Name: item.Name}.String()
+		if att, ok := r.Attesters[key]; ok {
+			attesters[key] = att
+		}
+	}
+	for _, item := range clusterList.Items {
+		key := ClusterAttesterKey(item.Name)
+		if att, ok := r.Attesters[key]; ok {
+			attesters[key] = att
+		}
+	}
+
+	return attesters, nil
+}
+
+// loadedAttester returns the loaded attester with the given namespace/name key
+func (r *AttesterReconciler) loadedAttester(key string) (attester.Attester, bool) {
+	r.attestersMutex.RLock()
+	defer r.attestersMutex.RUnlock()
+
+	att, ok := r.Attesters[key]
+	return att, ok
+}
+
+// setAttester loads the attester under the given namespace/name key, replacing any attester already loaded
+func (r *AttesterReconciler) setAttester(key string, att attester.Attester) {
+	r.attestersMutex.Lock()
+	defer r.attestersMutex.Unlock()
+
+	r.Attesters[key] = att
+}
+
+// deleteAttester unloads the attester with the given namespace/name key
+func (r *AttesterReconciler) deleteAttester(key string) {
+	r.attestersMutex.Lock()
+	defer r.attestersMutex.Unlock()
+
+	delete(r.Attesters, key)
 }
 
 var (
+	// attesterFinalizerName is the finalizer added to attesters when the reconciler doesn't set one
 	attesterFinalizerName = "attester.finalizers.rode.liatr.io"
+
+	// secretQuotaRetryInterval is how long to wait before creating an attester's secret again when the namespace's
+	// secret quota has been exceeded
+	secretQuotaRetryInterval = time.Minute
+
+	// importedKeyRetryInterval is how long to wait before reading an imported key again when it's missing or invalid,
+	// since changes to secrets aren't watched
+	importedKeyRetryInterval = time.Minute
+
+	// passphraseRetryInterval is how long to wait before reading an attester's passphrase again when it's unavailable,
+	// since changes to the passphrase secret aren't watched
+	passphraseRetryInterval = time.Minute
+
+	// defaultPolicyEvalTimeout is how long a policy evaluation can take when the reconciler doesn't set a timeout
+	defaultPolicyEvalTimeout = 5 * time.Second
+
+	// defaultPolicyPollInterval is how often an attester's policy Git repository is pulled when it doesn't set an
+	// interval
+	defaultPolicyPollInterval = 5 * time.Minute
+
+	// warmUpReconciles is the most times WarmUp reconciles each attester to load it, following the requeues after the
+	// attester is updated
+	warmUpReconciles = 5
+
+	// warmUpRetryBaseDelay is how long to wait before warming up again when the attesters can't be listed, doubling with
+	// each consecutive failure up to warmUpRetryMaxDelay
+	warmUpRetryBaseDelay = time.Second
+	warmUpRetryMaxDelay  = time.Minute
+
+	// deletedSecretRetryInterval is how long to wait before checking whether a deleted secret was restored when the
+	// reconciler fails on deleted secrets
+	deletedSecretRetryInterval = time.Minute
+
+	// secretRetryBaseBackoff is how long to wait before retrying an attester's secret after the first transient error,
+	// doubling with each consecutive error up to secretRetryMaxBackoff
+	secretRetryBaseBackoff = time.Second
+	secretRetryMaxBackoff  = 5 * time.Minute
 )
 
+const secretQuotaExceededMessage = "namespace secret quota exceeded"
+
+// OPATraceAnnotation traces every evaluation of the attester's policy when it's set to "true" on the attester, logging
+// the trace at debug level. Tracing is slow, so it's meant for debugging why a policy rejects a resource.
+const OPATraceAnnotation = "rode.liatr.io/opa-trace"
+
+// LogLevelAnnotation shows the attester's reconcile logs up to the verbosity it's set to, such as "1", without raising
+// the verbosity of the logs of other attesters
+const LogLevelAnnotation = "rode.liatr.io/log-level"
+
 // +kubebuilder:rbac:groups=rode.liatr.io,resources=attesters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rode.liatr.io,resources=attesters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update
 
 // Reconcile runs whenever a change to an Attester is made. It attempts to match the current state of the attester to the desired state.
-// nolint: gocyclo
 func (r *AttesterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
-	log := r.Log.WithValues("attester", req.NamespacedName)
-	opaTrace := false
+	ctx, span := r.tracer().Start(r.baseContext(), "Reconcile", tracing.String("attester", req.NamespacedName.String()))
+	defer span.End()
+
+	result, err := r.reconcile(ctx, req)
+	recordReconcile(req.NamespacedName, err)
+	span.RecordError(err)
+
+	return result, err
+}
+
+// reconcile does the work of Reconcile, which records its outcome
+// nolint: gocyclo
+func (r *AttesterReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("attester", req.NamespacedName, "reconcileID", uuid.NewUUID())
 
 	log.Info("Reconciling attester")
 
 	att := &rodev1alpha1.Attester{}
 	err := r.Get(ctx, req.NamespacedName, att)
 	if err != nil {
+		if errors.IsNotFound(err) {
+			// the attester was deleted without being finalized, so it may still be loaded
+			r.unloadAttester(req.NamespacedName.String())
+			if err := r.unpublishPublicKey(ctx, req.NamespacedName); err != nil {
+				log.Error(err, "Unable to remove the public key from the public keys ConfigMap")
+				return ctrl.Result{}, err
+			}
+
+			// delete the secrets released by the attester once their grace period has passed
+			next, err := attester.DeleteReleasedSecrets(ctx, r.Client, req.NamespacedName, time.Now())
+			if err != nil {
+				log.Error(err, "Unable to delete released secrets")
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{RequeueAfter: next}, nil
+		}
+
 		log.Error(err, "Unable to load attester")
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	log = withLogLevel(log, att)
+	log.V(1).Info("Loaded attester", "generation", att.Generation, "resourceVersion", att.ResourceVersion, "secret", att.GetPgpSecret(), "secretNamespace", att.GetPgpSecretNamespace())
+
+	// An attester that's being deleted is unloaded and never loaded again, so that a reconcile that was queued before
+	// it was deleted can't recreate its secret after the secret has been deleted
+	if !att.ObjectMeta.DeletionTimestamp.IsZero() {
+		r.unloadAttester(req.NamespacedName.String())
+		if err := r.unpublishPublicKey(ctx, req.NamespacedName); err != nil {
+			log.Error(err, "Unable to remove the public key from the public keys ConfigMap")
+			return ctrl.Result{}, err
+		}
+
+		if !containsFinalizer(att.ObjectMeta.Finalizers, r.finalizerName()) {
+			log.Info("Attester is being deleted")
+			return ctrl.Result{}, nil
+		}
+
+		// The secret is deleted before the finalizer is removed, so that deleting it is retried if it fails rather
+		// than the secret being left behind
+		if err := r.finalizeSecret(ctx, log, att); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		log.Info("Removing finalizer")
+		att.ObjectMeta.Finalizers = removeFinalizer(att.ObjectMeta.Finalizers, r.finalizerName())
+		if err := r.Update(ctx, att); err != nil {
+			log.Error(err, "Error Removing the finalizer")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
 	// Register finalizer
-	err = r.registerFinalizer(log, att)
+	err = r.registerFinalizer(ctx, log, att)
 	if err != nil {
 		log.Error(err, "Error registering finalizer")
+		r.eventf(att, corev1.EventTypeWarning, "FinalizerRegistrationFailed", "Unable to register finalizer: %s", err)
 	}
 
-	// If the attester is being deleted then remove the finalizer, delete the secret,
-	// and remove the Attester object from r.Attesters
-	if !att.ObjectMeta.DeletionTimestamp.IsZero() && containsFinalizer(att.ObjectMeta.Finalizers, attesterFinalizerName) {
-		log.Info("Removing finalizer")
+	opaTrace := att.Annotations[OPATraceAnnotation] == "true"
+	traceLog := withLogLevel(r.Log.WithValues("attester", req.NamespacedName), att)
 
-		// Removing finalizer
-		att.ObjectMeta.Finalizers = removeFinalizer(att.ObjectMeta.Finalizers, attesterFinalizerName)
-		err := r.Update(ctx, att)
-		if err != nil {
-			log.Error(err, "Error Removing the finalizer")
+	// Initialize the conditions, or repair them if they were written by an older version of the controller
+	normalized := normalizeAttesterConditions(att)
+	if r.deriveReadyCondition(att) || normalized {
+		if err := r.updateAttesterStatus(ctx, att); err != nil {
+			log.Error(err, "Unable to initialize attester status")
 			return ctrl.Result{}, err
 		}
+	}
 
-		// Deleting secret
-		err = attester.DeleteSecret(ctx, att, r.Client, types.NamespacedName{
-			Name:      att.Spec.PgpSecret,
-			Namespace: req.Namespace,
-		})
-		if err != nil {
-			log.Error(err, "Failed to delete the secret")
+	// The attestations counted since the status was last written are added to the counts in the status
+	if r.Attestations != nil {
+		if counts := r.Attestations.Take(req.NamespacedName.String()); !counts.IsZero() {
+			addAttestationCounts(att, counts)
+			if err := r.updateAttesterStatus(ctx, att); err != nil {
+				r.Attestations.Restore(req.NamespacedName.String(), counts)
+				log.Error(err, "Unable to update attestation counts")
+				return ctrl.Result{}, err
+			}
 		}
+	}
 
-		// Deleting attester object
-		delete(r.Attesters, req.NamespacedName.String())
+	// A suspended attester is unloaded so that it stops attesting, but its secret and compiled policy are kept so that
+	// it's loaded again as soon as it's resumed
+	if att.Spec.Suspend {
+		if _, ok := r.loadedAttester(req.NamespacedName.String()); ok {
+			log.Info("Suspending attester")
+			r.eventf(att, corev1.EventTypeNormal, "Suspended", "Attester is suspended")
+		}
+		r.deleteAttester(req.NamespacedName.String())
 
-		return ctrl.Result{}, err
+		changed := r.setCondition(att, rodev1alpha1.ConditionSuspended, rodev1alpha1.ConditionStatusTrue, "Suspended", "")
+		if att.Status.ObservedGeneration != att.Generation {
+			att.Status.ObservedGeneration = att.Generation
+			changed = true
+		}
+		if changed {
+			if err := r.updateAttesterStatus(ctx, att); err != nil {
+				log.Error(err, "Unable to update the suspended status")
+				return ctrl.Result{}, err
+			}
+		}
+
+		log.Info("Attester is suspended")
+		return ctrl.Result{}, nil
 	}
 
-	// If there are 0 conditions then initialize conditions by adding two with false statuses
-	if len(att.Status.Conditions) == 0 {
-		policyCondition := rodev1alpha1.Condition{
-			Type:   rodev1alpha1.ConditionCompiled,
-			Status: rodev1alpha1.ConditionStatusFalse,
+	attestedSubjects := att.Status.AttestedSubjects
+	if r.Subjects != nil {
+		att.Status.AttestedSubjects = r.Subjects.Count(req.NamespacedName.String())
+	}
+
+	lastViolations := att.Status.LastViolations
+	if r.Violations != nil {
+		if last := r.Violations.Last(req.NamespacedName.String()); last != nil {
+			att.Status.LastViolations = last
 		}
+	}
 
-		att.Status.Conditions = append(att.Status.Conditions, policyCondition)
+	evalThrottled := att.Status.EvalThrottled
+	if r.EvalBudget != nil {
+		att.Status.EvalThrottled = r.EvalBudget.Throttled(req.NamespacedName.String())
 
-		secretCondition := rodev1alpha1.Condition{
-			Type:   rodev1alpha1.ConditionSecret,
-			Status: rodev1alpha1.ConditionStatusFalse,
+		budget := time.Duration(0)
+		if att.Spec.PolicyEvalBudget != nil {
+			budget = att.Spec.PolicyEvalBudget.Duration
 		}
+		r.EvalBudget.SetBudget(req.NamespacedName.String(), budget)
+	}
+
+	keysChanged, nextRotation, err := r.rotateKey(ctx, log, att, req.Namespace)
+	if err != nil {
+		log.Error(err, "Unable to rotate the signer key")
+		return ctrl.Result{}, err
+	}
 
-		att.Status.Conditions = append(att.Status.Conditions, secretCondition)
+	// Skip recompiling the policy and reloading the key if they were loaded for the current spec and are healthy
+	if !r.takeReload(req.NamespacedName.String()) && !keysChanged && r.isUpToDate(att, req.NamespacedName.String()) && !r.policyRepositoryChanged(ctx, att, req.NamespacedName.String()) {
+		if att.Status.AttestedSubjects != attestedSubjects || att.Status.EvalThrottled != evalThrottled || !equality.Semantic.DeepEqual(att.Status.LastViolations, lastViolations) {
+			if err := r.updateAttesterStatus(ctx, att); err != nil {
+				log.Error(err, "Unable to update attested subjects, throttling and violations")
+				return ctrl.Result{}, err
+			}
+		}
 
-		if err := r.Status().Update(ctx, att); err != nil {
-			log.Error(err, "Unable to initialize attester status")
+		if err := r.publishPublicKey(ctx, att, req.NamespacedName); err != nil {
+			log.Error(err, "Unable to publish the public key")
 			return ctrl.Result{}, err
 		}
+
+		log.Info("Attester is up to date")
+		return ctrl.Result{RequeueAfter: r.resyncAfter(untilPolicyPoll(att, nextRotation))}, nil
+	}
+
+	// Compile the policy, unless it's the same policy that the attester was last loaded with
+	modules, policyVersion, err := r.policyModules(ctx, att, req.Name)
+	if isTransient(err) {
+		log.Error(err, "Unable to get the policy ConfigMap")
+		return ctrl.Result{}, err
 	}
 
-	// Always recompile the policy
-	policy, err := attester.NewPolicy(req.Name, att.Spec.Policy, opaTrace)
+	var policy attester.Policy
+	if err == nil {
+		compileVersion := fmt.Sprintf("%s/trace=%t/rego=%s", attester.PolicyVersion(modules, att.Spec.PolicyQuery), opaTrace, att.Spec.RegoVersion)
+		if cached, ok := r.cachedPolicy(req.NamespacedName.String(), compileVersion); ok {
+			log.Info("Reusing the compiled policy", "version", compileVersion)
+			recordPolicyCompileCacheHit(req.NamespacedName)
+			policy = cached
+		} else {
+			log.V(1).Info("Compiling the policy", "version", compileVersion, "modules", len(modules))
+			compileStart := time.Now()
+			compileCtx, span := r.tracer().Start(ctx, "NewPolicy", tracing.String("attester", req.NamespacedName.String()), tracing.Int("modules", len(modules)))
+			policy, err = attester.NewPolicyFromModules(compileCtx, req.Name, modules, opaTrace,
+				attester.WithQuery(att.Spec.PolicyQuery),
+				attester.WithRegoVersion(att.Spec.RegoVersion),
+				attester.WithTraceLogger(traceLog),
+				attester.WithTimings(r.PolicyTimings),
+				attester.WithPartialEval(r.PolicyPartialEval),
+				attester.WithEvalCache(r.EvalCache),
+				attester.WithEvalTimeout(r.policyEvalTimeout()),
+				attester.WithBuiltins(r.Builtins...))
+			span.RecordError(err)
+			span.End()
+			recordPolicyCompile(req.NamespacedName, time.Since(compileStart))
+			if err == nil {
+				r.cachePolicy(req.NamespacedName.String(), compileVersion, policy)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		// the controller is stopping, which says nothing about the policy
+		log.Info("Stopped compiling the policy", "reason", ctx.Err().Error())
+		return ctrl.Result{}, ctx.Err()
+	}
 	if err != nil {
 		log.Error(err, "Unable to create policy")
+		r.eventf(att, corev1.EventTypeWarning, "PolicyCompileFailed", "Unable to compile policy: %s", err)
+
+		// the loaded attester isn't replaced until a policy compiles, so it keeps evaluating the previous policy
+		message := err.Error()
+		if _, ok := r.loadedAttester(req.NamespacedName.String()); ok && att.Status.PolicyVersion != "" {
+			message = fmt.Sprintf("%s; still evaluating policy version %s", message, att.Status.PolicyVersion)
+		}
 
-		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusFalse)
+		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", message)
 		if err != nil {
 			log.Error(err, "Unable to update Attester's compiled status to false")
 		}
@@ -137,56 +600,240 @@ func (r *AttesterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
-	if att.Status.Conditions[0].Status != rodev1alpha1.ConditionStatusTrue {
-		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusTrue)
+	var migration *attester.PolicyMigration
+	if m := att.Spec.PolicyMigration; m != nil {
+		migration, err = r.newPolicyMigration(ctx, req.Name, att.Spec.PolicyQuery, att.Spec.RegoVersion, m, opaTrace, traceLog)
+		if err != nil {
+			log.Error(err, "Unable to create the policy being migrated from")
+			r.eventf(att, corev1.EventTypeWarning, "PolicyCompileFailed", "Unable to compile the policy being migrated from: %s", err)
+
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusFalse, "MigrationCompileFailed", err.Error())
+			if err != nil {
+				log.Error(err, "Unable to update Attester's compiled status to false")
+			}
+
+			return ctrl.Result{}, err
+		}
+	}
+
+	var transform attester.InputTransform
+	if att.Spec.InputTransform != nil {
+		transform, err = attester.NewInputTransform(req.Name, att.Spec.InputTransform)
+		if err != nil {
+			log.Error(err, "Unable to create the input transform")
+			r.eventf(att, corev1.EventTypeWarning, "PolicyCompileFailed", "Unable to compile the input transform: %s", err)
+
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusFalse, "TransformCompileFailed", err.Error())
+			if err != nil {
+				log.Error(err, "Unable to update Attester's compiled status to false")
+			}
+
+			return ctrl.Result{}, err
+		}
+	}
+
+	if r.PolicyTimings {
+		loaded, _ := r.loadedAttester(req.NamespacedName.String())
+		r.recordPolicyTimings(att, policy, loaded)
+	}
+
+	r.recordCompiled(att, req.NamespacedName.String(), policyVersion)
+
+	// A policy that can never have violations still compiles, but it's flagged since it attests every resource
+	compiledReason, compiledMessage := "PolicyCompiled", ""
+	if err := attester.CheckRules(policy); err != nil {
+		compiledReason, compiledMessage = "PolicyHasNoRules", err.Error()
+	}
+
+	if condition := attesterCondition(att, rodev1alpha1.ConditionCompiled); condition.Status != rodev1alpha1.ConditionStatusTrue || condition.Reason != compiledReason {
+		if compiledReason == "PolicyHasNoRules" {
+			log.Info("The policy has no rules", "reason", compiledMessage)
+			r.eventf(att, corev1.EventTypeWarning, "PolicyHasNoRules", "Policy compiled but has no rules: %s", compiledMessage)
+		}
+
+		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusTrue, compiledReason, compiledMessage)
 		if err != nil {
 			log.Error(err, "Unable to update Attester's compiled status to true")
+			return ctrl.Result{}, err
 		}
 
 		log.Info("Setting Policy status to true")
-		// Return to avoid race condition on att
-		return ctrl.Result{}, nil
+		// Requeue to continue with the updated attester, since the status update is filtered from the watch
+		return ctrl.Result{Requeue: true}, nil
 	}
 
 	signerSecret := &corev1.Secret{}
 	var signer attester.Signer
 
-	// If there isn't already a secret name specified, use req.Name
-	if att.Spec.PgpSecret == "" {
-		att.Spec.PgpSecret = req.Name
-		err = r.Update(ctx, att)
+	err = r.ensureNotes(ctx, att, req.NamespacedName.String())
+	if err != nil {
+		log.Error(err, "Unable to create the attester's notes")
+		return ctrl.Result{}, err
+	}
+
+	// Read the imported key if there is one. Otherwise check that the secret exists, if it does, recreate a signer from
+	// the secret.
+	if ref := att.Spec.PgpSecretRef; ref != nil {
+		// The key is managed outside of rode, so it's read as it is and never generated or overwritten
+		signer, err = r.readImportedSigner(ctx, att, req.Namespace)
 		if err != nil {
-			log.Error(err, "Could not update the Attester's PgpSecret field")
+			log.Error(err, "Unable to import the signer key", "retryAfter", importedKeyRetryInterval)
+			r.eventf(att, corev1.EventTypeWarning, "KeyImportFailed", "Unable to import key: %s", err)
+
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "KeyImportFailed", err.Error())
+			if err != nil {
+				log.Error(err, "Unable to update Attester's secret status to false")
+			}
+			return ctrl.Result{RequeueAfter: importedKeyRetryInterval}, nil
+		}
+
+		if err := r.checkKeyStrength(log, att, signer, ref.Name); err != nil {
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "WeakKey", err.Error())
 			return ctrl.Result{}, err
 		}
 
-		log.Info("Setting PgpSecret to req.Name")
-		// Return to avoid race condition
-		return ctrl.Result{}, nil
-	}
+		if err := setPublicKey(att, signer); err != nil {
+			return ctrl.Result{}, err
+		}
+		att.Status.ObservedGeneration = att.Generation
+		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue, "KeyImported", "")
+		if err != nil {
+			log.Error(err, "Unable to update Attester's secret status to true")
+		}
 
-	// Check that the secret exists, if it does, recreate a signer from the secret
-	err = r.Get(ctx, types.NamespacedName{
-		Name:      att.Spec.PgpSecret,
-		Namespace: req.Namespace,
-	}, signerSecret)
-	if err != nil {
+		log.Info("Imported the signer key", "keyID", signer.KeyID())
+	} else if path := att.Spec.VaultPath; path != "" {
+		// The key is kept in Vault rather than a secret, and is generated there if it doesn't exist
+		var created bool
+		signer, created, err = r.storedSigner(ctx, att, req.Namespace)
+		if err != nil && ctx.Err() != nil {
+			// the controller is stopping, so the key is read again when the attester is next reconciled
+			log.Info("Stopped getting the signer key from Vault", "reason", ctx.Err().Error())
+			return ctrl.Result{}, ctx.Err()
+		}
+		if err != nil {
+			log.Error(err, "Unable to get the signer key from Vault", "path", path)
+			r.eventf(att, corev1.EventTypeWarning, "VaultKeyFailed", "Unable to get key from Vault path %s: %s", path, err)
+
+			if statusErr := r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "VaultKeyFailed", err.Error()); statusErr != nil {
+				log.Error(statusErr, "Unable to update Attester's secret status to false")
+			}
+			return ctrl.Result{}, err
+		}
+
+		if err := r.checkKeyStrength(log, att, signer, path); err != nil {
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "WeakKey", err.Error())
+			return ctrl.Result{}, err
+		}
+
+		reason := "VaultKeyLoaded"
+		if created {
+			reason = "VaultKeyCreated"
+			log.Info("Created the signer key in Vault", "path", path, "keyID", signer.KeyID())
+			r.eventf(att, corev1.EventTypeNormal, "VaultKeyCreated", "Created key %s in Vault path %s", signer.KeyID(), path)
+		}
+
+		if err := setPublicKey(att, signer); err != nil {
+			return ctrl.Result{}, err
+		}
+		att.Status.ObservedGeneration = att.Generation
+		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue, reason, "")
+		if err != nil {
+			log.Error(err, "Unable to update Attester's secret status to true")
+		}
+	} else if err = r.Get(ctx, types.NamespacedName{
+		Name:      att.GetPgpSecret(),
+		Namespace: att.GetPgpSecretNamespace(),
+	}, signerSecret); err != nil {
 		// If the secret wasn't found then create the secret
+		if isTransient(err) {
+			return r.backOffSecret(ctx, log, att, err)
+		}
+		if errors.IsForbidden(err) {
+			// The controller's role doesn't grant access to secrets in the namespace, which retrying won't fix until
+			// it's granted
+			message := fmt.Sprintf("not allowed to get secret %s in namespace %s, grant the controller access to secrets in the namespace: %s", att.GetPgpSecret(), att.GetPgpSecretNamespace(), err)
+			log.Error(err, "Not allowed to get the secret", "secretNamespace", att.GetPgpSecretNamespace())
+			r.eventf(att, corev1.EventTypeWarning, "SecretForbidden", "Not allowed to get secret %s in namespace %s", att.GetPgpSecret(), att.GetPgpSecretNamespace())
+
+			if statusErr := r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretForbidden", message); statusErr != nil {
+				log.Error(statusErr, "Unable to update Attester's secret status to false")
+			}
+			return ctrl.Result{}, err
+		}
 		if !errors.IsNotFound(err) {
 			log.Error(err, "Unable to get the secret")
 			return ctrl.Result{}, err
 		}
 
+		// A key was loaded from the secret before, so it was deleted out of band
+		if keyID := att.Status.KeyID; keyID != "" {
+			if r.FailOnDeletedSecret {
+				message := fmt.Sprintf("secret %s containing key %s was deleted, restore it or recreate the attester to generate a new key", att.GetPgpSecret(), keyID)
+				log.Info("The signer secret was deleted, not generating a new key", "keyID", keyID, "retryAfter", deletedSecretRetryInterval)
+				r.eventf(att, corev1.EventTypeWarning, "SecretMissing", "Secret %s containing key %s was deleted", att.GetPgpSecret(), keyID)
+
+				// the key can't be used without its secret, so the attester stops attesting until it's restored
+				r.deleteAttester(req.NamespacedName.String())
+				r.loadedMutex.Lock()
+				delete(r.signers, req.NamespacedName.String())
+				r.loadedMutex.Unlock()
+
+				err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretMissing", message)
+				if err != nil {
+					log.Error(err, "Unable to update Attester's secret status to false")
+				}
+				return ctrl.Result{RequeueAfter: deletedSecretRetryInterval}, nil
+			}
+
+			log.Info("The signer secret was deleted, generating a new key", "previousKeyID", keyID)
+			r.eventf(att, corev1.EventTypeWarning, "SecretMissing", "Secret %s containing key %s was deleted, generating a new key", att.GetPgpSecret(), keyID)
+		}
+
 		log.Info("Couldn't find secret, creating a new one")
 
-		signer, err = attester.NewSecret(ctx, att, r.Client, types.NamespacedName{
-			Namespace: req.Namespace,
-			Name:      att.Spec.PgpSecret,
-		})
+		// The generated key is encrypted with the passphrase, so the secret isn't created until the passphrase is
+		// available
+		var passphrase []byte
+		passphrase, err = r.getPassphrase(ctx, att, req.Namespace)
+		if err != nil {
+			return r.passphraseUnavailable(ctx, log, att, err)
+		}
+
+		secretCtx, span := r.tracer().Start(ctx, "NewSecret", tracing.String("attester", req.NamespacedName.String()), tracing.String("keyType", string(att.Spec.KeyType)))
+		signer, err = attester.NewSecret(secretCtx, att, r.Client, types.NamespacedName{
+			Namespace: att.GetPgpSecretNamespace(),
+			Name:      att.GetPgpSecret(),
+		}, passphrase)
+		span.RecordError(err)
+		span.End()
+		if err != nil && ctx.Err() != nil {
+			// the controller is stopping, so the key is generated again when the attester is next reconciled
+			log.Info("Stopped creating the signer secret", "reason", ctx.Err().Error())
+			return ctrl.Result{}, ctx.Err()
+		}
+		if err != nil {
+			recordSecretCreationFailure(req.NamespacedName)
+		}
+		if isQuotaExceeded(err) {
+			// Retrying immediately won't succeed until quota is freed, so wait rather than hot loop
+			log.Error(err, "Unable to create the signer secret, namespace secret quota exceeded", "retryAfter", secretQuotaRetryInterval)
+			r.eventf(att, corev1.EventTypeWarning, "SecretQuotaExceeded", "Unable to create secret %s: %s", att.GetPgpSecret(), err)
+
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretQuotaExceeded", secretQuotaExceededMessage)
+			if err != nil {
+				log.Error(err, "Unable to update Attester's secret status to false")
+			}
+			return ctrl.Result{RequeueAfter: secretQuotaRetryInterval}, nil
+		}
+		if isTransient(err) {
+			return r.backOffSecret(ctx, log, att, err)
+		}
 		if err != nil {
 			log.Error(err, "Failed to create the signer secret")
+			r.eventf(att, corev1.EventTypeWarning, "SecretCreationFailed", "Unable to create secret %s: %s", att.GetPgpSecret(), err)
 
-			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse)
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretCreationFailed", err.Error())
 			if err != nil {
 				log.Error(err, "Unable to update Attester's secret status to false")
 			}
@@ -194,74 +841,1617 @@ func (r *AttesterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		}
 
 		// Update the status to true
-		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue)
+		if err := setPublicKey(att, signer); err != nil {
+			return ctrl.Result{}, err
+		}
+		att.Status.PgpSecret = att.GetPgpSecret()
+		att.Status.ObservedGeneration = att.Generation
+		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue, "SecretCreated", "")
 		if err != nil {
 			log.Error(err, "Unable to update Attester's secret status to true")
 		}
 
 		log.Info("Created the signer secret")
-	} else {
-		// The secret does exist, recreate the signer from the secret
-		buf := bytes.NewBuffer(signerSecret.Data["keys"])
+		r.eventf(att, corev1.EventTypeNormal, "SecretCreated", "Created secret %s with key %s", att.GetPgpSecret(), signer.KeyID())
 
-		signer, err = attester.ReadSigner(buf)
+		if escrow := att.Spec.KeyEscrow; escrow != nil {
+			log.Info("Escrowed the signer key", "keyID", signer.KeyID(), "threshold", escrow.Threshold, "shares", len(escrow.Secrets))
+			r.eventf(att, corev1.EventTypeNormal, "KeyEscrowed", "Escrowed key %s in %d shares, %d required to recover it", signer.KeyID(), len(escrow.Secrets), escrow.Threshold)
+		}
+	} else {
+		// The secret does exist, reclaim it if it was released by a deleted attester with the same name
+		reclaimed, err := attester.ReclaimSecret(ctx, att, r.Client, signerSecret)
 		if err != nil {
-			log.Error(err, "Unable to create signer from secret")
-			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse)
+			log.Error(err, "Unable to reclaim the secret")
 			return ctrl.Result{}, err
 		}
-
-		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue)
-		if err != nil {
-			log.Error(err, "Unable to update Attester's secret status to true")
+		if reclaimed {
+			log.Info("Reclaimed the secret released by a deleted attester")
 		}
-	}
 
-	// Create the attester if it doesn't already exist, otherwise update it
-	r.Attesters[req.NamespacedName.String()] = attester.NewAttester(req.NamespacedName.String(), policy, signer)
-
-	return ctrl.Result{}, nil
-}
+		// secrets created before they were labeled are labeled, so that their deletion is noticed
+		if _, err := attester.LabelSecret(ctx, att, r.Client, signerSecret); err != nil {
+			log.Error(err, "Unable to label the secret")
+			return ctrl.Result{}, err
+		}
 
-func (r *AttesterReconciler) registerFinalizer(logger logr.Logger, attester *rodev1alpha1.Attester) error {
-	// If the attester isn't being deleted and it doesn't contain a finalizer, then add one
-	if attester.ObjectMeta.DeletionTimestamp.IsZero() && !containsFinalizer(attester.ObjectMeta.Finalizers, attesterFinalizerName) {
-		logger.Info("Creating attester finalizer...")
-		attester.ObjectMeta.Finalizers = append(attester.ObjectMeta.Finalizers, attesterFinalizerName)
+		// Recreate the signer from the secret
+		buf := bytes.NewBuffer(signerSecret.Data[att.Spec.GetPgpSecretKey()])
 
-		if err := r.Update(context.Background(), attester); err != nil {
-			return err
+		passphrase, err := r.getPassphrase(ctx, att, req.Namespace)
+		if err != nil {
+			return r.passphraseUnavailable(ctx, log, att, err)
 		}
-	}
+
+		signer, err = attester.ReadSignerWithPassphrase(buf, passphrase)
+		if err != nil {
+			log.Error(err, "Unable to create signer from secret")
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "InvalidKey", err.Error())
+			return ctrl.Result{}, err
+		}
+
+		if err := r.checkKeyStrength(log, att, signer, att.GetPgpSecret()); err != nil {
+			// The key won't get any stronger by retrying, so wait for the secret to be replaced
+			err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "WeakKey", err.Error())
+			return ctrl.Result{}, err
+		}
+
+		if err := setPublicKey(att, signer); err != nil {
+			return ctrl.Result{}, err
+		}
+		att.Status.PgpSecret = att.GetPgpSecret()
+		att.Status.ObservedGeneration = att.Generation
+		err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue, "SecretLoaded", "")
+		if err != nil {
+			log.Error(err, "Unable to update Attester's secret status to true")
+		}
+	}
+
+	opts := []attester.AttesterOption{
+		attester.WithStage(att.Spec.Stage),
+		attester.WithNoteKinds(noteKinds(att)),
+		attester.WithNotes(attesterNotes(att, req.NamespacedName.String())),
+		attester.WithSignatureFormat(att.Spec.SignatureFormat),
+		attester.WithPayloadFormat(att.Spec.PayloadFormat),
+		attester.WithEvalBudget(r.EvalBudget),
+	}
+	if r.Attestations != nil {
+		opts = append(opts, attester.WithAttestationCounter(r.Attestations))
+	}
+	if transform != nil {
+		opts = append(opts, attester.WithInputTransform(transform))
+	}
+	if migration != nil {
+		log.Info("Migrating policy", "phase", migration.Phase(time.Now()), "start", migration.Start, "end", migration.End)
+		opts = append(opts, attester.WithPolicyMigration(migration))
+	}
+	if att.Spec.RequireSignedInput {
+		inputSigners, err := r.getVerifiers(ctx, att.Spec.InputSigners, req.Namespace, "input signer")
+		if err != nil {
+			log.Error(err, "Unable to load the trusted input signers")
+			return ctrl.Result{}, err
+		}
+		opts = append(opts, attester.WithInputSigners(inputSigners))
+	}
+	if att.Spec.RequiredSignatures > 1 || len(att.Spec.CoSigners) > 0 {
+		coSigners, err := r.getVerifiers(ctx, att.Spec.CoSigners, req.Namespace, "co-signer")
+		if err != nil {
+			log.Error(err, "Unable to load the co-signers")
+			return ctrl.Result{}, err
+		}
+		opts = append(opts, attester.WithCoSigners(coSigners, att.Spec.RequiredSignatures))
+	}
+
+	if len(att.Status.PreviousPublicKeys) > 0 {
+		opts = append(opts, attester.WithPreviousVerifiers(previousVerifiers(log, att)))
+	}
+
+	// Rotate the signer of the loaded attester if the key changed, so that anything still holding it signs with the new key
+	opts = append(opts, attester.WithSignerProvider(r.rotateSigner(log, req.NamespacedName.String(), signer)))
+
+	// Create the attester if it doesn't already exist, otherwise replace it. The policy, key and options are all ready,
+	// so the attester is swapped in a single assignment and anything listing attesters sees either the old or the new
+	// policy.
+	r.setAttester(req.NamespacedName.String(), attester.NewAttester(req.NamespacedName.String(), policy, signer, opts...))
+	r.loadedMutex.Lock()
+	if r.traced == nil {
+		r.traced = make(map[string]bool)
+	}
+	r.traced[req.NamespacedName.String()] = opaTrace
+	r.loadedMutex.Unlock()
+
+	policyCommit := ""
+	if att.Spec.PolicyGitRepository != nil {
+		policyCommit = policyVersion
+	}
+	if version := attester.PolicyVersion(modules, att.Spec.PolicyQuery); att.Status.PolicyVersion != version || att.Status.PolicyCommit != policyCommit {
+		log.Info("Loaded policy version", "version", version, "previousVersion", att.Status.PolicyVersion, "commit", policyCommit)
+		att.Status.PolicyVersion = version
+		att.Status.PolicyCommit = policyCommit
+		if err := r.updateAttesterStatus(ctx, att); err != nil {
+			log.Error(err, "Unable to update the attester's policy version")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.publishPublicKey(ctx, att, req.NamespacedName); err != nil {
+		log.Error(err, "Unable to publish the public key")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.resyncAfter(untilPolicyPoll(att, nextRotation))}, nil
+}
+
+// publishPublicKey stores the attester's public key in the public keys ConfigMap, if there is one
+func (r *AttesterReconciler) publishPublicKey(ctx context.Context, att *rodev1alpha1.Attester, key types.NamespacedName) error {
+	if r.PublicKeysConfigMap.Name == "" || att.Status.PublicKey == "" {
+		return nil
+	}
+
+	return attester.PublishPublicKey(ctx, r.Client, r.PublicKeysConfigMap, key, att.Status.PublicKey)
+}
+
+// unpublishPublicKey removes the public key of a deleted attester from the public keys ConfigMap, if there is one
+func (r *AttesterReconciler) unpublishPublicKey(ctx context.Context, key types.NamespacedName) error {
+	if r.PublicKeysConfigMap.Name == "" {
+		return nil
+	}
+
+	return attester.UnpublishPublicKey(ctx, r.Client, r.PublicKeysConfigMap, key)
+}
+
+// tracer returns the tracer of reconciles, which doesn't record anything when there isn't a TracerProvider
+func (r *AttesterReconciler) tracer() tracing.Tracer {
+	if r.TracerProvider == nil {
+		return tracing.NoopTracerProvider().Tracer("attester-controller")
+	}
+
+	return r.TracerProvider.Tracer("attester-controller")
+}
+
+// baseContext returns the context of reconciles, which is cancelled when the manager stops
+func (r *AttesterReconciler) baseContext() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+
+	return r.ctx
+}
+
+// resyncAfter returns when a loaded attester is reconciled next, which is after the jittered resync period unless its
+// key is rotated sooner. next is when the key is rotated, or 0 if it isn't.
+func (r *AttesterReconciler) resyncAfter(next time.Duration) time.Duration {
+	if r.ResyncPeriod <= 0 {
+		return next
+	}
+
+	resync := r.ResyncPeriod
+	if r.ResyncJitter > 0 {
+		resync += time.Duration(rand.Float64() * r.ResyncJitter * float64(r.ResyncPeriod))
+	}
+	if next > 0 && next < resync {
+		return next
+	}
+
+	return resync
+}
+
+// addAttestationCounts adds the counts to the attestation counts in the attester's status
+func addAttestationCounts(att *rodev1alpha1.Attester, counts attester.AttestationCounts) {
+	att.Status.AttestationsSigned += counts.Signed
+	att.Status.AttestationsRejected += counts.Rejected
+	if last := att.Status.LastAttestationTime; last == nil || counts.Last.After(last.Time) {
+		// the status is stored with a precision of seconds
+		t := metav1.NewTime(counts.Last.Truncate(time.Second))
+		att.Status.LastAttestationTime = &t
+	}
+}
+
+// unloadAttester forgets everything loaded for the attester
+func (r *AttesterReconciler) unloadAttester(key string) {
+	r.deleteAttester(key)
+	r.loadedMutex.Lock()
+	delete(r.signers, key)
+	delete(r.compiledVersions, key)
+	delete(r.policyCommits, key)
+	delete(r.traced, key)
+	delete(r.policies, key)
+	r.loadedMutex.Unlock()
+	if r.Subjects != nil {
+		r.Subjects.Remove(key)
+	}
+	if r.Violations != nil {
+		r.Violations.Remove(key)
+	}
+	if r.EvalBudget != nil {
+		r.EvalBudget.Remove(key)
+	}
+	if r.Attestations != nil {
+		r.Attestations.Remove(key)
+	}
+}
+
+// finalizeSecret deletes the secret of an attester that's being deleted, or releases it to be deleted once the grace
+// period has passed. Imported keys are left alone, and keys in Vault are deleted from Vault.
+func (r *AttesterReconciler) finalizeSecret(ctx context.Context, log logr.Logger, att *rodev1alpha1.Attester) error {
+	if att.Spec.VaultPath != "" {
+		return r.finalizeVaultKey(ctx, log, att)
+	}
+
+	secretName := types.NamespacedName{
+		Name:      att.GetPgpSecret(),
+		Namespace: att.GetPgpSecretNamespace(),
+	}
+
+	if att.Spec.PgpSecretRef != nil {
+		log.Info("Keeping the imported key's secret", "secret", att.Spec.PgpSecretRef.Name)
+		return nil
+	}
+
+	// released secrets are only deleted from the attester's namespace, so secrets in other namespaces are deleted
+	// straight away
+	if grace := att.Spec.SecretDeletionGracePeriod; grace != nil && grace.Duration > 0 && secretName.Namespace == att.Namespace {
+		err := attester.ReleaseSecret(ctx, att, r.Client, secretName, time.Now().Add(grace.Duration))
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			log.Error(err, "Failed to release the secret")
+			r.eventf(att, corev1.EventTypeWarning, "SecretReleaseFailed", "Unable to release secret %s: %s", secretName.Name, err)
+			return err
+		}
+
+		log.Info("Released the secret to be deleted after the grace period", "gracePeriod", grace.Duration)
+		r.eventf(att, corev1.EventTypeNormal, "SecretReleased", "Released secret %s to be deleted after %s", secretName.Name, grace.Duration)
+		r.enqueueAfter(fmt.Sprintf("%s/%s", att.Namespace, att.Name), grace.Duration)
+		return nil
+	}
+
+	deleted, err := attester.DeleteSecret(ctx, att, r.Client, secretName)
+	if err != nil {
+		log.Error(err, "Failed to delete the secret")
+		r.eventf(att, corev1.EventTypeWarning, "SecretDeletionFailed", "Unable to delete secret %s: %s", secretName.Name, err)
+		return err
+	}
+	if deleted {
+		r.eventf(att, corev1.EventTypeNormal, "SecretDeleted", "Deleted secret %s", secretName.Name)
+	}
+
+	return nil
+}
+
+// finalizeVaultKey deletes the Vault key of an attester that's being deleted. The key is left in Vault when the
+// controller isn't configured with a Vault server, since the attester couldn't be deleted otherwise.
+func (r *AttesterReconciler) finalizeVaultKey(ctx context.Context, log logr.Logger, att *rodev1alpha1.Attester) error {
+	if r.VaultStore == nil {
+		log.Info("Keeping the key in Vault, the controller isn't configured with a Vault server", "path", att.Spec.VaultPath)
+		r.eventf(att, corev1.EventTypeWarning, "VaultKeyKept", "Kept key in Vault path %s, the controller isn't configured with a Vault server", att.Spec.VaultPath)
+		return nil
+	}
+
+	deleted, err := r.VaultStore.DeleteSigner(ctx, att)
+	if err != nil {
+		log.Error(err, "Failed to delete the key from Vault", "path", att.Spec.VaultPath)
+		r.eventf(att, corev1.EventTypeWarning, "VaultKeyDeletionFailed", "Unable to delete key in Vault path %s: %s", att.Spec.VaultPath, err)
+		return err
+	}
+	if deleted {
+		r.eventf(att, corev1.EventTypeNormal, "VaultKeyDeleted", "Deleted key in Vault path %s", att.Spec.VaultPath)
+	}
+
+	return nil
+}
+
+func (r *AttesterReconciler) registerFinalizer(ctx context.Context, logger logr.Logger, attester *rodev1alpha1.Attester) error {
+	if !r.usesFinalizer(attester) {
+		return nil
+	}
+
+	// If the attester isn't being deleted and it doesn't contain a finalizer, then add one
+	finalizer := r.finalizerName()
+	if attester.ObjectMeta.DeletionTimestamp.IsZero() && !containsFinalizer(attester.ObjectMeta.Finalizers, finalizer) {
+		logger.Info("Creating attester finalizer...", "finalizer", finalizer)
+		attester.ObjectMeta.Finalizers = append(attester.ObjectMeta.Finalizers, finalizer)
+
+		if err := r.Update(ctx, attester); err != nil {
+			return err
+		}
+
+		r.eventf(attester, corev1.EventTypeNormal, "FinalizerRegistered", "Registered finalizer %s", finalizer)
+	}
+
+	return nil
+}
+
+// policyEvalTimeout returns the timeout of policy evaluations, or 0 if they aren't limited
+func (r *AttesterReconciler) policyEvalTimeout() time.Duration {
+	if r.PolicyEvalTimeout == 0 {
+		return defaultPolicyEvalTimeout
+	}
+	if r.PolicyEvalTimeout < 0 {
+		return 0
+	}
+
+	return r.PolicyEvalTimeout
+}
+
+// usesFinalizer returns whether the finalizer is added to the attester, rather than leaving its secret to be deleted by
+// the garbage collector. Attesters that already have the finalizer are still finalized when they're deleted.
+func (r *AttesterReconciler) usesFinalizer(att *rodev1alpha1.Attester) bool {
+	return !r.NoFinalizer && att.Annotations[attester.NoFinalizerAnnotation] != "true"
+}
+
+// finalizerName returns the finalizer the reconciler adds to attesters
+func (r *AttesterReconciler) finalizerName() string {
+	if r.FinalizerName == "" {
+		return attesterFinalizerName
+	}
+
+	return r.FinalizerName
+}
+
+// ensureNotes creates the attester's default note and a note for each of its note kinds, recording their names in the
+// status
+func (r *AttesterReconciler) ensureNotes(ctx context.Context, att *rodev1alpha1.Attester, name string) error {
+	noteNames := make([]string, 0)
+	notes := attesterNotes(att, name)
+
+	for _, kind := range append([]string{""}, noteKinds(att)...) {
+		if r.Notes != nil {
+			err := r.Notes.CreateNote(ctx, notes.NoteID(kind), notes.Note(kind))
+			if err != nil {
+				return err
+			}
+		}
+
+		noteNames = append(noteNames, notes.NoteName(kind))
+	}
+
+	att.Status.NoteNames = noteNames
+
+	return nil
+}
+
+// attesterNotes returns the notes the attester stores its attestations under, which are named after the attester unless
+// its spec configures them
+func attesterNotes(att *rodev1alpha1.Attester, name string) attester.Notes {
+	notes := attester.DefaultNotes(name)
+	if note := att.Spec.Note; note != nil {
+		if note.Name != "" {
+			notes.ID = note.Name
+		}
+		if note.Project != "" {
+			notes.Project = note.Project
+		}
+		if note.Description != "" {
+			notes.Description = note.Description
+		}
+		notes.Authority = note.Authority
+	}
+
+	return notes
+}
+
+func noteKinds(att *rodev1alpha1.Attester) []string {
+	kinds := make([]string, 0)
+	for _, kind := range att.Spec.NoteKinds {
+		kinds = append(kinds, string(kind))
+	}
+
+	return kinds
+}
+
+// isUpToDate returns true if the attester is loaded, was loaded for the current generation of its spec, and all of its
+// conditions are true
+func (r *AttesterReconciler) isUpToDate(att *rodev1alpha1.Attester, key string) bool {
+	if _, ok := r.loadedAttester(key); !ok {
+		return false
+	}
+
+	if att.Status.ObservedGeneration != att.Generation {
+		return false
+	}
+
+	if att.Status.SecretRetry != nil {
+		return false
+	}
+
+	// the annotation doesn't change the generation, so the policy is recompiled when tracing is toggled
+	r.loadedMutex.Lock()
+	traced := r.traced[key]
+	r.loadedMutex.Unlock()
+	if traced != (att.Annotations[OPATraceAnnotation] == "true") {
+		return false
+	}
+
+	for _, condition := range att.Status.Conditions {
+		if condition.Status != rodev1alpha1.ConditionStatusTrue {
+			return false
+		}
+	}
+
+	return true
+}
+
+// passphraseUnavailable reports that the passphrase for the attester's key couldn't be read, and requeues the attester
+// to read it again, since the passphrase secret may not have been created yet
+func (r *AttesterReconciler) passphraseUnavailable(ctx context.Context, log logr.Logger, att *rodev1alpha1.Attester, err error) (ctrl.Result, error) {
+	log.Error(err, "Unable to get the passphrase for the signer", "retryAfter", passphraseRetryInterval)
+
+	err = r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "PassphraseUnavailable", err.Error())
+	if err != nil {
+		log.Error(err, "Unable to update Attester's secret status to false")
+	}
+	return ctrl.Result{RequeueAfter: passphraseRetryInterval}, nil
+}
+
+// getPassphrase returns the passphrase for the attester's private key from the secret referenced by the attester,
+// or nil if the attester doesn't reference a passphrase
+func (r *AttesterReconciler) getPassphrase(ctx context.Context, att *rodev1alpha1.Attester, namespace string) ([]byte, error) {
+	ref := att.Spec.PgpPassphraseSecretRef
+	if ref == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: namespace,
+	}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("passphrase secret %s/%s does not contain key %s", namespace, ref.Name, ref.Key)
+	}
+
+	return passphrase, nil
+}
+
+// readImportedSigner reads the signer from the key referenced by the attester's PgpSecretRef. The secret is never
+// created or overwritten, so it's an error for it to be missing or not to contain a valid private key.
+func (r *AttesterReconciler) readImportedSigner(ctx context.Context, att *rodev1alpha1.Attester, namespace string) (attester.Signer, error) {
+	ref := att.Spec.PgpSecretRef
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: namespace,
+	}, secret)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("secret %s containing the imported key does not exist", ref.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := secret.Data[ref.Key]
+	if len(key) == 0 {
+		return nil, fmt.Errorf("secret %s does not contain the imported key in %s", ref.Name, ref.Key)
+	}
+
+	passphrase, err := r.getPassphrase(ctx, att, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := attester.ReadSignerWithPassphrase(bytes.NewReader(key), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s does not contain a valid private key in %s: %v", ref.Name, ref.Key, err)
+	}
+
+	return signer, nil
+}
+
+// storedSigner reads the attester's key from Vault, generating it if Vault doesn't hold one yet, and returns whether it
+// was generated
+func (r *AttesterReconciler) storedSigner(ctx context.Context, att *rodev1alpha1.Attester, namespace string) (attester.Signer, bool, error) {
+	if r.VaultStore == nil {
+		return nil, false, fmt.Errorf("vaultPath is set but the controller isn't configured with a Vault server")
+	}
+
+	passphrase, err := r.getPassphrase(ctx, att, namespace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	signer, err := r.VaultStore.ReadSigner(ctx, att, passphrase)
+	if err != nil || signer != nil {
+		return signer, false, err
+	}
+
+	signer, err = r.VaultStore.CreateSigner(ctx, att, passphrase)
+	return signer, err == nil, err
+}
+
+// checkKeyStrength returns an error if the signer's key doesn't meet the minimum key strength, recording why on the
+// attester
+func (r *AttesterReconciler) checkKeyStrength(log logr.Logger, att *rodev1alpha1.Attester, signer attester.Signer, secretName string) error {
+	if r.KeyStrength == nil {
+		return nil
+	}
+
+	err := r.KeyStrength.Check(signer)
+	if err != nil {
+		log.Error(err, "Signer key doesn't meet the minimum key strength")
+		r.eventf(att, corev1.EventTypeWarning, "WeakKey", "Secret %s: %s", secretName, err)
+	}
+
+	return err
+}
+
+// getVerifiers returns the verifiers for the public keys in the referenced secrets, such as the attester's input
+// signers or co-signers
+func (r *AttesterReconciler) getVerifiers(ctx context.Context, refs []corev1.SecretKeySelector, namespace, kind string) ([]attester.Verifier, error) {
+	verifiers := make([]attester.Verifier, 0, len(refs))
+	for _, ref := range refs {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+			return nil, err
+		}
+
+		key, ok := secret.Data[ref.Key]
+		if !ok {
+			return nil, fmt.Errorf("secret %s has no key %s", ref.Name, ref.Key)
+		}
+
+		verifier, err := attester.ReadVerifier(bytes.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s key in secret %s: %v", kind, ref.Name, err)
+		}
+		verifiers = append(verifiers, verifier)
+	}
+
+	return verifiers, nil
+}
+
+// rotateSigner returns the signer provider of the attester with the given namespace/name key, rotating it to the
+// signer if it provides a different key
+func (r *AttesterReconciler) rotateSigner(log logr.Logger, key string, signer attester.Signer) *attester.RotatingSigner {
+	r.loadedMutex.Lock()
+	defer r.loadedMutex.Unlock()
+
+	if r.signers == nil {
+		r.signers = make(map[string]*attester.RotatingSigner)
+	}
+
+	provider, ok := r.signers[key]
+	if !ok {
+		provider = attester.NewRotatingSigner(signer)
+		r.signers[key] = provider
+		return provider
+	}
+
+	if previous := provider.Signer().KeyID(); previous != signer.KeyID() {
+		log.Info("Rotating the signer key", "previousKeyID", previous, "keyID", signer.KeyID())
+		provider.Rotate(signer)
+	}
+
+	return provider
+}
+
+// rotateKey replaces the attester's generated key once it's older than the rotation interval, keeping the public key it
+// replaces in the status for the grace period, and removes the previous public keys whose grace period has passed. It
+// returns whether the keys changed and how long until they next need to change.
+func (r *AttesterReconciler) rotateKey(ctx context.Context, log logr.Logger, att *rodev1alpha1.Attester, namespace string) (bool, time.Duration, error) {
+	now := r.now()
+
+	var next time.Duration
+	after := func(d time.Duration) {
+		if d > 0 && (next == 0 || d < next) {
+			next = d
+		}
+	}
+
+	previous := make([]rodev1alpha1.PreviousPublicKey, 0, len(att.Status.PreviousPublicKeys))
+	for _, key := range att.Status.PreviousPublicKeys {
+		if remaining := key.ExpiresAt.Sub(now); remaining > 0 {
+			previous = append(previous, key)
+			after(remaining)
+		} else {
+			log.Info("Removing the previous public key after its grace period", "keyID", key.KeyID)
+		}
+	}
+	expired := len(previous) != len(att.Status.PreviousPublicKeys)
+	att.Status.PreviousPublicKeys = previous
+
+	var secret *corev1.Secret
+	rotation := att.Spec.KeyRotation
+	if rotation != nil && rotation.RotateAfter.Duration > 0 && att.GetPgpSecret() != "" {
+		secret = &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: att.GetPgpSecret(), Namespace: att.GetPgpSecretNamespace()}, secret)
+		if client.IgnoreNotFound(err) != nil {
+			return false, 0, err
+		}
+
+		// keys that weren't generated for the attester, or whose age is unknown, aren't rotated
+		if err != nil || !attester.ControlsSecret(att, secret) || attester.KeyCreatedAt(secret).IsZero() {
+			secret = nil
+		} else if age := now.Sub(attester.KeyCreatedAt(secret)); age < rotation.RotateAfter.Duration {
+			after(rotation.RotateAfter.Duration - age)
+			secret = nil
+		}
+	}
+
+	if secret == nil {
+		if expired {
+			return true, next, r.updateAttesterStatus(ctx, att)
+		}
+		return false, next, nil
+	}
+
+	passphrase, err := r.getPassphrase(ctx, att, namespace)
+	if err != nil {
+		return false, 0, err
+	}
+
+	replaced, err := attester.ReadSignerWithPassphrase(bytes.NewReader(secret.Data[att.Spec.GetPgpSecretKey()]), passphrase)
+	if err != nil {
+		return false, 0, err
+	}
+
+	grace := rotation.RotateAfter.Duration
+	if rotation.GracePeriod != nil {
+		grace = rotation.GracePeriod.Duration
+	}
+
+	// the replaced key is recorded before it's rotated, so that it's never lost if rotating fails
+	if grace > 0 && !hasPreviousPublicKey(att, replaced.KeyID()) {
+		publicKey := &bytes.Buffer{}
+		if err := attester.SerializePublicKey(replaced, publicKey); err != nil {
+			return false, 0, err
+		}
+
+		att.Status.PreviousPublicKeys = append(att.Status.PreviousPublicKeys, rodev1alpha1.PreviousPublicKey{
+			KeyID:     replaced.KeyID(),
+			PublicKey: publicKey.String(),
+			RotatedAt: metav1.NewTime(now),
+			ExpiresAt: metav1.NewTime(now.Add(grace)),
+		})
+		after(grace)
+	}
+	if err := r.updateAttesterStatus(ctx, att); err != nil {
+		return false, 0, err
+	}
+
+	signer, err := attester.RotateSecret(ctx, att, r.Client, secret, now, passphrase)
+	if err != nil {
+		return false, 0, err
+	}
+	after(rotation.RotateAfter.Duration)
+
+	log.Info("Rotated the signer key", "previousKeyID", replaced.KeyID(), "keyID", signer.KeyID(), "gracePeriod", grace)
+	r.eventf(att, corev1.EventTypeNormal, "KeyRotated", "Rotated key %s to %s, the previous key is accepted for %s", replaced.KeyID(), signer.KeyID(), grace)
+
+	return true, next, nil
+}
+
+// setPublicKey records the ID and public key of the signer the attester was loaded with in its status
+func setPublicKey(att *rodev1alpha1.Attester, signer attester.Signer) error {
+	publicKey := &bytes.Buffer{}
+	if err := attester.SerializePublicKey(signer, publicKey); err != nil {
+		return err
+	}
+
+	att.Status.KeyID = signer.KeyID()
+	att.Status.PublicKey = publicKey.String()
+	return nil
+}
+
+func hasPreviousPublicKey(att *rodev1alpha1.Attester, keyID string) bool {
+	for _, key := range att.Status.PreviousPublicKeys {
+		if key.KeyID == keyID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// previousVerifiers returns the verifiers for the attester's previous public keys, skipping any that can't be read
+func previousVerifiers(log logr.Logger, att *rodev1alpha1.Attester) []attester.Verifier {
+	verifiers := make([]attester.Verifier, 0, len(att.Status.PreviousPublicKeys))
+	for _, key := range att.Status.PreviousPublicKeys {
+		verifier, err := attester.ReadVerifier(strings.NewReader(key.PublicKey))
+		if err != nil {
+			log.Error(err, "Unable to read the previous public key", "keyID", key.KeyID)
+			continue
+		}
+		verifiers = append(verifiers, verifier)
+	}
+
+	return verifiers
+}
+
+func (r *AttesterReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+
+	return r.Clock.Now()
+}
+
+// policyModules returns the modules of the attester's policy, keyed by filename, along with the resource version of the
+// ConfigMap or the commit of the Git repository they were read from. An inline policy is a single module and has no
+// version.
+func (r *AttesterReconciler) policyModules(ctx context.Context, att *rodev1alpha1.Attester, name string) (map[string]string, string, error) {
+	ref := att.Spec.PolicyConfigMapRef
+	if repo := att.Spec.PolicyGitRepository; repo != nil {
+		if att.Spec.Policy != "" || len(att.Spec.Policies) > 0 || ref != nil {
+			return nil, "", fmt.Errorf("policyGitRepository can't be set along with policy, policies or policyConfigMapRef")
+		}
+
+		commit, err := r.policyRepositoryCommit(ctx, att)
+		if err != nil {
+			return nil, "", err
+		}
+		modules, err := r.PolicyRepositories.Modules(ctx, repo.URL, commit, repo.Path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return modules, commit, nil
+	}
+
+	if len(att.Spec.Policies) > 0 {
+		if att.Spec.Policy != "" || ref != nil {
+			return nil, "", fmt.Errorf("policies can't be set along with policy or policyConfigMapRef")
+		}
+
+		return att.Spec.Policies, "", nil
+	}
+
+	if ref == nil {
+		return map[string]string{fmt.Sprintf("%s.rego", name): att.Spec.Policy}, "", nil
+	}
+
+	if att.Spec.Policy != "" {
+		return nil, "", fmt.Errorf("policy and policyConfigMapRef can't both be set")
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: att.Namespace,
+	}, configMap)
+	if errors.IsNotFound(err) {
+		return nil, "", fmt.Errorf("policy ConfigMap %s does not exist", ref.Name)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return configMap.Data, configMap.ResourceVersion, nil
+}
+
+// policyCommit is the commit that an attester's policy Git repository was at when it was fetched
+type policyCommit struct {
+	url     string
+	ref     string
+	commit  string
+	fetched time.Time
+}
+
+// policyRepositoryCommit returns the commit of the attester's policy Git repository, which is only fetched again once
+// its poll interval has passed, or its URL or ref has changed, since it was last fetched
+func (r *AttesterReconciler) policyRepositoryCommit(ctx context.Context, att *rodev1alpha1.Attester) (string, error) {
+	if r.PolicyRepositories == nil {
+		return "", fmt.Errorf("policy Git repositories aren't enabled in the controller")
+	}
+
+	repo := att.Spec.PolicyGitRepository
+	key := types.NamespacedName{Namespace: att.Namespace, Name: att.Name}.String()
+	now := r.now()
+	r.loadedMutex.Lock()
+	fetched, ok := r.policyCommits[key]
+	r.loadedMutex.Unlock()
+	if ok && fetched.url == repo.URL && fetched.ref == repo.Ref && now.Sub(fetched.fetched) < policyPollInterval(repo) {
+		return fetched.commit, nil
+	}
+
+	commit, err := r.PolicyRepositories.Fetch(ctx, repo.URL, repo.Ref)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch policy repository %s: %v", repo.URL, err)
+	}
+
+	r.loadedMutex.Lock()
+	if r.policyCommits == nil {
+		r.policyCommits = make(map[string]policyCommit)
+	}
+	r.policyCommits[key] = policyCommit{url: repo.URL, ref: repo.Ref, commit: commit, fetched: now}
+	r.loadedMutex.Unlock()
+
+	return commit, nil
+}
+
+// policyRepositoryChanged returns whether the attester's policy is read from a Git repository that's at a commit the
+// policy wasn't compiled from, or that can't be fetched, so that the policy is recompiled or the failure is reported
+func (r *AttesterReconciler) policyRepositoryChanged(ctx context.Context, att *rodev1alpha1.Attester, key string) bool {
+	if att.Spec.PolicyGitRepository == nil {
+		return false
+	}
+
+	commit, err := r.policyRepositoryCommit(ctx, att)
+	if err != nil {
+		return true
+	}
+
+	r.loadedMutex.Lock()
+	defer r.loadedMutex.Unlock()
+	return r.compiledVersions[key] != compiledVersion(att, commit)
+}
+
+// policyPollInterval returns how often the policy Git repository is pulled
+func policyPollInterval(repo *rodev1alpha1.PolicyGitRepository) time.Duration {
+	if repo.PollInterval == nil || repo.PollInterval.Duration <= 0 {
+		return defaultPolicyPollInterval
+	}
+
+	return repo.PollInterval.Duration
+}
+
+// untilPolicyPoll returns how long until the attester's policy Git repository is pulled, or next if that's sooner or
+// the attester's policy isn't read from a repository
+func untilPolicyPoll(att *rodev1alpha1.Attester, next time.Duration) time.Duration {
+	repo := att.Spec.PolicyGitRepository
+	if repo == nil {
+		return next
+	}
+
+	poll := policyPollInterval(repo)
+	if next > 0 && next < poll {
+		return next
+	}
+
+	return poll
+}
+
+// reloadPolicyConfigMap queues the attesters whose policy is in the ConfigMap to be reconciled with their policy
+// recompiled
+func (r *AttesterReconciler) reloadPolicyConfigMap(ctx context.Context, configMap types.NamespacedName) error {
+	attesters := &rodev1alpha1.AttesterList{}
+	if err := r.List(ctx, attesters, client.InNamespace(configMap.Namespace)); err != nil {
+		return err
+	}
+
+	for _, att := range attesters.Items {
+		if ref := att.Spec.PolicyConfigMapRef; ref == nil || ref.Name != configMap.Name {
+			continue
+		}
+
+		r.reload(types.NamespacedName{Namespace: att.Namespace, Name: att.Name}.String())
+	}
 
 	return nil
 }
 
-func (r *AttesterReconciler) updateStatus(ctx context.Context, attester *rodev1alpha1.Attester, conditionType rodev1alpha1.ConditionType, status rodev1alpha1.ConditionStatus) error {
-	if conditionType == rodev1alpha1.ConditionCompiled {
-		attester.Status.Conditions[0].Status = status
+// policyConfigMapChanged reloads the attesters whose policy is in the ConfigMap that was added, updated or deleted
+func (r *AttesterReconciler) policyConfigMapChanged(obj interface{}) {
+	key, err := toolscache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	namespace, name, err := toolscache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return
+	}
+
+	if err := r.reloadPolicyConfigMap(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}); err != nil {
+		r.Log.Error(err, "Unable to reload the attesters using the policy ConfigMap", "configMap", key)
+	}
+}
+
+// signerSecretDeleted reloads the attester that controlled the deleted secret, so that the missing key is noticed
+// without waiting for the attester to change
+func (r *AttesterReconciler) signerSecretDeleted(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+
+	// secrets in another namespace than their attester record it in an annotation rather than an owner reference
+	if controller, ok := attester.SecretControllerName(secret); ok {
+		r.reload(controller.String())
+		return
+	}
+
+	owner := metav1.GetControllerOf(secret)
+	if owner == nil || owner.Kind != "Attester" || owner.APIVersion != rodev1alpha1.GroupVersion.String() {
+		return
+	}
+
+	r.reload(types.NamespacedName{Namespace: secret.Namespace, Name: owner.Name}.String())
+}
+
+// newPolicyMigration compiles the policy being migrated from, which is evaluated with the same query, and parsed as the
+// same version of Rego, as the new policy
+func (r *AttesterReconciler) newPolicyMigration(ctx context.Context, name, query string, regoVersion rodev1alpha1.RegoVersion, m *rodev1alpha1.PolicyMigration, opaTrace bool, traceLog logr.Logger) (*attester.PolicyMigration, error) {
+	if !m.End.After(m.Start.Time) {
+		return nil, fmt.Errorf("policy migration must end after it starts")
+	}
+
+	oldPolicy, err := attester.NewPolicy(ctx, name, m.OldPolicy, opaTrace,
+		attester.WithQuery(query),
+		attester.WithRegoVersion(regoVersion),
+		attester.WithTraceLogger(traceLog),
+		attester.WithPartialEval(r.PolicyPartialEval),
+		attester.WithEvalCache(r.EvalCache),
+		attester.WithEvalTimeout(r.policyEvalTimeout()),
+		attester.WithBuiltins(r.Builtins...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &attester.PolicyMigration{
+		OldPolicy: oldPolicy,
+		Start:     m.Start.Time,
+		End:       m.End.Time,
+	}, nil
+}
+
+// recordPolicyTimings stores the parse and compile duration of the policy in the status, along with the most recent
+// evaluation duration of the policy that it replaces
+func (r *AttesterReconciler) recordPolicyTimings(att *rodev1alpha1.Attester, policy attester.Policy, previous attester.Attester) {
+	timings := policy.Timings()
+
+	if att.Status.Timings == nil {
+		att.Status.Timings = &rodev1alpha1.PolicyTimings{}
+	}
+
+	att.Status.Timings.Parse = metav1.Duration{Duration: timings.Parse}
+	att.Status.Timings.Compile = metav1.Duration{Duration: timings.Compile}
+
+	if timed, ok := previous.(attester.Timed); ok && timed.Timings().Eval > 0 {
+		att.Status.Timings.Eval = metav1.Duration{Duration: timed.Timings().Eval}
 	}
+}
+
+// updateStatus sets the status, reason and message of the attester's condition of the given type, adding the condition
+// if it's missing. The condition's last transition time is only changed when its status changes.
+func (r *AttesterReconciler) updateStatus(ctx context.Context, attester *rodev1alpha1.Attester, conditionType rodev1alpha1.ConditionType, status rodev1alpha1.ConditionStatus, reason, message string) error {
+	transitioned := conditionTransitions(attester, conditionType, rodev1alpha1.ConditionReady)
+	r.setCondition(attester, conditionType, status, reason, message)
+	r.deriveReadyCondition(attester)
+	transitioned(tracing.SpanFromContext(ctx))
 
-	if conditionType == rodev1alpha1.ConditionSecret {
-		attester.Status.Conditions[1].Status = status
+	if conditionType == rodev1alpha1.ConditionSecret && status == rodev1alpha1.ConditionStatusTrue {
+		attester.Status.SecretRetry = nil
 	}
 
-	if err := r.Status().Update(ctx, attester); err != nil {
+	if err := r.updateAttesterStatus(ctx, attester); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// conditionTransitions returns a function that adds an event to the span for each of the conditions whose status has
+// transitioned since conditionTransitions was called
+func conditionTransitions(att *rodev1alpha1.Attester, conditionTypes ...rodev1alpha1.ConditionType) func(tracing.Span) {
+	previous := make([]rodev1alpha1.Condition, len(conditionTypes))
+	for i, conditionType := range conditionTypes {
+		previous[i] = *attesterCondition(att, conditionType)
+	}
+
+	return func(span tracing.Span) {
+		for i, conditionType := range conditionTypes {
+			condition := attesterCondition(att, conditionType)
+			if condition.LastTransitionTime == previous[i].LastTransitionTime {
+				continue
+			}
+
+			span.AddEvent("ConditionTransition",
+				tracing.String("type", string(conditionType)),
+				tracing.String("from", string(previous[i].Status)),
+				tracing.String("to", string(condition.Status)),
+				tracing.String("reason", condition.Reason))
+		}
+	}
+}
+
+// updateAttesterStatus writes the attester's status. When the attester changed since it was read, the update conflicts
+// and is retried with the status reapplied to the latest version of the attester, since the status is only written by
+// the controller. An attester that's been deleted is ignored.
+func (r *AttesterReconciler) updateAttesterStatus(ctx context.Context, att *rodev1alpha1.Attester) error {
+	err := r.Status().Update(ctx, att)
+	if !errors.IsConflict(err) {
+		return client.IgnoreNotFound(err)
+	}
+
+	status := att.Status.DeepCopy()
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &rodev1alpha1.Attester{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, latest); err != nil {
+			return err
+		}
+
+		latest.Status = *status.DeepCopy()
+		if err := r.Status().Update(ctx, latest); err != nil {
+			return err
+		}
+
+		// the rest of the reconcile continues with the spec it read, but later updates need the latest version
+		att.ResourceVersion = latest.ResourceVersion
+		return nil
+	})
+
+	return client.IgnoreNotFound(err)
+}
+
+// setCondition sets the status, reason and message of the attester's condition of the given type, adding the condition
+// if it's missing. It returns whether the condition was changed.
+func (r *AttesterReconciler) setCondition(attester *rodev1alpha1.Attester, conditionType rodev1alpha1.ConditionType, status rodev1alpha1.ConditionStatus, reason, message string) bool {
+	condition := attesterCondition(attester, conditionType)
+	if condition.LastTransitionTime != nil && condition.Status == status && condition.Reason == reason && condition.Message == message {
+		return false
+	}
+
+	if condition.Status != status || condition.LastTransitionTime == nil {
+		now := metav1.NewTime(r.now())
+		condition.LastTransitionTime = &now
+	}
+	condition.Status = status
+	condition.Reason = reason
+	condition.Message = message
+
+	return true
+}
+
+// deriveReadyCondition sets the attester's Ready condition to True when its Policy and Key conditions are both True,
+// and otherwise to False with the reason and message of the first of them that isn't. A suspended attester is never
+// Ready. It returns whether the Ready condition was changed.
+func (r *AttesterReconciler) deriveReadyCondition(attester *rodev1alpha1.Attester) bool {
+	if attester.Spec.Suspend {
+		return r.setCondition(attester, rodev1alpha1.ConditionReady, rodev1alpha1.ConditionStatusFalse, "Suspended", "Attester is suspended")
+	}
+
+	for _, conditionType := range []rodev1alpha1.ConditionType{rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionSecret} {
+		condition := attesterCondition(attester, conditionType)
+		if condition.Status == rodev1alpha1.ConditionStatusTrue {
+			continue
+		}
+
+		reason := condition.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("%sNotReady", conditionType)
+		}
+		message := fmt.Sprintf("%s condition is %s", conditionType, condition.Status)
+		if condition.Message != "" {
+			message = fmt.Sprintf("%s: %s", message, condition.Message)
+		}
+
+		return r.setCondition(attester, rodev1alpha1.ConditionReady, rodev1alpha1.ConditionStatusFalse, reason, message)
+	}
+
+	return r.setCondition(attester, rodev1alpha1.ConditionReady, rodev1alpha1.ConditionStatusTrue, "Ready", "")
+}
+
+// cachedPolicy returns the compiled policy of the attester with the given namespace/name key if it was compiled from
+// the version
+func (r *AttesterReconciler) cachedPolicy(key, version string) (attester.Policy, bool) {
+	r.loadedMutex.Lock()
+	defer r.loadedMutex.Unlock()
+
+	cached, ok := r.policies[key]
+	if !ok || cached.version != version {
+		return nil, false
+	}
+
+	return cached.policy, true
+}
+
+// cachePolicy keeps the compiled policy of the attester with the given namespace/name key, replacing the policy it was
+// compiled from before
+func (r *AttesterReconciler) cachePolicy(key, version string, policy attester.Policy) {
+	r.loadedMutex.Lock()
+	defer r.loadedMutex.Unlock()
+
+	if r.policies == nil {
+		r.policies = make(map[string]compiledPolicy)
+	}
+	r.policies[key] = compiledPolicy{version: version, policy: policy}
+}
+
+// compiledVersion returns the version of the attester's policy that a compile is recorded for, which is its generation
+// and the version of its policy ConfigMap or Git repository
+func compiledVersion(att *rodev1alpha1.Attester, policyVersion string) string {
+	return fmt.Sprintf("%d/%s", att.Generation, policyVersion)
+}
+
+// recordCompiled records an event the first time the attester's policy compiles for its current generation and policy
+// ConfigMap or Git repository version
+func (r *AttesterReconciler) recordCompiled(att *rodev1alpha1.Attester, key, policyVersion string) {
+	r.loadedMutex.Lock()
+	if r.compiledVersions == nil {
+		r.compiledVersions = make(map[string]string)
+	}
+
+	version := compiledVersion(att, policyVersion)
+	compiled, ok := r.compiledVersions[key]
+	r.compiledVersions[key] = version
+	r.loadedMutex.Unlock()
+	if ok && compiled == version {
+		return
+	}
+
+	if repo := att.Spec.PolicyGitRepository; repo != nil {
+		r.eventf(att, corev1.EventTypeNormal, "PolicyCompiled", "Compiled policy for generation %d from commit %s of %s", att.Generation, policyVersion, repo.URL)
+		return
+	}
+	if policyVersion != "" {
+		r.eventf(att, corev1.EventTypeNormal, "PolicyCompiled", "Compiled policy for generation %d from ConfigMap %s", att.Generation, att.Spec.PolicyConfigMapRef.Name)
+		return
+	}
+	r.eventf(att, corev1.EventTypeNormal, "PolicyCompiled", "Compiled policy for generation %d", att.Generation)
+}
+
+// eventf records an event for the attester when the reconciler has a recorder
+func (r *AttesterReconciler) eventf(att *rodev1alpha1.Attester, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder != nil {
+		r.Recorder.Eventf(att, eventType, reason, messageFmt, args...)
+	}
+}
+
+// isTransient returns whether the API server error is likely to succeed when retried, such as a timeout or a conflict
+func isTransient(err error) bool {
+	return errors.IsTimeout(err) ||
+		errors.IsServerTimeout(err) ||
+		errors.IsConflict(err) ||
+		errors.IsTooManyRequests(err) ||
+		errors.IsServiceUnavailable(err)
+}
+
+// backOffSecret records a transient error getting or creating the attester's secret in its status, and requeues the
+// attester after a backoff that grows with each consecutive error
+func (r *AttesterReconciler) backOffSecret(ctx context.Context, log logr.Logger, att *rodev1alpha1.Attester, err error) (ctrl.Result, error) {
+	attempts := int32(1)
+	if att.Status.SecretRetry != nil {
+		attempts = att.Status.SecretRetry.Attempts + 1
+	}
+	backoff := secretRetryBackoff(attempts)
+
+	log.Error(err, "Transient error handling the signer secret, backing off", "attempts", attempts, "retryAfter", backoff)
+
+	att.Status.SecretRetry = &rodev1alpha1.SecretRetry{
+		Attempts:  attempts,
+		Backoff:   metav1.Duration{Duration: backoff},
+		LastError: err.Error(),
+	}
+	if err := r.updateAttesterStatus(ctx, att); err != nil {
+		log.Error(err, "Unable to record the secret retry backoff")
+	}
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
+
+// secretRetryBackoff returns how long to wait before the given attempt, doubling from secretRetryBaseBackoff up to
+// secretRetryMaxBackoff
+func secretRetryBackoff(attempts int32) time.Duration {
+	backoff := secretRetryBaseBackoff
+	for i := int32(1); i < attempts && backoff < secretRetryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > secretRetryMaxBackoff {
+		backoff = secretRetryMaxBackoff
+	}
+
+	return backoff
+}
+
+// isQuotaExceeded returns whether the API server rejected a request because it would exceed a ResourceQuota
+func isQuotaExceeded(err error) bool {
+	return errors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota")
+}
+
+// attesterCondition returns the attester's condition of the given type, appending a False condition of the type if the
+// attester doesn't have one
+func attesterCondition(attester *rodev1alpha1.Attester, conditionType rodev1alpha1.ConditionType) *rodev1alpha1.Condition {
+	for i := range attester.Status.Conditions {
+		if attester.Status.Conditions[i].Type == conditionType {
+			return &attester.Status.Conditions[i]
+		}
+	}
+
+	attester.Status.Conditions = append(attester.Status.Conditions, rodev1alpha1.Condition{
+		Type:   conditionType,
+		Status: rodev1alpha1.ConditionStatusFalse,
+	})
+
+	return &attester.Status.Conditions[len(attester.Status.Conditions)-1]
+}
+
+// normalizeAttesterConditions ensures that the attester has exactly one Compiled, one Secret and one Ready condition, in
+// that order, followed by a Suspended condition while it's suspended, preserving the status of any existing conditions.
+// It returns true if the conditions were changed.
+func normalizeAttesterConditions(attester *rodev1alpha1.Attester) bool {
+	expected := []rodev1alpha1.ConditionType{rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionReady}
+	if attester.Spec.Suspend {
+		expected = append(expected, rodev1alpha1.ConditionSuspended)
+	}
+
+	if len(attester.Status.Conditions) == len(expected) {
+		valid := true
+		for i, conditionType := range expected {
+			if attester.Status.Conditions[i].Type != conditionType {
+				valid = false
+			}
+		}
+
+		if valid {
+			return false
+		}
+	}
+
+	conditions := make([]rodev1alpha1.Condition, 0, len(expected))
+	for _, conditionType := range expected {
+		condition := rodev1alpha1.Condition{
+			Type:   conditionType,
+			Status: rodev1alpha1.ConditionStatusFalse,
+		}
+
+		for _, existing := range attester.Status.Conditions {
+			if existing.Type == conditionType {
+				condition = existing
+				break
+			}
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	attester.Status.Conditions = conditions
+
+	return true
+}
+
 // SetupWithManager sets up the watching of Attester objects and filters out the events we don't want to watch
 func (r *AttesterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	r.events = make(chan event.GenericEvent, 1024)
+	registerMetrics()
+
+	if r.Subjects != nil {
+		r.Subjects.OnChange = r.enqueue
+	}
+	if r.Violations != nil {
+		r.Violations.OnChange = r.enqueue
+	}
+	if r.EvalBudget != nil {
+		r.EvalBudget.OnChange = r.enqueue
+	}
+	if r.Attestations != nil {
+		r.Attestations.OnChange = r.enqueue
+	}
+
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	err := mgr.Add(nonLeaderRunnable(func(stop <-chan struct{}) error {
+		<-stop
+		r.cancel()
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
+	err = mgr.Add(manager.RunnableFunc(func(<-chan struct{}) error {
+		r.enqueueReleasedSecrets(r.baseContext())
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
+	// the attesters are loaded before the controller reconciles any changes, once the cache has synced. Every replica
+	// loads them, not only the leader, so that replicas waiting to be elected serve requests and report being ready too
+	r.warmingUp.Add(1)
+	err = mgr.Add(nonLeaderRunnable(func(stop <-chan struct{}) error {
+		defer r.warmingUp.Done()
+		r.warmUpUntilLoaded(stop)
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
+	// the manager's readiness probe fails until they have been
+	if err := mgr.AddReadyzCheck("attesters", r.ReadyzCheck); err != nil {
+		return err
+	}
+
+	// Policy ConfigMaps and signer secrets are watched through the channel of attester events, so that they aren't
+	// subject to the event filters for attesters
+	configMaps, err := mgr.GetCache().GetInformer(&corev1.ConfigMap{})
+	if err != nil {
+		return err
+	}
+	configMaps.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: r.policyConfigMapChanged,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// periodic resyncs don't change the ConfigMap
+			if oldObj.(*corev1.ConfigMap).ResourceVersion != newObj.(*corev1.ConfigMap).ResourceVersion {
+				r.policyConfigMapChanged(newObj)
+			}
+		},
+		DeleteFunc: r.policyConfigMapChanged,
+	})
+
+	// only the secrets labeled as signer secrets are watched, rather than caching every secret in the cluster
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	secrets := toolscache.NewSharedIndexInformer(
+		toolscache.NewFilteredListWatchFromClient(clientset.CoreV1().RESTClient(), "secrets", metav1.NamespaceAll, func(options *metav1.ListOptions) {
+			options.LabelSelector = attester.SecretSignerLabel + "=true"
+		}),
+		&corev1.Secret{}, 0, toolscache.Indexers{})
+	secrets.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: r.signerSecretDeleted,
+	})
+	err = mgr.Add(nonLeaderRunnable(func(stop <-chan struct{}) error {
+		secrets.Run(stop)
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&rodev1alpha1.Attester{}).
+		Watches(&source.Channel{Source: r.events}, &handler.EnqueueRequestForObject{}).
 		WithEventFilter(ignoreConditionStatusUpdateToActive(attesterToConditioner, rodev1alpha1.ConditionCompiled)).
 		WithEventFilter(ignoreConditionStatusUpdateToActive(attesterToConditioner, rodev1alpha1.ConditionSecret)).
-		WithEventFilter(ignoreFinalizerUpdate()).
-		WithEventFilter(ignoreDelete()).
-		Complete(r)
+		WithEventFilter(ignoreConditionStatusUpdateToActive(attesterToConditioner, rodev1alpha1.ConditionReady)).
+		WithEventFilter(ignoreFinalizerUpdate(r.finalizerName())).
+		WithEventFilter(ignoreDelete())
+
+	// attesters requeue themselves after their own jittered resync period, rather than all at once on the informer's
+	if r.ResyncPeriod > 0 {
+		builder = builder.WithEventFilter(ignoreResync())
+	}
+
+	c, err := builder.
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Build(reconcile.Func(func(req ctrl.Request) (ctrl.Result, error) {
+			r.warmingUp.Wait()
+			return r.Reconcile(req)
+		}))
+	if err != nil {
+		return err
+	}
+
+	return setRateLimiter(c, "attester", r.rateLimiter())
+}
+
+// nonLeaderRunnable is a runnable that the manager starts on every replica, rather than only on the elected leader
+type nonLeaderRunnable manager.RunnableFunc
+
+// Start implements manager.Runnable
+func (f nonLeaderRunnable) Start(stop <-chan struct{}) error {
+	return f(stop)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (nonLeaderRunnable) NeedLeaderElection() bool {
+	return false
+}
+
+// WarmUp loads the policies and signers of all attesters by reconciling each of them, so that resources can be attested
+// as soon as the controller is ready rather than once each attester's first reconcile comes off the queue. Attesters
+// that fail to load are left to the controller to retry. The reconciler reports being ready once it returns.
+func (r *AttesterReconciler) WarmUp(ctx context.Context) error {
+	log := r.Log.WithName("warmup")
+
+	attesters := &rodev1alpha1.AttesterList{}
+	if err := r.List(ctx, attesters); err != nil {
+		log.Error(err, "Unable to list attesters")
+		return err
+	}
+
+	// the attesters are loaded by as many workers as the controller reconciles attesters with
+	workers := r.MaxConcurrentReconciles
+	if workers < 1 {
+		workers = 1
+	}
+	requests := make(chan ctrl.Request)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range requests {
+				r.warmUpAttester(log, req)
+			}
+		}()
+	}
+
+	for _, att := range attesters.Items {
+		requests <- ctrl.Request{NamespacedName: types.NamespacedName{Namespace: att.Namespace, Name: att.Name}}
+	}
+	close(requests)
+	wg.Wait()
+
+	log.Info("Loaded attesters", "loaded", len(r.ListAttesters()), "total", len(attesters.Items))
+	atomic.StoreInt32(&r.warmedUp, 1)
+
+	return nil
+}
+
+// warmUpUntilLoaded warms up until the attesters are loaded or the manager stops, waiting longer after each time the
+// attesters can't be listed rather than stopping the manager
+func (r *AttesterReconciler) warmUpUntilLoaded(stop <-chan struct{}) {
+	delay := warmUpRetryBaseDelay
+	for r.WarmUp(r.baseContext()) != nil {
+		r.Log.Info("Retrying warm-up", "delay", delay)
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > warmUpRetryMaxDelay {
+			delay = warmUpRetryMaxDelay
+		}
+	}
+}
+
+// warmUpAttester reconciles the attester until it's loaded, or fails to load
+func (r *AttesterReconciler) warmUpAttester(log logr.Logger, req ctrl.Request) {
+	for i := 0; i < warmUpReconciles; i++ {
+		result, err := r.Reconcile(req)
+		if err != nil {
+			log.Error(err, "Unable to load attester", "attester", req.NamespacedName)
+			return
+		}
+		if !result.Requeue {
+			return
+		}
+	}
+}
+
+// ReadyzCheck reports that the reconciler isn't ready until WarmUp has loaded the attesters
+func (r *AttesterReconciler) ReadyzCheck(_ *http.Request) error {
+	if atomic.LoadInt32(&r.warmedUp) == 0 {
+		return fmt.Errorf("attesters have not been loaded")
+	}
+
+	return nil
+}
+
+// Reload rebuilds the loaded attesters from the Attester objects in the cluster. Every attester is queued to be
+// reconciled with its policy recompiled and its key reloaded, and loaded attesters that no longer exist are removed.
+func (r *AttesterReconciler) Reload(ctx context.Context) (*ReloadSummary, error) {
+	attesters := &rodev1alpha1.AttesterList{}
+	if err := r.List(ctx, attesters); err != nil {
+		return nil, err
+	}
+
+	summary := &ReloadSummary{
+		Reloaded: []string{},
+		Removed:  []string{},
+	}
+	existing := make(map[string]bool)
+
+	r.reloadMutex.Lock()
+	if r.reloads == nil {
+		r.reloads = make(map[string]bool)
+	}
+	for _, att := range attesters.Items {
+		key := types.NamespacedName{Namespace: att.Namespace, Name: att.Name}.String()
+		existing[key] = true
+		r.reloads[key] = true
+		summary.Reloaded = append(summary.Reloaded, key)
+	}
+	r.reloadMutex.Unlock()
+
+	for key := range r.ListAttesters() {
+		// cluster attesters are reloaded by their own controller
+		if !existing[key] && !isClusterAttesterKey(key) {
+			summary.Removed = append(summary.Removed, key)
+		}
+	}
+
+	sort.Strings(summary.Reloaded)
+	sort.Strings(summary.Removed)
+
+	for _, key := range summary.Reloaded {
+		r.enqueue(key)
+	}
+	for _, key := range summary.Removed {
+		r.enqueue(key)
+	}
+
+	r.Log.Info("Reloading attesters", "reloaded", len(summary.Reloaded), "removed", len(summary.Removed),
+		"attesters", summary.Reloaded, "removedAttesters", summary.Removed)
+
+	return summary, nil
+}
+
+// enqueueReleasedSecrets enqueues the deleted attesters with released secrets, so that the secrets released before a
+// restart are still deleted once their grace period has passed. A failure to list them is logged rather than stopping
+// the manager, and they're listed again after the next restart.
+func (r *AttesterReconciler) enqueueReleasedSecrets(ctx context.Context) {
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.MatchingLabels{attester.SecretReleasedLabel: "true"}); err != nil {
+		r.Log.Error(err, "Unable to list released secrets")
+		return
+	}
+
+	for _, secret := range secrets.Items {
+		if name, ok := secret.Annotations[attester.SecretAttesterAnnotation]; ok {
+			r.enqueue(types.NamespacedName{Namespace: secret.Namespace, Name: name}.String())
+		}
+	}
+}
+
+// enqueueAfter queues the attester with the given namespace/name key to be reconciled once the delay has passed
+func (r *AttesterReconciler) enqueueAfter(key string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		r.enqueue(key)
+	})
+}
+
+// reload queues the attester with the given namespace/name key to be reconciled with its policy recompiled and its key
+// reloaded
+func (r *AttesterReconciler) reload(key string) {
+	r.reloadMutex.Lock()
+	if r.reloads == nil {
+		r.reloads = make(map[string]bool)
+	}
+	r.reloads[key] = true
+	r.reloadMutex.Unlock()
+
+	r.enqueue(key)
+}
+
+// takeReload returns whether the attester was queued by Reload, clearing the request
+func (r *AttesterReconciler) takeReload(key string) bool {
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+
+	if !r.reloads[key] {
+		return false
+	}
+
+	delete(r.reloads, key)
+	return true
+}
+
+// enqueue queues the attester with the given namespace/name key to be reconciled
+func (r *AttesterReconciler) enqueue(key string) {
+	namespacedName := strings.SplitN(key, "/", 2)
+	if len(namespacedName) != 2 {
+		return
+	}
+
+	if namespacedName[0] == "" {
+		if r.enqueueCluster != nil {
+			r.enqueueCluster(namespacedName[1])
+		}
+		return
+	}
+
+	if r.events == nil {
+		return
+	}
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespacedName[0],
+			Name:      namespacedName[1],
+		},
+	}
+
+	// drop the event rather than block the caller if the controller is falling behind
+	select {
+	case r.events <- event.GenericEvent{Meta: att, Object: att}:
+	default:
+	}
 }
 
 // AttesterToConditioner takes an Attester and returns a util.Conditioner
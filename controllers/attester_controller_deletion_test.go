@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+// secrets that aren't controlled by an attester don't reload anything
+func TestAttesterReconciler_UnownedSecretDeleted(t *testing.T) {
+	r := newUnitTestAttesterReconciler()
+	r.signerSecretDeleted(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}})
+	r.signerSecretDeleted("not a secret")
+	assert.Empty(t, r.reloads)
+}
+
+func TestAttesterReconciler_SecretDeletionGracePeriod(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("grace")
+	att.UID = "deleted-uid"
+	att.Spec.PgpSecret = "grace"
+	att.Spec.SecretDeletionGracePeriod = &metav1.Duration{Duration: time.Hour}
+	att.Finalizers = []string{attesterFinalizerName}
+	now := metav1.Now()
+	att.DeletionTimestamp = &now
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "grace",
+			Namespace:       att.Namespace,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(att, rodev1alpha1.GroupVersion.WithKind("Attester"))},
+		},
+	}
+	expired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "expired",
+			Namespace: att.Namespace,
+			Annotations: map[string]string{
+				attester.SecretAttesterAnnotation:    "grace",
+				attester.SecretDeleteAfterAnnotation: time.Now().Add(-time.Minute).Format(time.RFC3339),
+			},
+		},
+	}
+
+	r := newUnitTestAttesterReconciler(att, secret, expired)
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+
+	// the secret is released rather than deleted
+	released := &corev1.Secret{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "grace"}, released)
+	assert.NoError(err)
+	assert.Empty(released.OwnerReferences)
+	assert.Equal("grace", released.Annotations[attester.SecretAttesterAnnotation])
+	assert.Equal("true", released.Labels[attester.SecretReleasedLabel])
+	assert.NotContains(released.Labels, attester.SecretSignerLabel)
+
+	// once the attester is gone, only the secrets past their grace period are deleted
+	err = r.Delete(ctx, att)
+	assert.NoError(err)
+	result, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.True(result.RequeueAfter > 0 && result.RequeueAfter <= time.Hour)
+	err = r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "expired"}, &corev1.Secret{})
+	assert.True(errors.IsNotFound(err))
+
+	// recreating the attester reclaims the released secret
+	recreated := newUnitTestAttester("grace")
+	recreated.Spec.PgpSecret = "grace"
+	err = r.Create(ctx, recreated)
+	assert.NoError(err)
+	reconcileUnitTestAttester(r, recreated, 3)
+
+	reclaimed := &corev1.Secret{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "grace"}, reclaimed)
+	assert.NoError(err)
+	assert.NotContains(reclaimed.Annotations, attester.SecretDeleteAfterAnnotation)
+	assert.NotContains(reclaimed.Labels, attester.SecretReleasedLabel)
+	assert.Equal("true", reclaimed.Labels[attester.SecretSignerLabel])
+	assert.Len(reclaimed.OwnerReferences, 1)
+}
+
+func TestAttesterReconciler_EnqueueReleasedSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	released := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "released",
+			Namespace:   "default",
+			Labels:      map[string]string{attester.SecretReleasedLabel: "true"},
+			Annotations: map[string]string{attester.SecretAttesterAnnotation: "deleted"},
+		},
+	}
+	unrelated := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "unrelated",
+			Namespace:   "default",
+			Annotations: map[string]string{attester.SecretAttesterAnnotation: "unrelated"},
+		},
+	}
+
+	// only the attesters of the secrets labelled as released are enqueued
+	r := newUnitTestAttesterReconciler(released, unrelated)
+	r.events = make(chan event.GenericEvent, 10)
+	r.enqueueReleasedSecrets(context.Background())
+	assert.Len(r.events, 1)
+	e := <-r.events
+	assert.Equal("deleted", e.Meta.GetName())
+
+	// failing to list the secrets is logged rather than returned
+	logger := newRecordingLogger()
+	r.Log = logger
+	r.Client = &failingListClient{Client: r.Client, failures: 1}
+	r.enqueueReleasedSecrets(context.Background())
+	assert.Empty(r.events)
+	assert.Len(*logger.lines, 1)
+}
+
+// failingSecretDeletesClient fails the first failures deletes of a secret
+type failingSecretDeletesClient struct {
+	client.Client
+	failures int
+}
+
+func (c *failingSecretDeletesClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if _, ok := obj.(*corev1.Secret); ok && c.failures > 0 {
+		c.failures--
+		return errors.NewServiceUnavailable("etcd leader changed")
+	}
+
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func TestAttesterReconciler_InterleavedDeletion(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("interleaved")
+	r := newUnitTestAttesterReconciler(att)
+	r.Client = &failingSecretDeletesClient{Client: r.Client, failures: 1}
+	key := unitTestRequest(att).NamespacedName
+	reconcileUnitTestAttester(r, att, 4)
+	assert.Contains(r.Attesters, key.String())
+	secretName := types.NamespacedName{Namespace: att.Namespace, Name: "interleaved"}
+	assert.NoError(r.Get(ctx, secretName, &corev1.Secret{}))
+
+	// another finalizer keeps the attester around after rode's finalizer is removed
+	deleting := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, key, deleting))
+	deleting.Finalizers = append(deleting.Finalizers, "example.com/other")
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+	assert.NoError(r.Update(ctx, deleting))
+
+	finalizers := func() []string {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, key, current))
+		return current.Finalizers
+	}
+
+	// the finalizer is kept until the secret has been deleted, but the attester is unloaded straight away
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.Error(err)
+	assert.Equal([]string{attesterFinalizerName, "example.com/other"}, finalizers())
+	assert.NotContains(r.Attesters, key.String())
+	assert.NoError(r.Get(ctx, secretName, &corev1.Secret{}))
+
+	_, err = r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Equal([]string{"example.com/other"}, finalizers())
+	assert.True(errors.IsNotFound(r.Get(ctx, secretName, &corev1.Secret{})))
+
+	// reconciles queued before the attester was deleted neither recreate the secret nor load the attester
+	for i := 0; i < 3; i++ {
+		_, err = r.Reconcile(unitTestRequest(att))
+		assert.NoError(err)
+	}
+	assert.True(errors.IsNotFound(r.Get(ctx, secretName, &corev1.Secret{})))
+	assert.NotContains(r.Attesters, key.String())
+	assert.Equal([]string{"example.com/other"}, finalizers())
+}
+
+func TestAttesterReconciler_FinalizerName(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("finalizers")
+	dev := newUnitTestAttesterReconciler(att)
+	dev.FinalizerName = "attester.finalizers.dev.rode.liatr.io"
+	prod := newUnitTestAttesterReconciler()
+	prod.Client = dev.Client
+	prod.FinalizerName = "attester.finalizers.prod.rode.liatr.io"
+
+	finalizers := func() []string {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(dev.Get(ctx, unitTestRequest(att).NamespacedName, current))
+		return current.Finalizers
+	}
+
+	// each installation registers its own finalizer
+	reconcileUnitTestAttester(dev, att, 2)
+	reconcileUnitTestAttester(prod, att, 2)
+	assert.Equal([]string{dev.FinalizerName, prod.FinalizerName}, finalizers())
+
+	deleting := &rodev1alpha1.Attester{}
+	assert.NoError(dev.Get(ctx, unitTestRequest(att).NamespacedName, deleting))
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+	assert.NoError(dev.Update(ctx, deleting))
+
+	// and only removes its own finalizer when the attester is deleted
+	_, err := dev.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Equal([]string{prod.FinalizerName}, finalizers())
+
+	_, err = prod.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Empty(finalizers())
+
+	// reconcilers without a name use the default
+	assert.Equal(attesterFinalizerName, newUnitTestAttesterReconciler().finalizerName())
+}
+
+func TestAttesterReconciler_NoFinalizer(t *testing.T) {
+	tests := map[string]struct {
+		noFinalizer bool
+		annotations map[string]string
+	}{
+		"annotation": {false, map[string]string{attester.NoFinalizerAnnotation: "true"}},
+		"reconciler": {true, nil},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx := context.Background()
+
+			att := newUnitTestAttester("ephemeral")
+			att.Annotations = tc.annotations
+			r := newUnitTestAttesterReconciler(att)
+			r.NoFinalizer = tc.noFinalizer
+			key := unitTestRequest(att).NamespacedName
+			reconcileUnitTestAttester(r, att, 4)
+
+			// the attester is loaded without a finalizer, and its secret is left to the garbage collector
+			loaded := &rodev1alpha1.Attester{}
+			assert.NoError(r.Get(ctx, key, loaded))
+			assert.Empty(loaded.Finalizers)
+			assert.Contains(r.Attesters, key.String())
+			secret := &corev1.Secret{}
+			assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "ephemeral"}, secret))
+			assert.Len(secret.OwnerReferences, 1)
+			assert.Equal("ephemeral", secret.OwnerReferences[0].Name)
+
+			// so deleting it completes straight away, without the controller
+			assert.NoError(r.Delete(ctx, loaded))
+			assert.True(errors.IsNotFound(r.Get(ctx, key, &rodev1alpha1.Attester{})))
+
+			// and the controller unloads it once it's gone
+			_, err := r.Reconcile(unitTestRequest(att))
+			assert.NoError(err)
+			assert.NotContains(r.Attesters, key.String())
+		})
+	}
+
+	// other attesters still get the finalizer
+	assert := assert.New(t)
+	att := newUnitTestAttester("finalized")
+	r := newUnitTestAttesterReconciler(att)
+	reconcileUnitTestAttester(r, att, 4)
+	finalized := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(context.Background(), unitTestRequest(att).NamespacedName, finalized))
+	assert.Equal([]string{attesterFinalizerName}, finalized.Finalizers)
+}
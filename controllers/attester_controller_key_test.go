@@ -0,0 +1,586 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func TestAttesterReconciler_DecryptsPassphraseProtectedKey(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := ioutil.ReadFile("../test/pgp_encrypted_key.asc")
+	assert.NoError(err)
+
+	for passphrase, shouldLoad := range map[string]bool{"correct horse": true, "wrong": false} {
+		att := newUnitTestAttester("passphrase")
+		att.Spec.PgpSecret = "imported"
+		att.Spec.PgpPassphraseSecretRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "imported-passphrase"},
+			Key:                  "passphrase",
+		}
+
+		keySecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "imported", Namespace: att.Namespace},
+			Data:       map[string][]byte{"keys": key},
+		}
+		passphraseSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "imported-passphrase", Namespace: att.Namespace},
+			Data:       map[string][]byte{"passphrase": []byte(passphrase)},
+		}
+
+		r := newUnitTestAttesterReconciler(att, keySecret, passphraseSecret)
+		reconcileUnitTestAttester(r, att, 3)
+
+		_, loaded := r.Attesters[unitTestRequest(att).NamespacedName.String()]
+		assert.Equal(shouldLoad, loaded, "passphrase %s", passphrase)
+	}
+}
+
+func TestAttesterReconciler_EncryptsGeneratedKey(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("encrypted")
+	att.Spec.PgpSecret = "encrypted"
+	att.Spec.PgpPassphraseSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "encrypted-passphrase"},
+		Key:                  "passphrase",
+	}
+
+	// the key isn't generated until the passphrase is available
+	r := newUnitTestAttesterReconciler(att)
+	reconcileUnitTestAttester(r, att, 3)
+	err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "encrypted"}, &corev1.Secret{})
+	assert.True(errors.IsNotFound(err))
+
+	current := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+	condition := attesterCondition(current, rodev1alpha1.ConditionSecret)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, condition.Status)
+	assert.Equal("PassphraseUnavailable", condition.Reason)
+
+	passphraseSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "encrypted-passphrase", Namespace: att.Namespace},
+		Data:       map[string][]byte{"passphrase": []byte("correct horse")},
+	}
+	assert.NoError(r.Create(ctx, passphraseSecret))
+	reconcileUnitTestAttester(r, att, 3)
+
+	_, loaded := r.Attesters[unitTestRequest(att).NamespacedName.String()]
+	assert.True(loaded)
+
+	keySecret := &corev1.Secret{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "encrypted"}, keySecret))
+	_, err = attester.ReadSigner(bytes.NewReader(keySecret.Data["keys"]))
+	assert.Error(err)
+	s, err := attester.ReadSignerWithPassphrase(bytes.NewReader(keySecret.Data["keys"]), []byte("correct horse"))
+	assert.NoError(err)
+	assert.Equal(r.signers[unitTestRequest(att).NamespacedName.String()].Signer().KeyID(), s.KeyID())
+}
+
+func TestAttesterReconciler_RequeuesUntilPassphraseAvailable(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("passphrase")
+	att.Spec.PgpPassphraseSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "later-passphrase"},
+		Key:                  "passphrase",
+	}
+	r := newUnitTestAttesterReconciler(att)
+	key := unitTestRequest(att).NamespacedName.String()
+
+	// reconciles the attester until it's requeued, returning the result
+	reconcile := func() ctrl.Result {
+		for i := 0; i < 5; i++ {
+			result, err := r.Reconcile(unitTestRequest(att))
+			assert.NoError(err)
+			if result.RequeueAfter > 0 {
+				return result
+			}
+		}
+		return ctrl.Result{}
+	}
+
+	// an attester whose passphrase secret doesn't exist yet is requeued rather than waiting for another change
+	assert.Equal(passphraseRetryInterval, reconcile().RequeueAfter)
+	assert.NotContains(r.Attesters, key)
+
+	passphraseSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "later-passphrase", Namespace: att.Namespace},
+		Data:       map[string][]byte{"passphrase": []byte("correct horse")},
+	}
+	assert.NoError(r.Create(ctx, passphraseSecret))
+
+	// so it's loaded when it's reconciled again once the passphrase secret is created
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Contains(r.Attesters, key)
+
+	// the same goes for reading the key back from its secret
+	assert.NoError(r.Delete(ctx, passphraseSecret))
+	r.reload(key)
+	assert.Equal(passphraseRetryInterval, reconcile().RequeueAfter)
+	current := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+	assert.Equal("PassphraseUnavailable", attesterCondition(current, rodev1alpha1.ConditionSecret).Reason)
+}
+
+func TestAttesterReconciler_PublicKeyStatus(t *testing.T) {
+	for _, keyType := range []rodev1alpha1.KeyType{rodev1alpha1.KeyTypePGPRSA, rodev1alpha1.KeyTypeEd25519} {
+		t.Run(string(keyType), func(t *testing.T) {
+			assert := assert.New(t)
+			ctx := context.Background()
+
+			att := newUnitTestAttester("public")
+			att.Spec.KeyType = keyType
+			r := newUnitTestAttesterReconciler(att)
+			reconcileUnitTestAttester(r, att, 3)
+
+			current := &rodev1alpha1.Attester{}
+			assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+			key := unitTestRequest(att).NamespacedName.String()
+			assert.Equal(r.signers[key].Signer().KeyID(), current.Status.KeyID)
+
+			verifier, err := attester.ReadVerifier(strings.NewReader(current.Status.PublicKey))
+			assert.NoError(err)
+			assert.Equal(current.Status.KeyID, verifier.KeyID())
+
+			res, err := r.Attesters[key].Attest(ctx, &attester.AttestRequest{ResourceURI: "harbor.example.com/app@sha256:123"})
+			assert.NoError(err)
+			assert.NoError(attester.VerifyAttestation(verifier, res.Attestation, ""))
+		})
+	}
+}
+
+func TestAttesterReconciler_SignatureFormat(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("dsse")
+	att.Spec.SignatureFormat = rodev1alpha1.SignatureFormatDSSE
+	r := newUnitTestAttesterReconciler(att)
+	reconcileUnitTestAttester(r, att, 4)
+
+	loaded, ok := r.Attesters[unitTestRequest(att).NamespacedName.String()]
+	assert.True(ok)
+	res, err := loaded.Attest(ctx, &attester.AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+
+	decoded, err := base64.StdEncoding.DecodeString(res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetSignature())
+	assert.NoError(err)
+	envelope := &attester.DSSEEnvelope{}
+	assert.NoError(json.Unmarshal(decoded, envelope))
+	assert.Equal(attester.DSSEPayloadType, envelope.PayloadType)
+	assert.NoError(loaded.Verify(ctx, &attester.VerifyRequest{Occurrence: res.Attestation}))
+}
+
+func TestAttesterReconciler_PublishesPublicKeys(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	first := newUnitTestAttester("first")
+	second := newUnitTestAttester("second")
+	r := newUnitTestAttesterReconciler(first, second)
+	r.PublicKeysConfigMap = types.NamespacedName{Namespace: "rode", Name: "attester-public-keys"}
+
+	publicKeys := func() map[string]string {
+		configMap := &corev1.ConfigMap{}
+		assert.NoError(r.Get(ctx, r.PublicKeysConfigMap, configMap))
+		return configMap.Data
+	}
+	status := func(att *rodev1alpha1.Attester) rodev1alpha1.AttesterStatus {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+		return current.Status
+	}
+
+	// the ConfigMap is created with the key of the first attester that's loaded, and the others are added to it
+	reconcileUnitTestAttester(r, first, 5)
+	reconcileUnitTestAttester(r, second, 5)
+	assert.Equal(map[string]string{
+		"default.first":  status(first).PublicKey,
+		"default.second": status(second).PublicKey,
+	}, publicKeys())
+
+	// a new key replaces the published one
+	previous := status(first).PublicKey
+	secret := &corev1.Secret{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "first"}, secret))
+	assert.NoError(r.Delete(ctx, secret))
+	r.signerSecretDeleted(secret)
+	reconcileUnitTestAttester(r, first, 2)
+	assert.NotEqual(previous, status(first).PublicKey)
+	assert.Equal(status(first).PublicKey, publicKeys()["default.first"])
+
+	// a deleted attester's key is removed, leaving the others
+	assert.NoError(r.Get(ctx, unitTestRequest(first).NamespacedName, first))
+	assert.NoError(r.Delete(ctx, first))
+	reconcileUnitTestAttester(r, first, 1)
+	assert.Equal(map[string]string{"default.second": status(second).PublicKey}, publicKeys())
+}
+
+func TestAttesterReconciler_RecordsKeyEscrow(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("escrow")
+	att.Spec.KeyEscrow = &rodev1alpha1.KeyEscrow{
+		Threshold: 2,
+		Secrets:   []corev1.SecretReference{{Name: "escrow-a"}, {Name: "escrow-b"}},
+	}
+	r := newUnitTestAttesterReconciler(att)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Reconcile(unitTestRequest(att))
+		assert.NoError(err)
+	}
+	assert.Contains(r.Attesters, "default/escrow")
+	assert.Contains(eventReasons(recorder), "Normal KeyEscrowed")
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "escrow"}, secret)
+	assert.NoError(err)
+	assert.Equal("2 of default/escrow-a,default/escrow-b", secret.Annotations[attester.SecretEscrowAnnotation])
+}
+
+func TestAttesterReconciler_RotatesSigner(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("rotate")
+	att.Generation = 1
+	r := newUnitTestAttesterReconciler(att)
+	r.events = make(chan event.GenericEvent, 10)
+	key := unitTestRequest(att).NamespacedName.String()
+
+	reconcileUnitTestAttester(r, att, 4)
+	loaded, ok := r.Attesters[key]
+	assert.True(ok)
+
+	// replace the key in the secret and reload it
+	rotated, err := attester.NewSigner(key)
+	assert.NoError(err)
+	buf := &bytes.Buffer{}
+	assert.NoError(rotated.Serialize(buf))
+
+	secret := &corev1.Secret{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "rotate"}, secret))
+	secret.Data["keys"] = buf.Bytes()
+	assert.NoError(r.Update(ctx, secret))
+
+	_, err = r.Reload(ctx)
+	assert.NoError(err)
+	_, err = r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+
+	// the attester loaded before the rotation signs with the new key
+	res, err := loaded.Attest(ctx, &attester.AttestRequest{ResourceURI: "harbor.liatr.io/rode/app@sha256:abc"})
+	assert.NoError(err)
+	assert.Equal(rotated.KeyID(), res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId())
+	assert.NoError(r.Attesters[key].Verify(ctx, &attester.VerifyRequest{Occurrence: res.Attestation}))
+}
+
+func TestAttesterReconciler_RejectsWeakKey(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	for bits, shouldLoad := range map[int]bool{1024: false, 2048: true} {
+		entity, err := openpgp.NewEntity("imported", "", "", &packet.Config{DefaultHash: crypto.SHA256, RSABits: bits})
+		assert.NoError(err)
+		buf := &bytes.Buffer{}
+		assert.NoError(entity.SerializePrivate(buf, nil))
+
+		att := newUnitTestAttester("strength")
+		att.Spec.PgpSecret = "imported"
+		keySecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "imported", Namespace: att.Namespace},
+			Data:       map[string][]byte{"keys": buf.Bytes()},
+		}
+
+		r := newUnitTestAttesterReconciler(att, keySecret)
+		r.KeyStrength = &attester.DefaultKeyStrengthPolicy
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		reconcileUnitTestAttester(r, att, 3)
+
+		_, loaded := r.Attesters[unitTestRequest(att).NamespacedName.String()]
+		assert.Equal(shouldLoad, loaded, "%d bit key", bits)
+
+		updated := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, updated))
+		if shouldLoad {
+			assert.Equal(rodev1alpha1.ConditionStatusTrue, updated.Status.Conditions[1].Status)
+			continue
+		}
+
+		assert.Equal(rodev1alpha1.ConditionStatusFalse, updated.Status.Conditions[1].Status)
+		assert.Contains(updated.Status.Conditions[1].Message, "RSA key size 1024 is below the minimum of 2048")
+		assert.Contains(eventReasons(recorder), "Warning WeakKey")
+	}
+}
+
+func TestAttesterReconciler_RotatesKeyAfterInterval(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	subject := "harbor.liatr.io/rode/app@sha256:abc"
+
+	att := newUnitTestAttester("rotation")
+	att.Spec.KeyRotation = &rodev1alpha1.KeyRotation{
+		RotateAfter: metav1.Duration{Duration: 24 * time.Hour},
+		GracePeriod: &metav1.Duration{Duration: 12 * time.Hour},
+	}
+	r := newUnitTestAttesterReconciler(att)
+	fakeClock := clock.NewFakeClock(start)
+	r.Clock = fakeClock
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	key := unitTestRequest(att).NamespacedName.String()
+	secretName := types.NamespacedName{Namespace: att.Namespace, Name: "rotation"}
+
+	reconcileUnitTestAttester(r, att, 4)
+	eventReasons(recorder)
+	original, err := r.Attesters[key].Attest(ctx, &attester.AttestRequest{ResourceURI: subject})
+	assert.NoError(err)
+	originalKeyID := original.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId()
+
+	// the fake client doesn't set creation timestamps
+	secret := &corev1.Secret{}
+	assert.NoError(r.Get(ctx, secretName, secret))
+	secret.CreationTimestamp = metav1.NewTime(start)
+	assert.NoError(r.Update(ctx, secret))
+
+	// the key isn't rotated before the interval, and the attester is requeued for when it's due
+	fakeClock.Step(23 * time.Hour)
+	result, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Equal(time.Hour, result.RequeueAfter)
+	assert.Empty(eventReasons(recorder))
+
+	fakeClock.Step(2 * time.Hour)
+	reconcileUnitTestAttester(r, att, 3)
+	assert.Equal([]string{"Normal KeyRotated"}, eventReasons(recorder))
+
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, updated))
+	assert.Len(updated.Status.PreviousPublicKeys, 1)
+	previous := updated.Status.PreviousPublicKeys[0]
+	assert.Equal(originalKeyID, previous.KeyID)
+	assert.Equal(start.Add(37*time.Hour), previous.ExpiresAt.UTC())
+
+	verifier, err := attester.ReadVerifier(strings.NewReader(previous.PublicKey))
+	assert.NoError(err)
+	assert.NoError(attester.VerifyAttestation(verifier, original.Attestation, ""))
+
+	// new attestations are signed with the new key, and those signed with the old key are accepted for the grace period
+	rotated, err := r.Attesters[key].Attest(ctx, &attester.AttestRequest{ResourceURI: subject})
+	assert.NoError(err)
+	assert.NotEqual(originalKeyID, rotated.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId())
+	assert.NoError(r.Attesters[key].Verify(ctx, &attester.VerifyRequest{Occurrence: original.Attestation}))
+
+	// once the grace period has passed the old key is removed and no longer accepted, without rotating again
+	fakeClock.Step(13 * time.Hour)
+	reconcileUnitTestAttester(r, att, 3)
+	assert.Empty(eventReasons(recorder))
+
+	updated = &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, updated))
+	assert.Empty(updated.Status.PreviousPublicKeys)
+	assert.Error(r.Attesters[key].Verify(ctx, &attester.VerifyRequest{Occurrence: original.Attestation}))
+	assert.NoError(r.Attesters[key].Verify(ctx, &attester.VerifyRequest{Occurrence: rotated.Attestation}))
+}
+
+func TestAttesterReconciler_ImportsKey(t *testing.T) {
+	entity, err := openpgp.NewEntity("external", "", "", &packet.Config{DefaultHash: crypto.SHA256, RSABits: 2048})
+	assert.NoError(t, err)
+	buf := &bytes.Buffer{}
+	assert.NoError(t, entity.SerializePrivate(buf, nil))
+
+	tests := map[string]struct {
+		data    map[string][]byte
+		message string
+	}{
+		"valid key":     {map[string][]byte{"keys": buf.Bytes()}, ""},
+		"malformed key": {map[string][]byte{"keys": []byte("not a key")}, "secret external does not contain a valid private key in keys"},
+		"missing key":   {map[string][]byte{"other": buf.Bytes()}, "secret external does not contain the imported key in keys"},
+		"no secret":     {nil, "secret external containing the imported key does not exist"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx := context.Background()
+
+			att := newUnitTestAttester("imported")
+			att.Spec.PgpSecretRef = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "external"},
+				Key:                  "keys",
+			}
+
+			objs := []runtime.Object{att}
+			if tc.data != nil {
+				objs = append(objs, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: att.Namespace},
+					Data:       tc.data,
+				})
+			}
+
+			r := newUnitTestAttesterReconciler(objs...)
+			recorder := record.NewFakeRecorder(10)
+			r.Recorder = recorder
+			reconcileUnitTestAttester(r, att, 3)
+
+			_, ok := r.Attesters[unitTestRequest(att).NamespacedName.String()]
+			updated := &rodev1alpha1.Attester{}
+			assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, updated))
+			assert.Empty(updated.Spec.PgpSecret)
+
+			// the secret is never created or overwritten
+			secret := &corev1.Secret{}
+			err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: "external"}, secret)
+			if tc.data == nil {
+				assert.True(errors.IsNotFound(err))
+			} else {
+				assert.NoError(err)
+				assert.Equal(tc.data, secret.Data)
+				assert.Empty(secret.OwnerReferences)
+			}
+
+			if tc.message == "" {
+				assert.True(ok)
+				assert.Equal(entity.PrimaryKey.KeyIdString(), r.signers[unitTestRequest(att).NamespacedName.String()].Signer().KeyID())
+				assert.Equal(rodev1alpha1.ConditionStatusTrue, updated.Status.Conditions[1].Status)
+				return
+			}
+
+			assert.False(ok)
+			assert.Equal(rodev1alpha1.ConditionStatusFalse, updated.Status.Conditions[1].Status)
+			assert.Contains(updated.Status.Conditions[1].Message, tc.message)
+			assert.Contains(eventReasons(recorder), "Warning KeyImportFailed")
+		})
+	}
+}
+
+// mapSignerStore is a signer store that keeps keys in memory, keyed by the attester's VaultPath
+type mapSignerStore struct {
+	signers map[string]attester.Signer
+	deleted []string
+}
+
+func (s *mapSignerStore) CreateSigner(ctx context.Context, att *rodev1alpha1.Attester, passphrase []byte) (attester.Signer, error) {
+	if signer, ok := s.signers[att.Spec.VaultPath]; ok {
+		return signer, nil
+	}
+
+	signer, err := attester.NewSigner(att.Name)
+	if err != nil {
+		return nil, err
+	}
+	s.signers[att.Spec.VaultPath] = signer
+	return signer, nil
+}
+
+func (s *mapSignerStore) ReadSigner(ctx context.Context, att *rodev1alpha1.Attester, passphrase []byte) (attester.Signer, error) {
+	return s.signers[att.Spec.VaultPath], nil
+}
+
+func (s *mapSignerStore) DeleteSigner(ctx context.Context, att *rodev1alpha1.Attester) (bool, error) {
+	if _, ok := s.signers[att.Spec.VaultPath]; !ok {
+		return false, nil
+	}
+
+	delete(s.signers, att.Spec.VaultPath)
+	s.deleted = append(s.deleted, att.Spec.VaultPath)
+	return true, nil
+}
+
+func TestAttesterReconciler_VaultStore(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("vault")
+	att.Spec.VaultPath = "rode/default/vault"
+	store := &mapSignerStore{signers: make(map[string]attester.Signer)}
+
+	r := newUnitTestAttesterReconciler(att)
+	recorder := record.NewFakeRecorder(20)
+	r.Recorder = recorder
+	r.VaultStore = store
+	reconcileUnitTestAttester(r, att, 5)
+
+	// the key is generated in the store rather than a secret
+	assert.Contains(r.ListAttesters(), "default/vault")
+	assert.Contains(store.signers, "rode/default/vault")
+	secrets := &corev1.SecretList{}
+	assert.NoError(r.List(ctx, secrets))
+	assert.Empty(secrets.Items)
+
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, updated))
+	assert.Empty(updated.Spec.PgpSecret)
+	assert.Equal("VaultKeyCreated", attesterCondition(updated, rodev1alpha1.ConditionSecret).Reason)
+	assert.Equal(store.signers["rode/default/vault"].KeyID(), updated.Status.KeyID)
+	assert.Contains(eventReasons(recorder), "Normal VaultKeyCreated")
+
+	// the stored key is loaded again after a restart
+	restarted := newUnitTestAttesterReconciler(updated)
+	restarted.VaultStore = store
+	updated.Status.Conditions = nil
+	assert.NoError(restarted.Update(ctx, updated))
+	reconcileUnitTestAttester(restarted, att, 5)
+	assert.NoError(restarted.Get(ctx, unitTestRequest(att).NamespacedName, updated))
+	assert.Equal("VaultKeyLoaded", attesterCondition(updated, rodev1alpha1.ConditionSecret).Reason)
+	assert.Equal(store.signers["rode/default/vault"].KeyID(), updated.Status.KeyID)
+
+	// the key is deleted from the store with the attester
+	now := metav1.Now()
+	updated.DeletionTimestamp = &now
+	assert.NoError(restarted.Update(ctx, updated))
+	_, err := restarted.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Equal([]string{"rode/default/vault"}, store.deleted)
+}
+
+func TestAttesterReconciler_VaultStoreNotConfigured(t *testing.T) {
+	assert := assert.New(t)
+
+	att := newUnitTestAttester("vault")
+	att.Spec.VaultPath = "rode/default/vault"
+
+	r := newUnitTestAttesterReconciler(att)
+	reconcileUnitTestAttester(r, att, 5)
+	assert.NotContains(r.ListAttesters(), "default/vault")
+
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(context.Background(), unitTestRequest(att).NamespacedName, updated))
+	condition := attesterCondition(updated, rodev1alpha1.ConditionSecret)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, condition.Status)
+	assert.Equal("VaultKeyFailed", condition.Reason)
+	assert.Contains(condition.Message, "isn't configured with a Vault server")
+}
@@ -0,0 +1,593 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/test"
+)
+
+func TestAttesterReconciler_OPATraceAnnotation(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("traced")
+	r := newUnitTestAttesterReconciler(att)
+	logger := newRecordingLogger()
+	r.Log = logger
+	key := unitTestRequest(att).NamespacedName.String()
+
+	traces := func() []string {
+		_, err := r.Attesters[key].Evaluate(ctx, &attester.AttestRequest{ResourceURI: "harbor.example.com/app@sha256:123"})
+		assert.NoError(err)
+
+		found := make([]string, 0)
+		for _, line := range *logger.lines {
+			if trace, ok := line["trace"]; ok {
+				found = append(found, trace.(string))
+			}
+		}
+		*logger.lines = nil
+		return found
+	}
+
+	// tracing is off by default
+	reconcileUnitTestAttester(r, att, 3)
+	assert.Empty(traces())
+
+	current := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+	current.Annotations = map[string]string{OPATraceAnnotation: "true"}
+	assert.NoError(r.Update(ctx, current))
+	reconcileUnitTestAttester(r, att, 1)
+
+	found := traces()
+	assert.Len(found, 1)
+	assert.Contains(found[0], "Enter data.traced.violation")
+
+	current = &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+	current.Annotations = nil
+	assert.NoError(r.Update(ctx, current))
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Empty(traces())
+}
+
+func TestAttesterReconciler_PolicyModules(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	lib := "package lib\n\nunfinished(status) {\n\tstatus != \"FINISHED_SUCCESS\"\n}\n"
+	att := newUnitTestAttester("modules")
+	att.Spec.Policy = ""
+	att.Spec.Policies = map[string]string{
+		"lib.rego":  lib,
+		"main.rego": "package modules\n\nimport data.lib\n\nviolation[{\"msg\": \"analysis not finished\"}] {\n\tlib.unfinished(input.occurrences[_].discovered.discovered.analysisStatus)\n}\n",
+	}
+	broken := newUnitTestAttester("broken")
+	broken.Spec.Policy = ""
+	broken.Spec.Policies = map[string]string{"lib.rego": "package lib\nunfinished(status) {", "main.rego": unitTestPolicy("broken")}
+	conflict := newUnitTestAttester("conflict")
+	conflict.Spec.Policies = map[string]string{"lib.rego": lib}
+
+	r := newUnitTestAttesterReconciler(att, broken, conflict)
+	for _, a := range []*rodev1alpha1.Attester{att, broken, conflict} {
+		reconcileUnitTestAttester(r, a, 4)
+	}
+
+	// the modules are compiled together so that the main module can use the rules of the other
+	loaded, ok := r.ListAttesters()[unitTestRequest(att).NamespacedName.String()]
+	assert.True(ok)
+	violations, err := loaded.Evaluate(ctx, &attester.AttestRequest{
+		Occurrences: []*grafeas.Occurrence{{
+			Details: &grafeas.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_PENDING},
+				},
+			},
+		}},
+	})
+	assert.NoError(err)
+	assert.Len(violations, 1)
+
+	messages := map[string]string{
+		"broken":   "lib.rego",
+		"conflict": "policies can't be set along with policy or policyConfigMapRef",
+	}
+	for _, a := range []*rodev1alpha1.Attester{broken, conflict} {
+		assert.NotContains(r.ListAttesters(), unitTestRequest(a).NamespacedName.String())
+		updated := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, unitTestRequest(a).NamespacedName, updated))
+		compiled := attesterCondition(updated, rodev1alpha1.ConditionCompiled)
+		assert.Equal(rodev1alpha1.ConditionStatusFalse, compiled.Status, a.Name)
+		assert.Contains(compiled.Message, messages[a.Name])
+	}
+}
+
+func TestAttesterReconciler_PolicyEvalTimeout(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	assert.Equal(5*time.Second, (&AttesterReconciler{}).policyEvalTimeout())
+	assert.Equal(time.Duration(0), (&AttesterReconciler{PolicyEvalTimeout: -1}).policyEvalTimeout())
+
+	// the policy iterates over every triple of occurrences, which takes far longer than the timeout
+	att := newUnitTestAttester("slow")
+	att.Spec.Policy = `
+package slow
+
+violation[{"msg": "never"}] {
+	count([1 | input.occurrences[_]; input.occurrences[_]; input.occurrences[_]]) < 0
+}
+`
+	r := newUnitTestAttesterReconciler(att)
+	r.PolicyEvalTimeout = 100 * time.Millisecond
+	reconcileUnitTestAttester(r, att, 4)
+
+	req := &attester.AttestRequest{}
+	for i := 0; i < 2000; i++ {
+		req.Occurrences = append(req.Occurrences, &grafeas.Occurrence{Name: fmt.Sprintf("occurrence-%d", i)})
+	}
+
+	loaded, ok := r.ListAttesters()[unitTestRequest(att).NamespacedName.String()]
+	assert.True(ok)
+	violations, err := loaded.Evaluate(ctx, req)
+	assert.NoError(err)
+	assert.Len(violations, 1)
+	assert.Equal("policy evaluation timed out after 100ms", violations[0].Msg)
+}
+
+func TestAttesterReconciler_Builtins(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("allowlist")
+	att.Spec.Policy = `
+package allowlist
+
+violation[{"msg": "image not allowed"}] {
+	uri := input.occurrences[_].resource.uri
+	not rode.image_allowed(uri)
+}
+`
+	key := unitTestRequest(att).NamespacedName
+
+	// the policy doesn't compile without the builtin
+	r := newUnitTestAttesterReconciler(att)
+	reconcileUnitTestAttester(r, att, 4)
+	assert.NotContains(r.ListAttesters(), key.String())
+
+	r = newUnitTestAttesterReconciler(att)
+	r.Builtins = []attester.Builtin{attester.NewImageAllowlist([]string{"sha256:123"}).Builtin()}
+	reconcileUnitTestAttester(r, att, 4)
+	loaded, ok := r.ListAttesters()[key.String()]
+	assert.True(ok)
+
+	evaluate := func(uri string) []*attester.Violation {
+		violations, err := loaded.Evaluate(ctx, &attester.AttestRequest{
+			ResourceURI: uri,
+			Occurrences: []*grafeas.Occurrence{{Name: "scan", Resource: &grafeas.Resource{Uri: uri}}},
+		})
+		assert.NoError(err)
+		return violations
+	}
+	assert.Empty(evaluate("harbor.example.com/app@sha256:123"))
+	assert.Len(evaluate("harbor.example.com/app@sha256:456"), 1)
+}
+
+func TestAttesterReconciler_KeepsPolicyWhenCompileFails(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("versioned")
+	att.Generation = 1
+	r := newUnitTestAttesterReconciler(att)
+	key := unitTestRequest(att).NamespacedName.String()
+	pending := &attester.AttestRequest{
+		ResourceURI: "image",
+		Occurrences: []*grafeas.Occurrence{{
+			Details: &grafeas.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_PENDING},
+				},
+			},
+		}},
+	}
+
+	reconcileUnitTestAttester(r, att, 4)
+	loaded, ok := r.Attesters[key]
+	assert.True(ok)
+	result := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, result))
+	version := result.Status.PolicyVersion
+	assert.NotEmpty(version)
+
+	// a policy that doesn't compile leaves the previous policy loaded
+	result.Spec.Policy = "package versioned\n\nviolation[{\"msg\": msg}] {\n\tmsg := undefined\n"
+	result.Generation = 2
+	assert.NoError(r.Update(ctx, result))
+	reconcileUnitTestAttester(r, att, 1)
+
+	assert.Same(loaded, r.Attesters[key])
+	violations, err := r.Attesters[key].Evaluate(ctx, pending)
+	assert.NoError(err)
+	assert.Len(violations, 1)
+
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, result))
+	assert.Equal(version, result.Status.PolicyVersion)
+	condition := attesterCondition(result, rodev1alpha1.ConditionCompiled)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, condition.Status)
+	assert.Equal("PolicyCompileFailed", condition.Reason)
+	assert.Contains(condition.Message, "still evaluating policy version "+version)
+
+	// once the policy compiles, it replaces the previous one
+	result.Spec.Policy = "package versioned\n\nviolation[{\"msg\": \"never\"}] {\n\tfalse\n}\n"
+	result.Generation = 3
+	assert.NoError(r.Update(ctx, result))
+	reconcileUnitTestAttester(r, att, 4)
+
+	assert.False(loaded == r.Attesters[key])
+	violations, err = r.Attesters[key].Evaluate(ctx, pending)
+	assert.NoError(err)
+	assert.Empty(violations)
+
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, result))
+	assert.NotEmpty(result.Status.PolicyVersion)
+	assert.NotEqual(version, result.Status.PolicyVersion)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, attesterCondition(result, rodev1alpha1.ConditionCompiled).Status)
+}
+
+func TestAttesterReconciler_ReusesCompiledPolicy(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("compilecache")
+	att.Generation = 1
+	r := newUnitTestAttesterReconciler(att)
+	name := unitTestRequest(att).NamespacedName
+	compiles := func() uint64 {
+		metric := &dto.Metric{}
+		assert.NoError(attesterPolicyCompileDuration.WithLabelValues(name.Namespace, name.Name).(prometheus.Metric).Write(metric))
+		return metric.GetHistogram().GetSampleCount()
+	}
+	update := func(mutate func(att *rodev1alpha1.Attester)) {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, name, current))
+		mutate(current)
+		current.Generation++
+		assert.NoError(r.Update(ctx, current))
+		reconcileUnitTestAttester(r, att, 4)
+	}
+
+	reconcileUnitTestAttester(r, att, 4)
+	assert.Equal(uint64(1), compiles())
+	loaded := r.Attesters[name.String()]
+
+	// reloading the attester or changing the rest of its spec reuses the compiled policy
+	r.reload(name.String())
+	reconcileUnitTestAttester(r, att, 1)
+	update(func(att *rodev1alpha1.Attester) {
+		att.Spec.SecretDeletionGracePeriod = &metav1.Duration{Duration: time.Hour}
+	})
+	assert.Equal(uint64(1), compiles())
+	assert.GreaterOrEqual(testutil.ToFloat64(attesterPolicyCompileCacheHits.WithLabelValues(name.Namespace, name.Name)), float64(2))
+	assert.False(loaded == r.Attesters[name.String()])
+
+	// changing the policy compiles it, and so does changing it back, since only the last compiled policy is kept
+	original := att.Spec.Policy
+	update(func(att *rodev1alpha1.Attester) {
+		att.Spec.Policy = "package compilecache\n\nviolation[{\"msg\": \"never\"}] {\n\tfalse\n}\n"
+	})
+	assert.Equal(uint64(2), compiles())
+	update(func(att *rodev1alpha1.Attester) { att.Spec.Policy = original })
+	assert.Equal(uint64(3), compiles())
+	r.reload(name.String())
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Equal(uint64(3), compiles())
+
+	// the query and tracing are compiled into the policy too
+	update(func(att *rodev1alpha1.Attester) { att.Spec.PolicyQuery = "data.compilecache.violation" })
+	assert.Equal(uint64(4), compiles())
+	update(func(att *rodev1alpha1.Attester) { att.Annotations = map[string]string{OPATraceAnnotation: "true"} })
+	assert.Equal(uint64(5), compiles())
+
+	// the cached policy is forgotten when the attester is unloaded
+	r.unloadAttester(name.String())
+	reconcileUnitTestAttester(r, att, 4)
+	assert.Equal(uint64(6), compiles())
+}
+
+func TestAttesterReconciler_PolicyHasNoRules(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	empty := newUnitTestAttester("empty")
+	empty.Spec.Policy = "package empty\n\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}\n"
+	normal := newUnitTestAttester("normal")
+	r := newUnitTestAttesterReconciler(empty, normal)
+	recorder := record.NewFakeRecorder(20)
+	r.Recorder = recorder
+
+	// the policy without rules compiles and the attester is loaded, with a warning
+	reconcileUnitTestAttester(r, empty, 4)
+	assert.Contains(r.ListAttesters(), unitTestRequest(empty).NamespacedName.String())
+	assert.Contains(eventReasons(recorder), "Warning PolicyHasNoRules")
+	result := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(empty).NamespacedName, result))
+	condition := attesterCondition(result, rodev1alpha1.ConditionCompiled)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, condition.Status)
+	assert.Equal("PolicyHasNoRules", condition.Reason)
+	assert.Contains(condition.Message, "rules are false")
+
+	// the warning isn't repeated on later reconciles
+	reconcileUnitTestAttester(r, empty, 2)
+	assert.NotContains(eventReasons(recorder), "Warning PolicyHasNoRules")
+
+	reconcileUnitTestAttester(r, normal, 4)
+	assert.NotContains(eventReasons(recorder), "Warning PolicyHasNoRules")
+	assert.NoError(r.Get(ctx, unitTestRequest(normal).NamespacedName, result))
+	condition = attesterCondition(result, rodev1alpha1.ConditionCompiled)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, condition.Status)
+	assert.Equal("PolicyCompiled", condition.Reason)
+}
+
+func TestAttesterReconciler_PolicyMigration(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("migration")
+	att.Spec.PolicyMigration = &rodev1alpha1.PolicyMigration{
+		OldPolicy: unitTestPolicy("migration"),
+		Start:     metav1.NewTime(time.Now().Add(time.Hour)),
+		End:       metav1.NewTime(time.Now()),
+	}
+	r := newUnitTestAttesterReconciler(att)
+
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, updated))
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, updated.Status.Conditions[0].Status)
+
+	updated.Spec.PolicyMigration.End = metav1.NewTime(time.Now().Add(2 * time.Hour))
+	assert.NoError(r.Update(ctx, updated))
+
+	for i := 0; i < 3; i++ {
+		_, err = r.Reconcile(unitTestRequest(att))
+		assert.NoError(err)
+	}
+	assert.Contains(r.Attesters, "default/migration")
+}
+
+func TestAttesterReconciler_PolicyQuery(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	module := `
+package shared
+
+strict_violation[{"msg": "analysis not finished"}] {
+	input.occurrences[_].discovered.discovered.analysisStatus != "FINISHED_SUCCESS"
+}
+
+lenient_violation[{"msg": "analysis failed"}] {
+	input.occurrences[_].discovered.discovered.analysisStatus == "FINISHED_FAILED"
+}
+`
+
+	strict := newUnitTestAttester("strict")
+	strict.Spec.Policy = module
+	strict.Spec.PolicyQuery = "data.shared.strict_violation"
+	lenient := newUnitTestAttester("lenient")
+	lenient.Spec.Policy = module
+	lenient.Spec.PolicyQuery = "data.shared.lenient_violation"
+	missing := newUnitTestAttester("missing")
+	missing.Spec.Policy = module
+	missing.Spec.PolicyQuery = "data.shared.missing_violation"
+
+	r := newUnitTestAttesterReconciler(strict, lenient, missing)
+	for _, att := range []*rodev1alpha1.Attester{strict, lenient, missing} {
+		reconcileUnitTestAttester(r, att, 4)
+	}
+
+	req := &attester.AttestRequest{
+		Occurrences: []*grafeas.Occurrence{{
+			Details: &grafeas.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_PENDING},
+				},
+			},
+		}},
+	}
+
+	violations, err := r.Attesters[unitTestRequest(strict).NamespacedName.String()].Evaluate(ctx, req)
+	assert.NoError(err)
+	assert.Len(violations, 1)
+	violations, err = r.Attesters[unitTestRequest(lenient).NamespacedName.String()].Evaluate(ctx, req)
+	assert.NoError(err)
+	assert.Empty(violations)
+
+	// a query that doesn't refer to a rule in the policy fails to compile
+	_, loaded := r.Attesters[unitTestRequest(missing).NamespacedName.String()]
+	assert.False(loaded)
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(missing).NamespacedName, updated))
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, updated.Status.Conditions[0].Status)
+}
+
+func TestAttesterReconciler_PolicyConfigMap(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default"},
+		Data: map[string]string{
+			"lib.rego": `
+package lib
+
+unfinished(status) {
+	status != "FINISHED_SUCCESS"
+}
+`,
+			"configmap.rego": `
+package configmap
+
+import data.lib
+
+violation[{"msg": "analysis not finished"}] {
+	lib.unfinished(input.occurrences[_].discovered.discovered.analysisStatus)
+}
+`,
+		},
+	}
+
+	att := newUnitTestAttester("configmap")
+	att.Spec.Policy = ""
+	att.Spec.PolicyConfigMapRef = &corev1.LocalObjectReference{Name: "policy"}
+	missing := newUnitTestAttester("missing")
+	missing.Spec.Policy = ""
+	missing.Spec.PolicyConfigMapRef = &corev1.LocalObjectReference{Name: "missing"}
+	conflict := newUnitTestAttester("conflict")
+	conflict.Spec.PolicyConfigMapRef = &corev1.LocalObjectReference{Name: "conflict"}
+
+	r := newUnitTestAttesterReconciler(att, missing, conflict, configMap)
+	r.events = make(chan event.GenericEvent, 10)
+	key := unitTestRequest(att).NamespacedName
+	for _, a := range []*rodev1alpha1.Attester{att, missing, conflict} {
+		reconcileUnitTestAttester(r, a, 4)
+	}
+
+	req := &attester.AttestRequest{
+		Occurrences: []*grafeas.Occurrence{{
+			Details: &grafeas.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_PENDING},
+				},
+			},
+		}},
+	}
+
+	// the modules in the ConfigMap are compiled together
+	violations, err := r.Attesters[key.String()].Evaluate(ctx, req)
+	assert.NoError(err)
+	assert.Len(violations, 1)
+
+	for _, a := range []*rodev1alpha1.Attester{missing, conflict} {
+		assert.NotContains(r.Attesters, unitTestRequest(a).NamespacedName.String())
+		updated := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, unitTestRequest(a).NamespacedName, updated))
+		assert.Equal(rodev1alpha1.ConditionStatusFalse, updated.Status.Conditions[0].Status, a.Name)
+	}
+
+	// editing the ConfigMap recompiles the policy of the attesters that use it
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Empty(r.events)
+
+	updated := &corev1.ConfigMap{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "policy"}, updated))
+	updated.Data["lib.rego"] = "package lib\n\nunfinished(status) {\n\tstatus == \"FINISHED_FAILED\"\n}\n"
+	assert.NoError(r.Update(ctx, updated))
+
+	assert.NoError(r.reloadPolicyConfigMap(ctx, types.NamespacedName{Namespace: "default", Name: "policy"}))
+	assert.Len(r.events, 1)
+	e := <-r.events
+	assert.Equal(key.Name, e.Meta.GetName())
+	_, _ = r.Reconcile(ctrl.Request{NamespacedName: key})
+
+	violations, err = r.Attesters[key.String()].Evaluate(ctx, req)
+	assert.NoError(err)
+	assert.Empty(violations)
+}
+
+func TestAttesterReconciler_PolicyGitRepository(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "policy-repository")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	repo := test.NewGitRepository(t, dir)
+	first := repo.Push("main", map[string]string{"policies/gitpolicy.rego": unitTestPolicy("gitpolicy")})
+
+	att := newUnitTestAttester("gitpolicy")
+	att.Spec.Policy = ""
+	att.Spec.PolicyGitRepository = &rodev1alpha1.PolicyGitRepository{
+		URL:          repo.URL,
+		Ref:          "main",
+		Path:         "policies",
+		PollInterval: &metav1.Duration{Duration: time.Minute},
+	}
+	r := newUnitTestAttesterReconciler(att)
+	r.PolicyRepositories = attester.NewPolicyRepositories(filepath.Join(dir, "cache"), attester.WithRepositoryProtocols("file"))
+	fakeClock := clock.NewFakeClock(time.Now())
+	r.Clock = fakeClock
+	recorder := record.NewFakeRecorder(20)
+	r.Recorder = recorder
+	key := unitTestRequest(att).NamespacedName
+
+	current := func() *rodev1alpha1.Attester {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, key, current))
+		return current
+	}
+
+	// the policy is compiled from the commit the ref is at, and the attester is requeued to pull the repository again
+	reconcileUnitTestAttester(r, att, 4)
+	assert.Contains(r.Attesters, key.String())
+	loaded := current()
+	assert.Equal(first, loaded.Status.PolicyCommit)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, attesterCondition(loaded, rodev1alpha1.ConditionCompiled).Status)
+	result, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Equal(time.Minute, result.RequeueAfter)
+
+	// a new commit is picked up once the poll interval has passed, recompiling the policy
+	second := repo.Push("main", map[string]string{"policies/gitpolicy.rego": unitTestPolicy("gitpolicy") + "\n# changed\n"})
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Equal(first, current().Status.PolicyCommit)
+
+	fakeClock.Step(time.Minute)
+	reconcileUnitTestAttester(r, att, 1)
+	updated := current()
+	assert.Equal(second, updated.Status.PolicyCommit)
+	assert.NotEqual(loaded.Status.PolicyVersion, updated.Status.PolicyVersion)
+
+	compiles := 0
+	for _, reason := range eventReasons(recorder) {
+		if reason == "Normal PolicyCompiled" {
+			compiles++
+		}
+	}
+	assert.Equal(2, compiles)
+
+	// a commit whose policy doesn't compile leaves the attester evaluating the policy it was loaded with
+	repo.Push("main", map[string]string{"policies/gitpolicy.rego": "package gitpolicy\n\nviolation[{"})
+	fakeClock.Step(time.Minute)
+	reconcileUnitTestAttester(r, att, 1)
+	failed := current()
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, attesterCondition(failed, rodev1alpha1.ConditionCompiled).Status)
+	assert.Equal(second, failed.Status.PolicyCommit)
+	assert.Contains(r.Attesters, key.String())
+}
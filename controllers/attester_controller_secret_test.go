@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+// forbiddenSecretsClient is forbidden from secrets in a namespace, like a controller whose role isn't bound in it
+type forbiddenSecretsClient struct {
+	client.Client
+	namespace string
+}
+
+func (c *forbiddenSecretsClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.Secret); ok && key.Namespace == c.namespace {
+		return errors.NewForbidden(corev1.Resource("secrets"), key.Name, fmt.Errorf("the controller can't get secrets in namespace %s", c.namespace))
+	}
+
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestAttesterReconciler_PgpSecretNamespace(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	same := newUnitTestAttester("samenamespace")
+	same.Spec.PgpSecretNamespace = same.Namespace
+	other := newUnitTestAttester("othernamespace")
+	other.UID = "othernamespace-uid"
+	other.Spec.PgpSecretNamespace = "keys"
+	forbidden := newUnitTestAttester("forbiddennamespace")
+	forbidden.Spec.PgpSecretNamespace = "vault"
+	r := newUnitTestAttesterReconciler(same, other, forbidden)
+	r.Client = &forbiddenSecretsClient{Client: r.Client, namespace: "vault"}
+	for _, att := range []*rodev1alpha1.Attester{same, other, forbidden} {
+		reconcileUnitTestAttester(r, att, 4)
+	}
+
+	// a secret in the attester's namespace is owned by the attester
+	secret := &corev1.Secret{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: same.Namespace, Name: same.Name}, secret))
+	assert.Len(secret.OwnerReferences, 1)
+	assert.Contains(r.Attesters, unitTestRequest(same).NamespacedName.String())
+
+	// a secret in another namespace is created there and records the attester in an annotation
+	assert.True(errors.IsNotFound(r.Get(ctx, types.NamespacedName{Namespace: other.Namespace, Name: other.Name}, &corev1.Secret{})))
+	secret = &corev1.Secret{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: "keys", Name: other.Name}, secret))
+	assert.Empty(secret.OwnerReferences)
+	assert.Equal("default/othernamespace/othernamespace-uid", secret.Annotations[attester.SecretControllerAnnotation])
+	loaded, ok := r.Attesters[unitTestRequest(other).NamespacedName.String()]
+	assert.True(ok)
+	signer, err := attester.ReadSigner(bytes.NewReader(secret.Data["keys"]))
+	assert.NoError(err)
+	res, err := loaded.Attest(ctx, &attester.AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+	assert.Equal(signer.KeyID(), res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId())
+
+	// the key is reloaded from the other namespace rather than generated again
+	r.reload(unitTestRequest(other).NamespacedName.String())
+	reconcileUnitTestAttester(r, other, 1)
+	res, err = r.Attesters[unitTestRequest(other).NamespacedName.String()].Attest(ctx, &attester.AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+	assert.Equal(signer.KeyID(), res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId())
+
+	// the controller isn't allowed to access secrets in the namespace, which is reported rather than generating a key
+	assert.NotContains(r.Attesters, unitTestRequest(forbidden).NamespacedName.String())
+	result := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(forbidden).NamespacedName, result))
+	condition := attesterCondition(result, rodev1alpha1.ConditionSecret)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, condition.Status)
+	assert.Equal("SecretForbidden", condition.Reason)
+	assert.Contains(condition.Message, "not allowed to get secret forbiddennamespace in namespace vault")
+
+	// the secret in the other namespace is deleted with the attester
+	assert.NoError(r.Get(ctx, unitTestRequest(other).NamespacedName, result))
+	now := metav1.Now()
+	result.DeletionTimestamp = &now
+	assert.NoError(r.Update(ctx, result))
+	reconcileUnitTestAttester(r, other, 1)
+	assert.True(errors.IsNotFound(r.Get(ctx, types.NamespacedName{Namespace: "keys", Name: other.Name}, &corev1.Secret{})))
+}
+
+func TestAttesterReconciler_RecreatesDeletedSecret(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("deleted")
+	r := newUnitTestAttesterReconciler(att)
+	recorder := record.NewFakeRecorder(20)
+	r.Recorder = recorder
+	reconcileUnitTestAttester(r, att, 3)
+	key := unitTestRequest(att).NamespacedName.String()
+	keyID := r.signers[key].Signer().KeyID()
+	eventReasons(recorder)
+
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: att.Namespace, Name: "deleted"}
+	assert.NoError(r.Get(ctx, secretName, secret))
+	assert.NoError(r.Delete(ctx, secret))
+
+	// the attester is up to date until the deletion is seen
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Empty(eventReasons(recorder))
+
+	r.signerSecretDeleted(secret)
+	reconcileUnitTestAttester(r, att, 1)
+	assert.NoError(r.Get(ctx, secretName, &corev1.Secret{}))
+	assert.NotEqual(keyID, r.signers[key].Signer().KeyID())
+	assert.Equal([]string{"Warning SecretMissing", "Normal SecretCreated"}, eventReasons(recorder))
+
+	current := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+	assert.Equal(r.signers[key].Signer().KeyID(), current.Status.KeyID)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, attesterCondition(current, rodev1alpha1.ConditionSecret).Status)
+}
+
+func TestAttesterReconciler_FailOnDeletedSecret(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("deleted")
+	r := newUnitTestAttesterReconciler(att)
+	r.FailOnDeletedSecret = true
+	reconcileUnitTestAttester(r, att, 3)
+	key := unitTestRequest(att).NamespacedName.String()
+
+	secret := &corev1.Secret{}
+	secretName := types.NamespacedName{Namespace: att.Namespace, Name: "deleted"}
+	assert.NoError(r.Get(ctx, secretName, secret))
+	assert.NoError(r.Delete(ctx, secret))
+
+	r.signerSecretDeleted(toolscache.DeletedFinalStateUnknown{Key: secretName.String(), Obj: secret})
+	result, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Equal(deletedSecretRetryInterval, result.RequeueAfter)
+
+	err = r.Get(ctx, secretName, &corev1.Secret{})
+	assert.True(errors.IsNotFound(err))
+	assert.NotContains(r.Attesters, key)
+
+	current := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+	condition := attesterCondition(current, rodev1alpha1.ConditionSecret)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, condition.Status)
+	assert.Equal("SecretMissing", condition.Reason)
+	assert.Contains(condition.Message, current.Status.KeyID)
+
+	// restoring the secret loads the attester again
+	secret.ResourceVersion = ""
+	assert.NoError(r.Create(ctx, secret))
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Contains(r.Attesters, key)
+}
+
+// quotaExceededClient rejects creating secrets as if the namespace's secret quota was exceeded
+type quotaExceededClient struct {
+	client.Client
+}
+
+func (c *quotaExceededClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if secret, ok := obj.(*corev1.Secret); ok {
+		return errors.NewForbidden(corev1.Resource("secrets"), secret.Name,
+			fmt.Errorf("exceeded quota: secrets, requested: secrets=1, used: secrets=10, limited: secrets=10"))
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestAttesterReconciler_BacksOffWhenSecretQuotaExceeded(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("quota")
+	r := newUnitTestAttesterReconciler(att)
+	r.Client = &quotaExceededClient{r.Client}
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+
+	var result ctrl.Result
+	var err error
+	for i := 0; i < 2; i++ {
+		result, err = r.Reconcile(unitTestRequest(att))
+	}
+	assert.NoError(err)
+	assert.Equal(secretQuotaRetryInterval, result.RequeueAfter)
+	assert.Equal([]string{"Normal FinalizerRegistered", "Normal PolicyCompiled", "Warning SecretQuotaExceeded"}, eventReasons(recorder))
+
+	updated := &rodev1alpha1.Attester{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, updated)
+	assert.NoError(err)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, updated.Status.Conditions[1].Status)
+	assert.Equal(secretQuotaExceededMessage, updated.Status.Conditions[1].Message)
+}
+
+// failingSecretsClient fails the first failures gets of a secret with err
+type failingSecretsClient struct {
+	client.Client
+	err      error
+	failures int
+}
+
+func (c *failingSecretsClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.Secret); ok && c.failures > 0 {
+		c.failures--
+		return c.err
+	}
+
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestAttesterReconciler_BacksOffOnTransientSecretErrors(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("flaky")
+	att.Spec.PgpSecret = "flaky"
+	r := newUnitTestAttesterReconciler(att)
+	r.Client = &failingSecretsClient{Client: r.Client, err: errors.NewTimeoutError("secrets", 1), failures: 3}
+	key := unitTestRequest(att).NamespacedName
+
+	backoffs := make([]time.Duration, 0)
+	for i := 0; i < 10; i++ {
+		result, err := r.Reconcile(unitTestRequest(att))
+		assert.NoError(err)
+		if result.RequeueAfter > 0 {
+			backoffs = append(backoffs, result.RequeueAfter)
+
+			updated := &rodev1alpha1.Attester{}
+			assert.NoError(r.Get(ctx, key, updated))
+			assert.Equal(int32(len(backoffs)), updated.Status.SecretRetry.Attempts)
+			assert.Equal(result.RequeueAfter, updated.Status.SecretRetry.Backoff.Duration)
+			assert.Contains(updated.Status.SecretRetry.LastError, "Timeout")
+		}
+	}
+	assert.Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, backoffs)
+
+	// the retry is cleared once the secret is loaded
+	assert.Contains(r.Attesters, key.String())
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, key, updated))
+	assert.Nil(updated.Status.SecretRetry)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, updated.Status.Conditions[1].Status)
+}
+
+func TestAttesterReconciler_DoesNotBackOffOnPermanentSecretErrors(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("forbidden")
+	att.Spec.PgpSecret = "forbidden"
+	r := newUnitTestAttesterReconciler(att)
+	r.Client = &failingSecretsClient{Client: r.Client, err: errors.NewForbidden(corev1.Resource("secrets"), "forbidden", nil), failures: 10}
+
+	var result ctrl.Result
+	var err error
+	for i := 0; i < 3; i++ {
+		result, err = r.Reconcile(unitTestRequest(att))
+	}
+	assert.True(errors.IsForbidden(err))
+	assert.Zero(result.RequeueAfter)
+
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, updated))
+	assert.Nil(updated.Status.SecretRetry)
+}
+
+func TestSecretRetryBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(secretRetryBaseBackoff, secretRetryBackoff(1))
+	assert.Equal(8*secretRetryBaseBackoff, secretRetryBackoff(4))
+	assert.Equal(secretRetryMaxBackoff, secretRetryBackoff(20))
+	assert.Equal(secretRetryMaxBackoff, secretRetryBackoff(1000))
+}
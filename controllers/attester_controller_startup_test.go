@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func TestAttesterReconciler_WarmUp(t *testing.T) {
+	assert := assert.New(t)
+
+	first := newUnitTestAttester("warmfirst")
+	second := newUnitTestAttester("warmsecond")
+	r := newUnitTestAttesterReconciler(first, second)
+
+	assert.Error(r.ReadyzCheck(nil))
+
+	assert.NoError(r.WarmUp(context.Background()))
+
+	// the attesters are loaded without being reconciled by the controller
+	assert.Contains(r.Attesters, unitTestRequest(first).NamespacedName.String())
+	assert.Contains(r.Attesters, unitTestRequest(second).NamespacedName.String())
+	assert.NoError(r.ReadyzCheck(nil))
+}
+
+type failingListClient struct {
+	client.Client
+	failures int
+}
+
+func (c *failingListClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if c.failures > 0 {
+		c.failures--
+		return errors.NewServiceUnavailable("unavailable")
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestAttesterReconciler_WarmUpRetriesList(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func(base time.Duration) { warmUpRetryBaseDelay = base }(warmUpRetryBaseDelay)
+	warmUpRetryBaseDelay = time.Millisecond
+
+	att := newUnitTestAttester("warmretry")
+	r := newUnitTestAttesterReconciler(att)
+	r.Client = &failingListClient{Client: r.Client, failures: 2}
+
+	// the warm-up runs on every replica, and failing to list the attesters is retried rather than stopping the manager
+	assert.False(nonLeaderRunnable(nil).NeedLeaderElection())
+	r.warmUpUntilLoaded(make(chan struct{}))
+	assert.Contains(r.Attesters, unitTestRequest(att).NamespacedName.String())
+	assert.NoError(r.ReadyzCheck(nil))
+
+	// and the retries stop with the manager
+	r = newUnitTestAttesterReconciler(att)
+	r.Client = &failingListClient{Client: r.Client, failures: 1000}
+	stop := make(chan struct{})
+	close(stop)
+	r.warmUpUntilLoaded(stop)
+	assert.Error(r.ReadyzCheck(nil))
+}
+
+func TestAttesterReconciler_ReadyzProbe(t *testing.T) {
+	assert := assert.New(t)
+
+	att := newUnitTestAttester("probe")
+	r := newUnitTestAttesterReconciler(att)
+	probe := &healthz.Handler{Checks: map[string]healthz.Checker{"attesters": r.ReadyzCheck}}
+	readyz := func() int {
+		recorder := httptest.NewRecorder()
+		probe.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		return recorder.Code
+	}
+
+	// the probe fails until the attesters that exist have been loaded
+	assert.Equal(http.StatusInternalServerError, readyz())
+	assert.Empty(r.ListAttesters())
+
+	assert.NoError(r.WarmUp(context.Background()))
+	assert.Contains(r.Attesters, unitTestRequest(att).NamespacedName.String())
+	assert.Equal(http.StatusOK, readyz())
+
+	// and keeps passing as attesters are reconciled afterwards
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Equal(http.StatusOK, readyz())
+}
+
+func TestAttesterReconciler_ReadyWithoutLeadership(t *testing.T) {
+	assert := assert.New(t)
+
+	att := newUnitTestAttester("standby")
+	r := newUnitTestAttesterReconciler(att)
+	r.Recorder = record.NewFakeRecorder(20)
+
+	// the manager can't reach an API server, so it's never elected leader and only starts the runnables that don't
+	// need leader election
+	mgr, err := ctrl.NewManager(&rest.Config{Host: "http://127.0.0.1:1"}, ctrl.Options{
+		Scheme:                  unitTestScheme(),
+		MetricsBindAddress:      "0",
+		LeaderElection:          true,
+		LeaderElectionNamespace: "default",
+		LeaderElectionID:        "rode-standby-test",
+		MapperProvider: func(*rest.Config) (meta.RESTMapper, error) {
+			return meta.NewDefaultRESTMapper(nil), nil
+		},
+		NewCache: func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &informertest.FakeInformers{Scheme: unitTestScheme()}, nil
+		},
+	})
+	assert.NoError(err)
+	assert.NoError(r.SetupWithManager(mgr))
+
+	stop := make(chan struct{})
+	stopped := make(chan error)
+	go func() {
+		stopped <- mgr.Start(stop)
+	}()
+
+	// the standby replica loads the attesters and reports being ready without becoming leader
+	assert.Eventually(func() bool {
+		return r.ReadyzCheck(nil) == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Contains(r.ListAttesters(), unitTestRequest(att).NamespacedName.String())
+
+	close(stop)
+	assert.NoError(<-stopped)
+}
+
+func TestAttesterReconciler_ConcurrentListAttesters(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var atts []*rodev1alpha1.Attester
+	var objs []runtime.Object
+	for i := 0; i < 3; i++ {
+		att := newUnitTestAttester(fmt.Sprintf("concurrent%d", i))
+		atts = append(atts, att)
+		objs = append(objs, att)
+	}
+	r := newUnitTestAttesterReconciler(objs...)
+
+	// readers list the attesters while they're loaded and unloaded, which the race detector checks
+	done := make(chan struct{})
+	readers := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for key, att := range r.ListAttesters() {
+					assert.NotEmpty(key)
+					assert.NotNil(att)
+				}
+			}
+		}()
+	}
+
+	for _, att := range atts {
+		reconcileUnitTestAttester(r, att, 4)
+	}
+	assert.Len(r.ListAttesters(), len(atts))
+
+	for _, att := range atts {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+		assert.NoError(r.Delete(ctx, current))
+		reconcileUnitTestAttester(r, att, 1)
+	}
+
+	close(done)
+	readers.Wait()
+	assert.Empty(r.ListAttesters())
+}
+
+func TestAttesterReconciler_MaxConcurrentReconciles(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var atts []*rodev1alpha1.Attester
+	var objs []runtime.Object
+	for i := 0; i < 8; i++ {
+		att := newUnitTestAttester(fmt.Sprintf("parallel%d", i))
+		atts = append(atts, att)
+		objs = append(objs, att)
+	}
+	r := newUnitTestAttesterReconciler(objs...)
+	r.MaxConcurrentReconciles = 4
+	r.Subjects = attester.NewSubjectTracker()
+	r.Violations = attester.NewViolationTracker()
+
+	// the attesters are loaded by several workers at once, which the race detector checks
+	assert.NoError(r.WarmUp(ctx))
+	assert.Len(r.ListAttesters(), len(atts))
+
+	// like the controller, each attester is only reconciled by one worker at a time, while the others reconcile other
+	// attesters
+	reconcileConcurrently := func(reconcile func(att *rodev1alpha1.Attester)) {
+		workers := sync.WaitGroup{}
+		for _, att := range atts {
+			workers.Add(1)
+			go func(att *rodev1alpha1.Attester) {
+				defer workers.Done()
+				reconcile(att)
+			}(att)
+		}
+		workers.Wait()
+	}
+
+	reconcileConcurrently(func(att *rodev1alpha1.Attester) {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+		current.Annotations = map[string]string{OPATraceAnnotation: "true"}
+		assert.NoError(r.Update(ctx, current))
+		reconcileUnitTestAttester(r, att, 4)
+	})
+	assert.Len(r.ListAttesters(), len(atts))
+	for _, att := range atts {
+		assert.True(r.traced[unitTestRequest(att).NamespacedName.String()])
+	}
+
+	reconcileConcurrently(func(att *rodev1alpha1.Attester) {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, current))
+		assert.NoError(r.Delete(ctx, current))
+		reconcileUnitTestAttester(r, att, 1)
+	})
+	assert.Empty(r.ListAttesters())
+	assert.Empty(r.signers)
+	assert.Empty(r.traced)
+}
+
+func TestAttesterReconciler_ResyncJitter(t *testing.T) {
+	assert := assert.New(t)
+
+	var atts []*rodev1alpha1.Attester
+	var objs []runtime.Object
+	for i := 0; i < 20; i++ {
+		att := newUnitTestAttester(fmt.Sprintf("resync%d", i))
+		att.Spec.KeyType = rodev1alpha1.KeyTypeEd25519
+		atts = append(atts, att)
+		objs = append(objs, att)
+	}
+	r := newUnitTestAttesterReconciler(objs...)
+	r.ResyncPeriod = time.Hour
+	r.ResyncJitter = 0.5
+
+	// the attesters are loaded together, but each is requeued at a different time within the jitter window
+	var earliest, latest time.Duration
+	for _, att := range atts {
+		reconcileUnitTestAttester(r, att, 4)
+		result, err := r.Reconcile(unitTestRequest(att))
+		assert.NoError(err)
+		assert.GreaterOrEqual(int64(result.RequeueAfter), int64(time.Hour))
+		assert.LessOrEqual(int64(result.RequeueAfter), int64(90*time.Minute))
+
+		if earliest == 0 || result.RequeueAfter < earliest {
+			earliest = result.RequeueAfter
+		}
+		if result.RequeueAfter > latest {
+			latest = result.RequeueAfter
+		}
+	}
+	assert.Greater(int64(latest-earliest), int64(15*time.Minute))
+
+	// without jitter every attester is resynced after the period, unless its key is rotated sooner
+	r.ResyncJitter = 0
+	result, err := r.Reconcile(unitTestRequest(atts[0]))
+	assert.NoError(err)
+	assert.Equal(time.Hour, result.RequeueAfter)
+	assert.Equal(time.Minute, r.resyncAfter(time.Minute))
+
+	// attesters aren't resynced by the controller without a period
+	r.ResyncPeriod = 0
+	result, err = r.Reconcile(unitTestRequest(atts[0]))
+	assert.NoError(err)
+	assert.Zero(result.RequeueAfter)
+}
+
+func TestIgnoreResync(t *testing.T) {
+	assert := assert.New(t)
+
+	old := newUnitTestAttester("resync")
+	old.ResourceVersion = "1"
+	resynced := old.DeepCopy()
+	updated := old.DeepCopy()
+	updated.ResourceVersion = "2"
+
+	p := ignoreResync()
+	assert.False(p.Update(event.UpdateEvent{MetaOld: old, ObjectOld: old, MetaNew: resynced, ObjectNew: resynced}))
+	assert.True(p.Update(event.UpdateEvent{MetaOld: old, ObjectOld: old, MetaNew: updated, ObjectNew: updated}))
+	assert.True(p.Create(event.CreateEvent{Meta: old, Object: old}))
+}
@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func TestAttesterReconciler_RepairsShortConditions(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("shortconditions")
+	att.Status.Conditions = []rodev1alpha1.Condition{
+		{
+			Type:   rodev1alpha1.ConditionCompiled,
+			Status: rodev1alpha1.ConditionStatusTrue,
+		},
+	}
+
+	r := newUnitTestAttesterReconciler(att)
+
+	assert.NotPanics(func() {
+		_, _ = r.Reconcile(unitTestRequest(att))
+	})
+
+	result := &rodev1alpha1.Attester{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result)
+	assert.NoError(err)
+	assert.Len(result.Status.Conditions, 3)
+	assert.Equal(rodev1alpha1.ConditionCompiled, result.Status.Conditions[0].Type)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, result.Status.Conditions[0].Status)
+	assert.Equal(rodev1alpha1.ConditionSecret, result.Status.Conditions[1].Type)
+	assert.Equal(rodev1alpha1.ConditionReady, result.Status.Conditions[2].Type)
+}
+
+func TestAttesterReconciler_UpdateStatusByType(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("reordered")
+	att.Status.Conditions = []rodev1alpha1.Condition{
+		{
+			Type:    rodev1alpha1.ConditionSecret,
+			Status:  rodev1alpha1.ConditionStatusFalse,
+			Message: secretQuotaExceededMessage,
+		},
+		{
+			Type:   rodev1alpha1.ConditionCompiled,
+			Status: rodev1alpha1.ConditionStatusFalse,
+		},
+	}
+	r := newUnitTestAttesterReconciler(att)
+
+	assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusTrue, "PolicyCompiled", ""))
+	assert.Equal(rodev1alpha1.ConditionSecret, att.Status.Conditions[0].Type)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, att.Status.Conditions[0].Status)
+	assert.Equal(rodev1alpha1.ConditionCompiled, att.Status.Conditions[1].Type)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, att.Status.Conditions[1].Status)
+
+	assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue, "SecretLoaded", ""))
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, att.Status.Conditions[0].Status)
+	assert.Empty(att.Status.Conditions[0].Message)
+
+	// missing conditions are added rather than overwriting another condition
+	att.Status.Conditions = att.Status.Conditions[1:]
+	assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretCreationFailed", "forbidden"))
+	assert.Len(att.Status.Conditions, 3)
+	assert.Equal(rodev1alpha1.ConditionCompiled, att.Status.Conditions[0].Type)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, att.Status.Conditions[0].Status)
+	assert.Equal(rodev1alpha1.ConditionReady, att.Status.Conditions[1].Type)
+	assert.Equal(rodev1alpha1.ConditionSecret, att.Status.Conditions[2].Type)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, att.Status.Conditions[2].Status)
+
+	result := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, result))
+	assert.Len(result.Status.Conditions, 3)
+	assert.Equal(rodev1alpha1.ConditionSecret, result.Status.Conditions[2].Type)
+	assert.Equal("forbidden", result.Status.Conditions[2].Message)
+}
+
+func TestAttesterReconciler_ReadyCondition(t *testing.T) {
+	tests := map[string]struct {
+		compiled rodev1alpha1.ConditionStatus
+		secret   rodev1alpha1.ConditionStatus
+		ready    rodev1alpha1.ConditionStatus
+		reason   string
+		message  string
+	}{
+		"both true":                 {rodev1alpha1.ConditionStatusTrue, rodev1alpha1.ConditionStatusTrue, rodev1alpha1.ConditionStatusTrue, "Ready", ""},
+		"policy false":              {rodev1alpha1.ConditionStatusFalse, rodev1alpha1.ConditionStatusTrue, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", "Policy condition is False: syntax error"},
+		"key false":                 {rodev1alpha1.ConditionStatusTrue, rodev1alpha1.ConditionStatusFalse, rodev1alpha1.ConditionStatusFalse, "SecretCreationFailed", "Key condition is False: forbidden"},
+		"both false":                {rodev1alpha1.ConditionStatusFalse, rodev1alpha1.ConditionStatusFalse, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", "Policy condition is False: syntax error"},
+		"policy unknown":            {rodev1alpha1.ConditionStatusUnknown, rodev1alpha1.ConditionStatusTrue, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", "Policy condition is Unknown: syntax error"},
+		"key unknown":               {rodev1alpha1.ConditionStatusTrue, rodev1alpha1.ConditionStatusUnknown, rodev1alpha1.ConditionStatusFalse, "SecretCreationFailed", "Key condition is Unknown: forbidden"},
+		"both unknown":              {rodev1alpha1.ConditionStatusUnknown, rodev1alpha1.ConditionStatusUnknown, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", "Policy condition is Unknown: syntax error"},
+		"policy unknown, key false": {rodev1alpha1.ConditionStatusUnknown, rodev1alpha1.ConditionStatusFalse, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", "Policy condition is Unknown: syntax error"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx := context.Background()
+
+			att := newUnitTestAttester("ready")
+			normalizeAttesterConditions(att)
+			r := newUnitTestAttesterReconciler(att)
+
+			assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionCompiled, tc.compiled, "PolicyCompileFailed", "syntax error"))
+			assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, tc.secret, "SecretCreationFailed", "forbidden"))
+
+			result := &rodev1alpha1.Attester{}
+			assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, result))
+			ready := attesterCondition(result, rodev1alpha1.ConditionReady)
+			assert.Equal(tc.ready, ready.Status)
+			assert.Equal(tc.reason, ready.Reason)
+			assert.Equal(tc.message, ready.Message)
+		})
+	}
+}
+
+func TestAttesterReconciler_ReadyConditionUpdates(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("ready")
+	r := newUnitTestAttesterReconciler(att)
+	key := unitTestRequest(att).NamespacedName
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(start)
+	r.Clock = fakeClock
+
+	ready := func() rodev1alpha1.Condition {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, key, current))
+		return *attesterCondition(current, rodev1alpha1.ConditionReady)
+	}
+
+	// the attester is ready once its policy is compiled and its key loaded
+	reconcileUnitTestAttester(r, att, 4)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, ready().Status)
+	assert.True(start.Equal(ready().LastTransitionTime.Time))
+
+	// and stops being ready when either of them fails
+	fakeClock.Step(time.Minute)
+	current := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, key, current))
+	assert.NoError(r.updateStatus(ctx, current, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretMissing", "secret was deleted"))
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, ready().Status)
+	assert.Equal("SecretMissing", ready().Reason)
+	assert.True(start.Add(time.Minute).Equal(ready().LastTransitionTime.Time))
+
+	// attesters whose conditions were written before the Ready condition get one when they're reconciled
+	assert.NoError(r.Get(ctx, key, current))
+	current.Status.Conditions = []rodev1alpha1.Condition{
+		{Type: rodev1alpha1.ConditionCompiled, Status: rodev1alpha1.ConditionStatusTrue},
+		{Type: rodev1alpha1.ConditionSecret, Status: rodev1alpha1.ConditionStatusTrue},
+	}
+	assert.NoError(r.Status().Update(ctx, current))
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, ready().Status)
+}
+
+func TestAttesterReconciler_UpdateStatusTransitionTime(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("transitions")
+	normalizeAttesterConditions(att)
+	r := newUnitTestAttesterReconciler(att)
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(start)
+	r.Clock = fakeClock
+
+	assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretCreationFailed", "forbidden"))
+	condition := attesterCondition(att, rodev1alpha1.ConditionSecret)
+	assert.Equal(start, condition.LastTransitionTime.Time)
+	assert.Equal("SecretCreationFailed", condition.Reason)
+	assert.Equal("forbidden", condition.Message)
+
+	// updates that don't change the status keep the transition time, but record the latest reason and message
+	fakeClock.Step(time.Minute)
+	assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, "SecretQuotaExceeded", secretQuotaExceededMessage))
+	condition = attesterCondition(att, rodev1alpha1.ConditionSecret)
+	assert.Equal(start, condition.LastTransitionTime.Time)
+	assert.Equal("SecretQuotaExceeded", condition.Reason)
+	assert.Equal(secretQuotaExceededMessage, condition.Message)
+
+	fakeClock.Step(time.Minute)
+	assert.NoError(r.updateStatus(ctx, att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue, "SecretCreated", ""))
+	condition = attesterCondition(att, rodev1alpha1.ConditionSecret)
+	assert.Equal(start.Add(2*time.Minute), condition.LastTransitionTime.Time)
+	assert.Equal("SecretCreated", condition.Reason)
+	assert.Empty(condition.Message)
+
+	// the other condition isn't touched
+	assert.Nil(attesterCondition(att, rodev1alpha1.ConditionCompiled).LastTransitionTime)
+}
+
+func TestAttesterReconciler_RecordsPolicyTimings(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("timings")
+	r := newUnitTestAttesterReconciler(att)
+	r.PolicyTimings = true
+
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+
+	result := &rodev1alpha1.Attester{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result)
+	assert.NoError(err)
+	assert.NotNil(result.Status.Timings)
+	assert.NotZero(result.Status.Timings.Parse.Duration)
+	assert.NotZero(result.Status.Timings.Compile.Duration)
+}
+
+func TestAttesterReconciler_RecordsAttestedSubjects(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("subjects")
+	r := newUnitTestAttesterReconciler(att)
+	r.Subjects = attester.NewSubjectTracker()
+
+	key := unitTestRequest(att).NamespacedName.String()
+	r.Subjects.Attested(key, "image-a")
+	r.Subjects.Attested(key, "image-b")
+
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+
+	result := &rodev1alpha1.Attester{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result)
+	assert.NoError(err)
+	assert.Equal(2, result.Status.AttestedSubjects)
+}
+
+func TestAttesterReconciler_RecordsLastViolations(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("violations")
+	att.Generation = 1
+	r := newUnitTestAttesterReconciler(att)
+	r.Violations = attester.NewViolationTracker()
+	key := unitTestRequest(att).NamespacedName.String()
+	reconcileUnitTestAttester(r, att, 4)
+
+	violations := make([]*attester.Violation, 0, attester.MaxLastViolations+3)
+	for i := 0; i < attester.MaxLastViolations+3; i++ {
+		violations = append(violations, &attester.Violation{Msg: fmt.Sprintf("vulnerability %d found", i)})
+	}
+	r.Violations.Rejected(key, "image", violations)
+
+	// the violations are written to the status of an attester that's otherwise up to date
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	result := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result))
+	assert.NotNil(result.Status.LastViolations)
+	assert.Equal("image", result.Status.LastViolations.ResourceURI)
+	assert.False(result.Status.LastViolations.Time.IsZero())
+	assert.Len(result.Status.LastViolations.Messages, attester.MaxLastViolations)
+	assert.Equal("vulnerability 0 found", result.Status.LastViolations.Messages[0])
+	assert.Equal(3, result.Status.LastViolations.Omitted)
+
+	// the status isn't updated again until there are new violations
+	_, err = r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	unchanged := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, unchanged))
+	assert.Equal(result.ResourceVersion, unchanged.ResourceVersion)
+}
+
+func TestAttesterReconciler_RecordsAttestationCounts(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("counted")
+	r := newUnitTestAttesterReconciler(att)
+	r.Attestations = attester.NewAttestationCounter(0)
+	key := unitTestRequest(att).NamespacedName.String()
+	reconcileUnitTestAttester(r, att, 4)
+
+	attest := func(analysisStatus discovery.Discovered_AnalysisStatus) {
+		_, _ = r.ListAttesters()[key].Attest(ctx, &attester.AttestRequest{
+			ResourceURI: "image@sha256:1",
+			Occurrences: []*grafeas.Occurrence{{
+				Resource: &grafeas.Resource{Uri: "image@sha256:1"},
+				Details: &grafeas.Occurrence_Discovered{Discovered: &discovery.Details{Discovered: &discovery.Discovered{
+					AnalysisStatus: analysisStatus,
+				}}},
+			}},
+		})
+	}
+	status := func() rodev1alpha1.AttesterStatus {
+		result := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result))
+		return result.Status
+	}
+
+	attest(discovery.Discovered_FINISHED_SUCCESS)
+	attest(discovery.Discovered_FINISHED_SUCCESS)
+	attest(discovery.Discovered_FINISHED_FAILED)
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Equal(int64(2), status().AttestationsSigned)
+	assert.Equal(int64(1), status().AttestationsRejected)
+	assert.NotNil(status().LastAttestationTime)
+
+	// the counts in the status are added to rather than replaced, and aren't added again
+	attest(discovery.Discovered_FINISHED_SUCCESS)
+	reconcileUnitTestAttester(r, att, 2)
+	assert.Equal(int64(3), status().AttestationsSigned)
+	assert.Equal(int64(1), status().AttestationsRejected)
+}
+
+func TestAttesterReconciler_RecordsEvalThrottling(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("throttled")
+	att.Spec.PolicyEvalBudget = &metav1.Duration{Duration: time.Second}
+	r := newUnitTestAttesterReconciler(att)
+	r.EvalBudget = attester.NewEvalBudget(0, time.Minute)
+	key := unitTestRequest(att).NamespacedName.String()
+
+	reconcileUnitTestAttester(r, att, 4)
+	r.EvalBudget.Record(key, 2*time.Second)
+	reconcileUnitTestAttester(r, att, 1)
+
+	result := &rodev1alpha1.Attester{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result)
+	assert.NoError(err)
+	assert.True(result.Status.EvalThrottled)
+}
@@ -3,10 +3,12 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
 	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -103,16 +105,203 @@ var _ = Context("attester controller", func() {
 		})
 
 	})
+
+	When("an Attester uses an existing secret", func() {
+		var (
+			secretName string
+			signer     attester.Signer
+		)
+
+		BeforeEach(func() {
+			shouldBeDeleted = true
+
+			attesterName = fmt.Sprintf("attester%s", rand.String(10))
+			secretName = fmt.Sprintf("existing%s", rand.String(10))
+
+			var err error
+			signer, err = attester.NewSigner(fmt.Sprintf("%s/%s", namespace.Name, attesterName))
+			Expect(err).ToNot(HaveOccurred(), "failed to create test signer", err)
+
+			buf := &bytes.Buffer{}
+			err = signer.Serialize(buf)
+			Expect(err).ToNot(HaveOccurred(), "failed to serialize test signer", err)
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretName,
+					Namespace: namespace.Name,
+				},
+				Data: map[string][]byte{
+					"privateKey": buf.Bytes(),
+				},
+			}
+
+			err = k8sClient.Create(ctx, secret)
+			Expect(err).ToNot(HaveOccurred(), "failed to create test secret", err)
+
+			createAttester(ctx, &rodev1alpha1.Attester{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      attesterName,
+					Namespace: namespace.Name,
+				},
+				Spec: rodev1alpha1.AttesterSpec{
+					Policy:       basicAttesterPolicy(attesterName),
+					PgpSecret:    secretName,
+					PgpSecretKey: "privateKey",
+				},
+			})
+		})
+
+		AfterEach(func() {
+			if shouldBeDeleted == true {
+				destroyAttester(ctx, attesterName, namespace.Name)
+			}
+		})
+
+		It("should sign with the key in the secret", func() {
+			Eventually(func() string {
+				att := rodev1alpha1.Attester{}
+
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      attesterName,
+					Namespace: namespace.Name,
+				}, &att)
+				if err != nil {
+					return ""
+				}
+
+				return att.Status.KeyID
+			}, checkDuration, checkInterval).Should(Equal(signer.KeyID()))
+
+			secret := corev1.Secret{}
+
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      secretName,
+				Namespace: namespace.Name,
+			}, &secret)
+
+			Expect(err).ToNot(HaveOccurred(), "error getting test secret", err)
+			Expect(secret.Data).To(HaveLen(1))
+			Expect(secret.Data).To(HaveKey("privateKey"))
+		})
+
+		It("should not delete the secret if it didn't create it", func() {
+			destroyAttester(ctx, attesterName, namespace.Name)
+			shouldBeDeleted = false
+
+			secret := corev1.Secret{}
+
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      secretName,
+				Namespace: namespace.Name,
+			}, &secret)
+
+			Expect(err).ToNot(HaveOccurred(), "secret was deleted", err)
+		})
+	})
+
+	When("an Attester is suspended", func() {
+		var secret corev1.Secret
+
+		BeforeEach(func() {
+			attesterName = fmt.Sprintf("attester%s", rand.String(10))
+
+			createAttester(ctx, &rodev1alpha1.Attester{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      attesterName,
+					Namespace: namespace.Name,
+				},
+				Spec: rodev1alpha1.AttesterSpec{
+					Policy: basicAttesterPolicy(attesterName),
+				},
+			})
+
+			secret = corev1.Secret{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      attesterName,
+				Namespace: namespace.Name,
+			}, &secret)
+			Expect(err).ToNot(HaveOccurred(), "error getting test secret", err)
+
+			setAttesterSuspend(ctx, attesterName, namespace.Name, true)
+		})
+
+		AfterEach(func() {
+			destroyAttester(ctx, attesterName, namespace.Name)
+		})
+
+		It("should stop being ready and keep its secret", func() {
+			Eventually(func() rodev1alpha1.ConditionStatus {
+				return attesterConditionStatus(ctx, attesterName, namespace.Name, rodev1alpha1.ConditionSuspended)
+			}, checkDuration, checkInterval).Should(Equal(rodev1alpha1.ConditionStatusTrue))
+
+			Expect(attesterConditionStatus(ctx, attesterName, namespace.Name, rodev1alpha1.ConditionReady)).
+				To(Equal(rodev1alpha1.ConditionStatusFalse))
+
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      attesterName,
+				Namespace: namespace.Name,
+			}, &corev1.Secret{})
+
+			Expect(err).ToNot(HaveOccurred(), "secret was deleted while suspended", err)
+		})
+
+		It("should be ready with the same key when it's resumed", func() {
+			Eventually(func() rodev1alpha1.ConditionStatus {
+				return attesterConditionStatus(ctx, attesterName, namespace.Name, rodev1alpha1.ConditionSuspended)
+			}, checkDuration, checkInterval).Should(Equal(rodev1alpha1.ConditionStatusTrue))
+
+			setAttesterSuspend(ctx, attesterName, namespace.Name, false)
+
+			Eventually(func() rodev1alpha1.ConditionStatus {
+				return attesterConditionStatus(ctx, attesterName, namespace.Name, rodev1alpha1.ConditionReady)
+			}, checkDuration, checkInterval).Should(Equal(rodev1alpha1.ConditionStatusTrue))
+
+			resumed := corev1.Secret{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      attesterName,
+				Namespace: namespace.Name,
+			}, &resumed)
+
+			Expect(err).ToNot(HaveOccurred(), "error getting test secret", err)
+			Expect(resumed.Data).To(Equal(secret.Data))
+		})
+	})
+
 	//TODO: Actually create an attestation occurence in grafeas when occurences don't violate policy
 
 	//TODO: Don't create an attestation occurence when occurences violate policy
 
 	//TODO: Create invalid rego test
 
-	//TODO: Create reuse existing secret test
-
-	//TODO: Create a test that assures that the controller does not delete the secret if it didn't create it
-
 	//TODO: Invalid Pgp key in existing secret that user applied
 
 })
+
+func setAttesterSuspend(ctx context.Context, name, namespace string, suspend bool) {
+	att := rodev1alpha1.Attester{}
+
+	err := k8sClient.Get(ctx, types.NamespacedName{
+		Name:      name,
+		Namespace: namespace,
+	}, &att)
+	Expect(err).ToNot(HaveOccurred(), "error getting test attester", err)
+
+	att.Spec.Suspend = suspend
+	err = k8sClient.Update(ctx, &att)
+	Expect(err).ToNot(HaveOccurred(), "error updating test attester", err)
+}
+
+func attesterConditionStatus(ctx context.Context, name, namespace string, conditionType rodev1alpha1.ConditionType) rodev1alpha1.ConditionStatus {
+	att := rodev1alpha1.Attester{}
+
+	err := k8sClient.Get(ctx, types.NamespacedName{
+		Name:      name,
+		Namespace: namespace,
+	}, &att)
+	if err != nil {
+		return ""
+	}
+
+	return attesterCondition(&att, conditionType).Status
+}
@@ -0,0 +1,715 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/tracing"
+)
+
+func TestNormalizeAttesterConditions(t *testing.T) {
+	assert := assert.New(t)
+
+	att := &rodev1alpha1.Attester{}
+	assert.True(normalizeAttesterConditions(att))
+	assert.Len(att.Status.Conditions, 3)
+	assert.False(normalizeAttesterConditions(att))
+
+	att.Status.Conditions = []rodev1alpha1.Condition{
+		{
+			Type:   rodev1alpha1.ConditionSecret,
+			Status: rodev1alpha1.ConditionStatusTrue,
+		},
+	}
+	assert.True(normalizeAttesterConditions(att))
+	assert.Equal(rodev1alpha1.ConditionCompiled, att.Status.Conditions[0].Type)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, att.Status.Conditions[0].Status)
+	assert.Equal(rodev1alpha1.ConditionSecret, att.Status.Conditions[1].Type)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, att.Status.Conditions[1].Status)
+}
+
+func TestAttesterReconciler_DoesNotMutateSpec(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("gitops")
+	desired := att.Spec.DeepCopy()
+	r := newUnitTestAttesterReconciler(att)
+	key := unitTestRequest(att).NamespacedName
+
+	// a GitOps controller reverts the attester's spec to the one in git whenever it drifts, which would start a loop of
+	// reconciles if the controller wrote its defaults to the spec
+	reverts := 0
+	for i := 0; i < 4; i++ {
+		current := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, key, current))
+		if !reflect.DeepEqual(*desired, current.Spec) {
+			reverts++
+			current.Spec = *desired.DeepCopy()
+			assert.NoError(r.Update(ctx, current))
+		}
+
+		_, err := r.Reconcile(unitTestRequest(att))
+		assert.NoError(err)
+	}
+	assert.Equal(0, reverts)
+
+	// the secret the key was generated in is recorded in the status instead
+	current := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, key, current))
+	assert.Empty(current.Spec.PgpSecret)
+	assert.Equal("gitops", current.Status.PgpSecret)
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, attesterCondition(current, rodev1alpha1.ConditionSecret).Status)
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "gitops"}, &corev1.Secret{}))
+	assert.Contains(r.Attesters, key.String())
+}
+
+func newUnitTestAttester(name string) *rodev1alpha1.Attester {
+	return &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: rodev1alpha1.AttesterSpec{
+			Policy: unitTestPolicy(name),
+		},
+	}
+}
+
+func newUnitTestAttesterReconciler(objs ...runtime.Object) *AttesterReconciler {
+	return &AttesterReconciler{
+		Client:    newUnitTestClient(objs...),
+		Log:       logf.NullLogger{},
+		Scheme:    unitTestScheme(),
+		Attesters: make(map[string]attester.Attester),
+	}
+}
+
+func newUnitTestClient(objs ...runtime.Object) client.Client {
+	return fake.NewFakeClientWithScheme(unitTestScheme(), objs...)
+}
+
+func unitTestScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = rodev1alpha1.AddToScheme(s)
+
+	return s
+}
+
+func unitTestRequest(att *rodev1alpha1.Attester) ctrl.Request {
+	return ctrl.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: att.Namespace,
+			Name:      att.Name,
+		},
+	}
+}
+
+func unitTestPolicy(name string) string {
+	return fmt.Sprintf(`
+package %s
+
+violation[{"msg":"analysis failed"}]{
+	input.occurrences[_].discovered.discovered.analysisStatus != "FINISHED_SUCCESS"
+}
+`, name)
+}
+
+func TestAttesterReconciler_ListAttestersFiltered(t *testing.T) {
+	newLabelledAttester := func(namespace, name string, labels map[string]string) *rodev1alpha1.Attester {
+		att := newUnitTestAttester(name)
+		att.Namespace = namespace
+		att.Labels = labels
+		return att
+	}
+	prodA := newLabelledAttester("team-a", "proda", map[string]string{"team": "a", "tier": "prod"})
+	devA := newLabelledAttester("team-a", "deva", map[string]string{"team": "a", "tier": "dev"})
+	prodB := newLabelledAttester("team-b", "prodb", map[string]string{"team": "b", "tier": "prod"})
+	unlabelled := newLabelledAttester("team-b", "unlabelled", nil)
+	// attesters that exist but haven't been loaded aren't listed
+	unloaded := newLabelledAttester("team-a", "unloaded", map[string]string{"team": "a", "tier": "prod"})
+
+	r := newUnitTestAttesterReconciler(prodA, devA, prodB, unlabelled, unloaded)
+	for _, att := range []*rodev1alpha1.Attester{prodA, devA, prodB, unlabelled} {
+		reconcileUnitTestAttester(r, att, 4)
+	}
+
+	tests := map[string]struct {
+		opts     attester.ListOptions
+		expected []string
+	}{
+		"everything": {attester.ListOptions{}, []string{"team-a/deva", "team-a/proda", "team-b/prodb", "team-b/unlabelled"}},
+		"namespace":  {attester.ListOptions{Namespace: "team-a"}, []string{"team-a/deva", "team-a/proda"}},
+		"labels": {attester.ListOptions{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}},
+		}, []string{"team-a/proda", "team-b/prodb"}},
+		"namespace and labels": {attester.ListOptions{
+			Namespace:     "team-a",
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}},
+		}, []string{"team-a/proda"}},
+		"expression": {attester.ListOptions{
+			LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+				{Key: "tier", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"dev"}},
+			}},
+		}, []string{"team-a/proda", "team-b/prodb"}},
+		"unlabelled": {attester.ListOptions{
+			LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "team", Operator: metav1.LabelSelectorOpDoesNotExist},
+			}},
+		}, []string{"team-b/unlabelled"}},
+		"no matches": {attester.ListOptions{Namespace: "team-c"}, []string{}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			attesters, err := r.ListAttestersFiltered(tc.opts)
+			assert.NoError(err)
+
+			keys := make([]string, 0, len(attesters))
+			for key, att := range attesters {
+				assert.Equal(key, att.String())
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			assert.Equal(tc.expected, keys)
+		})
+	}
+
+	_, err := r.ListAttestersFiltered(attester.ListOptions{LabelSelector: &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Matches"}},
+	}})
+	assert.Error(t, err)
+}
+
+func TestAttesterReconciler_ConvergesByRequeueing(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("converges")
+	r := newUnitTestAttesterReconciler(att)
+
+	// only the requeues the reconciler asks for are followed, as if no watch events arrived
+	reconciles := 0
+	for result := (ctrl.Result{Requeue: true}); result.Requeue; reconciles++ {
+		if !assert.True(reconciles < 10, "the attester didn't converge") {
+			return
+		}
+
+		var err error
+		result, err = r.Reconcile(unitTestRequest(att))
+		assert.NoError(err)
+	}
+
+	assert.Contains(r.ListAttesters(), unitTestRequest(att).NamespacedName.String())
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, updated))
+	for _, condition := range updated.Status.Conditions {
+		assert.Equal(rodev1alpha1.ConditionStatusTrue, condition.Status, condition.Type)
+	}
+
+	// once it has converged, reconciling again doesn't requeue
+	result, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.False(result.Requeue)
+}
+
+// eventReasons drains the events recorded so far, returning the type and reason of each
+func eventReasons(recorder *record.FakeRecorder) []string {
+	reasons := make([]string, 0)
+	for len(recorder.Events) > 0 {
+		fields := strings.Fields(<-recorder.Events)
+		reasons = append(reasons, strings.Join(fields[:2], " "))
+	}
+
+	return reasons
+}
+
+// reconcileUnitTestAttester reconciles the attester the given number of times, ignoring errors
+func reconcileUnitTestAttester(r *AttesterReconciler, att *rodev1alpha1.Attester, times int) {
+	for i := 0; i < times; i++ {
+		_, _ = r.Reconcile(unitTestRequest(att))
+	}
+}
+
+func TestAttesterReconciler_SkipsUpToDateAttester(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("uptodate")
+	att.Generation = 1
+	r := newUnitTestAttesterReconciler(att)
+	key := unitTestRequest(att).NamespacedName.String()
+
+	reconcileUnitTestAttester(r, att, 4)
+	loaded, ok := r.Attesters[key]
+	assert.True(ok)
+
+	result := &rodev1alpha1.Attester{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result)
+	assert.NoError(err)
+	assert.Equal(int64(1), result.Status.ObservedGeneration)
+
+	// nothing changed, so the policy isn't recompiled
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Same(loaded, r.Attesters[key])
+
+	// a new generation of the spec is recompiled
+	result.Generation = 2
+	err = r.Update(ctx, result)
+	assert.NoError(err)
+
+	reconcileUnitTestAttester(r, att, 1)
+	assert.False(loaded == r.Attesters[key])
+}
+
+type fakeNoteCreator struct {
+	notes map[string]*grafeas.Note
+}
+
+func (c *fakeNoteCreator) CreateNote(ctx context.Context, noteID string, note *grafeas.Note) error {
+	c.notes[noteID] = note
+	return nil
+}
+
+func TestAttesterReconciler_CreatesNotes(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("notes")
+	att.Spec.NoteKinds = []rodev1alpha1.NoteKind{"VULNERABILITY", "DISCOVERY"}
+	notes := &fakeNoteCreator{notes: make(map[string]*grafeas.Note)}
+
+	r := newUnitTestAttesterReconciler(att)
+	r.Notes = notes
+	reconcileUnitTestAttester(r, att, 4)
+
+	assert.Len(notes.notes, 3)
+	assert.Contains(notes.notes, "default.notes.vulnerability")
+
+	result := &rodev1alpha1.Attester{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result)
+	assert.NoError(err)
+	assert.Equal([]string{
+		"projects/rode/notes/default.notes",
+		"projects/rode/notes/default.notes.vulnerability",
+		"projects/rode/notes/default.notes.discovery",
+	}, result.Status.NoteNames)
+}
+
+func TestAttesterReconciler_CreatesConfiguredNote(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("customnote")
+	att.Spec.NoteKinds = []rodev1alpha1.NoteKind{"VULNERABILITY"}
+	att.Spec.Note = &rodev1alpha1.Note{Name: "scan-approved", Project: "security", Authority: "Security team"}
+	notes := &fakeNoteCreator{notes: make(map[string]*grafeas.Note)}
+
+	r := newUnitTestAttesterReconciler(att)
+	r.Notes = notes
+	reconcileUnitTestAttester(r, att, 4)
+
+	// the note is named by the spec, and the description defaults to the attester's
+	assert.Len(notes.notes, 2)
+	assert.Equal("projects/security/notes/scan-approved", notes.notes["scan-approved"].Name)
+	assert.Equal("Attestations by default/customnote", notes.notes["scan-approved"].ShortDescription)
+	assert.Equal("Security team", notes.notes["scan-approved"].GetAttestationAuthority().GetHint().GetHumanReadableName())
+	assert.Equal("projects/security/notes/scan-approved.vulnerability", notes.notes["scan-approved.vulnerability"].Name)
+
+	result := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: att.Namespace, Name: att.Name}, result))
+	assert.Equal([]string{
+		"projects/security/notes/scan-approved",
+		"projects/security/notes/scan-approved.vulnerability",
+	}, result.Status.NoteNames)
+
+	// and the loaded attester stores its attestations under it
+	loaded := r.Attesters[unitTestRequest(att).NamespacedName.String()]
+	assert.NotNil(loaded)
+	res, err := loaded.Attest(ctx, &attester.AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+	assert.Equal("projects/security/notes/scan-approved", res.Attestation.NoteName)
+}
+
+func TestAttesterReconciler_Reload(t *testing.T) {
+	assert := assert.New(t)
+
+	att := newUnitTestAttester("reload")
+	att.Generation = 1
+	r := newUnitTestAttesterReconciler(att)
+	r.events = make(chan event.GenericEvent, 10)
+	key := unitTestRequest(att).NamespacedName.String()
+
+	reconcileUnitTestAttester(r, att, 4)
+	loaded, ok := r.Attesters[key]
+	assert.True(ok)
+
+	r.Attesters["default/deleted"] = loaded
+
+	summary, err := r.Reload(context.Background())
+	assert.NoError(err)
+	assert.Equal([]string{key}, summary.Reloaded)
+	assert.Equal([]string{"default/deleted"}, summary.Removed)
+	assert.Len(r.events, 2)
+
+	for len(r.events) > 0 {
+		e := <-r.events
+		_, _ = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()}})
+	}
+
+	// the up to date attester is rebuilt rather than skipped, and the deleted attester is removed
+	assert.False(loaded == r.Attesters[key])
+	assert.NotContains(r.Attesters, "default/deleted")
+}
+
+// conflictingStatusClient fails the first conflicts status updates with a conflict, as if the object had changed since
+// it was read
+type conflictingStatusClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingStatusClient) Status() client.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	client *conflictingStatusClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if w.client.conflicts > 0 {
+		w.client.conflicts--
+		return errors.NewConflict(rodev1alpha1.GroupVersion.WithResource("attesters").GroupResource(), "conflict", fmt.Errorf("the object has been modified"))
+	}
+
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func TestAttesterReconciler_RetriesStatusUpdateConflicts(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("conflict")
+	r := newUnitTestAttesterReconciler(att)
+	conflicting := &conflictingStatusClient{Client: r.Client, conflicts: 1}
+	r.Client = conflicting
+
+	// the first status update conflicts, and is retried rather than aborting the reconcile
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Zero(conflicting.conflicts)
+
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, updated))
+	assert.Len(updated.Status.Conditions, 3)
+
+	reconcileUnitTestAttester(r, att, 5)
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, updated))
+	assert.Equal(rodev1alpha1.ConditionStatusTrue, attesterCondition(updated, rodev1alpha1.ConditionReady).Status)
+	assert.Contains(r.ListAttesters(), "default/conflict")
+
+	// a conflicting change made since the attester was read is kept, with the condition change reapplied on top of it
+	stale := updated.DeepCopy()
+	updated.Labels = map[string]string{"team": "platform"}
+	assert.NoError(r.Update(ctx, updated))
+	conflicting.conflicts = 1
+	assert.NoError(r.updateStatus(ctx, stale, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", "invalid policy"))
+
+	latest := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, latest))
+	assert.Equal("platform", latest.Labels["team"])
+	assert.Equal("PolicyCompileFailed", attesterCondition(latest, rodev1alpha1.ConditionCompiled).Reason)
+	assert.Equal(latest.ResourceVersion, stale.ResourceVersion)
+
+	// a conflict on an attester that's since been deleted isn't an error
+	assert.NoError(r.Delete(ctx, latest))
+	conflicting.conflicts = 1
+	assert.NoError(r.updateStatus(ctx, stale, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusTrue, "PolicyCompiled", ""))
+}
+
+func TestAttesterReconciler_StopsOnShutdown(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("shutdown")
+	r := newUnitTestAttesterReconciler(att)
+	recorder := record.NewFakeRecorder(20)
+	r.Recorder = recorder
+	r.ctx, r.cancel = context.WithCancel(ctx)
+
+	// the manager stopping cancels the reconcile before the policy is compiled and the key generated
+	r.cancel()
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.Equal(context.Canceled, err)
+	assert.NotContains(r.ListAttesters(), "default/shutdown")
+	assert.Error(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "shutdown"}, &corev1.Secret{}))
+
+	// the cancelled compile isn't recorded as a policy failure
+	updated := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, unitTestRequest(att).NamespacedName, updated))
+	assert.NotEqual("PolicyCompileFailed", attesterCondition(updated, rodev1alpha1.ConditionCompiled).Reason)
+	assert.NotContains(eventReasons(recorder), "Warning PolicyCompileFailed")
+}
+
+// recordingLogger records the key/value pairs of every line logged through it or the loggers derived from it
+type recordingLogger struct {
+	values []interface{}
+	lines  *[]map[string]interface{}
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{lines: &[]map[string]interface{}{}}
+}
+
+func (l *recordingLogger) record(keysAndValues []interface{}) {
+	line := make(map[string]interface{})
+	all := append(append([]interface{}{}, l.values...), keysAndValues...)
+	for i := 0; i+1 < len(all); i += 2 {
+		line[fmt.Sprint(all[i])] = all[i+1]
+	}
+	*l.lines = append(*l.lines, line)
+}
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.record(keysAndValues)
+}
+
+func (l *recordingLogger) Enabled() bool {
+	return true
+}
+
+func (l *recordingLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.record(keysAndValues)
+}
+
+func (l *recordingLogger) V(level int) logr.InfoLogger {
+	return l
+}
+
+func (l *recordingLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return &recordingLogger{values: append(append([]interface{}{}, l.values...), keysAndValues...), lines: l.lines}
+}
+
+func (l *recordingLogger) WithName(name string) logr.Logger {
+	return l
+}
+
+func TestAttesterReconciler_CorrelatesLogs(t *testing.T) {
+	assert := assert.New(t)
+
+	att := newUnitTestAttester("correlated")
+	r := newUnitTestAttesterReconciler(att)
+	logger := newRecordingLogger()
+	r.Log = logger
+
+	reconcileIDs := make(map[interface{}]bool)
+	for i := 0; i < 4; i++ {
+		*logger.lines = nil
+		_, _ = r.Reconcile(unitTestRequest(att))
+		assert.NotEmpty(*logger.lines)
+
+		passID := (*logger.lines)[0]["reconcileID"]
+		assert.NotEmpty(passID)
+		assert.False(reconcileIDs[passID], "reconcile IDs are unique")
+		reconcileIDs[passID] = true
+
+		for _, line := range *logger.lines {
+			assert.Equal(unitTestRequest(att).NamespacedName, line["attester"])
+			assert.Equal(passID, line["reconcileID"])
+		}
+	}
+}
+
+// defaultVerbosityLogger records the messages logged at level 0, the verbosity the controller logs at by default,
+// with the attester they were logged for
+type defaultVerbosityLogger struct {
+	attester interface{}
+	level    int
+	messages *[]string
+}
+
+func (l *defaultVerbosityLogger) Info(msg string, keysAndValues ...interface{}) {
+	if l.level == 0 {
+		*l.messages = append(*l.messages, fmt.Sprintf("%v: %s", l.attester, msg))
+	}
+}
+
+func (l *defaultVerbosityLogger) Enabled() bool {
+	return l.level == 0
+}
+
+func (l *defaultVerbosityLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.Info(msg)
+}
+
+func (l *defaultVerbosityLogger) V(level int) logr.InfoLogger {
+	return &defaultVerbosityLogger{attester: l.attester, level: l.level + level, messages: l.messages}
+}
+
+func (l *defaultVerbosityLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	att := l.attester
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if keysAndValues[i] == "attester" {
+			att = keysAndValues[i+1]
+		}
+	}
+
+	return &defaultVerbosityLogger{attester: att, level: l.level, messages: l.messages}
+}
+
+func (l *defaultVerbosityLogger) WithName(name string) logr.Logger {
+	return l
+}
+
+func TestAttesterReconciler_LogLevelAnnotation(t *testing.T) {
+	assert := assert.New(t)
+
+	verbose := newUnitTestAttester("verbose")
+	verbose.Annotations = map[string]string{LogLevelAnnotation: "1"}
+	quiet := newUnitTestAttester("quiet")
+	invalid := newUnitTestAttester("invalid")
+	invalid.Annotations = map[string]string{LogLevelAnnotation: "debug"}
+	r := newUnitTestAttesterReconciler(verbose, quiet, invalid)
+	messages := &[]string{}
+	r.Log = &defaultVerbosityLogger{messages: messages}
+
+	reconcileUnitTestAttester(r, verbose, 2)
+	reconcileUnitTestAttester(r, quiet, 2)
+	reconcileUnitTestAttester(r, invalid, 2)
+
+	// the debug logs only show for the annotated attester, and the logs shown by default still show for the others
+	assert.Contains(*messages, fmt.Sprintf("%v: Loaded attester", unitTestRequest(verbose).NamespacedName))
+	assert.Contains(*messages, fmt.Sprintf("%v: Compiling the policy", unitTestRequest(verbose).NamespacedName))
+	for _, att := range []*rodev1alpha1.Attester{quiet, invalid} {
+		name := unitTestRequest(att).NamespacedName
+		assert.NotContains(*messages, fmt.Sprintf("%v: Loaded attester", name))
+		assert.NotContains(*messages, fmt.Sprintf("%v: Compiling the policy", name))
+		assert.Contains(*messages, fmt.Sprintf("%v: Reconciling attester", name))
+	}
+	assert.Contains(*messages, fmt.Sprintf("%v: Ignoring invalid log level annotation", unitTestRequest(invalid).NamespacedName))
+}
+
+func TestAttesterReconciler_RecordsEvents(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("events")
+	att.Generation = 1
+	r := newUnitTestAttesterReconciler(att)
+	recorder := record.NewFakeRecorder(20)
+	r.Recorder = recorder
+	key := unitTestRequest(att).NamespacedName
+
+	reconcileUnitTestAttester(r, att, 4)
+	assert.Equal([]string{"Normal FinalizerRegistered", "Normal PolicyCompiled", "Normal SecretCreated"}, eventReasons(recorder))
+
+	// the fake client doesn't increment the generation when the spec changes
+	update := func(policy string, generation int64) {
+		updated := &rodev1alpha1.Attester{}
+		assert.NoError(r.Get(ctx, key, updated))
+		updated.Spec.Policy = policy
+		updated.Generation = generation
+		assert.NoError(r.Update(ctx, updated))
+	}
+
+	update("package events\nviolation[{\"msg\": msg}] { true }", 2)
+	reconcileUnitTestAttester(r, att, 2)
+	events := make([]string, 0)
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	assert.Len(events, 2)
+	for _, e := range events {
+		assert.Contains(e, "Warning PolicyCompileFailed Unable to compile policy")
+		assert.Contains(e, "var msg is unsafe")
+	}
+
+	update(unitTestPolicy("events"), 3)
+	reconcileUnitTestAttester(r, att, 3)
+	assert.Equal([]string{"Normal PolicyCompiled"}, eventReasons(recorder))
+
+	// a change to a loaded attester that still compiles is recorded too
+	update(unitTestPolicy("events")+"\n# changed", 4)
+	reconcileUnitTestAttester(r, att, 3)
+	assert.Equal([]string{"Normal PolicyCompiled"}, eventReasons(recorder))
+
+	deleted := &rodev1alpha1.Attester{}
+	assert.NoError(r.Get(ctx, key, deleted))
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	assert.NoError(r.Update(ctx, deleted))
+	reconcileUnitTestAttester(r, att, 1)
+	assert.Equal([]string{"Normal SecretDeleted"}, eventReasons(recorder))
+}
+
+func TestAttesterReconciler_Tracing(t *testing.T) {
+	assert := assert.New(t)
+
+	att := newUnitTestAttester("traced")
+	r := newUnitTestAttesterReconciler(att)
+	recorder := tracing.NewRecorder()
+	r.TracerProvider = recorder
+	reconcileUnitTestAttester(r, att, 5)
+
+	spans := make(map[string][]tracing.RecordedSpan)
+	for _, span := range recorder.Spans() {
+		assert.Equal("attester-controller", span.Tracer)
+		assert.Equal("default/traced", span.Attributes["attester"])
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+
+	assert.Len(spans["Reconcile"], 5)
+	assert.Len(spans["NewPolicy"], 1)
+	assert.Equal("Reconcile", spans["NewPolicy"][0].Parent)
+	assert.Equal(1, spans["NewPolicy"][0].Attributes["modules"])
+	assert.Len(spans["NewSecret"], 1)
+	assert.Equal("Reconcile", spans["NewSecret"][0].Parent)
+	assert.Empty(spans["NewSecret"][0].Errors)
+
+	transitions := make([]string, 0)
+	for _, span := range spans["Reconcile"] {
+		assert.Empty(span.Parent)
+		for _, event := range span.Events {
+			assert.Equal("ConditionTransition", event.Name)
+			transitions = append(transitions, fmt.Sprintf("%s:%s->%s", event.Attributes["type"], event.Attributes["from"], event.Attributes["to"]))
+		}
+	}
+	assert.Contains(transitions, "Policy:False->True")
+	assert.Contains(transitions, "Key:False->True")
+	assert.Contains(transitions, "Ready:False->True")
+
+	// reconciling a loaded attester doesn't compile its policy or create its secret again
+	recorder.Reset()
+	_, err := r.Reconcile(unitTestRequest(att))
+	assert.NoError(err)
+	assert.Len(recorder.Spans(), 1)
+	assert.Equal("Reconcile", recorder.Spans()[0].Name)
+}
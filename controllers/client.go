@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewClient creates the manager's client, which reads objects from the manager's cache like the default client except
+// for secrets. Secrets are read from the API server, so that the cache doesn't hold every secret in the cluster and
+// their data, when the controllers only read a few of them.
+func NewClient(cache cache.Cache, config *rest.Config, options client.Options) (client.Client, error) {
+	c, err := client.New(config, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.DelegatingClient{
+		Reader: &uncachedSecretsReader{
+			cached: &client.DelegatingReader{
+				CacheReader:  cache,
+				ClientReader: c,
+			},
+			uncached: c,
+		},
+		Writer:       c,
+		StatusClient: c,
+	}, nil
+}
+
+// uncachedSecretsReader reads secrets with the uncached reader and everything else with the cached reader
+type uncachedSecretsReader struct {
+	cached   client.Reader
+	uncached client.Reader
+}
+
+func (r *uncachedSecretsReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if _, ok := obj.(*corev1.Secret); ok {
+		return r.uncached.Get(ctx, key, obj)
+	}
+	return r.cached.Get(ctx, key, obj)
+}
+
+func (r *uncachedSecretsReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if _, ok := list.(*corev1.SecretList); ok {
+		return r.uncached.List(ctx, list, opts...)
+	}
+	return r.cached.List(ctx, list, opts...)
+}
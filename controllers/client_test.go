@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUncachedSecretsReader(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "signer"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "policy"}}
+	r := &uncachedSecretsReader{
+		cached:   fake.NewFakeClientWithScheme(unitTestScheme(), configMap),
+		uncached: fake.NewFakeClientWithScheme(unitTestScheme(), secret),
+	}
+
+	// secrets are only read from the API server
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "signer"}, &corev1.Secret{}))
+	secrets := &corev1.SecretList{}
+	assert.NoError(r.List(ctx, secrets))
+	assert.Len(secrets.Items, 1)
+
+	// everything else is read from the cache
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "policy"}, &corev1.ConfigMap{}))
+	configMaps := &corev1.ConfigMapList{}
+	assert.NoError(r.List(ctx, configMaps))
+	assert.Len(configMaps.Items, 1)
+	assert.Error(r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "signer"}, &corev1.ConfigMap{}))
+}
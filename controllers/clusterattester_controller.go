@@ -0,0 +1,303 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+// ClusterAttesterReconciler reconciles a ClusterAttester object. Cluster attesters are loaded into the Attesters of the
+// AttesterReconciler, keyed by ClusterAttesterKey, so that they're listed and attest alongside namespaced attesters with
+// the same policy options.
+type ClusterAttesterReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Attesters is the reconciler of namespaced attesters, which holds the loaded attesters and the options their
+	// policies are compiled with
+	Attesters *AttesterReconciler
+
+	// SecretNamespace is the namespace that the secrets of cluster attesters are stored in, which is usually the
+	// controller's own namespace
+	SecretNamespace string
+
+	// Recorder records events for cluster attesters when set
+	Recorder record.EventRecorder
+
+	// events enqueues cluster attesters whose attestation counts or subjects changed
+	events chan event.GenericEvent
+}
+
+// ClusterAttesterKey returns the key that the cluster attester with the given name is loaded under. It has an empty
+// namespace, so it can't collide with the namespace/name key of a namespaced attester.
+func ClusterAttesterKey(name string) string {
+	return types.NamespacedName{Name: name}.String()
+}
+
+// isClusterAttesterKey returns whether the key of a loaded attester is the key of a cluster attester
+func isClusterAttesterKey(key string) bool {
+	return strings.HasPrefix(key, "/")
+}
+
+// +kubebuilder:rbac:groups=rode.liatr.io,resources=clusterattesters,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=rode.liatr.io,resources=clusterattesters/status,verbs=get;update;patch
+
+// Reconcile loads the cluster attester's policy and the key from its secret, generating the key if the secret doesn't
+// exist
+func (r *ClusterAttesterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := r.Attesters.baseContext()
+	key := ClusterAttesterKey(req.Name)
+	log := r.Log.WithValues("clusterAttester", req.Name, "reconcileID", uuid.NewUUID())
+
+	log.Info("Reconciling cluster attester")
+
+	cluster := &rodev1alpha1.ClusterAttester{}
+	if err := r.Get(ctx, types.NamespacedName{Name: req.Name}, cluster); err != nil {
+		if errors.IsNotFound(err) {
+			// the secret is garbage collected through its owner reference
+			r.Attesters.unloadAttester(key)
+			return ctrl.Result{}, nil
+		}
+
+		log.Error(err, "Unable to load cluster attester")
+		return ctrl.Result{}, err
+	}
+
+	if !cluster.ObjectMeta.DeletionTimestamp.IsZero() {
+		log.Info("Cluster attester is being deleted")
+		r.Attesters.unloadAttester(key)
+		return ctrl.Result{}, nil
+	}
+
+	// The conditions of a cluster attester follow the same rules as a namespaced attester's, so they're kept by the same
+	// helpers on an attester with its metadata, spec and status
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: cluster.ObjectMeta,
+		Spec:       cluster.Spec.AttesterSpec(),
+		Status:     *cluster.Status.DeepCopy(),
+	}
+	if att.Spec.PgpSecret == "" {
+		att.Spec.PgpSecret = req.Name
+	}
+	normalizeAttesterConditions(att)
+	r.Attesters.deriveReadyCondition(att)
+
+	var counts attester.AttestationCounts
+	if r.Attesters.Attestations != nil {
+		counts = r.Attesters.Attestations.Take(key)
+		if !counts.IsZero() {
+			addAttestationCounts(att, counts)
+		}
+	}
+	if r.Attesters.Subjects != nil {
+		att.Status.AttestedSubjects = r.Attesters.Subjects.Count(key)
+	}
+
+	var loadErr error
+	if r.Attesters.isUpToDate(att, key) {
+		log.Info("Cluster attester is up to date")
+	} else {
+		loadErr = r.load(ctx, log, cluster, att, key)
+	}
+	r.Attesters.deriveReadyCondition(att)
+
+	if !equality.Semantic.DeepEqual(cluster.Status, att.Status) {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			cluster.Status = att.Status
+			err := r.Status().Update(ctx, cluster)
+			if errors.IsConflict(err) {
+				// the status is only written by the controller, so it's reapplied to the latest version
+				if err := r.Get(ctx, types.NamespacedName{Name: req.Name}, cluster); err != nil {
+					return err
+				}
+			}
+			return err
+		})
+		if err := client.IgnoreNotFound(err); err != nil {
+			if !counts.IsZero() {
+				r.Attesters.Attestations.Restore(key, counts)
+			}
+			log.Error(err, "Unable to update cluster attester status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, loadErr
+}
+
+// load compiles the cluster attester's policy and reads its key, loading the attester under the key once both are ready.
+// The outcome is recorded in the conditions of att.
+func (r *ClusterAttesterReconciler) load(ctx context.Context, log logr.Logger, cluster *rodev1alpha1.ClusterAttester, att *rodev1alpha1.Attester, key string) error {
+	modules, _, err := r.Attesters.policyModules(ctx, att, cluster.Name)
+	var policy attester.Policy
+	if err == nil {
+		policy, err = attester.NewPolicyFromModules(ctx, cluster.Name, modules, false,
+			attester.WithQuery(att.Spec.PolicyQuery),
+			attester.WithRegoVersion(att.Spec.RegoVersion),
+			attester.WithTimings(r.Attesters.PolicyTimings),
+			attester.WithPartialEval(r.Attesters.PolicyPartialEval),
+			attester.WithEvalCache(r.Attesters.EvalCache),
+			attester.WithEvalTimeout(r.Attesters.policyEvalTimeout()),
+			attester.WithBuiltins(r.Attesters.Builtins...))
+	}
+	if ctx.Err() != nil {
+		// the controller is stopping, which says nothing about the policy
+		return ctx.Err()
+	}
+	if err != nil {
+		log.Error(err, "Unable to create policy")
+		r.eventf(cluster, corev1.EventTypeWarning, "PolicyCompileFailed", "Unable to compile policy: %s", err)
+		r.Attesters.setCondition(att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusFalse, "PolicyCompileFailed", err.Error())
+		return err
+	}
+
+	compiledReason, compiledMessage := "PolicyCompiled", ""
+	if err := attester.CheckRules(policy); err != nil {
+		compiledReason, compiledMessage = "PolicyHasNoRules", err.Error()
+	}
+	r.Attesters.setCondition(att, rodev1alpha1.ConditionCompiled, rodev1alpha1.ConditionStatusTrue, compiledReason, compiledMessage)
+
+	signer, reason, err := r.signer(ctx, log, cluster, att)
+	if err != nil {
+		r.Attesters.setCondition(att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusFalse, reason, err.Error())
+		return err
+	}
+	if err := setPublicKey(att, signer); err != nil {
+		return err
+	}
+	r.Attesters.setCondition(att, rodev1alpha1.ConditionSecret, rodev1alpha1.ConditionStatusTrue, reason, "")
+
+	if err := r.Attesters.ensureNotes(ctx, att, key); err != nil {
+		log.Error(err, "Unable to create the cluster attester's notes")
+		return err
+	}
+
+	opts := []attester.AttesterOption{
+		attester.WithStage(att.Spec.Stage),
+		attester.WithSignatureFormat(att.Spec.SignatureFormat),
+		attester.WithEvalBudget(r.Attesters.EvalBudget),
+		attester.WithSignerProvider(r.Attesters.rotateSigner(log, key, signer)),
+	}
+	if r.Attesters.Attestations != nil {
+		opts = append(opts, attester.WithAttestationCounter(r.Attesters.Attestations))
+	}
+
+	r.Attesters.setAttester(key, attester.NewAttester(key, policy, signer, opts...))
+	att.Status.PolicyVersion = attester.PolicyVersion(modules, att.Spec.PolicyQuery)
+	att.Status.ObservedGeneration = cluster.Generation
+	log.Info("Loaded cluster attester", "keyID", signer.KeyID(), "policyVersion", att.Status.PolicyVersion)
+
+	return nil
+}
+
+// signer reads the cluster attester's key from its secret in the SecretNamespace, creating the secret if it doesn't
+// exist. It returns the reason for the attester's Key condition.
+func (r *ClusterAttesterReconciler) signer(ctx context.Context, log logr.Logger, cluster *rodev1alpha1.ClusterAttester, att *rodev1alpha1.Attester) (attester.Signer, string, error) {
+	name := types.NamespacedName{Namespace: r.SecretNamespace, Name: att.Spec.PgpSecret}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, name, secret)
+	if errors.IsNotFound(err) {
+		log.Info("Couldn't find secret, creating a new one", "secret", name)
+		signer, err := attester.NewClusterSecret(ctx, cluster, r.Client, name)
+		if err != nil {
+			log.Error(err, "Failed to create the signer secret")
+			r.eventf(cluster, corev1.EventTypeWarning, "SecretCreationFailed", "Unable to create secret %s: %s", name, err)
+			return nil, "SecretCreationFailed", err
+		}
+
+		r.eventf(cluster, corev1.EventTypeNormal, "SecretCreated", "Created secret %s with key %s", name, signer.KeyID())
+		return signer, "SecretCreated", nil
+	}
+	if err != nil {
+		log.Error(err, "Unable to get the secret", "secret", name)
+		return nil, "SecretUnavailable", err
+	}
+
+	signer, err := attester.ReadSigner(bytes.NewBuffer(secret.Data[att.Spec.GetPgpSecretKey()]))
+	if err != nil {
+		log.Error(err, "Unable to create signer from secret", "secret", name)
+		return nil, "InvalidKey", err
+	}
+
+	if strength := r.Attesters.KeyStrength; strength != nil {
+		if err := strength.Check(signer); err != nil {
+			log.Error(err, "Signer key doesn't meet the minimum key strength", "secret", name)
+			r.eventf(cluster, corev1.EventTypeWarning, "WeakKey", "Secret %s: %s", name, err)
+			return nil, "WeakKey", err
+		}
+	}
+
+	return signer, "SecretLoaded", nil
+}
+
+// eventf records an event for the cluster attester if the reconciler has a recorder
+func (r *ClusterAttesterReconciler) eventf(cluster *rodev1alpha1.ClusterAttester, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder != nil {
+		r.Recorder.Eventf(cluster, eventType, reason, messageFmt, args...)
+	}
+}
+
+// enqueue queues the cluster attester with the given name to be reconciled
+func (r *ClusterAttesterReconciler) enqueue(name string) {
+	if r.events == nil {
+		return
+	}
+
+	cluster := &rodev1alpha1.ClusterAttester{ObjectMeta: metav1.ObjectMeta{Name: name}}
+
+	// drop the event rather than block the caller if the controller is falling behind
+	select {
+	case r.events <- event.GenericEvent{Meta: cluster, Object: cluster}:
+	default:
+	}
+}
+
+// SetupWithManager sets up the watching of ClusterAttester objects. Changes to the counts tracked by the
+// AttesterReconciler for cluster attesters are routed to this controller.
+func (r *ClusterAttesterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.events = make(chan event.GenericEvent, 1024)
+	r.Attesters.enqueueCluster = r.enqueue
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&rodev1alpha1.ClusterAttester{}).
+		Watches(&source.Channel{Source: r.events}, &handler.EnqueueRequestForObject{}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		Complete(r)
+}
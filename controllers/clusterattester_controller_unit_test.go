@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func newUnitTestClusterAttester(name string) *rodev1alpha1.ClusterAttester {
+	return &rodev1alpha1.ClusterAttester{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: rodev1alpha1.ClusterAttesterSpec{
+			Policy: unitTestPolicy(name),
+		},
+	}
+}
+
+func newUnitTestClusterAttesterReconciler(attesters *AttesterReconciler) *ClusterAttesterReconciler {
+	return &ClusterAttesterReconciler{
+		Client:          attesters.Client,
+		Log:             logf.NullLogger{},
+		Scheme:          attesters.Scheme,
+		Attesters:       attesters,
+		SecretNamespace: "rode",
+	}
+}
+
+func unitTestClusterRequest(cluster *rodev1alpha1.ClusterAttester) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name}}
+}
+
+func TestClusterAttesterReconciler(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := newUnitTestAttester("local")
+	cluster := newUnitTestClusterAttester("shared")
+	attesters := newUnitTestAttesterReconciler(att, cluster)
+	r := newUnitTestClusterAttesterReconciler(attesters)
+
+	reconcileUnitTestAttester(attesters, att, 5)
+	_, err := r.Reconcile(unitTestClusterRequest(cluster))
+	assert.NoError(err)
+
+	// the cluster attester is loaded alongside the namespaced attester, under a key without a namespace
+	loaded := attesters.ListAttesters()
+	assert.Contains(loaded, "default/local")
+	assert.Contains(loaded, "/shared")
+	assert.Equal("/shared", ClusterAttesterKey("shared"))
+
+	// it's shared by every namespace, so it's listed for namespaces without attesters of their own
+	filtered, err := attesters.ListAttestersFiltered(attester.ListOptions{Namespace: "other"})
+	assert.NoError(err)
+	assert.Len(filtered, 1)
+	assert.Contains(filtered, "/shared")
+
+	filtered, err = attesters.ListAttestersFiltered(attester.ListOptions{})
+	assert.NoError(err)
+	assert.Len(filtered, 2)
+
+	// the key is stored in the secret namespace, controlled by the cluster attester
+	secret := &corev1.Secret{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Namespace: "rode", Name: "shared"}, secret))
+	owner := metav1.GetControllerOf(secret)
+	if assert.NotNil(owner) {
+		assert.Equal("ClusterAttester", owner.Kind)
+		assert.Equal("shared", owner.Name)
+	}
+
+	result := &rodev1alpha1.ClusterAttester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Name: "shared"}, result))
+	assert.NotEmpty(result.Status.KeyID)
+	assert.NotEmpty(result.Status.PolicyVersion)
+	assert.Equal(result.Generation, result.Status.ObservedGeneration)
+	for _, condition := range result.Status.Conditions {
+		assert.Equal(rodev1alpha1.ConditionStatusTrue, condition.Status, "condition %s", condition.Type)
+	}
+
+	// reconciling again reads the key from the secret rather than generating another
+	delete(attesters.Attesters, "/shared")
+	_, err = r.Reconcile(unitTestClusterRequest(cluster))
+	assert.NoError(err)
+	reloaded := &rodev1alpha1.ClusterAttester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Name: "shared"}, reloaded))
+	assert.Equal(result.Status.KeyID, reloaded.Status.KeyID)
+	assert.Equal("SecretLoaded", attesterCondition(&rodev1alpha1.Attester{Status: reloaded.Status}, rodev1alpha1.ConditionSecret).Reason)
+
+	// deleting the cluster attester unloads it, leaving the namespaced attester
+	assert.NoError(r.Delete(ctx, reloaded))
+	_, err = r.Reconcile(unitTestClusterRequest(cluster))
+	assert.NoError(err)
+	loaded = attesters.ListAttesters()
+	assert.NotContains(loaded, "/shared")
+	assert.Contains(loaded, "default/local")
+}
+
+func TestClusterAttesterReconciler_PolicyCompileFailed(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	cluster := newUnitTestClusterAttester("invalid")
+	cluster.Spec.Policy = "package invalid\n\nviolation[{\"msg\":\"x\"}]{"
+	attesters := newUnitTestAttesterReconciler(cluster)
+	r := newUnitTestClusterAttesterReconciler(attesters)
+
+	_, err := r.Reconcile(unitTestClusterRequest(cluster))
+	assert.Error(err)
+	assert.NotContains(attesters.ListAttesters(), "/invalid")
+
+	result := &rodev1alpha1.ClusterAttester{}
+	assert.NoError(r.Get(ctx, types.NamespacedName{Name: "invalid"}, result))
+	status := &rodev1alpha1.Attester{Status: result.Status}
+	assert.Equal("PolicyCompileFailed", attesterCondition(status, rodev1alpha1.ConditionCompiled).Reason)
+	assert.Equal(rodev1alpha1.ConditionStatusFalse, attesterCondition(status, rodev1alpha1.ConditionReady).Status)
+
+	// the key isn't generated until the policy compiles
+	secrets := &corev1.SecretList{}
+	assert.NoError(r.List(ctx, secrets))
+	assert.Empty(secrets.Items)
+}
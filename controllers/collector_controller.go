@@ -31,6 +31,7 @@ import (
 	v1beta1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -68,7 +69,9 @@ var (
 // nolint: gocyclo
 func (r *CollectorReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
-	log := r.Log.WithValues("collector", req.NamespacedName)
+	// collectors outlive the reconcile that starts them, so their logs only carry the collector's name
+	collectorLog := r.Log.WithValues("collector", req.NamespacedName)
+	log := collectorLog.WithValues("reconcileID", uuid.NewUUID())
 
 	log.Info("Reconciling collector")
 
@@ -92,7 +95,7 @@ func (r *CollectorReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	} else {
 		switch col.Spec.CollectorType {
 		case "ecr":
-			c = collector.NewEcrEventCollector(r.Log, r.AWSConfig, col.Spec.ECR.QueueName)
+			c = collector.NewEcrEventCollector(collectorLog, r.AWSConfig, col.Spec.ECR.QueueName)
 		case "harbor":
 			secret, err := r.getHarborSecret(ctx, col.Spec.Harbor.Secret)
 			if err != nil {
@@ -103,9 +106,9 @@ func (r *CollectorReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				log.Info("Ingress doesn't exist or isn't properly configured; proceeding without ingress data")
 				ingress = &v1beta1.Ingress{}
 			}
-			c = collector.NewHarborEventCollector(r.Log, col.Spec.Harbor.HarborURL, secret, col.Spec.Harbor.Project, col.ObjectMeta.Namespace, ingress)
+			c = collector.NewHarborEventCollector(collectorLog, col.Spec.Harbor.HarborURL, secret, col.Spec.Harbor.Project, col.ObjectMeta.Namespace, ingress)
 		case "test":
-			c = collector.NewTestCollector(r.Log, "foo")
+			c = collector.NewTestCollector(collectorLog, "foo")
 		default:
 			err = errors.New("Unknown collector type")
 			// Loud output when erroring, getting more reconciles than expected.
@@ -262,7 +265,7 @@ func (r *CollectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		WithEventFilter(ignoreConditionStatusUpdateToActive(func(o runtime.Object) util.Conditioner {
 			return o.(*rodev1alpha1.Collector)
 		}, rodev1alpha1.ConditionActive)).
-		WithEventFilter(ignoreFinalizerUpdate()).
+		WithEventFilter(ignoreFinalizerUpdate(collectorFinalizerName)).
 		WithEventFilter(ignoreDelete()).
 		Complete(r)
 }
@@ -233,7 +233,7 @@ func createInternalAttester(ctx context.Context, att *rodev1alpha1.Attester) *at
 		Name:      att.Name,
 	}
 
-	policy, err := attester.NewPolicy(att.Name, att.Spec.Policy, false)
+	policy, err := attester.NewPolicy(ctx, att.Name, att.Spec.Policy, false)
 	Expect(err).ToNot(HaveOccurred(), "failed to create test attester policy", err)
 
 	signerSecret := &corev1.Secret{}
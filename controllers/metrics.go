@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	attesterReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_attester_reconciles_total",
+		Help: "Number of times each attester has been reconciled",
+	}, []string{"namespace", "name"})
+
+	attesterReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_attester_reconcile_errors_total",
+		Help: "Number of reconciles of each attester that returned an error",
+	}, []string{"namespace", "name"})
+
+	attesterPolicyCompileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "rode_attester_policy_compile_duration_seconds",
+		Help: "Duration of compiling each attester's policy when it's reconciled",
+		// large policies can take several seconds to compile
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"namespace", "name"})
+
+	attesterPolicyCompileCacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_attester_policy_compile_cache_hits_total",
+		Help: "Number of times each attester's policy was reused rather than compiled because it hadn't changed",
+	}, []string{"namespace", "name"})
+
+	attesterSecretCreationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_attester_secret_creation_failures_total",
+		Help: "Number of times creating the secret for each attester's key failed",
+	}, []string{"namespace", "name"})
+
+	registerAttesterMetrics sync.Once
+)
+
+// registerMetrics registers the attester reconciler's metrics with the controller-runtime registry that the manager
+// serves. They're only registered once however many reconcilers are set up.
+func registerMetrics() {
+	registerAttesterMetrics.Do(func() {
+		metrics.Registry.MustRegister(attesterReconciles, attesterReconcileErrors, attesterPolicyCompileDuration, attesterPolicyCompileCacheHits, attesterSecretCreationFailures)
+	})
+}
+
+// recordReconcile counts a reconcile of the attester and whether it returned an error
+func recordReconcile(name types.NamespacedName, err error) {
+	attesterReconciles.WithLabelValues(name.Namespace, name.Name).Inc()
+	if err != nil {
+		attesterReconcileErrors.WithLabelValues(name.Namespace, name.Name).Inc()
+	}
+}
+
+// recordPolicyCompile records how long compiling the attester's policy took
+func recordPolicyCompile(name types.NamespacedName, duration time.Duration) {
+	attesterPolicyCompileDuration.WithLabelValues(name.Namespace, name.Name).Observe(duration.Seconds())
+}
+
+// recordPolicyCompileCacheHit counts a reconcile of the attester that reused its compiled policy
+func recordPolicyCompileCacheHit(name types.NamespacedName) {
+	attesterPolicyCompileCacheHits.WithLabelValues(name.Namespace, name.Name).Inc()
+}
+
+// recordSecretCreationFailure counts a failure to create the secret for the attester's key
+func recordSecretCreationFailure(name types.NamespacedName) {
+	attesterSecretCreationFailures.WithLabelValues(name.Namespace, name.Name).Inc()
+}
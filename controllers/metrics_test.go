@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestAttesterReconciler_RecordsMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	att := newUnitTestAttester("metrics")
+	r := newUnitTestAttesterReconciler(att)
+	name := unitTestRequest(att).NamespacedName
+
+	reconcileUnitTestAttester(r, att, 4)
+	assert.Equal(float64(4), testutil.ToFloat64(attesterReconciles.WithLabelValues(name.Namespace, name.Name)))
+	assert.Equal(float64(0), testutil.ToFloat64(attesterReconcileErrors.WithLabelValues(name.Namespace, name.Name)))
+	assert.Equal(float64(0), testutil.ToFloat64(attesterSecretCreationFailures.WithLabelValues(name.Namespace, name.Name)))
+
+	compiles := &dto.Metric{}
+	assert.NoError(attesterPolicyCompileDuration.WithLabelValues(name.Namespace, name.Name).(prometheus.Metric).Write(compiles))
+	assert.NotZero(compiles.GetHistogram().GetSampleCount())
+
+	// failing to create the secret is counted
+	quota := newUnitTestAttester("metricsquota")
+	r = newUnitTestAttesterReconciler(quota)
+	r.Client = &quotaExceededClient{r.Client}
+	quotaName := unitTestRequest(quota).NamespacedName
+
+	reconcileUnitTestAttester(r, quota, 4)
+	assert.Equal(float64(4), testutil.ToFloat64(attesterReconciles.WithLabelValues(quotaName.Namespace, quotaName.Name)))
+	assert.NotZero(testutil.ToFloat64(attesterSecretCreationFailures.WithLabelValues(quotaName.Namespace, quotaName.Name)))
+
+	// reconciles that return an error are counted
+	forbidden := newUnitTestAttester("metricsforbidden")
+	forbidden.Spec.PgpSecret = "metricsforbidden"
+	r = newUnitTestAttesterReconciler(forbidden)
+	r.Client = &failingSecretsClient{Client: r.Client, err: errors.NewForbidden(corev1.Resource("secrets"), "metricsforbidden", nil), failures: 4}
+	forbiddenName := unitTestRequest(forbidden).NamespacedName
+
+	reconcileUnitTestAttester(r, forbidden, 4)
+	assert.Equal(float64(4), testutil.ToFloat64(attesterReconciles.WithLabelValues(forbiddenName.Namespace, forbiddenName.Name)))
+	assert.NotZero(testutil.ToFloat64(attesterReconcileErrors.WithLabelValues(forbiddenName.Namespace, forbiddenName.Name)))
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	// the reconciler can be set up more than once, such as in tests, without registering its metrics twice
+	assert.NotPanics(t, registerMetrics)
+	assert.NotPanics(t, registerMetrics)
+}
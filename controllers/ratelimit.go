@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// rateLimiter returns the rate limiter of the attester controller's workqueue. When RateLimitBaseDelay is set, each
+// attester that's requeued is backed off on its own, exponentially from RateLimitBaseDelay up to RateLimitMaxDelay, so
+// that an attester that keeps getting requeued doesn't starve the others. Otherwise it's controller-runtime's default.
+func (r *AttesterReconciler) rateLimiter() workqueue.RateLimiter {
+	if r.RateLimitBaseDelay <= 0 {
+		return workqueue.DefaultControllerRateLimiter()
+	}
+
+	return workqueue.NewItemExponentialFailureRateLimiter(r.RateLimitBaseDelay, r.RateLimitMaxDelay)
+}
+
+// setRateLimiter makes the controller's workqueue use the rate limiter. The controller.Options of the controller-runtime
+// version rode is built with have no RateLimiter, so the function that the controller makes its queue with when it's
+// started is replaced instead.
+func setRateLimiter(c controller.Controller, name string, limiter workqueue.RateLimiter) error {
+	v := reflect.ValueOf(c)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	var makeQueue func() workqueue.RateLimitingInterface
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("unable to set the rate limiter of controller %s", name)
+	}
+	field := v.FieldByName("MakeQueue")
+	if !field.IsValid() || !field.CanSet() || field.Type() != reflect.TypeOf(makeQueue) {
+		return fmt.Errorf("unable to set the rate limiter of controller %s", name)
+	}
+
+	makeQueue = func() workqueue.RateLimitingInterface {
+		return workqueue.NewNamedRateLimitingQueue(limiter, name)
+	}
+	field.Set(reflect.ValueOf(makeQueue))
+
+	return nil
+}
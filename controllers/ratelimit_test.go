@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestAttesterReconciler_RateLimiter(t *testing.T) {
+	assert := assert.New(t)
+	hot := types.NamespacedName{Namespace: "default", Name: "hot"}
+
+	// the default rate limiter is kept unless the base delay is set
+	r := &AttesterReconciler{}
+	assert.Equal(5*time.Millisecond, r.rateLimiter().When(hot))
+
+	r = &AttesterReconciler{RateLimitBaseDelay: 10 * time.Millisecond, RateLimitMaxDelay: 40 * time.Millisecond}
+	limiter := r.rateLimiter()
+	for _, delay := range []time.Duration{10, 20, 40, 40} {
+		assert.Equal(delay*time.Millisecond, limiter.When(hot))
+	}
+	assert.Equal(10*time.Millisecond, limiter.When(types.NamespacedName{Namespace: "default", Name: "other"}))
+}
+
+func TestSetRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	mgr, err := ctrl.NewManager(&rest.Config{Host: "http://127.0.0.1:1"}, ctrl.Options{
+		Scheme:             unitTestScheme(),
+		MetricsBindAddress: "0",
+		MapperProvider: func(*rest.Config) (meta.RESTMapper, error) {
+			return meta.NewDefaultRESTMapper(nil), nil
+		},
+		NewCache: func(*rest.Config, cache.Options) (cache.Cache, error) {
+			return &informertest.FakeInformers{Scheme: unitTestScheme()}, nil
+		},
+	})
+	assert.NoError(err)
+
+	// the hot object keeps getting requeued, as an attester whose reconcile keeps failing does
+	mutex := sync.Mutex{}
+	reconciles := make(map[string]int)
+	c, err := controller.New("throttled", mgr, controller.Options{
+		Reconciler: reconcile.Func(func(req ctrl.Request) (ctrl.Result, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			reconciles[req.Name]++
+			return ctrl.Result{Requeue: req.Name == "hot"}, nil
+		}),
+	})
+	assert.NoError(err)
+	events := make(chan event.GenericEvent, 2)
+	assert.NoError(c.Watch(&source.Channel{Source: events}, &handler.EnqueueRequestForObject{}))
+	assert.NoError(setRateLimiter(c, "throttled", workqueue.NewItemExponentialFailureRateLimiter(50*time.Millisecond, time.Second)))
+
+	stop := make(chan struct{})
+	stopped := make(chan error)
+	go func() {
+		stopped <- mgr.Start(stop)
+	}()
+
+	hot := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "hot"}}
+	events <- event.GenericEvent{Meta: hot, Object: hot}
+	time.Sleep(500 * time.Millisecond)
+
+	// the hot object is reconciled at 0, 50, 150 and 350ms rather than as fast as it's requeued, and doesn't hold up
+	// another object
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}}
+	events <- event.GenericEvent{Meta: other, Object: other}
+	assert.Eventually(func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return reconciles["other"] == 1
+	}, 100*time.Millisecond, 5*time.Millisecond)
+
+	mutex.Lock()
+	assert.True(reconciles["hot"] >= 2 && reconciles["hot"] <= 5, "hot object reconciled %d times", reconciles["hot"])
+	mutex.Unlock()
+
+	close(stop)
+	assert.NoError(<-stopped)
+
+	assert.Error(setRateLimiter(nil, "nil", workqueue.DefaultControllerRateLimiter()))
+}
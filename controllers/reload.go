@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+
+	"github.com/liatrio/rode/pkg/auth"
+)
+
+type reloadHandler struct {
+	log        logr.Logger
+	reconciler *AttesterReconciler
+	authorizer auth.Authorizer
+}
+
+// NewReloadHandler creates a handler that serves POST /attesters/reload, reloading the attesters with the reconciler and
+// returning a summary of them. Since every attester is reloaded, callers must be allowed to update Attester objects in
+// all namespaces.
+func NewReloadHandler(log logr.Logger, reconciler *AttesterReconciler, authorizer auth.Authorizer) http.Handler {
+	return &reloadHandler{
+		log,
+		reconciler,
+		authorizer,
+	}
+}
+
+func (h *reloadHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := h.authorizer.Authenticate(request)
+	if err == auth.ErrUnauthenticated {
+		http.Error(writer, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.log.Error(err, "Unable to authenticate request")
+		http.Error(writer, "unable to authenticate request", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.authorizer.Authorize(request.Context(), user, &authorizationv1.ResourceAttributes{
+		Verb:     "update",
+		Group:    "rode.liatr.io",
+		Resource: "attesters",
+	})
+	if _, ok := err.(auth.ForbiddenError); ok {
+		http.Error(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		h.log.Error(err, "Unable to authorize request", "user", user.Username)
+		http.Error(writer, "unable to authorize request", http.StatusInternalServerError)
+		return
+	}
+
+	summary, err := h.reconciler.Reload(request.Context())
+	if err != nil {
+		h.log.Error(err, "Unable to reload attesters", "user", user.Username)
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(writer).Encode(summary)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/liatrio/rode/pkg/auth"
+)
+
+// fakeAuthorizer authenticates the "admin" and "viewer" tokens, and allows only admin to update attesters in all
+// namespaces
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Authenticate(req *http.Request) (*authenticationv1.UserInfo, error) {
+	switch req.Header.Get("Authorization") {
+	case "Bearer admin":
+		return &authenticationv1.UserInfo{Username: "admin"}, nil
+	case "Bearer viewer":
+		return &authenticationv1.UserInfo{Username: "viewer"}, nil
+	}
+
+	return nil, auth.ErrUnauthenticated
+}
+
+func (fakeAuthorizer) Authorize(ctx context.Context, user *authenticationv1.UserInfo, resource *authorizationv1.ResourceAttributes) error {
+	if user.Username != "admin" || resource.Namespace != "" || resource.Verb != "update" || resource.Resource != "attesters" {
+		return auth.ForbiddenError{User: user.Username}
+	}
+
+	return nil
+}
+
+func TestReloadHandler(t *testing.T) {
+	att := newUnitTestAttester("reloaded")
+	r := newUnitTestAttesterReconciler(att)
+	handler := NewReloadHandler(logf.NullLogger{}, r, fakeAuthorizer{})
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		status int
+	}{
+		{name: "reloads for allowed users", method: http.MethodPost, token: "admin", status: http.StatusAccepted},
+		{name: "requires POST", method: http.MethodGet, token: "admin", status: http.StatusMethodNotAllowed},
+		{name: "requires a token", method: http.MethodPost, status: http.StatusUnauthorized},
+		{name: "rejects unknown tokens", method: http.MethodPost, token: "unknown", status: http.StatusUnauthorized},
+		{name: "forbids users who can't update attesters", method: http.MethodPost, token: "viewer", status: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			request := httptest.NewRequest(tc.method, "/attesters/reload", nil)
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			assert.Equal(tc.status, recorder.Code)
+
+			if tc.status == http.StatusAccepted {
+				summary := &ReloadSummary{}
+				assert.NoError(json.NewDecoder(recorder.Body).Decode(summary))
+				assert.Equal([]string{"default/reloaded"}, summary.Reloaded)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package controllers
 
 import (
+	"strconv"
+
+	"github.com/go-logr/logr"
 	"github.com/liatrio/rode/api/util"
 	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -31,14 +34,24 @@ func removeFinalizer(slice []string, str string) []string {
 	return result
 }
 
-func ignoreFinalizerUpdate() predicate.Predicate {
+func ignoreFinalizerUpdate(finalizerName string) predicate.Predicate {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			oldObjectMeta := e.MetaOld
 			newObjectMeta := e.MetaNew
 
 			// NO enqueue whenever a finalizer is added or removed
-			return !containsFinalizer(oldObjectMeta.GetFinalizers(), collectorFinalizerName) != containsFinalizer(newObjectMeta.GetFinalizers(), collectorFinalizerName)
+			return !containsFinalizer(oldObjectMeta.GetFinalizers(), finalizerName) != containsFinalizer(newObjectMeta.GetFinalizers(), finalizerName)
+		},
+	}
+}
+
+// ignoreResync drops the updates that the informer delivers for every object each sync period, which don't change the
+// object, so that objects are resynced on a schedule of their own
+func ignoreResync() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.MetaOld.GetResourceVersion() != e.MetaNew.GetResourceVersion()
 		},
 	}
 }
@@ -67,3 +80,43 @@ func ignoreConditionStatusUpdateToActive(cc conditionerConverter, ct rodev1alpha
 		},
 	}
 }
+
+// withLogLevel returns a logger that shows the attester's logs up to the level set by its log level annotation. The
+// logger is returned as it is if the attester isn't annotated, or the annotation isn't a verbosity.
+func withLogLevel(log logr.Logger, att *rodev1alpha1.Attester) logr.Logger {
+	value, ok := att.Annotations[LogLevelAnnotation]
+	if !ok {
+		return log
+	}
+
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 0 {
+		log.Info("Ignoring invalid log level annotation", "annotation", LogLevelAnnotation, "value", value)
+		return log
+	}
+
+	return verboseLogger{log, level}
+}
+
+// verboseLogger logs the lines of a logger at up to level as if they were logged at level 0, so that they're shown
+// whatever verbosity the logger was configured with. Lines at higher levels are logged as they would be by the logger.
+type verboseLogger struct {
+	logr.Logger
+	level int
+}
+
+func (l verboseLogger) V(level int) logr.InfoLogger {
+	if level <= l.level {
+		return l.Logger
+	}
+
+	return l.Logger.V(level)
+}
+
+func (l verboseLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return verboseLogger{l.Logger.WithValues(keysAndValues...), l.level}
+}
+
+func (l verboseLogger) WithName(name string) logr.Logger {
+	return verboseLogger{l.Logger.WithName(name), l.level}
+}
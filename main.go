@@ -17,25 +17,31 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
+	"encoding/json"
 	"flag"
-	"io/ioutil"
+	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
-	"github.com/go-logr/logr"
-
 	"github.com/liatrio/rode/pkg/enforcer"
 
 	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 	"github.com/liatrio/rode/controllers"
 	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/audit"
+	"github.com/liatrio/rode/pkg/auth"
 	"github.com/liatrio/rode/pkg/aws"
 	"github.com/liatrio/rode/pkg/occurrence"
+	"github.com/liatrio/rode/pkg/registry"
+	"github.com/liatrio/rode/pkg/tracing"
+	"github.com/liatrio/rode/pkg/verification"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -46,6 +52,9 @@ import (
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
+
+	// version is set at build time with -ldflags "-X main.version=..."
+	version = "unknown"
 )
 
 func init() {
@@ -60,17 +69,110 @@ func main() {
 	var healthAddr string
 	var certDir string
 	var enableLeaderElection bool
+	var policyTimings bool
+	var policyPartialEval bool
+	var policyEvalCacheTTL time.Duration
+	var imageAllowlist string
+	var policyEvalTimeout time.Duration
+	var policyRepositoryDir string
+	var policyEvalBudget time.Duration
+	var policyEvalBudgetWindow time.Duration
+	var attestationStatusInterval time.Duration
+	var fips bool
+	var auditLogPath string
+	var auditLogMaxSize int64
+	var auditLogMaxBackups int
+	var resolveImageDigests bool
+	var attestQueueDir string
+	var attestQueueWorkers int
+	var attestationSink string
+	var minRSAKeyBits int
+	var minECDSAKeyBits int
+	var attesterFinalizerName string
+	var attesterNoFinalizer bool
+	var failOnDeletedSecret bool
+	var clusterAttesterSecretNamespace string
+	var publicKeysConfigMap string
+	var publicKeysConfigMapNamespace string
+	var vaultAddress string
+	var vaultMount string
+	var maxConcurrentReconciles int
+	var attesterResyncPeriod time.Duration
+	var attesterResyncJitter float64
+	var attesterRateLimitBaseDelay time.Duration
+	var attesterRateLimitMaxDelay time.Duration
+	var occurrenceNotifications bool
+	var verificationAddr string
+	var shutdownDrainTimeout time.Duration
+	var grafeasEndpoint string
+	var traceExporter string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":9090", "The address the metric endpoint binds to.")
 	flag.StringVar(&healthAddr, "health-addr", ":4000", "The address the health endpoint binds to.")
 	flag.StringVar(&certDir, "cert-dir", "/certificates", "The path to tls certificates.")
+	flag.StringVar(&grafeasEndpoint, "grafeas-endpoint", os.Getenv("GRAFEAS_ENDPOINT"), "The Grafeas server that occurrences are read from and attestations stored in. Defaults to the GRAFEAS_ENDPOINT environment variable.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&policyTimings, "policy-timings", false, "Record the duration of policy parsing, compilation and evaluation in the Attester status.")
+	flag.BoolVar(&policyPartialEval, "policy-partial-eval", false, "Partially evaluate Attester policies once so that only the occurrences are evaluated for each resource.")
+	flag.StringVar(&auditLogPath, "audit-log-path", "", "The file to write an audit log of attestation decisions to. Disabled when empty.")
+	flag.Int64Var(&auditLogMaxSize, "audit-log-max-size", 100*1024*1024, "The size in bytes at which the audit log is rotated.")
+	flag.IntVar(&auditLogMaxBackups, "audit-log-max-backups", 5, "The number of rotated audit logs to keep.")
+	flag.BoolVar(&resolveImageDigests, "resolve-image-digests", false, "Resolve pod images to their current digest in the registry and only accept attestations for that digest.")
+	flag.StringVar(&attestQueueDir, "attest-queue-dir", "", "Attest resources asynchronously, persisting queued resources in this directory. Attestation is synchronous when empty.")
+	flag.IntVar(&attestQueueWorkers, "attest-queue-workers", 2, "The number of workers attesting queued resources.")
+	flag.BoolVar(&occurrenceNotifications, "occurrence-notifications", false, fmt.Sprintf("Attest the resources of occurrences stored in Grafeas when they're posted to %s.", attester.OccurrenceNotificationPath))
+	flag.StringVar(&attestationSink, "attestation-sink", attester.DefaultAttestationSink, fmt.Sprintf("Where attestations are stored, one of %v.", attester.AttestationSinks()))
+	flag.StringVar(&imageAllowlist, "image-allowlist", "", fmt.Sprintf("A file of image digests, one on each line, that policies can check images against with %s(image). The function isn't available when empty.", attester.ImageAllowedBuiltin))
+	flag.DurationVar(&policyEvalCacheTTL, "policy-eval-cache-ttl", 0, "Cache the result of evaluating a policy with the same occurrences for this long. Disabled when 0.")
+	flag.StringVar(&policyRepositoryDir, "policy-repository-dir", filepath.Join(os.TempDir(), "rode-policy-repositories"), "The directory that the Git repositories of attesters' policies are fetched into.")
+	flag.DurationVar(&policyEvalTimeout, "policy-eval-timeout", 5*time.Second, "Cancel policy evaluations that take longer than this, rejecting the resource. Evaluations aren't limited when negative.")
+	flag.DurationVar(&policyEvalBudget, "policy-eval-budget", 0, "The default time each attester can spend evaluating its policy in a budget window before it's throttled. Attesters without a budget of their own aren't limited when 0.")
+	flag.DurationVar(&policyEvalBudgetWindow, "policy-eval-budget-window", time.Minute, "The window that policy evaluation budgets apply to.")
+	flag.DurationVar(&attestationStatusInterval, "attestation-status-interval", 10*time.Second, "How often the attestation counts in an attester's status are updated while it's attesting.")
+	flag.IntVar(&minRSAKeyBits, "min-rsa-key-bits", attester.DefaultKeyStrengthPolicy.MinRSABits, "The smallest RSA key an attester's existing secret can contain.")
+	flag.IntVar(&minECDSAKeyBits, "min-ecdsa-key-bits", attester.DefaultKeyStrengthPolicy.MinECDSABits, "The smallest ECDSA curve an attester's existing secret can contain a key for.")
+	flag.StringVar(&attesterFinalizerName, "attester-finalizer-name", "attester.finalizers.rode.liatr.io", "The finalizer added to attesters. Installations sharing a cluster must use distinct names.")
+	flag.BoolVar(&attesterNoFinalizer, "attester-no-finalizer", false, "Don't add a finalizer to attesters, leaving their secrets to be garbage collected, so that attesters can be deleted while the controller is down.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1, "The number of attesters reconciled at once, including when they're loaded at startup.")
+	flag.DurationVar(&attesterResyncPeriod, "attester-resync-period", 0, "Reconcile each attester this often on a schedule of its own, rather than all at once when the informer resyncs. Disabled when 0.")
+	flag.Float64Var(&attesterResyncJitter, "attester-resync-jitter", 0.5, "Spread attester resyncs by delaying each by up to this fraction of the resync period.")
+	flag.DurationVar(&attesterRateLimitBaseDelay, "attester-rate-limit-base-delay", 5*time.Millisecond, "The delay before an attester that's requeued is reconciled again, doubling each time it's requeued in a row.")
+	flag.DurationVar(&attesterRateLimitMaxDelay, "attester-rate-limit-max-delay", 1000*time.Second, "The longest delay before an attester that's requeued is reconciled again.")
+	flag.BoolVar(&failOnDeletedSecret, "fail-on-deleted-secret", false, "Stop loading an attester whose secret is deleted after its key was loaded, rather than generating a new key.")
+	flag.StringVar(&clusterAttesterSecretNamespace, "cluster-attester-secret-namespace", os.Getenv("POD_NAMESPACE"), "The namespace that the secrets of ClusterAttesters are stored in. Defaults to the POD_NAMESPACE environment variable, or rode when it isn't set.")
+	flag.StringVar(&vaultAddress, "vault-address", os.Getenv("VAULT_ADDR"), "The Vault server that the keys of attesters with a vaultPath are stored in, authenticating with the VAULT_TOKEN environment variable. Defaults to the VAULT_ADDR environment variable.")
+	flag.StringVar(&vaultMount, "vault-mount", "secret", "The mount of the KV version 2 secrets engine that attester keys are stored in.")
+	flag.StringVar(&publicKeysConfigMap, "public-keys-configmap", "", "The ConfigMap that the public key of every attester is published in, for verifiers without access to the attesters. Disabled when empty.")
+	flag.StringVar(&publicKeysConfigMapNamespace, "public-keys-configmap-namespace", os.Getenv("POD_NAMESPACE"), "The namespace of the public keys ConfigMap. Defaults to the POD_NAMESPACE environment variable, or rode when it isn't set.")
+	flag.StringVar(&verificationAddr, "verification-addr", "", "The address the verification server, which attests resources posted to it, binds to. Disabled when empty.")
+	flag.DurationVar(&shutdownDrainTimeout, "shutdown-drain-timeout", 30*time.Second, "How long to wait for in-flight requests, and the attestations they're signing, to complete when shutting down.")
+	flag.StringVar(&traceExporter, "trace-exporter", "", fmt.Sprintf("Trace attester reconciles, and the policy compiles and key generation within them, with this exporter, one of %v. Disabled when empty.", tracing.Exporters()))
+	flag.BoolVar(&fips, "fips", false, "Only use FIPS-validated crypto. Requires a binary built with BoringCrypto.")
 	flag.Parse()
 
+	if clusterAttesterSecretNamespace == "" {
+		clusterAttesterSecretNamespace = "rode"
+	}
+	if publicKeysConfigMapNamespace == "" {
+		publicKeysConfigMapNamespace = "rode"
+	}
+
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
 		o.Development = true
 	}))
 
+	if err := attester.SetFIPSMode(fips); err != nil {
+		setupLog.Error(err, "unable to enable FIPS mode")
+		os.Exit(1)
+	}
+	setupLog.Info("starting rode", "version", version, "fips", attester.FIPSMode())
+
+	builtins, err := policyBuiltins(imageAllowlist)
+	if err != nil {
+		setupLog.Error(err, "unable to load the image allowlist", "file", imageAllowlist)
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -78,6 +180,7 @@ func main() {
 		CertDir:                certDir,
 		LeaderElection:         enableLeaderElection,
 		Port:                   9443,
+		NewClient:              controllers.NewClient,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -85,30 +188,103 @@ func main() {
 	}
 
 	attesters := &controllers.AttesterReconciler{
-		Client:    mgr.GetClient(),
-		Log:       ctrl.Log.WithName("controllers").WithName("Attester"),
-		Scheme:    mgr.GetScheme(),
-		Attesters: make(map[string]attester.Attester),
+		Client:            mgr.GetClient(),
+		Log:               ctrl.Log.WithName("controllers").WithName("Attester"),
+		Scheme:            mgr.GetScheme(),
+		Attesters:         make(map[string]attester.Attester),
+		PolicyTimings:     policyTimings,
+		PolicyPartialEval: policyPartialEval,
+		PolicyEvalTimeout: policyEvalTimeout,
+		EvalCache:         newEvalCache(policyEvalCacheTTL),
+		Builtins:          builtins,
+		EvalBudget:        newEvalBudget(policyEvalBudget, policyEvalBudgetWindow),
+		Subjects:          attester.NewSubjectTracker(),
+		Violations:        attester.NewViolationTracker(),
+		Attestations:      attester.NewAttestationCounter(attestationStatusInterval),
+		Recorder:          mgr.GetEventRecorderFor("attester-controller"),
+		KeyStrength: &attester.KeyStrengthPolicy{
+			MinRSABits:   minRSAKeyBits,
+			MinECDSABits: minECDSAKeyBits,
+		},
+		FinalizerName:           attesterFinalizerName,
+		NoFinalizer:             attesterNoFinalizer,
+		FailOnDeletedSecret:     failOnDeletedSecret,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		ResyncPeriod:            attesterResyncPeriod,
+		ResyncJitter:            attesterResyncJitter,
+		RateLimitBaseDelay:      attesterRateLimitBaseDelay,
+		RateLimitMaxDelay:       attesterRateLimitMaxDelay,
+		PublicKeysConfigMap:     types.NamespacedName{Namespace: publicKeysConfigMapNamespace, Name: publicKeysConfigMap},
+	}
+	if vaultAddress != "" {
+		attesters.VaultStore = attester.NewVaultStore(vaultAddress, os.Getenv("VAULT_TOKEN"), vaultMount, &http.Client{Timeout: 10 * time.Second})
+	}
+	if traceExporter != "" {
+		attesters.TracerProvider, err = tracing.NewExporter(traceExporter, ctrl.Log.WithName("tracing"))
+		if err != nil {
+			setupLog.Error(err, "unable to create trace exporter")
+			os.Exit(1)
+		}
 	}
 	if err = attesters.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Attester")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.ClusterAttesterReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("ClusterAttester"),
+		Scheme:          mgr.GetScheme(),
+		Attesters:       attesters,
+		SecretNamespace: clusterAttesterSecretNamespace,
+		Recorder:        mgr.GetEventRecorderFor("clusterattester-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterAttester")
+		os.Exit(1)
+	}
+
 	awsConfig := aws.NewAWSConfig(ctrl.Log.WithName("aws").WithName("AWSConfig"))
 
-	grafeasTLSConfig, err := grafeasTLSConfig(setupLog)
+	grafeasTLSConfig, err := occurrence.NewGrafeasTLSConfig(setupLog)
 	if err != nil {
 		setupLog.Error(err, "error creating grafeas TLS config")
 		os.Exit(1)
 	}
-	grafeasClient, err := occurrence.NewGrafeasClient(ctrl.Log.WithName("occurrence").WithName("GrafeasClient"), grafeasTLSConfig, os.Getenv("GRAFEAS_ENDPOINT"))
+	grafeasClient, err := occurrence.NewGrafeasClient(ctrl.Log.WithName("occurrence").WithName("GrafeasClient"), grafeasTLSConfig, grafeasEndpoint)
 	if err != nil {
 		setupLog.Error(err, "error initializing grafeas client")
 		os.Exit(1)
 	}
+	attesters.Notes = grafeasClient
+	attesters.PolicyRepositories = attester.NewPolicyRepositories(policyRepositoryDir)
+
+	var decisionLogger attester.DecisionLogger
+	var auditLog *audit.FileLog
+	if auditLogPath != "" {
+		auditLog, err = audit.NewFileLog(auditLogPath, auditLogMaxSize, auditLogMaxBackups)
+		if err != nil {
+			setupLog.Error(err, "unable to open audit log", "path", auditLogPath)
+			os.Exit(1)
+		}
+		decisionLogger = auditLog
+	}
 
-	occurrenceCreator := attester.NewAttestWrapper(ctrl.Log.WithName("attester").WithName("AttestWrapper"), grafeasClient, grafeasClient, attesters)
+	var attestQueue *attester.AttestQueue
+	if attestQueueDir != "" {
+		attestQueue, err = attester.NewAttestQueue(ctrl.Log.WithName("attester").WithName("AttestQueue"), attestQueueDir)
+		if err != nil {
+			setupLog.Error(err, "unable to create attestation queue", "dir", attestQueueDir)
+			os.Exit(1)
+		}
+	}
+
+	sink, err := attester.NewAttestationSink(attestationSink, grafeasClient)
+	if err != nil {
+		setupLog.Error(err, "unable to create attestation sink")
+		os.Exit(1)
+	}
+
+	occurrenceCreator := attester.NewAttestWrapper(ctrl.Log.WithName("attester").WithName("AttestWrapper"), grafeasClient, grafeasClient, attesters, attesters.Subjects, attesters.Violations, decisionLogger, attestQueue, sink)
 
 	handlers := make(map[string]func(writer http.ResponseWriter, request *http.Request, occurrenceCreator occurrence.Creator))
 	webhookMux := http.NewServeMux()
@@ -121,9 +297,57 @@ func main() {
 			writer.WriteHeader(http.StatusNotFound)
 		}
 	})
+	webhookMux.HandleFunc("/version", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(map[string]interface{}{
+			"version": version,
+			"fips":    attester.FIPSMode(),
+		})
+	})
+	webhookMux.HandleFunc("/attestations/status", func(writer http.ResponseWriter, request *http.Request) {
+		if attestQueue == nil {
+			http.Error(writer, "asynchronous attestation is not enabled", http.StatusNotFound)
+			return
+		}
+
+		status, ok := attestQueue.Status(request.URL.Query().Get("resource"))
+		if !ok {
+			http.Error(writer, "no attestation queued for resource", http.StatusNotFound)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(status)
+	})
+	webhookMux.Handle("/attesters/reload", controllers.NewReloadHandler(
+		ctrl.Log.WithName("controllers").WithName("ReloadHandler"), attesters, auth.NewKubernetesAuthorizer(mgr.GetClient())))
+	webhookMux.Handle(attester.AttestationPathPrefix, attester.NewAttestationHandler(
+		ctrl.Log.WithName("attester").WithName("AttestationHandler"), grafeasClient, attesters, auth.NewKubernetesAuthorizer(mgr.GetClient())))
+	if occurrenceNotifications {
+		webhookMux.Handle(attester.OccurrenceNotificationPath, attester.NewOccurrenceNotificationHandler(
+			ctrl.Log.WithName("attester").WithName("OccurrenceNotificationHandler"), occurrenceCreator))
+	}
+	// requests in flight are drained when the manager is stopped, before the servers are shut down
+	drainer := verification.NewDrainer(ctrl.Log.WithName("verification").WithName("Drainer"), shutdownDrainTimeout)
+	if err := mgr.Add(drainer); err != nil {
+		setupLog.Error(err, "unable to add the request drainer")
+		os.Exit(1)
+	}
+
 	webhookServer := http.Server{
 		Addr:    ":8080",
-		Handler: webhookMux,
+		Handler: drainer.Handler(webhookMux),
+	}
+
+	var verificationServer *http.Server
+	if verificationAddr != "" {
+		verificationMux := http.NewServeMux()
+		verificationMux.Handle(verification.AttestPath, verification.NewHandler(
+			ctrl.Log.WithName("verification").WithName("Handler"), attesters, auth.NewKubernetesAuthorizer(mgr.GetClient())))
+		verificationServer = &http.Server{
+			Addr:    verificationAddr,
+			Handler: drainer.Handler(verificationMux),
+		}
 	}
 
 	if err = (&controllers.CollectorReconciler{
@@ -140,15 +364,22 @@ func main() {
 	}
 	// +kubebuilder:scaffold:builder
 
-	enforcer := enforcer.NewEnforcer(ctrl.Log.WithName("enforcer"), attesters, grafeasClient, mgr.GetClient())
-
-	checker := func(req *http.Request) error {
-		return nil
+	var digestResolver registry.Resolver
+	if resolveImageDigests {
+		digestResolver = registry.NewResolver(&http.Client{Timeout: 10 * time.Second})
 	}
 
-	_ = mgr.AddHealthzCheck("test", checker)
-	_ = mgr.AddReadyzCheck("test", checker)
+	enforcer := enforcer.NewEnforcer(ctrl.Log.WithName("enforcer"), attesters, grafeasClient, mgr.GetClient(), digestResolver)
+
+	// the manager is live as long as it's serving probes, and ready once its attesters are loaded, which the attester
+	// reconciler registers as a readyz check of its own
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to add healthz check")
+		os.Exit(1)
+	}
 	mgr.GetWebhookServer().Register("/validate-v1-pod", &webhook.Admission{Handler: enforcer})
+	attester.SetupDefaulterWithManager(mgr, ctrl.Log.WithName("attester").WithName("Defaulter"))
+	attester.SetupValidatorWithManager(mgr, ctrl.Log.WithName("attester").WithName("Validator"), builtins...)
 
 	go func() {
 		if err := webhookServer.ListenAndServe(); err != nil {
@@ -157,9 +388,22 @@ func main() {
 		}
 	}()
 
+	if verificationServer != nil {
+		go func() {
+			if err := verificationServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				setupLog.Error(err, "error starting verification server")
+				os.Exit(1)
+			}
+		}()
+	}
+
 	signalHandler := ctrl.SetupSignalHandler()
 	controllerSignalHandler := make(chan struct{})
 
+	if attestQueue != nil {
+		attestQueue.Start(attestQueueWorkers, controllerSignalHandler)
+	}
+
 	setupLog.Info("starting manager")
 	go func() {
 		if err := mgr.Start(controllerSignalHandler); err != nil {
@@ -170,35 +414,66 @@ func main() {
 
 	<-signalHandler
 	close(controllerSignalHandler)
+	// the drainer isn't started if the manager is stopped before its caches have synced
+	select {
+	case <-drainer.Drained():
+	case <-time.After(shutdownDrainTimeout):
+	}
 	ctrl.Log.Info("shutting down webhook server")
 	err = webhookServer.Shutdown(context.Background())
 	if err != nil {
 		ctrl.Log.Error(err, "error shutting down webhook server")
 	}
+
+	if verificationServer != nil {
+		ctrl.Log.Info("shutting down verification server")
+		if err := verificationServer.Shutdown(context.Background()); err != nil {
+			ctrl.Log.Error(err, "error shutting down verification server")
+		}
+	}
+
+	if auditLog != nil {
+		if err := auditLog.Close(); err != nil {
+			ctrl.Log.Error(err, "error closing audit log")
+		}
+	}
 }
 
-func grafeasTLSConfig(log logr.Logger) (*tls.Config, error) {
-	clientCert, err := tls.LoadX509KeyPair(os.Getenv("TLS_CLIENT_CERT"), os.Getenv("TLS_CLIENT_KEY"))
-	if err != nil {
-		log.Error(err, "Unable to load client cert")
-		return nil, err
+// newEvalBudget returns a policy evaluation budget for the window, or nil if the window is 0
+func newEvalBudget(budget, window time.Duration) *attester.EvalBudget {
+	if window <= 0 {
+		return nil
 	}
 
-	cf, err := ioutil.ReadFile(os.Getenv("TLS_CA_CERT"))
+	return attester.NewEvalBudget(budget, window)
+}
+
+// newEvalCache returns a policy evaluation cache with the TTL, or nil if the TTL is 0
+func newEvalCache(ttl time.Duration) *attester.EvalCache {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return attester.NewEvalCache(ttl)
+}
+
+// policyBuiltins returns the custom builtins that policies can call, which include rode.image_allowed when an image
+// allowlist file is given
+func policyBuiltins(imageAllowlist string) ([]attester.Builtin, error) {
+	if imageAllowlist == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(imageAllowlist)
 	if err != nil {
-		log.Error(err, "Unable to load CA cert")
 		return nil, err
 	}
+	defer file.Close()
 
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(cf)
-
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{clientCert},
-		RootCAs:      caCertPool,
-		//InsecureSkipVerify: true,
+	allowlist, err := attester.ReadImageAllowlist(file)
+	if err != nil {
+		return nil, err
 	}
-	tlsConfig.BuildNameToCertificate()
 
-	return tlsConfig, nil
+	return []attester.Builtin{allowlist.Builtin()}, nil
 }
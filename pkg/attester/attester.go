@@ -6,33 +6,140 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/jsonpb"
 
 	attestation "github.com/grafeas/grafeas/proto/v1beta1/attestation_go_proto"
 	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+const (
+	// stageSeparator separates the resource URI from the stage in the signed body of a staged attestation
+	stageSeparator = "\nstage="
+
+	// resultSeparator separates the policy's result from the rest of the signed body of an attestation
+	resultSeparator = "\nresult="
 )
 
 type attester struct {
-	projectID string
+	notes     Notes
 	name      string
 	policy    Policy
-	signer    Signer
+	signers   SignerProvider
+	stage     string
+	noteKinds map[string]bool
+	format    rodev1alpha1.SignatureFormat
+	budget    *EvalBudget
+	counter   *AttestationCounter
+	migration *PolicyMigration
+	transform InputTransform
+
+	// payloadFormat is the format occurrences are sent to the attester in
+	payloadFormat rodev1alpha1.PayloadFormat
+
+	requireSignedInput bool
+	inputSigners       []Verifier
+
+	// previousVerifiers verify attestations signed before the attester's key was rotated
+	previousVerifiers []Verifier
+
+	// coSigners are the keys of the other signers whose attestations count toward requiredSignatures
+	coSigners          []Verifier
+	requiredSignatures int
+}
+
+// AttesterOption configures optional behavior of an attester
+type AttesterOption func(*attester)
+
+// WithStage sets the stage, such as dev or prod, that is embedded in the attester's attestations by default
+func WithStage(stage string) AttesterOption {
+	return func(a *attester) {
+		a.stage = stage
+	}
+}
+
+// WithNoteKinds stores attestations triggered by occurrences of the kinds under a note per kind, rather than under the
+// attester's default note
+func WithNoteKinds(kinds []string) AttesterOption {
+	return func(a *attester) {
+		a.noteKinds = make(map[string]bool)
+		for _, kind := range kinds {
+			a.noteKinds[kind] = true
+		}
+	}
+}
+
+// WithNotes stores the attester's attestations under the notes, rather than under notes named after the attester
+func WithNotes(notes Notes) AttesterOption {
+	return func(a *attester) {
+		a.notes = notes
+	}
 }
 
-// NewAttester creates a new attester
-func NewAttester(name string, policy Policy, signer Signer) Attester {
-	return &attester{
-		"rode",
-		name,
-		policy,
-		signer,
+// notesAttester is implemented by attesters that expose the notes they store attestations under
+type notesAttester interface {
+	notesOf() Notes
+}
+
+func (a *attester) notesOf() Notes {
+	return a.notes
+}
+
+// NotesOf returns the notes that the attester stores its attestations under
+func NotesOf(att Attester) Notes {
+	if na, ok := att.(notesAttester); ok {
+		return na.notesOf()
+	}
+
+	return DefaultNotes(att.String())
+}
+
+// WithSignatureFormat signs the attester's attestations in the format, rather than as PGP signed messages
+func WithSignatureFormat(format rodev1alpha1.SignatureFormat) AttesterOption {
+	return func(a *attester) {
+		a.format = format
 	}
 }
 
+// WithEvalBudget limits the time the attester spends evaluating its policy to its share of the budget
+func WithEvalBudget(budget *EvalBudget) AttesterOption {
+	return func(a *attester) {
+		a.budget = budget
+	}
+}
+
+// WithAttestationCounter counts the attestations the attester signs and rejects
+func WithAttestationCounter(counter *AttestationCounter) AttesterOption {
+	return func(a *attester) {
+		a.counter = counter
+	}
+}
+
+// NewAttester creates a new attester that signs with the signer, unless a signer provider is set with
+// WithSignerProvider
+func NewAttester(name string, policy Policy, signer Signer, opts ...AttesterOption) Attester {
+	a := &attester{
+		notes:   DefaultNotes(name),
+		name:    name,
+		policy:  policy,
+		signers: staticSigner{signer},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
 // Attester for performing attestation.  returns `ok` if attestation created
 type Attester interface {
 	Attest(ctx context.Context, req *AttestRequest) (*AttestResponse, error)
+	Evaluate(ctx context.Context, req *AttestRequest) ([]*Violation, error)
+	DryRun(ctx context.Context, req *AttestRequest) (*Decision, error)
 	Verify(ctx context.Context, req *VerifyRequest) error
 	String() string
 }
@@ -41,16 +148,36 @@ type Attester interface {
 type AttestRequest struct {
 	ResourceURI string
 	Occurrences []*grafeas.Occurrence
+
+	// Stage overrides the attester's stage for this attestation
+	Stage string
+
+	// Kind is the kind of occurrence that triggered the attestation, which selects the note the attestation is stored under
+	Kind string
 }
 
 // AttestResponse contains response from attester
 type AttestResponse struct {
 	Attestation *grafeas.Occurrence
+
+	// RejectedInputs are the names of the occurrences left out of the evaluation because they weren't signed by a
+	// trusted input signer
+	RejectedInputs []string
+
+	// Migration reports the evaluation of each policy when the attester is migrating between policies
+	Migration *MigrationOutcome
 }
 
 // ViolationError is a slice of Violations
 type ViolationError struct {
 	Violations []*Violation
+
+	// RejectedInputs are the names of the occurrences left out of the evaluation because they weren't signed by a
+	// trusted input signer
+	RejectedInputs []string
+
+	// Migration reports the evaluation of each policy when the attester is migrating between policies
+	Migration *MigrationOutcome
 }
 
 func (ve ViolationError) Error() string {
@@ -61,31 +188,49 @@ func (a *attester) String() string {
 	return a.name
 }
 
+// Timings returns the timings recorded by the attester's policy
+func (a *attester) Timings() PolicyTimings {
+	return a.policy.Timings()
+}
+
 // Attest takes a list of Occurrences and uses the Attester's policy to determine how many violations have occurred,
 // if there are no violations then the function will then create an Attestation Occurrence, sign it, and then return it.
+// The result of policies that define one is embedded in the signed body of the attestation.
 func (a *attester) Attest(ctx context.Context, req *AttestRequest) (*AttestResponse, error) {
-	// prepare the input
-	input := new(occurrenceInput)
-	for _, o := range req.Occurrences {
-		err := input.addOccurrence(o)
-		if err != nil {
-			return nil, err
+	evaluation, rejected, err := a.evaluate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(evaluation.Violations) > 0 {
+		if a.counter != nil {
+			a.counter.Rejected(a.name)
 		}
+		return nil, ViolationError{evaluation.Violations, rejected, evaluation.Migration}
 	}
 
-	violations := a.policy.Evaluate(ctx, input)
+	stage := a.stage
+	if req.Stage != "" {
+		stage = req.Stage
+	}
 
-	if len(violations) > 0 {
-		return nil, ViolationError{violations}
+	body, err := attestationBody(req.ResourceURI, stage, evaluation.Result)
+	if err != nil {
+		return nil, err
 	}
 
-	sig, err := a.signer.Sign(req.ResourceURI)
+	// the signer is only read once so that the signature and key ID match if the signer is rotated concurrently
+	signer := a.signers.Signer()
+	sig, err := signAttestationBody(signer, a.format, body)
 	if err != nil {
 		return nil, fmt.Errorf("Error signing resourceURI %v", err)
 	}
 
 	attestOccurrence := &grafeas.Occurrence{}
-	attestOccurrence.NoteName = fmt.Sprintf("projects/%s/notes/%s", a.projectID, strings.ReplaceAll(a.name, "/", "."))
+	attestOccurrence.NoteName = a.notes.NoteName("")
+	if a.noteKinds[req.Kind] {
+		attestOccurrence.NoteName = a.notes.NoteName(req.Kind)
+	}
 	attestOccurrence.Resource = &grafeas.Resource{Uri: req.ResourceURI}
 	attestOccurrence.Details = &grafeas.Occurrence_Attestation{
 		Attestation: &attestation.Details{
@@ -95,7 +240,7 @@ func (a *attester) Attest(ctx context.Context, req *AttestRequest) (*AttestRespo
 						ContentType: attestation.PgpSignedAttestation_CONTENT_TYPE_UNSPECIFIED,
 						Signature:   sig,
 						KeyId: &attestation.PgpSignedAttestation_PgpKeyId{
-							PgpKeyId: a.signer.KeyID(),
+							PgpKeyId: signer.KeyID(),
 						},
 					},
 				},
@@ -103,38 +248,261 @@ func (a *attester) Attest(ctx context.Context, req *AttestRequest) (*AttestRespo
 		},
 	}
 
+	if a.counter != nil {
+		a.counter.Signed(a.name)
+	}
+
 	return &AttestResponse{
-		Attestation: attestOccurrence,
+		Attestation:    attestOccurrence,
+		RejectedInputs: rejected,
+		Migration:      evaluation.Migration,
 	}, nil
 }
 
+// Evaluate returns the violations of the Attester's policy by the request's occurrences, without signing an attestation
+func (a *attester) Evaluate(ctx context.Context, req *AttestRequest) ([]*Violation, error) {
+	evaluation, _, err := a.evaluate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluation.Violations, nil
+}
+
+// DryRun evaluates the request's occurrences with the Attester's policy and returns the decision that attesting them
+// would make, with the messages of any violations, but doesn't sign an attestation. An error is returned if the request
+// can't be evaluated, such as when it contains an occurrence that's missing.
+func (a *attester) DryRun(ctx context.Context, req *AttestRequest) (*Decision, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request is empty")
+	}
+	for i, o := range req.Occurrences {
+		if o == nil {
+			return nil, fmt.Errorf("occurrence %d is empty", i)
+		}
+	}
+
+	evaluation, rejected, err := a.evaluate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(evaluation.Violations) > 0 {
+		return newDecision(a.name, req.ResourceURI, nil, ViolationError{evaluation.Violations, rejected, evaluation.Migration}), nil
+	}
+
+	return newDecision(a.name, req.ResourceURI, &AttestResponse{RejectedInputs: rejected, Migration: evaluation.Migration}, nil), nil
+}
+
+// evaluate evaluates the request's occurrences with the policy, returning the evaluation and the names of any
+// occurrences that were rejected because they weren't signed by a trusted input signer
+func (a *attester) evaluate(ctx context.Context, req *AttestRequest) (*Evaluation, []string, error) {
+	if a.budget != nil {
+		if err := a.budget.Allow(a.name); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	occurrences := req.Occurrences
+	var rejected []string
+	if a.requireSignedInput {
+		occurrences, rejected = filterSignedInput(occurrences, a.inputSigners)
+	}
+
+	// prepare the input
+	input := newOccurrenceInput()
+	for _, o := range occurrences {
+		err := input.addOccurrence(ctx, o, a.transform)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	start := time.Now()
+	evaluation := a.policy.EvaluateResult(ctx, input)
+	if a.migration != nil {
+		evaluation = a.migration.evaluate(ctx, input, evaluation, start)
+	}
+
+	if a.budget != nil {
+		a.budget.Record(a.name, time.Since(start))
+	}
+
+	// an evaluation cut short by the caller giving up isn't a decision, so it's neither signed nor reported as violations
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return evaluation, rejected, nil
+}
+
 // VerifyRequest contains request for attester
 type VerifyRequest struct {
 	Occurrence *grafeas.Occurrence
+
+	// Stage is the stage the attestation must have been made for. Attestations for any stage are accepted when empty.
+	Stage string
+
+	// Occurrences are the other occurrences of the resource, which are searched for the attestations of co-signers when
+	// the attester requires more than one signature
+	Occurrences []*grafeas.Occurrence
 }
 
+// Verify checks that the occurrence is an attestation signed by any of the attester's keys, including the keys it
+// signed with before its key was rotated. The error from verifying with the key it signs with is returned otherwise.
+// When the attester requires more than one signature, enough of its co-signers must also have attested the same body
+// in the request's occurrences.
 func (a *attester) Verify(ctx context.Context, req *VerifyRequest) error {
-	if req.Occurrence == nil || req.Occurrence.GetAttestation() == nil {
-		return fmt.Errorf("Occurrence is not an attestation")
+	verifiers := signerVerifiers(a.signers.Signer())
+	body, err := verifyAttestation(verifiers[0], req.Occurrence, req.Stage)
+	if err != nil {
+		for _, verifier := range append(verifiers[1:], a.previousVerifiers...) {
+			if body, err = verifyAttestation(verifier, req.Occurrence, req.Stage); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
 	}
-	if a.signer.KeyID() != req.Occurrence.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId() {
-		return fmt.Errorf("Invalid keyID")
+
+	if a.requiredSignatures <= 1 {
+		return nil
+	}
+
+	// the attester's own signature counts toward the required signatures
+	signatures := 1 + countCoSignatures(a.coSigners, req.Occurrences, req.Occurrence.GetResource().GetUri(), body)
+	if signatures < a.requiredSignatures {
+		return fmt.Errorf("Attestation has %d of the %d required signatures", signatures, a.requiredSignatures)
 	}
-	body, err := a.signer.Verify(req.Occurrence.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetSignature())
+
+	return nil
+}
+
+// VerifyAttestation checks that the occurrence is an attestation for its resource that was signed by the verifier's key.
+// When stage is set, the attestation must also have been made for that stage.
+func VerifyAttestation(verifier Verifier, occurrence *grafeas.Occurrence, stage string) error {
+	_, err := verifyAttestation(verifier, occurrence, stage)
+	return err
+}
+
+// verifyAttestation verifies the attestation like VerifyAttestation, returning its signed body
+func verifyAttestation(verifier Verifier, occurrence *grafeas.Occurrence, stage string) (string, error) {
+	if occurrence == nil || occurrence.GetAttestation() == nil {
+		return "", fmt.Errorf("Occurrence is not an attestation")
+	}
+	if verifier.KeyID() != occurrence.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId() {
+		return "", fmt.Errorf("Invalid keyID")
+	}
+	body, err := verifyAttestationSignature(verifier, occurrence.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetSignature())
+	if err != nil {
+		return "", err
+	}
+	resourceURI, attestedStage, _ := parseAttestationBody(body)
+	if resourceURI != occurrence.GetResource().GetUri() {
+		return "", fmt.Errorf("Signature body doesn't match")
+	}
+	if stage != "" && stage != attestedStage {
+		return "", fmt.Errorf("Attestation is for stage %q, not %q", attestedStage, stage)
+	}
+	return body, nil
+}
+
+// VerifySignature returns nil if the signature of an attestation, in either signature format, was made by the
+// verifier's key over the payload, which is the attestation's signed body. Unlike VerifyAttestation it doesn't need the
+// occurrence the attestation is stored in, so stored attestations can be checked offline.
+func VerifySignature(verifier Verifier, payload, signature string) error {
+	body, err := verifyAttestationSignature(verifier, signature)
 	if err != nil {
 		return err
 	}
-	if body != req.Occurrence.GetResource().GetUri() {
+	if body != payload {
 		return fmt.Errorf("Signature body doesn't match")
 	}
 	return nil
 }
 
+// attestationBody returns the body that is signed for an attestation. The stage and result are only included when set,
+// so that attestations without them remain a signature of the resource URI alone. The resource URI and stage can't
+// contain newlines, since they would be read back as the separators of the body's other fields.
+func attestationBody(resourceURI, stage string, result map[string]interface{}) (string, error) {
+	if strings.Contains(resourceURI, "\n") {
+		return "", fmt.Errorf("Invalid resource URI %q, it can't contain a newline", resourceURI)
+	}
+	if strings.Contains(stage, "\n") {
+		return "", fmt.Errorf("Invalid stage %q, it can't contain a newline", stage)
+	}
+
+	body := resourceURI
+	if stage != "" {
+		body += stageSeparator + stage
+	}
+
+	if result != nil {
+		// JSON strings escape newlines, so the encoded result can't contain a separator
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return "", fmt.Errorf("Error encoding policy result %v", err)
+		}
+		body += resultSeparator + string(encoded)
+	}
+
+	return body, nil
+}
+
+// parseAttestationBody returns the resource URI, stage and encoded policy result from the signed body of an attestation
+func parseAttestationBody(body string) (resourceURI, stage, result string) {
+	if parts := strings.SplitN(body, resultSeparator, 2); len(parts) == 2 {
+		body, result = parts[0], parts[1]
+	}
+
+	if parts := strings.SplitN(body, stageSeparator, 2); len(parts) == 2 {
+		return parts[0], parts[1], result
+	}
+
+	return body, "", result
+}
+
+// AttestationResult returns the policy result embedded in an attestation that was signed by the verifier's key, or nil
+// if the attester's policy didn't define a result
+func AttestationResult(verifier Verifier, occurrence *grafeas.Occurrence) (map[string]interface{}, error) {
+	if err := VerifyAttestation(verifier, occurrence, ""); err != nil {
+		return nil, err
+	}
+
+	body, err := verifyAttestationSignature(verifier, occurrence.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetSignature())
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, encoded := parseAttestationBody(body)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(encoded), &result); err != nil {
+		return nil, fmt.Errorf("Invalid policy result %v", err)
+	}
+
+	return result, nil
+}
+
+// occurrenceInput is the input document that policies are evaluated with. Policies can read the occurrences as they're
+// stored in Grafeas, or the summaries of them, which are built from the occurrences before they're transformed.
 type occurrenceInput struct {
+	// Occurrences are the resource's occurrences, transformed by the attester's input transform if it has one
 	Occurrences []map[string]interface{} `json:"occurrences"`
+
+	// Vulnerabilities counts the resource's vulnerability occurrences by severity
+	Vulnerabilities VulnerabilityCounts `json:"vulnerabilities"`
+
+	// Builds are the provenance of the resource's build occurrences
+	Builds []BuildProvenance `json:"builds"`
 }
 
-func (oi *occurrenceInput) addOccurrence(occurrence *grafeas.Occurrence) error {
+// addOccurrence adds the occurrence to the input, transformed by the transform if it's set
+func (oi *occurrenceInput) addOccurrence(ctx context.Context, occurrence *grafeas.Occurrence, transform InputTransform) error {
 	if oi.Occurrences == nil {
 		oi.Occurrences = make([]map[string]interface{}, 0)
 	}
@@ -152,6 +520,14 @@ func (oi *occurrenceInput) addOccurrence(occurrence *grafeas.Occurrence) error {
 		return err
 	}
 
+	if transform != nil {
+		occurrenceAsMap, err = transform.Transform(ctx, occurrenceAsMap)
+		if err != nil {
+			return fmt.Errorf("unable to transform occurrence %s: %v", occurrence.GetName(), err)
+		}
+	}
+
 	oi.Occurrences = append(oi.Occurrences, occurrenceAsMap)
+	oi.addSummary(occurrence)
 	return nil
 }
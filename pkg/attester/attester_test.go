@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
 	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
@@ -153,7 +154,7 @@ func TestAttester_VerifyBadKey(t *testing.T) {
 	newAttester, err := createAttester(attesterName, policyModule, false)
 	assert.NoError(err)
 
-	req := &VerifyRequest{res.Attestation}
+	req := &VerifyRequest{Occurrence: res.Attestation}
 	err = newAttester.Verify(ctx, req)
 	assert.Error(err)
 }
@@ -194,14 +195,14 @@ func TestAttester_VerifyValid(t *testing.T) {
 	res, err := att.Attest(ctx, attestRequest)
 	assert.NoError(err)
 
-	req := &VerifyRequest{res.Attestation}
+	req := &VerifyRequest{Occurrence: res.Attestation}
 
 	err = att.Verify(ctx, req)
 	assert.NoError(err)
 }
 
 func createAttester(attesterName string, policyModule string, badSigner bool) (Attester, error) {
-	policy, err := NewPolicy(attesterName, policyModule, true)
+	policy, err := NewPolicy(ctx, attesterName, policyModule, true)
 	if err != nil {
 		return nil, err
 	}
@@ -231,6 +232,14 @@ func (s *FakeSigner) Verify(string) (string, error) {
 	return s.name, fmt.Errorf("invalid signer")
 }
 
+func (s *FakeSigner) SignBytes([]byte) ([]byte, error) {
+	return nil, fmt.Errorf("invalid signer")
+}
+
+func (s *FakeSigner) VerifyBytes([]byte, []byte) error {
+	return fmt.Errorf("invalid signer")
+}
+
 func (s *FakeSigner) KeyID() string {
 	return s.name
 }
@@ -242,3 +251,161 @@ func (s *FakeSigner) Serialize(out io.Writer) error {
 	}
 	return fmt.Errorf("invalid signer")
 }
+
+func TestAttester_VerifyStage(t *testing.T) {
+	assert := assert.New(t)
+
+	attesterName = fmt.Sprintf("attester%s", rand.String(10))
+
+	policy, err := NewPolicy(ctx, attesterName, fmt.Sprintf("package %s\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", attesterName), false)
+	assert.NoError(err)
+	signer, err := NewSigner(attesterName)
+	assert.NoError(err)
+
+	att := NewAttester(attesterName, policy, signer, WithStage("staging"))
+
+	res, err := att.Attest(ctx, &AttestRequest{ResourceURI: attesterName})
+	assert.NoError(err)
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation}))
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation, Stage: "staging"}))
+	assert.Error(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation, Stage: "prod"}))
+
+	res, err = att.Attest(ctx, &AttestRequest{ResourceURI: attesterName, Stage: "prod"})
+	assert.NoError(err)
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation, Stage: "prod"}))
+	assert.Error(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation, Stage: "staging"}))
+
+	// a resource URI or stage with a newline would be signed as a body with a different stage or result
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: attesterName + "\nstage=prod"})
+	assert.EqualError(err, fmt.Sprintf("Invalid resource URI %q, it can't contain a newline", attesterName+"\nstage=prod"))
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: attesterName, Stage: "prod\nresult={}"})
+	assert.EqualError(err, `Invalid stage "prod\nresult={}", it can't contain a newline`)
+}
+
+func TestAttester_AttestEmbedsResult(t *testing.T) {
+	assert := assert.New(t)
+
+	attesterName = fmt.Sprintf("attester%s", rand.String(10))
+
+	policy, err := NewPolicy(ctx, attesterName, fmt.Sprintf("package %s\nresult = {\"pass\": true, \"score\": 90}\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", attesterName), false)
+	assert.NoError(err)
+	signer, err := NewSigner(attesterName)
+	assert.NoError(err)
+
+	att := NewAttester(attesterName, policy, signer, WithStage("staging"))
+
+	res, err := att.Attest(ctx, &AttestRequest{ResourceURI: attesterName})
+	assert.NoError(err)
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation, Stage: "staging"}))
+
+	result, err := AttestationResult(signer, res.Attestation)
+	assert.NoError(err)
+	assert.Equal(map[string]interface{}{"pass": true, "score": float64(90)}, result)
+}
+
+func TestAttester_DryRun(t *testing.T) {
+	attesterName = fmt.Sprintf("attester%s", rand.String(10))
+	policyModule := fmt.Sprintf(`
+	package %s
+	violation[{"msg":"analysis failed"}]{
+		input.occurrences[_].discovered.discovered.analysisStatus != "FINISHED_SUCCESS"
+	}
+	`, attesterName)
+
+	discovered := func(status discovery.Discovered_AnalysisStatus) *grafeas.Occurrence {
+		return &grafeas.Occurrence{
+			Resource: &grafeas.Resource{Uri: attesterName},
+			NoteName: fmt.Sprintf("projects/rode/notes/%s", attesterName),
+			Details: &grafeas.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: status},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		req        *AttestRequest
+		attested   bool
+		violations []string
+		err        bool
+	}{
+		{
+			name:     "pass",
+			req:      &AttestRequest{ResourceURI: attesterName, Occurrences: []*grafeas.Occurrence{discovered(discovery.Discovered_FINISHED_SUCCESS)}},
+			attested: true,
+		},
+		{
+			name:       "violations",
+			req:        &AttestRequest{ResourceURI: attesterName, Occurrences: []*grafeas.Occurrence{discovered(discovery.Discovered_FINISHED_FAILED)}},
+			violations: []string{"analysis failed"},
+		},
+		{
+			name: "empty request",
+			err:  true,
+		},
+		{
+			name: "missing occurrence",
+			req:  &AttestRequest{ResourceURI: attesterName, Occurrences: []*grafeas.Occurrence{discovered(discovery.Discovered_FINISHED_SUCCESS), nil}},
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// the signer fails to sign, so a dry run only succeeds if it doesn't sign
+			att, err := createAttester(attesterName, policyModule, true)
+			assert.NoError(err)
+
+			decision, err := att.DryRun(ctx, tc.req)
+			if tc.err {
+				assert.Error(err)
+				assert.Nil(decision)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(attesterName, decision.Attester)
+			assert.Equal(attesterName, decision.ResourceURI)
+			assert.Equal(tc.attested, decision.Attested)
+			assert.Equal(tc.violations, decision.Violations)
+			assert.Empty(decision.Error)
+		})
+	}
+}
+
+func TestAttester_AttestCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	// the policy iterates over every triple of occurrences, which takes far longer than the test waits
+	policy, err := NewPolicy(ctx, "slow", `
+package slow
+
+violation[{"msg": "never"}] {
+	count([1 | input.occurrences[_]; input.occurrences[_]; input.occurrences[_]]) < 0
+}
+`, false)
+	assert.NoError(err)
+	signer, err := NewSigner("slow")
+	assert.NoError(err)
+	counter := NewAttestationCounter(0)
+	att := NewAttester("slow", policy, signer, WithAttestationCounter(counter))
+
+	occurrences := make([]*grafeas.Occurrence, 2000)
+	for i := range occurrences {
+		occurrences[i] = &grafeas.Occurrence{}
+	}
+
+	// cancelling the context stops the evaluation, and the resource is neither attested nor rejected
+	cancelled, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	res, err := att.Attest(cancelled, &AttestRequest{ResourceURI: "slow", Occurrences: occurrences})
+	assert.True(time.Since(start) < 5*time.Second, "the evaluation wasn't cancelled")
+	assert.Equal(context.DeadlineExceeded, err)
+	assert.Nil(res)
+	assert.True(counter.Take("slow").IsZero())
+}
@@ -0,0 +1,149 @@
+package attester
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EvalBudget limits how long each attester can spend evaluating its policy within a window, so that a single
+// expensive policy can't starve the other attesters. An attester that uses up its budget is throttled, refusing to
+// evaluate until the window ends.
+type EvalBudget struct {
+	budget time.Duration
+	window time.Duration
+
+	mutex   sync.Mutex
+	usage   map[string]*evalUsage
+	budgets map[string]time.Duration
+
+	// OnChange is called with the attester name whenever the attester is throttled or the throttle ends
+	OnChange func(attester string)
+}
+
+type evalUsage struct {
+	start     time.Time
+	used      time.Duration
+	throttled bool
+}
+
+// EvalBudgetExceededError is returned when evaluating the policy of an attester that has used up its budget
+type EvalBudgetExceededError struct {
+	Attester string
+	Until    time.Time
+}
+
+func (e EvalBudgetExceededError) Error() string {
+	return fmt.Sprintf("attester %s exceeded its policy evaluation budget, throttled until %s", e.Attester, e.Until.Format(time.RFC3339))
+}
+
+// NewEvalBudget creates a budget that allows each attester to evaluate for up to budget in every window. Attesters
+// aren't limited by default when budget is 0, but can still be given a budget of their own.
+func NewEvalBudget(budget, window time.Duration) *EvalBudget {
+	return &EvalBudget{
+		budget:  budget,
+		window:  window,
+		usage:   make(map[string]*evalUsage),
+		budgets: make(map[string]time.Duration),
+	}
+}
+
+// SetBudget overrides the budget of the attester. The default budget is used when budget is 0.
+func (b *EvalBudget) SetBudget(attester string, budget time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if budget <= 0 {
+		delete(b.budgets, attester)
+		return
+	}
+
+	b.budgets[attester] = budget
+}
+
+// Allow returns an EvalBudgetExceededError if the attester is throttled
+func (b *EvalBudget) Allow(attester string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	usage := b.currentUsage(attester, time.Now())
+	if usage.throttled {
+		return EvalBudgetExceededError{Attester: attester, Until: usage.start.Add(b.window)}
+	}
+
+	return nil
+}
+
+// Record adds the duration of an evaluation to the attester's usage, throttling the attester if it exceeds its budget
+func (b *EvalBudget) Record(attester string, duration time.Duration) {
+	policyEvalSeconds.WithLabelValues(attester).Add(duration.Seconds())
+
+	b.mutex.Lock()
+	usage := b.currentUsage(attester, time.Now())
+	usage.used += duration
+
+	budget := b.budgetOf(attester)
+	throttled := !usage.throttled && budget > 0 && usage.used > budget
+	if throttled {
+		usage.throttled = true
+	}
+	remaining := time.Until(usage.start.Add(b.window))
+	b.mutex.Unlock()
+
+	if !throttled {
+		return
+	}
+
+	policyEvalThrottled.WithLabelValues(attester).Inc()
+	b.changed(attester)
+
+	// report the end of the throttle, since usage is otherwise only reset when the attester next evaluates
+	time.AfterFunc(remaining, func() {
+		b.changed(attester)
+	})
+}
+
+// Throttled returns whether the attester has used up its budget for the current window
+func (b *EvalBudget) Throttled(attester string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	usage, ok := b.usage[attester]
+	return ok && usage.throttled && time.Since(usage.start) < b.window
+}
+
+// Remove forgets the usage and budget of the attester
+func (b *EvalBudget) Remove(attester string) {
+	b.mutex.Lock()
+	delete(b.usage, attester)
+	delete(b.budgets, attester)
+	b.mutex.Unlock()
+
+	policyEvalSeconds.DeleteLabelValues(attester)
+	policyEvalThrottled.DeleteLabelValues(attester)
+}
+
+// currentUsage returns the attester's usage in the current window, starting a new window when the last one has ended
+func (b *EvalBudget) currentUsage(attester string, now time.Time) *evalUsage {
+	usage, ok := b.usage[attester]
+	if !ok || now.Sub(usage.start) >= b.window {
+		usage = &evalUsage{start: now}
+		b.usage[attester] = usage
+	}
+
+	return usage
+}
+
+func (b *EvalBudget) budgetOf(attester string) time.Duration {
+	if budget, ok := b.budgets[attester]; ok {
+		return budget
+	}
+
+	return b.budget
+}
+
+func (b *EvalBudget) changed(attester string) {
+	if b.OnChange != nil {
+		b.OnChange(attester)
+	}
+}
@@ -0,0 +1,81 @@
+package attester
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+func TestEvalBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	var mutex sync.Mutex
+	var changes []string
+	budget := NewEvalBudget(time.Second, 100*time.Millisecond)
+	budget.OnChange = func(attester string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		changes = append(changes, attester)
+	}
+
+	budget.Record("default/cheap", 500*time.Millisecond)
+	assert.NoError(budget.Allow("default/cheap"))
+	assert.False(budget.Throttled("default/cheap"))
+
+	budget.Record("default/expensive", 2*time.Second)
+	assert.IsType(EvalBudgetExceededError{}, budget.Allow("default/expensive"))
+	assert.True(budget.Throttled("default/expensive"))
+	assert.NoError(budget.Allow("default/cheap"))
+
+	// the throttle ends with the window, which is reported so the status can be updated
+	assert.Eventually(func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(changes) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.False(budget.Throttled("default/expensive"))
+	assert.NoError(budget.Allow("default/expensive"))
+
+	budget.SetBudget("default/cheap", 100*time.Millisecond)
+	budget.Record("default/cheap", 200*time.Millisecond)
+	assert.Error(budget.Allow("default/cheap"))
+
+	budget.Remove("default/cheap")
+	assert.NoError(budget.Allow("default/cheap"))
+}
+
+func TestEvalBudget_Unlimited(t *testing.T) {
+	assert := assert.New(t)
+
+	budget := NewEvalBudget(0, time.Minute)
+	budget.Record("default/foo", time.Hour)
+	assert.NoError(budget.Allow("default/foo"))
+
+	budget.SetBudget("default/foo", time.Second)
+	budget.Record("default/foo", 2*time.Second)
+	assert.Error(budget.Allow("default/foo"))
+}
+
+func TestAttester_EvalBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	attesterName = fmt.Sprintf("attester%s", rand.String(10))
+
+	policy, err := NewPolicy(ctx, attesterName, fmt.Sprintf("package %s\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", attesterName), false)
+	assert.NoError(err)
+	signer, err := NewSigner(attesterName)
+	assert.NoError(err)
+
+	budget := NewEvalBudget(time.Nanosecond, time.Minute)
+	att := NewAttester(attesterName, policy, signer, WithEvalBudget(budget))
+
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: attesterName})
+	assert.NoError(err)
+
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: attesterName})
+	assert.IsType(EvalBudgetExceededError{}, err)
+}
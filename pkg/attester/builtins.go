@@ -0,0 +1,116 @@
+package attester
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+)
+
+// Builtin is a custom built-in function that policies can call, in addition to the functions built into OPA
+type Builtin struct {
+	// Decl declares the function's name and type, which policies calling it are type checked against when they're
+	// compiled
+	Decl *rego.Function
+
+	// Function implements the function when policies are evaluated. It's the option created from Decl by rego.Function1
+	// and the like.
+	Function func(*rego.Rego)
+}
+
+// WithBuiltins makes the custom built-in functions available to the policy, both when it's compiled and when it's
+// evaluated
+func WithBuiltins(builtins ...Builtin) PolicyOption {
+	return func(o *policyOptions) {
+		o.builtins = append(o.builtins, builtins...)
+	}
+}
+
+// builtinDecls returns the declarations of the builtins, keyed by name, for the compiler to type check calls against
+func builtinDecls(builtins []Builtin) map[string]*ast.Builtin {
+	decls := make(map[string]*ast.Builtin, len(builtins))
+	for _, builtin := range builtins {
+		decls[builtin.Decl.Name] = &ast.Builtin{
+			Name: builtin.Decl.Name,
+			Decl: builtin.Decl.Decl,
+		}
+	}
+
+	return decls
+}
+
+// ImageAllowedBuiltin is the name of the built-in function that returns whether an image digest is in the
+// ImageAllowlist
+const ImageAllowedBuiltin = "rode.image_allowed"
+
+// ImageAllowlist is a list of image digests, such as sha256:..., that policies can check images against with the
+// rode.image_allowed built-in function
+type ImageAllowlist struct {
+	digests map[string]bool
+}
+
+// NewImageAllowlist creates an allowlist of the digests
+func NewImageAllowlist(digests []string) *ImageAllowlist {
+	allowlist := &ImageAllowlist{digests: make(map[string]bool, len(digests))}
+	for _, digest := range digests {
+		allowlist.digests[digest] = true
+	}
+
+	return allowlist
+}
+
+// ReadImageAllowlist reads an allowlist with a digest on each line. Blank lines and lines starting with # are ignored.
+func ReadImageAllowlist(in io.Reader) (*ImageAllowlist, error) {
+	var digests []string
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		digest := strings.TrimSpace(scanner.Text())
+		if digest == "" || strings.HasPrefix(digest, "#") {
+			continue
+		}
+		if !strings.Contains(digest, ":") || strings.Contains(digest, "@") {
+			return nil, fmt.Errorf("line %d: %q is not an image digest", line, digest)
+		}
+
+		digests = append(digests, digest)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewImageAllowlist(digests), nil
+}
+
+// Allowed returns whether the image is in the allowlist. The image is either a digest or a reference to an image by its
+// digest, such as harbor.example.com/app@sha256:...
+func (a *ImageAllowlist) Allowed(image string) bool {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		image = image[i+1:]
+	}
+
+	return a.digests[image]
+}
+
+// Builtin returns the rode.image_allowed built-in function, which is true when its argument is allowed by the allowlist
+func (a *ImageAllowlist) Builtin() Builtin {
+	decl := &rego.Function{
+		Name: ImageAllowedBuiltin,
+		Decl: types.NewFunction(types.Args(types.S), types.B),
+	}
+
+	return Builtin{
+		Decl: decl,
+		Function: rego.Function1(decl, func(_ rego.BuiltinContext, image *ast.Term) (*ast.Term, error) {
+			s, ok := image.Value.(ast.String)
+			if !ok {
+				return nil, fmt.Errorf("%s: image must be a string", ImageAllowedBuiltin)
+			}
+
+			return ast.BooleanTerm(a.Allowed(string(s))), nil
+		}),
+	}
+}
@@ -0,0 +1,95 @@
+package attester
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const allowedDigest = "sha256:b88ca0f4a7fe2c67fc8bdb67ad405f418ca0be6aec442c79ae32253138da2dd5"
+
+var allowlistPolicy = `
+package allowlist
+
+violation[{"msg":"image not allowed"}]{
+	not rode.image_allowed(input.image)
+}
+`
+
+func TestPolicy_ImageAllowlist(t *testing.T) {
+	for _, partialEval := range []bool{false, true} {
+		assert := assert.New(t)
+		ctx := context.Background()
+
+		allowlist := NewImageAllowlist([]string{allowedDigest})
+		p, err := NewPolicy(ctx, "allowlist", allowlistPolicy, false, WithBuiltins(allowlist.Builtin()), WithPartialEval(partialEval))
+		assert.NoError(err)
+
+		assert.Empty(p.Evaluate(ctx, map[string]interface{}{"image": allowedDigest}))
+		assert.Empty(p.Evaluate(ctx, map[string]interface{}{"image": "harbor.example.com/app@" + allowedDigest}))
+		violations := p.Evaluate(ctx, map[string]interface{}{"image": "harbor.example.com/app@sha256:0000"})
+		assert.Len(violations, 1)
+		assert.Equal("image not allowed", violations[0].Msg)
+	}
+}
+
+func TestPolicy_UndeclaredBuiltin(t *testing.T) {
+	// policies calling a builtin that isn't registered don't compile
+	_, err := NewPolicy(ctx, "allowlist", allowlistPolicy, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "undefined function rode.image_allowed")
+}
+
+func TestPolicy_CustomBuiltin(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	decl := &rego.Function{
+		Name: "example.severity_at_least",
+		Decl: types.NewFunction(types.Args(types.S, types.S), types.B),
+	}
+	severities := map[string]int{"LOW": 1, "MEDIUM": 2, "HIGH": 3, "CRITICAL": 4}
+	builtin := Builtin{
+		Decl: decl,
+		Function: rego.Function2(decl, func(_ rego.BuiltinContext, severity, minimum *ast.Term) (*ast.Term, error) {
+			return ast.BooleanTerm(severities[string(severity.Value.(ast.String))] >= severities[string(minimum.Value.(ast.String))]), nil
+		}),
+	}
+
+	p, err := NewPolicy(ctx, "severity", `
+package severity
+
+violation[{"msg":"severe vulnerability found"}]{
+	example.severity_at_least(input.occurrences[_].vulnerability.severity, "HIGH")
+}
+`, false, WithBuiltins(builtin))
+	assert.NoError(err)
+
+	assert.Empty(p.Evaluate(ctx, map[string]interface{}{"occurrences": []interface{}{
+		map[string]interface{}{"vulnerability": map[string]interface{}{"severity": "MEDIUM"}},
+	}}))
+	assert.Len(p.Evaluate(ctx, map[string]interface{}{"occurrences": []interface{}{
+		map[string]interface{}{"vulnerability": map[string]interface{}{"severity": "LOW"}},
+		map[string]interface{}{"vulnerability": map[string]interface{}{"severity": "CRITICAL"}},
+	}}), 1)
+}
+
+func TestReadImageAllowlist(t *testing.T) {
+	assert := assert.New(t)
+
+	allowlist, err := ReadImageAllowlist(strings.NewReader("# production images\n" + allowedDigest + "\n\n  sha256:1234  \n"))
+	assert.NoError(err)
+	assert.True(allowlist.Allowed(allowedDigest))
+	assert.True(allowlist.Allowed("sha256:1234"))
+	assert.True(allowlist.Allowed("harbor.example.com/app@sha256:1234"))
+	assert.False(allowlist.Allowed("sha256:5678"))
+	assert.False(allowlist.Allowed("harbor.example.com/app:1.0"))
+
+	_, err = ReadImageAllowlist(strings.NewReader("harbor.example.com/app@sha256:1234\n"))
+	assert.EqualError(err, `line 1: "harbor.example.com/app@sha256:1234" is not an image digest`)
+}
@@ -0,0 +1,89 @@
+package attester
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	evalCacheHit  = "hit"
+	evalCacheMiss = "miss"
+
+	// maxEvalCacheEntries bounds the memory used by the cache when many distinct inputs are evaluated within the TTL
+	maxEvalCacheEntries = 10000
+)
+
+// EvalCache caches the result of evaluating a policy for a short time, so that evaluating the same input again, such
+// as when the same occurrences are received repeatedly, doesn't run the policy again. Results are keyed by the policy,
+// its data and the input, so changing the policy or its data invalidates them.
+type EvalCache struct {
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*evalCacheEntry
+}
+
+type evalCacheEntry struct {
+	evaluation *Evaluation
+	expires    time.Time
+}
+
+// NewEvalCache creates a cache that keeps results for the TTL
+func NewEvalCache(ttl time.Duration) *EvalCache {
+	return &EvalCache{
+		ttl:     ttl,
+		entries: make(map[string]*evalCacheEntry),
+	}
+}
+
+func (c *EvalCache) get(policy, key string) (*Evaluation, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		evalCacheRequests.WithLabelValues(policy, evalCacheMiss).Inc()
+		return nil, false
+	}
+
+	evalCacheRequests.WithLabelValues(policy, evalCacheHit).Inc()
+	return entry.evaluation, true
+}
+
+func (c *EvalCache) set(key string, evaluation *Evaluation) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxEvalCacheEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	if len(c.entries) >= maxEvalCacheEntries {
+		return
+	}
+
+	c.entries[key] = &evalCacheEntry{
+		evaluation: evaluation,
+		expires:    now.Add(c.ttl),
+	}
+}
+
+// hashJSON returns a hash of the JSON encoding of the value. Map keys are sorted when encoded, so equal values have
+// equal hashes.
+func hashJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
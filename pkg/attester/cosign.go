@@ -0,0 +1,39 @@
+package attester
+
+import (
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+)
+
+// WithCoSigners requires the attester's attestations to be co-signed by enough of the co-signers that, counting the
+// attester's own signature, the same body has been signed by at least required distinct keys. A co-signer co-signs an
+// attestation by attesting the same resource with the same body, such as another attester with the same stage.
+func WithCoSigners(coSigners []Verifier, required int) AttesterOption {
+	return func(a *attester) {
+		a.coSigners = coSigners
+		a.requiredSignatures = required
+	}
+}
+
+// countCoSignatures returns the number of distinct co-signers that signed the body in an attestation of the resource
+// among the occurrences
+func countCoSignatures(coSigners []Verifier, occurrences []*grafeas.Occurrence, resourceURI, body string) int {
+	signed := make(map[string]bool)
+	for _, occ := range occurrences {
+		if occ.GetAttestation() == nil || occ.GetResource().GetUri() != resourceURI {
+			continue
+		}
+
+		for _, coSigner := range coSigners {
+			if signed[coSigner.KeyID()] {
+				continue
+			}
+
+			// a co-signature only counts when it signs the same stage and result as the attestation
+			if coSigned, err := verifyAttestation(coSigner, occ, ""); err == nil && coSigned == body {
+				signed[coSigner.KeyID()] = true
+			}
+		}
+	}
+
+	return len(signed)
+}
@@ -0,0 +1,96 @@
+package attester
+
+import (
+	"fmt"
+	"testing"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCoSigningAttester(t *testing.T, name, stage string, opts ...AttesterOption) (Attester, Signer) {
+	policy, err := NewPolicy(ctx, name, fmt.Sprintf("package %s\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", name), false)
+	assert.NoError(t, err)
+	signer, err := NewSigner(name)
+	assert.NoError(t, err)
+
+	return NewAttester(name, policy, signer, append([]AttesterOption{WithStage(stage)}, opts...)...), signer
+}
+
+func TestAttester_VerifyCoSigners(t *testing.T) {
+	assert := assert.New(t)
+	resource := "harbor.example.com/app@sha256:1234"
+
+	security, securitySigner := newCoSigningAttester(t, "security", "prod")
+	release, releaseSigner := newCoSigningAttester(t, "release", "prod")
+	staging, _ := newCoSigningAttester(t, "staging", "staging")
+	unknown, _ := newCoSigningAttester(t, "unknown", "prod")
+	securityStaging := NewAttester("security", security.(*attester).policy, securitySigner, WithStage("staging"))
+
+	att, _ := newCoSigningAttester(t, "quality", "prod",
+		WithCoSigners([]Verifier{securitySigner, releaseSigner}, 2))
+
+	attest := func(a Attester, uri string) *grafeas.Occurrence {
+		res, err := a.Attest(ctx, &AttestRequest{ResourceURI: uri})
+		assert.NoError(err)
+		return res.Attestation
+	}
+
+	own := attest(att, resource)
+	securityAttestation := attest(security, resource)
+
+	testCases := []struct {
+		name        string
+		occurrences []*grafeas.Occurrence
+		valid       bool
+	}{
+		{
+			name:        "below threshold",
+			occurrences: []*grafeas.Occurrence{own},
+		},
+		{
+			name:        "at threshold",
+			occurrences: []*grafeas.Occurrence{own, securityAttestation},
+			valid:       true,
+		},
+		{
+			name:        "above threshold",
+			occurrences: []*grafeas.Occurrence{own, securityAttestation, attest(release, resource)},
+			valid:       true,
+		},
+		{
+			name:        "co-signer attested another resource",
+			occurrences: []*grafeas.Occurrence{own, attest(security, "harbor.example.com/other@sha256:5678")},
+		},
+		{
+			name:        "co-signer attested another stage",
+			occurrences: []*grafeas.Occurrence{own, attest(securityStaging, resource)},
+		},
+		{
+			name:        "attestation by an attester that isn't a co-signer",
+			occurrences: []*grafeas.Occurrence{own, attest(unknown, resource), attest(staging, resource)},
+		},
+		{
+			name:        "co-signer counted once",
+			occurrences: []*grafeas.Occurrence{own, securityAttestation, attest(security, resource)},
+			valid:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := att.Verify(ctx, &VerifyRequest{Occurrence: own, Occurrences: tc.occurrences})
+			if tc.valid {
+				assert.NoError(err)
+			} else {
+				assert.EqualError(err, "Attestation has 1 of the 2 required signatures")
+			}
+		})
+	}
+
+	// a co-signer's attestation isn't valid for the attester on its own
+	assert.Error(att.Verify(ctx, &VerifyRequest{Occurrence: securityAttestation, Occurrences: []*grafeas.Occurrence{own, securityAttestation}}))
+
+	// attesters that require a single signature don't look for co-signers
+	assert.NoError(security.Verify(ctx, &VerifyRequest{Occurrence: securityAttestation}))
+}
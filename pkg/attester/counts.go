@@ -0,0 +1,120 @@
+package attester
+
+import (
+	"sync"
+	"time"
+)
+
+// AttestationCounts are the numbers of attestations an attester signed and rejected
+type AttestationCounts struct {
+	Signed   int64
+	Rejected int64
+
+	// Last is when the attester last signed or rejected an attestation, or zero if it hasn't
+	Last time.Time
+}
+
+// IsZero returns true if no attestations were counted
+func (c AttestationCounts) IsZero() bool {
+	return c.Signed == 0 && c.Rejected == 0
+}
+
+// AttestationCounter counts the attestations each attester signs and rejects until they're taken, so that the counts
+// can be added to the attester's status. Changes are reported through OnChange at most once per interval for each
+// attester, so that a busy attester's status isn't written on every attestation.
+type AttestationCounter struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mutex   sync.Mutex
+	counts  map[string]AttestationCounts
+	pending map[string]bool
+
+	// OnChange is called with the attester name once attestations have been counted for that attester since its counts
+	// were last reported
+	OnChange func(attester string)
+}
+
+// NewAttestationCounter creates a counter that reports changes to an attester's counts at most once per interval, or
+// on every attestation when the interval is 0
+func NewAttestationCounter(interval time.Duration) *AttestationCounter {
+	return &AttestationCounter{
+		interval: interval,
+		now:      time.Now,
+		counts:   make(map[string]AttestationCounts),
+		pending:  make(map[string]bool),
+	}
+}
+
+// Signed counts an attestation signed by the attester
+func (c *AttestationCounter) Signed(attester string) {
+	c.add(attester, AttestationCounts{Signed: 1, Last: c.now()})
+}
+
+// Rejected counts a resource the attester refused to attest because of violations
+func (c *AttestationCounter) Rejected(attester string) {
+	c.add(attester, AttestationCounts{Rejected: 1, Last: c.now()})
+}
+
+// Take returns the attester's counts since they were last taken, and resets them
+func (c *AttestationCounter) Take(attester string) AttestationCounts {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	counts := c.counts[attester]
+	delete(c.counts, attester)
+
+	return counts
+}
+
+// Restore adds counts that were taken back to the attester's counts, such as when they couldn't be stored
+func (c *AttestationCounter) Restore(attester string, counts AttestationCounts) {
+	if counts.IsZero() {
+		return
+	}
+
+	c.add(attester, counts)
+}
+
+// Remove forgets the attester's counts
+func (c *AttestationCounter) Remove(attester string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.counts, attester)
+}
+
+func (c *AttestationCounter) add(attester string, counts AttestationCounts) {
+	c.mutex.Lock()
+	current := c.counts[attester]
+	current.Signed += counts.Signed
+	current.Rejected += counts.Rejected
+	if counts.Last.After(current.Last) {
+		current.Last = counts.Last
+	}
+	c.counts[attester] = current
+
+	// a change is already being reported once the interval has passed
+	report := !c.pending[attester]
+	if report && c.interval > 0 {
+		c.pending[attester] = true
+	}
+	c.mutex.Unlock()
+
+	if !report || c.OnChange == nil {
+		return
+	}
+
+	if c.interval <= 0 {
+		c.OnChange(attester)
+		return
+	}
+
+	time.AfterFunc(c.interval, func() {
+		c.mutex.Lock()
+		delete(c.pending, attester)
+		c.mutex.Unlock()
+
+		c.OnChange(attester)
+	})
+}
@@ -0,0 +1,115 @@
+package attester
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttestationCounter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var changes []string
+	counter := NewAttestationCounter(0)
+	counter.now = func() time.Time { return now }
+	counter.OnChange = func(attester string) {
+		changes = append(changes, attester)
+	}
+
+	counter.Signed("default/foo")
+	counter.Signed("default/foo")
+	now = now.Add(time.Minute)
+	counter.Rejected("default/foo")
+	counter.Rejected("default/bar")
+	assert.Equal([]string{"default/foo", "default/foo", "default/foo", "default/bar"}, changes)
+
+	// counts are only taken once
+	counts := counter.Take("default/foo")
+	assert.Equal(AttestationCounts{Signed: 2, Rejected: 1, Last: now}, counts)
+	assert.True(counter.Take("default/foo").IsZero())
+
+	// counts that couldn't be stored are added back to the counts since
+	counter.Signed("default/foo")
+	counter.Restore("default/foo", counts)
+	assert.Equal(AttestationCounts{Signed: 3, Rejected: 1, Last: now}, counter.Take("default/foo"))
+
+	counter.Remove("default/bar")
+	assert.True(counter.Take("default/bar").IsZero())
+}
+
+func TestAttestationCounter_Interval(t *testing.T) {
+	assert := assert.New(t)
+
+	var mutex sync.Mutex
+	var changes []string
+	counter := NewAttestationCounter(50 * time.Millisecond)
+	counter.OnChange = func(attester string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		changes = append(changes, attester)
+	}
+	changed := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string{}, changes...)
+	}
+
+	// attestations within the interval are reported once the interval has passed
+	for i := 0; i < 10; i++ {
+		counter.Signed("default/foo")
+	}
+	assert.Empty(changed())
+	assert.Eventually(func() bool {
+		return len(changed()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(int64(10), counter.Take("default/foo").Signed)
+
+	// the next attestation is reported after another interval
+	counter.Rejected("default/foo")
+	assert.Eventually(func() bool {
+		return len(changed()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(int64(1), counter.Take("default/foo").Rejected)
+}
+
+func TestAttester_AttestationCounter(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	policy, err := NewPolicy(ctx, "counted", `
+package counted
+
+violation[{"msg":"rejected"}]{
+	input.occurrences[_].noteName == "projects/rode/notes/rejected"
+}
+`, false)
+	assert.NoError(err)
+	signer, err := NewSigner("counted")
+	assert.NoError(err)
+
+	counter := NewAttestationCounter(0)
+	att := NewAttester("default/counted", policy, signer, WithAttestationCounter(counter))
+
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: "image@sha256:1"})
+	assert.NoError(err)
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: "image@sha256:2"})
+	assert.NoError(err)
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: "image@sha256:3", Occurrences: []*grafeas.Occurrence{
+		{NoteName: "projects/rode/notes/rejected", Resource: &grafeas.Resource{Uri: "image@sha256:3"}},
+	}})
+	assert.IsType(ViolationError{}, err)
+
+	// dry runs aren't attestations
+	_, err = att.DryRun(ctx, &AttestRequest{ResourceURI: "image@sha256:4"})
+	assert.NoError(err)
+
+	counts := counter.Take("default/counted")
+	assert.Equal(int64(2), counts.Signed)
+	assert.Equal(int64(1), counts.Rejected)
+	assert.False(counts.Last.IsZero())
+}
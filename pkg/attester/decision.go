@@ -0,0 +1,51 @@
+package attester
+
+import "time"
+
+// Decision records the outcome of an attester evaluating a resource
+type Decision struct {
+	Time        time.Time `json:"time"`
+	Attester    string    `json:"attester"`
+	ResourceURI string    `json:"resourceUri"`
+	Attested    bool      `json:"attested"`
+	Violations  []string  `json:"violations,omitempty"`
+	Error       string    `json:"error,omitempty"`
+
+	// RejectedInputs are the occurrences left out of the evaluation because they weren't signed by a trusted signer
+	RejectedInputs []string `json:"rejectedInputs,omitempty"`
+
+	// Migration reports the violations of the old and new policies when the attester is migrating between policies
+	Migration *MigrationOutcome `json:"migration,omitempty"`
+}
+
+// DecisionLogger records attestation decisions
+type DecisionLogger interface {
+	LogDecision(decision *Decision) error
+}
+
+// newDecision creates a decision for the attester and resource from the result of attesting it
+func newDecision(attester, resourceURI string, resp *AttestResponse, err error) *Decision {
+	decision := &Decision{
+		Time:        time.Now().UTC(),
+		Attester:    attester,
+		ResourceURI: resourceURI,
+		Attested:    err == nil,
+	}
+
+	if resp != nil {
+		decision.RejectedInputs = resp.RejectedInputs
+		decision.Migration = resp.Migration
+	}
+
+	if vErr, ok := err.(ViolationError); ok {
+		for _, v := range vErr.Violations {
+			decision.Violations = append(decision.Violations, v.Msg)
+		}
+		decision.RejectedInputs = vErr.RejectedInputs
+		decision.Migration = vErr.Migration
+	} else if err != nil {
+		decision.Error = err.Error()
+	}
+
+	return decision
+}
@@ -0,0 +1,90 @@
+package attester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// DefaulterPath is the path the defaulter is served on by the webhook server
+const DefaulterPath = "/mutate-v1alpha1-attester"
+
+// +kubebuilder:webhook:path=/mutate-v1alpha1-attester,mutating=true,failurePolicy=fail,groups=rode.liatr.io,resources=attesters,verbs=create;update,versions=v1alpha1,name=mattester.rode.liatr.io
+
+// Defaulter sets the defaults of Attesters when they're admitted
+type Defaulter interface {
+	admission.Handler
+	admission.DecoderInjector
+}
+
+type defaulter struct {
+	log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// NewDefaulter creates a defaulter that sets the PgpSecret of Attesters that don't import their key or store it in Vault
+// to the attester's name, and their PayloadFormat and RegoVersion to json and v0, so that the controller doesn't have
+// to write the defaults back to the spec when the attester is reconciled
+func NewDefaulter(log logr.Logger) Defaulter {
+	return &defaulter{
+		log,
+		nil,
+	}
+}
+
+// SetupDefaulterWithManager registers a defaulter with the manager's webhook server
+func SetupDefaulterWithManager(mgr manager.Manager, log logr.Logger) {
+	mgr.GetWebhookServer().Register(DefaulterPath, &webhook.Admission{Handler: NewDefaulter(log)})
+}
+
+// DefaultAttester sets the defaults of the attester's spec that aren't already set
+func DefaultAttester(att *rodev1alpha1.Attester) {
+	if att.Spec.PgpSecret == "" && att.Spec.PgpSecretRef == nil && att.Spec.VaultPath == "" {
+		att.Spec.PgpSecret = att.Name
+	}
+	if att.Spec.PayloadFormat == "" {
+		att.Spec.PayloadFormat = rodev1alpha1.PayloadFormatJSON
+	}
+	if att.Spec.RegoVersion == "" {
+		att.Spec.RegoVersion = rodev1alpha1.RegoVersionV0
+	}
+}
+
+func (d *defaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	att := &rodev1alpha1.Attester{}
+	err := d.decoder.Decode(req, att)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// attesters being deleted are left as they are, so that removing their finalizer doesn't change their spec
+	if att.DeletionTimestamp != nil {
+		return admission.Allowed("")
+	}
+
+	DefaultAttester(att)
+
+	defaulted, err := json.Marshal(att)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	response := admission.PatchResponseFromRaw(req.Object.Raw, defaulted)
+	if len(response.Patches) > 0 {
+		d.log.V(1).Info("defaulting attester", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "patches", len(response.Patches))
+	}
+	return response
+}
+
+func (d *defaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
@@ -0,0 +1,97 @@
+package attester
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+func TestDefaulter(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	d := NewDefaulter(logf.NullLogger{})
+	assert.NoError(t, d.InjectDecoder(decoder))
+
+	deleted := metav1.Now()
+	tests := map[string]struct {
+		spec              rodev1alpha1.AttesterSpec
+		deletionTimestamp *metav1.Time
+		patches           map[string]interface{}
+	}{
+		"defaults": {
+			spec: rodev1alpha1.AttesterSpec{Policy: normalizedPolicy},
+			patches: map[string]interface{}{
+				"/spec/pgpSecret":     "defaulted",
+				"/spec/payloadFormat": "json",
+				"/spec/regoVersion":   "v0",
+			},
+		},
+		"pgpSecret already set": {
+			spec: rodev1alpha1.AttesterSpec{Policy: normalizedPolicy, PgpSecret: "existing", PayloadFormat: rodev1alpha1.PayloadFormatYAML},
+			patches: map[string]interface{}{
+				"/spec/regoVersion": "v0",
+			},
+		},
+		"imported key": {
+			spec: rodev1alpha1.AttesterSpec{
+				Policy:       normalizedPolicy,
+				PgpSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "imported"}, Key: "private.asc"},
+				RegoVersion:  rodev1alpha1.RegoVersionV0,
+			},
+			patches: map[string]interface{}{
+				"/spec/payloadFormat": "json",
+			},
+		},
+		"key in vault": {
+			spec: rodev1alpha1.AttesterSpec{Policy: normalizedPolicy, VaultPath: "rode/default/defaulted"},
+			patches: map[string]interface{}{
+				"/spec/payloadFormat": "json",
+				"/spec/regoVersion":   "v0",
+			},
+		},
+		"being deleted": {
+			spec:              rodev1alpha1.AttesterSpec{Policy: normalizedPolicy},
+			deletionTimestamp: &deleted,
+			patches:           map[string]interface{}{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "defaulted", DeletionTimestamp: tc.deletionTimestamp},
+				Spec:       tc.spec,
+			})
+			assert.NoError(err)
+
+			resp := d.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.True(resp.Allowed)
+
+			patches := make(map[string]interface{})
+			for _, patch := range resp.Patches {
+				assert.Equal("add", patch.Operation)
+				patches[patch.Path] = patch.Value
+			}
+			assert.Equal(tc.patches, patches)
+		})
+	}
+}
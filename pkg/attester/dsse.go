@@ -0,0 +1,137 @@
+package attester
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// DSSEPayloadType is the payload type of the DSSE envelopes that attestations are signed in. The payload is the same
+// body that's signed for attestations in the pgp format.
+const DSSEPayloadType = "application/vnd.rode.attestation.v1+text"
+
+// DSSEEnvelope is a DSSE (Dead Simple Signing Envelope) envelope, see
+// https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
+type DSSEEnvelope struct {
+	// PayloadType identifies how the payload is interpreted
+	PayloadType string `json:"payloadType"`
+
+	// Payload is the base64 encoded payload
+	Payload string `json:"payload"`
+
+	Signatures []DSSESignature `json:"signatures"`
+}
+
+// DSSESignature is a signature of a DSSE envelope's payload
+type DSSESignature struct {
+	KeyID string `json:"keyid"`
+
+	// Sig is the base64 encoded signature of the PAE encoding of the envelope's payload type and payload
+	Sig string `json:"sig"`
+}
+
+// PAE returns the DSSE pre-authentication encoding of the payload type and payload, which is what an envelope's
+// signatures sign, so that a payload can't be verified as a payload of another type
+func PAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// SignDSSE signs the payload with the signer, returning it in a DSSE envelope
+func SignDSSE(signer Signer, payloadType string, payload []byte) (*DSSEEnvelope, error) {
+	sig, err := signer.SignBytes(PAE(payloadType, payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DSSEEnvelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []DSSESignature{
+			{KeyID: signer.KeyID(), Sig: base64.StdEncoding.EncodeToString(sig)},
+		},
+	}, nil
+}
+
+// VerifyDSSE returns the payload of the envelope if one of its signatures was made by the verifier's key
+func VerifyDSSE(verifier Verifier, envelope *DSSEEnvelope) ([]byte, error) {
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSSE payload: %v", err)
+	}
+
+	pae := PAE(envelope.PayloadType, payload)
+	for _, signature := range envelope.Signatures {
+		if signature.KeyID != "" && signature.KeyID != verifier.KeyID() {
+			continue
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+
+		if verifier.VerifyBytes(pae, sig) == nil {
+			return payload, nil
+		}
+	}
+
+	return nil, fmt.Errorf("DSSE envelope is not signed by key %s", verifier.KeyID())
+}
+
+// signAttestationBody signs the body of an attestation in the format, returning the signature stored in the attestation
+func signAttestationBody(signer Signer, format rodev1alpha1.SignatureFormat, body string) (string, error) {
+	switch format {
+	case "", rodev1alpha1.SignatureFormatPGP:
+		return signer.Sign(body)
+	case rodev1alpha1.SignatureFormatDSSE:
+		envelope, err := SignDSSE(signer, DSSEPayloadType, []byte(body))
+		if err != nil {
+			return "", err
+		}
+
+		encoded, err := json.Marshal(envelope)
+		if err != nil {
+			return "", err
+		}
+
+		return base64.StdEncoding.EncodeToString(encoded), nil
+	}
+
+	return "", fmt.Errorf("unsupported signature format %q", format)
+}
+
+// verifyAttestationSignature returns the body signed by the signature of an attestation, whichever format it was
+// signed in, if it was signed by the verifier's key
+func verifyAttestationSignature(verifier Verifier, signature string) (string, error) {
+	if envelope := decodeDSSEEnvelope(signature); envelope != nil {
+		if envelope.PayloadType != DSSEPayloadType {
+			return "", fmt.Errorf("unsupported DSSE payload type %q", envelope.PayloadType)
+		}
+
+		payload, err := VerifyDSSE(verifier, envelope)
+		if err != nil {
+			return "", err
+		}
+
+		return string(payload), nil
+	}
+
+	return verifier.Verify(signature)
+}
+
+// decodeDSSEEnvelope returns the DSSE envelope that the signature encodes, or nil if it isn't a DSSE envelope
+func decodeDSSEEnvelope(signature string) *DSSEEnvelope {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil
+	}
+
+	envelope := &DSSEEnvelope{}
+	if err := json.Unmarshal(decoded, envelope); err != nil || envelope.PayloadType == "" || len(envelope.Signatures) == 0 {
+		return nil
+	}
+
+	return envelope
+}
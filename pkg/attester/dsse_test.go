@@ -0,0 +1,143 @@
+package attester
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// independentPAE builds the pre-authentication encoding as described by the DSSE protocol
+func independentPAE(payloadType string, payload []byte) []byte {
+	pae := []byte("DSSEv1 ")
+	pae = append(pae, strconv.Itoa(len(payloadType))...)
+	pae = append(pae, ' ')
+	pae = append(pae, payloadType...)
+	pae = append(pae, ' ')
+	pae = append(pae, strconv.Itoa(len(payload))...)
+	pae = append(pae, ' ')
+	return append(pae, payload...)
+}
+
+// independentVerify checks the envelope's signature with the public key using only the standard libraries
+func independentVerify(t *testing.T, signer Signer, envelope *DSSEEnvelope) error {
+	publicKey := &bytes.Buffer{}
+	assert.NoError(t, SerializePublicKey(signer, publicKey))
+
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	assert.NoError(t, err)
+	assert.Len(t, envelope.Signatures, 1)
+	sig, err := base64.StdEncoding.DecodeString(envelope.Signatures[0].Sig)
+	assert.NoError(t, err)
+	pae := independentPAE(envelope.PayloadType, payload)
+
+	if block, _ := pem.Decode(publicKey.Bytes()); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+		if !ed25519.Verify(key.(ed25519.PublicKey), pae, sig) {
+			return assert.AnError
+		}
+		return nil
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(publicKey)
+	assert.NoError(t, err)
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(pae), bytes.NewReader(sig))
+	return err
+}
+
+func TestPAE(t *testing.T) {
+	// the example from the DSSE protocol
+	assert.Equal(t, "DSSEv1 29 http://example.com/HelloWorld 11 hello world", string(PAE("http://example.com/HelloWorld", []byte("hello world"))))
+	assert.Equal(t, independentPAE(DSSEPayloadType, []byte("image\nstage=prod")), PAE(DSSEPayloadType, []byte("image\nstage=prod")))
+}
+
+func TestSignDSSE(t *testing.T) {
+	for _, keyType := range []rodev1alpha1.KeyType{rodev1alpha1.KeyTypePGPRSA, rodev1alpha1.KeyTypePGPECDSAP256, rodev1alpha1.KeyTypeEd25519} {
+		t.Run(string(keyType), func(t *testing.T) {
+			assert := assert.New(t)
+
+			signer, err := NewSignerWithKeyType("dsse", keyType)
+			assert.NoError(err)
+
+			envelope, err := SignDSSE(signer, DSSEPayloadType, []byte("image"))
+			assert.NoError(err)
+			assert.Equal(DSSEPayloadType, envelope.PayloadType)
+			assert.Equal(base64.StdEncoding.EncodeToString([]byte("image")), envelope.Payload)
+			assert.Equal(signer.KeyID(), envelope.Signatures[0].KeyID)
+			assert.NoError(independentVerify(t, signer, envelope))
+
+			payload, err := VerifyDSSE(signer, envelope)
+			assert.NoError(err)
+			assert.Equal("image", string(payload))
+
+			// the signature covers the payload type as well as the payload
+			retyped := *envelope
+			retyped.PayloadType = "application/json"
+			assert.Error(independentVerify(t, signer, &retyped))
+			_, err = VerifyDSSE(signer, &retyped)
+			assert.Error(err)
+
+			tampered := *envelope
+			tampered.Payload = base64.StdEncoding.EncodeToString([]byte("other"))
+			assert.Error(independentVerify(t, signer, &tampered))
+			_, err = VerifyDSSE(signer, &tampered)
+			assert.Error(err)
+
+			other, err := NewSignerWithKeyType("other", keyType)
+			assert.NoError(err)
+			_, err = VerifyDSSE(other, envelope)
+			assert.Error(err)
+		})
+	}
+}
+
+func TestAttester_DSSESignatureFormat(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	policy, err := NewPolicy(ctx, "dsse", "package dsse\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("dsse")
+	assert.NoError(err)
+	att := NewAttester("default/dsse", policy, signer, WithSignatureFormat(rodev1alpha1.SignatureFormatDSSE), WithStage("prod"))
+
+	res, err := att.Attest(ctx, &AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+	signed := res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation()
+	assert.Equal(signer.KeyID(), signed.GetPgpKeyId())
+
+	// the signature is a DSSE envelope of the attestation's body
+	decoded, err := base64.StdEncoding.DecodeString(signed.GetSignature())
+	assert.NoError(err)
+	envelope := &DSSEEnvelope{}
+	assert.NoError(json.Unmarshal(decoded, envelope))
+	assert.Equal(DSSEPayloadType, envelope.PayloadType)
+	assert.NoError(independentVerify(t, signer, envelope))
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	assert.NoError(err)
+	assert.Equal("image"+stageSeparator+"prod", string(payload))
+
+	// attestations are verified whichever format they were signed in
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation, Stage: "prod"}))
+	assert.Error(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation, Stage: "dev"}))
+	pgp := NewAttester("default/dsse", policy, signer)
+	assert.NoError(pgp.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation}))
+	pgpRes, err := pgp.Attest(ctx, &AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: pgpRes.Attestation}))
+
+	// an attestation for another resource doesn't verify
+	res.Attestation.Resource.Uri = "other"
+	assert.Error(att.Verify(ctx, &VerifyRequest{Occurrence: res.Attestation}))
+}
@@ -0,0 +1,161 @@
+package attester
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// pemPrivateKeyType is the PEM block type of a PKCS #8 private key
+	pemPrivateKeyType = "PRIVATE KEY"
+
+	// pemPublicKeyType is the PEM block type of a PKIX public key
+	pemPublicKeyType = "PUBLIC KEY"
+)
+
+// ed25519Envelope is the signed form of a message, since unlike a PGP signed message an Ed25519 signature doesn't
+// contain the message
+type ed25519Envelope struct {
+	KeyID     string `json:"keyId"`
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+type ed25519Signer struct {
+	publicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+}
+
+// newEd25519Signer generates an Ed25519 signer
+func newEd25519Signer() (Signer, error) {
+	if FIPSMode() {
+		return nil, fmt.Errorf("Ed25519 keys are not allowed in FIPS mode")
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ed25519Signer{
+		publicKey,
+		privateKey,
+	}, nil
+}
+
+// readEd25519Signer creates a signer from a PEM block containing a PKCS #8 Ed25519 private key
+func readEd25519Signer(block *pem.Block) (Signer, error) {
+	if FIPSMode() {
+		return nil, fmt.Errorf("Ed25519 keys are not allowed in FIPS mode")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key of type %T is not supported, only Ed25519 keys can be stored as PEM", key)
+	}
+
+	return &ed25519Signer{
+		privateKey.Public().(ed25519.PublicKey),
+		privateKey,
+	}, nil
+}
+
+// readEd25519Verifier creates a verifier from a PEM block containing a PKIX Ed25519 public key
+func readEd25519Verifier(block *pem.Block) (Verifier, error) {
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key of type %T is not supported, only Ed25519 keys can be stored as PEM", key)
+	}
+
+	return &ed25519Signer{
+		publicKey: publicKey,
+	}, nil
+}
+
+func (s *ed25519Signer) Sign(message string) (string, error) {
+	if s.privateKey == nil {
+		return "", fmt.Errorf("no private key to sign with")
+	}
+
+	envelope, err := json.Marshal(&ed25519Envelope{
+		KeyID:     s.KeyID(),
+		Payload:   []byte(message),
+		Signature: ed25519.Sign(s.privateKey, []byte(message)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+func (s *ed25519Signer) Verify(signedMessage string) (string, error) {
+	signedBytes, err := base64.StdEncoding.DecodeString(signedMessage)
+	if err != nil {
+		return "", err
+	}
+
+	envelope := &ed25519Envelope{}
+	if err := json.Unmarshal(signedBytes, envelope); err != nil {
+		return "", fmt.Errorf("signed message is not an Ed25519 envelope: %v", err)
+	}
+
+	if !ed25519.Verify(s.publicKey, envelope.Payload, envelope.Signature) {
+		return "", fmt.Errorf("signature is not valid for key %s", s.KeyID())
+	}
+
+	return string(envelope.Payload), nil
+}
+
+func (s *ed25519Signer) SignBytes(data []byte) ([]byte, error) {
+	if s.privateKey == nil {
+		return nil, fmt.Errorf("no private key to sign with")
+	}
+
+	return ed25519.Sign(s.privateKey, data), nil
+}
+
+func (s *ed25519Signer) VerifyBytes(data, signature []byte) error {
+	if !ed25519.Verify(s.publicKey, data, signature) {
+		return fmt.Errorf("signature is not valid for key %s", s.KeyID())
+	}
+
+	return nil
+}
+
+// KeyID is the first 8 bytes of the SHA-256 fingerprint of the public key, in the same form as a PGP key ID
+func (s *ed25519Signer) KeyID() string {
+	fingerprint := sha256.Sum256(s.publicKey)
+	return strings.ToUpper(fmt.Sprintf("%x", fingerprint[:8]))
+}
+
+func (s *ed25519Signer) Serialize(out io.Writer) error {
+	if s.privateKey == nil {
+		return fmt.Errorf("no private key to serialize")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(s.privateKey)
+	if err != nil {
+		return err
+	}
+
+	return pem.Encode(out, &pem.Block{Type: pemPrivateKeyType, Bytes: der})
+}
@@ -0,0 +1,106 @@
+package attester
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/shamir"
+)
+
+const (
+	// EscrowShareKey is the key in an escrow secret that holds the share of the private key
+	EscrowShareKey = "share"
+
+	// EscrowAttesterAnnotation records the namespace/name of the attester whose key an escrow secret holds a share of
+	EscrowAttesterAnnotation = "rode.liatr.io/escrow-attester"
+
+	// EscrowShareAnnotation records which share an escrow secret holds, and how many are needed to recover the key
+	EscrowShareAnnotation = "rode.liatr.io/escrow-share"
+
+	// SecretEscrowAnnotation records the escrow secrets on the secret holding an escrowed key
+	SecretEscrowAnnotation = "rode.liatr.io/escrow"
+)
+
+// EscrowKey splits the serialized private key into shares with Shamir's secret sharing and writes each share to one of
+// the attester's escrow secrets. The escrow secrets aren't owned by the attester, so the shares outlive the attester
+// and its key secret. An escrow secret that already exists is only overwritten if it holds a share for the same
+// attester. It returns the escrow secrets that were written.
+func EscrowKey(ctx context.Context, attester *rodev1alpha1.Attester, c client.Client, keys []byte) ([]types.NamespacedName, error) {
+	escrow := attester.Spec.KeyEscrow
+	if escrow == nil {
+		return nil, nil
+	}
+
+	shares, err := shamir.Split(keys, len(escrow.Secrets), escrow.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("unable to split the key for escrow: %v", err)
+	}
+
+	owner := types.NamespacedName{Namespace: attester.Namespace, Name: attester.Name}.String()
+	names := make([]types.NamespacedName, len(escrow.Secrets))
+	for i, ref := range escrow.Secrets {
+		names[i] = types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		if names[i].Namespace == "" {
+			names[i].Namespace = attester.Namespace
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: names[i].Namespace,
+				Name:      names[i].Name,
+				Annotations: map[string]string{
+					EscrowAttesterAnnotation: owner,
+					EscrowShareAnnotation:    fmt.Sprintf("%d of %d, %d required", i+1, len(shares), escrow.Threshold),
+				},
+			},
+			Data: map[string][]byte{EscrowShareKey: shares[i]},
+		}
+
+		if err := writeEscrowSecret(ctx, c, secret); err != nil {
+			return nil, fmt.Errorf("unable to write key share to secret %s: %v", names[i], err)
+		}
+	}
+
+	return names, nil
+}
+
+// EscrowDescription describes where a key was escrowed, for the annotation on the key's secret
+func EscrowDescription(threshold int, secrets []types.NamespacedName) string {
+	names := make([]string, len(secrets))
+	for i, secret := range secrets {
+		names[i] = secret.String()
+	}
+
+	return strconv.Itoa(threshold) + " of " + strings.Join(names, ",")
+}
+
+func writeEscrowSecret(ctx context.Context, c client.Client, secret *corev1.Secret) error {
+	err := c.Create(ctx, secret)
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	existing := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, existing); err != nil {
+		return err
+	}
+
+	// left over from an earlier attempt to generate the attester's key
+	if existing.Annotations[EscrowAttesterAnnotation] != secret.Annotations[EscrowAttesterAnnotation] {
+		return fmt.Errorf("secret already exists and doesn't hold a share for the attester")
+	}
+
+	existing.Annotations = secret.Annotations
+	existing.Data = secret.Data
+
+	return c.Update(ctx, existing)
+}
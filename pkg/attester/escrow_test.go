@@ -0,0 +1,117 @@
+package attester
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/shamir"
+)
+
+func newEscrowTestClient(objs ...runtime.Object) client.Client {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	_ = rodev1alpha1.AddToScheme(s)
+
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
+
+func newEscrowTestAttester() *rodev1alpha1.Attester {
+	return &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "escrowed"},
+		Spec: rodev1alpha1.AttesterSpec{
+			KeyEscrow: &rodev1alpha1.KeyEscrow{
+				Threshold: 2,
+				Secrets: []corev1.SecretReference{
+					{Name: "share-a"},
+					{Name: "share-b", Namespace: "security"},
+					{Name: "share-c", Namespace: "compliance"},
+				},
+			},
+		},
+	}
+}
+
+func TestNewSecret_EscrowsKey(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	att := newEscrowTestAttester()
+	c := newEscrowTestClient(att)
+
+	signer, err := NewSecret(ctx, att, c, types.NamespacedName{Namespace: "default", Name: "escrowed"}, nil)
+	assert.NoError(err)
+
+	keySecret := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "escrowed"}, keySecret))
+	assert.Equal("2 of default/share-a,security/share-b,compliance/share-c", keySecret.Annotations[SecretEscrowAnnotation])
+
+	var shares [][]byte
+	for _, name := range []types.NamespacedName{{Namespace: "default", Name: "share-a"}, {Namespace: "compliance", Name: "share-c"}} {
+		share := &corev1.Secret{}
+		assert.NoError(c.Get(ctx, name, share))
+		assert.Equal("default/escrowed", share.Annotations[EscrowAttesterAnnotation])
+		assert.Empty(share.OwnerReferences)
+		shares = append(shares, share.Data[EscrowShareKey])
+	}
+
+	keys, err := shamir.Combine(shares)
+	assert.NoError(err)
+	assert.Equal(keySecret.Data["keys"], keys)
+
+	recovered, err := ReadSigner(bytes.NewReader(keys))
+	assert.NoError(err)
+	assert.Equal(signer.KeyID(), recovered.KeyID())
+}
+
+func TestEscrowKey_OverwritesOwnShares(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	att := newEscrowTestAttester()
+	c := newEscrowTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "share-a",
+			Annotations: map[string]string{EscrowAttesterAnnotation: "default/escrowed"},
+		},
+		Data: map[string][]byte{EscrowShareKey: []byte("stale")},
+	})
+
+	names, err := EscrowKey(ctx, att, c, []byte("keys"))
+	assert.NoError(err)
+	assert.Len(names, 3)
+
+	share := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "share-a"}, share))
+	assert.NotEqual([]byte("stale"), share.Data[EscrowShareKey])
+}
+
+func TestEscrowKey_RefusesOtherSecrets(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	att := newEscrowTestAttester()
+	c := newEscrowTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "security", Name: "share-b"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	})
+
+	_, err := EscrowKey(ctx, att, c, []byte("keys"))
+	assert.Error(err)
+
+	existing := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, types.NamespacedName{Namespace: "security", Name: "share-b"}, existing))
+	assert.Equal([]byte("hunter2"), existing.Data["password"])
+
+	_, err = NewSecret(ctx, att, c, types.NamespacedName{Namespace: "default", Name: "escrowed"}, nil)
+	assert.Error(err)
+	assert.Error(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "escrowed"}, &corev1.Secret{}))
+}
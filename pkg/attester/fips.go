@@ -0,0 +1,105 @@
+package attester
+
+import (
+	"crypto"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// minimumFIPSRSABits is the smallest RSA key size accepted in FIPS mode
+const minimumFIPSRSABits = 2048
+
+var (
+	// fipsCapable is set when the binary is built with a FIPS-validated crypto provider (BoringCrypto)
+	fipsCapable = false
+
+	fipsMutex   sync.RWMutex
+	fipsEnabled = false
+)
+
+// FIPSCapable returns true if the binary was built with a FIPS-validated crypto provider
+func FIPSCapable() bool {
+	return fipsCapable
+}
+
+// SetFIPSMode enables or disables FIPS mode. In FIPS mode, keys and hashes that aren't FIPS approved are rejected when
+// generating or loading signers. FIPS mode can only be enabled in binaries built with BoringCrypto.
+func SetFIPSMode(enabled bool) error {
+	if enabled && !fipsCapable {
+		return fmt.Errorf("FIPS mode requires a binary built with BoringCrypto")
+	}
+
+	fipsMutex.Lock()
+	defer fipsMutex.Unlock()
+
+	fipsEnabled = enabled
+	return nil
+}
+
+// FIPSMode returns true if FIPS mode is enabled
+func FIPSMode() bool {
+	fipsMutex.RLock()
+	defer fipsMutex.RUnlock()
+
+	return fipsEnabled
+}
+
+// checkFIPSEntity returns an error if FIPS mode is enabled and the entity's signing key or hash isn't FIPS approved
+func checkFIPSEntity(entity *openpgp.Entity, hash crypto.Hash) error {
+	if !FIPSMode() {
+		return nil
+	}
+
+	if hash != crypto.SHA256 && hash != crypto.SHA384 && hash != crypto.SHA512 {
+		return fmt.Errorf("hash %v is not allowed in FIPS mode", hash)
+	}
+
+	publicKey := entity.PrimaryKey
+	switch publicKey.PubKeyAlgo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSASignOnly:
+		bits, err := publicKey.BitLength()
+		if err != nil {
+			return err
+		}
+		if bits < minimumFIPSRSABits {
+			return fmt.Errorf("RSA key size %d is below the FIPS minimum of %d", bits, minimumFIPSRSABits)
+		}
+	case packet.PubKeyAlgoECDSA:
+	default:
+		return fmt.Errorf("public key algorithm %v is not allowed in FIPS mode", publicKey.PubKeyAlgo)
+	}
+
+	return nil
+}
+
+// SelfTest generates a signer and checks that it can sign and verify a message, returning an error if the crypto
+// provider isn't working or isn't in the expected mode
+func SelfTest(requireFIPS bool) error {
+	if requireFIPS && !FIPSMode() {
+		return fmt.Errorf("FIPS mode is not active")
+	}
+
+	signer, err := NewSigner("selftest")
+	if err != nil {
+		return fmt.Errorf("unable to generate signer: %v", err)
+	}
+
+	message := "rode selftest"
+	signedMessage, err := signer.Sign(message)
+	if err != nil {
+		return fmt.Errorf("unable to sign message: %v", err)
+	}
+
+	verifiedMessage, err := signer.Verify(signedMessage)
+	if err != nil {
+		return fmt.Errorf("unable to verify message: %v", err)
+	}
+	if verifiedMessage != message {
+		return fmt.Errorf("verified message doesn't match")
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+// +build boringcrypto
+
+package attester
+
+import (
+	// restrict TLS to FIPS-approved settings
+	_ "crypto/tls/fipsonly"
+)
+
+func init() {
+	fipsCapable = true
+}
@@ -0,0 +1,49 @@
+package attester
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestSetFIPSMode(t *testing.T) {
+	assert := assert.New(t)
+
+	err := SetFIPSMode(true)
+	if FIPSCapable() {
+		assert.NoError(err)
+		assert.True(FIPSMode())
+		assert.NoError(SetFIPSMode(false))
+	} else {
+		assert.Error(err)
+		assert.False(FIPSMode())
+	}
+}
+
+func TestCheckFIPSEntity(t *testing.T) {
+	assert := assert.New(t)
+
+	weak, err := openpgp.NewEntity("weak", "", "", &packet.Config{RSABits: 1024})
+	assert.NoError(err)
+	strong, err := openpgp.NewEntity("strong", "", "", &packet.Config{RSABits: 2048})
+	assert.NoError(err)
+
+	assert.NoError(checkFIPSEntity(weak, crypto.SHA1), "FIPS mode disabled")
+
+	fipsEnabled = true
+	defer func() { fipsEnabled = false }()
+
+	assert.Error(checkFIPSEntity(weak, crypto.SHA256))
+	assert.Error(checkFIPSEntity(strong, crypto.SHA1))
+	assert.NoError(checkFIPSEntity(strong, crypto.SHA256))
+}
+
+func TestSelfTest(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(SelfTest(false))
+	assert.Error(SelfTest(true))
+}
@@ -0,0 +1,245 @@
+package attester
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+// PolicyRepositoryProtocols are the protocols that policy repositories can be fetched over by default, leaving out file,
+// which would let attesters read the manager's filesystem, and the unauthenticated git and http
+var PolicyRepositoryProtocols = []string{"https", "ssh"}
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// CheckPolicyRepositoryURL returns an error if the URL of a policy repository isn't fetched over one of the protocols
+func CheckPolicyRepositoryURL(url string, protocols []string) error {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return fmt.Errorf("invalid policy repository URL %s: %v", url, err)
+	}
+
+	for _, protocol := range protocols {
+		if endpoint.Protocol == protocol {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("policy repository URL %s uses protocol %s, must be one of %v", url, endpoint.Protocol, protocols)
+}
+
+// PolicyRepositories fetches the Git repositories that attesters read their policies from, with a Git client written in
+// Go so that it doesn't need the git command. Each repository is fetched into a bare repository of its own under a
+// directory, and its Rego modules are read from the fetched commit without checking it out.
+type PolicyRepositories struct {
+	dir       string
+	protocols []string
+
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// PolicyRepositoriesOption configures policy repositories
+type PolicyRepositoriesOption func(*PolicyRepositories)
+
+// WithRepositoryProtocols sets the protocols that policy repositories can be fetched over, in place of
+// PolicyRepositoryProtocols, such as file for repositories on the local filesystem in tests
+func WithRepositoryProtocols(protocols ...string) PolicyRepositoriesOption {
+	return func(p *PolicyRepositories) {
+		p.protocols = protocols
+	}
+}
+
+// NewPolicyRepositories creates policy repositories that are fetched into the directory, which is created when the
+// first repository is fetched
+func NewPolicyRepositories(dir string, opts ...PolicyRepositoriesOption) *PolicyRepositories {
+	p := &PolicyRepositories{
+		dir:       dir,
+		protocols: PolicyRepositoryProtocols,
+		locks:     make(map[string]*sync.Mutex),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Fetch fetches the ref of the repository at the URL, which is the repository's default branch when the ref is empty,
+// and returns the commit that it points to. The ref is a branch, a tag, the full name of a reference or a commit.
+func (p *PolicyRepositories) Fetch(ctx context.Context, url, ref string) (string, error) {
+	if err := CheckPolicyRepositoryURL(url, p.protocols); err != nil {
+		return "", err
+	}
+
+	dir, unlock := p.lock(url)
+	defer unlock()
+
+	repo, err := git.PlainOpen(dir)
+	if err == git.ErrRepositoryNotExists {
+		repo, err = git.PlainInit(dir, true)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	remote := git.NewRemote(repo.Storer, &config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}})
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to list the references of %s: %v", url, err)
+	}
+
+	var refSpecs []config.RefSpec
+	hash, name, ok := resolveRemoteRef(refs, ref)
+	switch {
+	case ok:
+		refSpecs = []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", name, name))}
+	case commitPattern.MatchString(ref):
+		hash = plumbing.NewHash(ref)
+		refSpecs = []config.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"}
+	default:
+		return "", fmt.Errorf("ref %s not found in %s", ref, url)
+	}
+
+	err = remote.FetchContext(ctx, &git.FetchOptions{RefSpecs: refSpecs, Tags: git.NoTags})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return "", fmt.Errorf("unable to fetch %s from %s: %v", ref, url, err)
+	}
+
+	commit, err := peelCommit(repo, hash)
+	if err != nil {
+		return "", fmt.Errorf("ref %s of %s isn't a commit: %v", ref, url, err)
+	}
+
+	return commit.Hash.String(), nil
+}
+
+// Modules returns the Rego modules under the path of a commit fetched from the repository at the URL, keyed by their
+// paths relative to it. Modules whose names end in _test.rego hold the policy's tests, so they're left out.
+func (p *PolicyRepositories) Modules(ctx context.Context, url, commit, dirPath string) (map[string]string, error) {
+	dir, unlock := p.lock(url)
+	defer unlock()
+
+	prefix := strings.Trim(path.Clean("/"+dirPath), "/")
+	notFound := func() error {
+		if prefix == "" {
+			prefix = "/"
+		}
+		return fmt.Errorf("no Rego modules in %s at commit %s of %s", prefix, commit, url)
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, err
+	}
+	fetched, err := repo.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read commit %s of %s: %v", commit, url, err)
+	}
+	tree, err := fetched.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		tree, err = tree.Tree(prefix)
+		if err == object.ErrDirectoryNotFound {
+			return nil, notFound()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	modules := make(map[string]string)
+	err = tree.Files().ForEach(func(file *object.File) error {
+		if !strings.HasSuffix(file.Name, ".rego") || strings.HasSuffix(file.Name, "_test.rego") {
+			return nil
+		}
+
+		module, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		modules[file.Name] = module
+		return nil
+	})
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(modules) == 0 {
+		return nil, notFound()
+	}
+
+	return modules, nil
+}
+
+// lock locks the bare repository that the repository at the URL is fetched into, returning its directory and the
+// function that unlocks it
+func (p *PolicyRepositories) lock(url string) (string, func()) {
+	hash := sha256.Sum256([]byte(url))
+	dir := filepath.Join(p.dir, hex.EncodeToString(hash[:8]))
+
+	p.mutex.Lock()
+	lock, ok := p.locks[dir]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.locks[dir] = lock
+	}
+	p.mutex.Unlock()
+
+	lock.Lock()
+	return dir, lock.Unlock
+}
+
+// resolveRemoteRef returns the hash and the name of the reference that the ref refers to among a remote's references,
+// the way git resolves a ref on the command line, following HEAD to the branch that it points to
+func resolveRemoteRef(refs []*plumbing.Reference, ref string) (plumbing.Hash, plumbing.ReferenceName, bool) {
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, r := range refs {
+		byName[r.Name()] = r
+	}
+
+	if ref == "" {
+		ref = string(plumbing.HEAD)
+	}
+	for _, name := range []string{ref, "refs/" + ref, "refs/tags/" + ref, "refs/heads/" + ref} {
+		r, ok := byName[plumbing.ReferenceName(name)]
+		for i := 0; ok && r.Type() == plumbing.SymbolicReference && i < 10; i++ {
+			r, ok = byName[r.Target()]
+		}
+		if ok && r.Type() == plumbing.HashReference {
+			return r.Hash(), r.Name(), true
+		}
+	}
+
+	return plumbing.ZeroHash, "", false
+}
+
+// peelCommit returns the commit of the hash, which is either a commit or an annotated tag of one
+func peelCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != plumbing.ErrObjectNotFound {
+		return commit, err
+	}
+
+	tag, err := repo.TagObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag.Commit()
+}
@@ -0,0 +1,107 @@
+package attester
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liatrio/rode/pkg/test"
+)
+
+func TestPolicyRepositories(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "policy-repositories")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	repo := test.NewGitRepository(t, dir)
+	first := repo.Push("main", map[string]string{
+		"README.md":                      "policies",
+		"policies/gitpolicy.rego":        normalizedPolicy,
+		"policies/lib/helpers.rego":      "package helpers\nallowed = true\n",
+		"policies/gitpolicy_test.rego":   "package gitpolicy\ntest_allowed { true }\n",
+		"other/unrelated.rego":           "package unrelated\n",
+		"policies/lib/testdata/data.txt": "data",
+	})
+
+	repositories := NewPolicyRepositories(filepath.Join(dir, "cache"), WithRepositoryProtocols("file"))
+
+	// the default branch is fetched when there's no ref, and modules outside the path, and tests, are left out
+	commit, err := repositories.Fetch(ctx, repo.URL, "")
+	assert.NoError(err)
+	assert.Equal(first, commit)
+
+	modules, err := repositories.Modules(ctx, repo.URL, commit, "policies/")
+	assert.NoError(err)
+	assert.Equal(map[string]string{
+		"gitpolicy.rego":   normalizedPolicy,
+		"lib/helpers.rego": "package helpers\nallowed = true\n",
+	}, modules)
+
+	all, err := repositories.Modules(ctx, repo.URL, commit, "")
+	assert.NoError(err)
+	assert.Len(all, 3)
+	assert.Contains(all, "other/unrelated.rego")
+
+	// pulling the repository again picks up the commits pushed since
+	second := repo.Push("main", map[string]string{"policies/gitpolicy.rego": normalizedPolicy + "\n"})
+	commit, err = repositories.Fetch(ctx, repo.URL, "main")
+	assert.NoError(err)
+	assert.Equal(second, commit)
+	modules, err = repositories.Modules(ctx, repo.URL, commit, "policies")
+	assert.NoError(err)
+	assert.Equal(normalizedPolicy+"\n", modules["gitpolicy.rego"])
+
+	// other branches are fetched by name, and the commits fetched before can still be read
+	staged := repo.Push("staging", map[string]string{"policies/gitpolicy.rego": "package gitpolicy\n"})
+	commit, err = repositories.Fetch(ctx, repo.URL, "staging")
+	assert.NoError(err)
+	assert.Equal(staged, commit)
+	modules, err = repositories.Modules(ctx, repo.URL, first, "policies")
+	assert.NoError(err)
+	assert.Equal(normalizedPolicy, modules["gitpolicy.rego"])
+
+	// paths without modules, refs that don't exist, and repositories that don't exist are errors
+	_, err = repositories.Modules(ctx, repo.URL, commit, "missing")
+	assert.EqualError(err, "no Rego modules in missing at commit "+staged+" of "+repo.URL)
+	_, err = repositories.Fetch(ctx, repo.URL, "missing")
+	assert.Error(err)
+	_, err = repositories.Fetch(ctx, filepath.Join(dir, "missing.git"), "")
+	assert.Error(err)
+
+	// commits are fetched by their hash
+	commit, err = repositories.Fetch(ctx, repo.URL, first)
+	assert.NoError(err)
+	assert.Equal(first, commit)
+
+	// repositories on the local filesystem can't be fetched by default
+	_, err = NewPolicyRepositories(filepath.Join(dir, "cache")).Fetch(ctx, repo.URL, "")
+	assert.EqualError(err, "policy repository URL "+repo.URL+" uses protocol file, must be one of [https ssh]")
+}
+
+func TestCheckPolicyRepositoryURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/liatrio/rode-policies.git":   "",
+		"ssh://git@github.com/liatrio/rode-policies.git": "",
+		"git@github.com:liatrio/rode-policies.git":       "",
+		"/etc/rode":                      "policy repository URL /etc/rode uses protocol file, must be one of [https ssh]",
+		"file:///etc/rode":               "policy repository URL file:///etc/rode uses protocol file, must be one of [https ssh]",
+		"http://github.com/liatrio/rode": "policy repository URL http://github.com/liatrio/rode uses protocol http, must be one of [https ssh]",
+		"git://github.com/liatrio/rode":  "policy repository URL git://github.com/liatrio/rode uses protocol git, must be one of [https ssh]",
+	}
+
+	for url, expected := range tests {
+		t.Run(url, func(t *testing.T) {
+			err := CheckPolicyRepositoryURL(url, PolicyRepositoryProtocols)
+			if expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package attester
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+
+	"github.com/liatrio/rode/pkg/auth"
+	"github.com/liatrio/rode/pkg/occurrence"
+)
+
+// AttestationPathPrefix is the path that the attestation handler is served under
+const AttestationPathPrefix = "/v1/attesters/"
+
+type attestationHandler struct {
+	log              logr.Logger
+	occurrenceLister occurrence.Lister
+	attesterLister   Lister
+	authorizer       auth.Authorizer
+}
+
+// NewAttestationHandler creates a handler that serves GET /v1/attesters/{name}/attestation?subject=<resource uri>,
+// returning the attester's most recent attestation for the subject. Callers must be allowed to get the Attester object.
+// The namespace query parameter selects the attester's namespace when attesters in several namespaces share the name.
+func NewAttestationHandler(log logr.Logger, occurrenceLister occurrence.Lister, attesterLister Lister, authorizer auth.Authorizer) http.Handler {
+	return &attestationHandler{
+		log,
+		occurrenceLister,
+		attesterLister,
+		authorizer,
+	}
+}
+
+func (h *attestationHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(request.URL.Path, AttestationPathPrefix), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "attestation" {
+		http.NotFound(writer, request)
+		return
+	}
+	name := parts[0]
+
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	subject := request.URL.Query().Get("subject")
+	if !strings.Contains(subject, "@") {
+		http.Error(writer, "subject must be an image reference with a digest", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.authorizer.Authenticate(request)
+	if err == auth.ErrUnauthenticated {
+		http.Error(writer, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.log.Error(err, "Unable to authenticate request")
+		http.Error(writer, "unable to authenticate request", http.StatusInternalServerError)
+		return
+	}
+
+	key, att, err := FindAttester(h.attesterLister, name, request.URL.Query().Get("namespace"))
+	if err == ErrAttesterNotFound {
+		http.Error(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == ErrAmbiguousAttester {
+		http.Error(writer, "attesters in several namespaces have the name, set the namespace parameter", http.StatusConflict)
+		return
+	}
+	namespace := strings.SplitN(key, "/", 2)[0]
+
+	err = h.authorizer.Authorize(request.Context(), user, &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "get",
+		Group:     "rode.liatr.io",
+		Resource:  "attesters",
+		Name:      name,
+	})
+	if _, ok := err.(auth.ForbiddenError); ok {
+		http.Error(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		h.log.Error(err, "Unable to authorize request", "user", user.Username)
+		http.Error(writer, "unable to authorize request", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := h.occurrenceLister.ListOccurrences(request.Context(), subject)
+	if err != nil {
+		h.log.Error(err, "Unable to list occurrences", "uri", subject)
+		http.Error(writer, "unable to list occurrences", http.StatusBadGateway)
+		return
+	}
+
+	stored := FindAttestation(request.Context(), att, subject, resp.GetOccurrences())
+	if stored == nil {
+		http.Error(writer, "no attestation found for subject", http.StatusNotFound)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(writer).Encode(stored)
+}
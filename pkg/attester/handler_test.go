@@ -0,0 +1,118 @@
+package attester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/liatrio/rode/pkg/auth"
+)
+
+// fakeAuthorizer authenticates the "reader" token and allows it to get attesters in the default namespace
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Authenticate(req *http.Request) (*authenticationv1.UserInfo, error) {
+	if req.Header.Get("Authorization") != "Bearer reader" {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	return &authenticationv1.UserInfo{Username: "reader"}, nil
+}
+
+func (fakeAuthorizer) Authorize(ctx context.Context, user *authenticationv1.UserInfo, resource *authorizationv1.ResourceAttributes) error {
+	if resource.Namespace != "default" || resource.Verb != "get" || resource.Resource != "attesters" {
+		return auth.ForbiddenError{User: user.Username}
+	}
+
+	return nil
+}
+
+func TestAttestationHandler(t *testing.T) {
+	ctx := context.Background()
+	subject := "harbor.liatr.io/rode/app@sha256:b88ca0f4a7fe2c67fc8bdb67ad405f418ca0be6aec442c79ae32253138da2dd5"
+
+	policy, err := NewPolicy(ctx, "handler", "package handler\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(t, err)
+	signer, err := NewSigner("handler")
+	assert.NoError(t, err)
+	otherSigner, err := NewSigner("other")
+	assert.NoError(t, err)
+	unusedSigner, err := NewSigner("unused")
+	assert.NoError(t, err)
+
+	att := NewAttester("default/handler", policy, signer, WithStage("prod"))
+	res, err := att.Attest(ctx, &AttestRequest{ResourceURI: subject})
+	assert.NoError(t, err)
+	res.Attestation.CreateTime, _ = ptypes.TimestampProto(time.Unix(1600000000, 0))
+
+	older, err := att.Attest(ctx, &AttestRequest{ResourceURI: subject, Stage: "dev"})
+	assert.NoError(t, err)
+	older.Attestation.CreateTime, _ = ptypes.TimestampProto(time.Unix(1500000000, 0))
+
+	forged, err := NewAttester("default/handler", policy, otherSigner).Attest(ctx, &AttestRequest{ResourceURI: subject})
+	assert.NoError(t, err)
+
+	client := &fakeOccurrenceClient{occurrences: []*grafeas.Occurrence{forged.Attestation, res.Attestation, older.Attestation}}
+	attesters := fakeAttesterLister{
+		"default/handler": att,
+		"prod/handler":    att,
+		"prod/other":      NewAttester("prod/other", policy, otherSigner),
+		"default/unused":  NewAttester("default/unused", policy, unusedSigner),
+	}
+	handler := NewAttestationHandler(logf.NullLogger{}, client, attesters, fakeAuthorizer{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"found", http.MethodGet, "/v1/attesters/handler/attestation?namespace=default&subject=" + subject, "reader", http.StatusOK},
+		{"unauthenticated", http.MethodGet, "/v1/attesters/handler/attestation?namespace=default&subject=" + subject, "", http.StatusUnauthorized},
+		{"forbidden", http.MethodGet, "/v1/attesters/other/attestation?subject=" + subject, "reader", http.StatusForbidden},
+		{"ambiguous", http.MethodGet, "/v1/attesters/handler/attestation?subject=" + subject, "reader", http.StatusConflict},
+		{"unknown attester", http.MethodGet, "/v1/attesters/missing/attestation?subject=" + subject, "reader", http.StatusNotFound},
+		{"no attestation", http.MethodGet, "/v1/attesters/unused/attestation?subject=" + subject, "reader", http.StatusNotFound},
+		{"no digest", http.MethodGet, "/v1/attesters/handler/attestation?namespace=default&subject=harbor.liatr.io/rode/app:1.0", "reader", http.StatusBadRequest},
+		{"read only", http.MethodPost, "/v1/attesters/handler/attestation?namespace=default&subject=" + subject, "reader", http.StatusMethodNotAllowed},
+		{"unknown path", http.MethodGet, "/v1/attesters/handler/attestations", "reader", http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(tc.status, rec.Code, rec.Body.String())
+
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			stored := &StoredAttestation{}
+			assert.NoError(json.NewDecoder(rec.Body).Decode(stored))
+			assert.Equal("default/handler", stored.Attester)
+			assert.Equal(subject, stored.Subject)
+			assert.Equal(signer.KeyID(), stored.KeyID)
+			assert.Equal(subject+"\nstage=prod", stored.Payload)
+			assert.Equal(res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetSignature(), stored.Signature)
+			assert.Equal(int64(1600000000), stored.CreateTime.Unix())
+		})
+	}
+}
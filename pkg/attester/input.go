@@ -0,0 +1,118 @@
+package attester
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	attestation "github.com/grafeas/grafeas/proto/v1beta1/attestation_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+)
+
+// WithInputSigners requires the occurrences the attester evaluates to be signed by one of the verifiers. Occurrences
+// without a valid signature are rejected and left out of the policy's input.
+func WithInputSigners(verifiers []Verifier) AttesterOption {
+	return func(a *attester) {
+		a.requireSignedInput = true
+		a.inputSigners = verifiers
+	}
+}
+
+// InputSignatureBody returns the body that is signed to vouch for an input occurrence. It identifies the occurrence by
+// name and by a hash of its content, so the signature doesn't carry over to a modified occurrence.
+func InputSignatureBody(occurrence *grafeas.Occurrence) (string, error) {
+	content := &grafeas.Occurrence{
+		Name:        occurrence.Name,
+		Resource:    occurrence.Resource,
+		NoteName:    occurrence.NoteName,
+		Kind:        occurrence.Kind,
+		Remediation: occurrence.Remediation,
+		Details:     occurrence.Details,
+	}
+
+	buf := new(bytes.Buffer)
+	if err := (&jsonpb.Marshaler{}).Marshal(buf, content); err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(buf.Bytes())
+	return fmt.Sprintf("occurrence=%s\nsha256=%s", occurrence.Name, hex.EncodeToString(hash[:])), nil
+}
+
+// SignInput creates an attestation occurrence under the note that vouches for the input occurrence, for a scanner or
+// other trusted source to store alongside the occurrences it creates
+func SignInput(signer Signer, occurrence *grafeas.Occurrence, noteName string) (*grafeas.Occurrence, error) {
+	body, err := InputSignatureBody(occurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signer.Sign(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grafeas.Occurrence{
+		NoteName: noteName,
+		Resource: occurrence.Resource,
+		Details: &grafeas.Occurrence_Attestation{
+			Attestation: &attestation.Details{
+				Attestation: &attestation.Attestation{
+					Signature: &attestation.Attestation_PgpSignedAttestation{
+						PgpSignedAttestation: &attestation.PgpSignedAttestation{
+							ContentType: attestation.PgpSignedAttestation_CONTENT_TYPE_UNSPECIFIED,
+							Signature:   sig,
+							KeyId: &attestation.PgpSignedAttestation_PgpKeyId{
+								PgpKeyId: signer.KeyID(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// filterSignedInput returns the occurrences that are attestations or are vouched for by an attestation signed by one
+// of the verifiers, along with the names of the occurrences that were rejected
+func filterSignedInput(occurrences []*grafeas.Occurrence, verifiers []Verifier) ([]*grafeas.Occurrence, []string) {
+	// the bodies signed by a trusted verifier
+	signed := make(map[string]bool)
+	for _, occ := range occurrences {
+		pgp := occ.GetAttestation().GetAttestation().GetPgpSignedAttestation()
+		if pgp == nil {
+			continue
+		}
+
+		for _, verifier := range verifiers {
+			if verifier.KeyID() != pgp.GetPgpKeyId() {
+				continue
+			}
+
+			if body, err := verifyAttestationSignature(verifier, pgp.GetSignature()); err == nil {
+				signed[body] = true
+			}
+		}
+	}
+
+	trusted := make([]*grafeas.Occurrence, 0, len(occurrences))
+	var rejected []string
+	for _, occ := range occurrences {
+		if occ.GetAttestation() != nil {
+			trusted = append(trusted, occ)
+			continue
+		}
+
+		body, err := InputSignatureBody(occ)
+		if err != nil || !signed[body] {
+			rejected = append(rejected, occ.Name)
+			continue
+		}
+
+		trusted = append(trusted, occ)
+	}
+
+	return trusted, rejected
+}
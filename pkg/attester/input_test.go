@@ -0,0 +1,74 @@
+package attester
+
+import (
+	"fmt"
+	"testing"
+
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+func TestAttester_RequireSignedInput(t *testing.T) {
+	assert := assert.New(t)
+
+	attesterName = fmt.Sprintf("attester%s", rand.String(10))
+	policy, err := NewPolicy(ctx, attesterName, fmt.Sprintf(`
+package %s
+violation[{"msg":"analysis not performed"}]{
+	count([s | s := input.occurrences[_].discovered.discovered.analysisStatus; s == "FINISHED_SUCCESS"]) = 0
+}
+`, attesterName), false)
+	assert.NoError(err)
+	signer, err := NewSigner(attesterName)
+	assert.NoError(err)
+	scanner, err := NewSigner("scanner")
+	assert.NoError(err)
+	untrusted, err := NewSigner("untrusted")
+	assert.NoError(err)
+
+	att := NewAttester(attesterName, policy, signer, WithInputSigners([]Verifier{scanner}))
+	scan := newDiscoveryOccurrence("projects/rode/occurrences/scan", discovery.Discovered_FINISHED_SUCCESS)
+
+	// unsigned evidence is rejected
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: "image", Occurrences: []*grafeas.Occurrence{scan}})
+	if assert.IsType(ViolationError{}, err) {
+		assert.Equal([]string{"projects/rode/occurrences/scan"}, err.(ViolationError).RejectedInputs)
+	}
+	assert.Equal([]string{"projects/rode/occurrences/scan"}, newDecision(attesterName, "image", nil, err).RejectedInputs)
+
+	// evidence signed by an untrusted key is rejected
+	untrustedSignature, err := SignInput(untrusted, scan, "projects/rode/notes/scanner")
+	assert.NoError(err)
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: "image", Occurrences: []*grafeas.Occurrence{scan, untrustedSignature}})
+	assert.IsType(ViolationError{}, err)
+
+	// evidence signed by the scanner is accepted
+	signature, err := SignInput(scanner, scan, "projects/rode/notes/scanner")
+	assert.NoError(err)
+	res, err := att.Attest(ctx, &AttestRequest{ResourceURI: "image", Occurrences: []*grafeas.Occurrence{scan, signature}})
+	assert.NoError(err)
+	assert.Empty(res.RejectedInputs)
+
+	// evidence modified after it was signed is rejected
+	forged := newDiscoveryOccurrence("projects/rode/occurrences/scan", discovery.Discovered_FINISHED_FAILED)
+	scan.Details = forged.Details
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: "image", Occurrences: []*grafeas.Occurrence{scan, signature}})
+	assert.IsType(ViolationError{}, err)
+}
+
+func newDiscoveryOccurrence(name string, status discovery.Discovered_AnalysisStatus) *grafeas.Occurrence {
+	return &grafeas.Occurrence{
+		Name:     name,
+		Resource: &grafeas.Resource{Uri: "image"},
+		NoteName: "projects/rode/notes/scanner",
+		Details: &grafeas.Occurrence_Discovered{
+			Discovered: &discovery.Details{
+				Discovered: &discovery.Discovered{
+					AnalysisStatus: status,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package attester
+
+import (
+	"io"
+)
+
+// KeyRing is a signer read from a secret holding several PGP keys, such as while transitioning from an old key to a new
+// one. It signs with its primary key, the first key in the secret, and accepts signatures made by any of its keys.
+type KeyRing interface {
+	Signer
+
+	// Verifiers returns a verifier for each of the keys, starting with the primary key
+	Verifiers() []Verifier
+}
+
+type keyRing struct {
+	// signer is the primary key, which signs and identifies the key ring
+	*signer
+
+	// keys are all of the keys, starting with the primary key
+	keys []*signer
+}
+
+// newKeyRing returns a signer that signs with the first of the keys and verifies with any of them, or the key itself
+// if there's only one
+func newKeyRing(keys []*signer) Signer {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+
+	return &keyRing{
+		signer: keys[0],
+		keys:   keys,
+	}
+}
+
+func (k *keyRing) Verifiers() []Verifier {
+	verifiers := make([]Verifier, 0, len(k.keys))
+	for _, key := range k.keys {
+		verifiers = append(verifiers, key)
+	}
+
+	return verifiers
+}
+
+// Verify returns the message signed by any of the keys. The primary key's error is returned if none of them signed it.
+func (k *keyRing) Verify(signedMessage string) (string, error) {
+	var primaryErr error
+	for _, key := range k.keys {
+		message, err := key.Verify(signedMessage)
+		if err == nil {
+			return message, nil
+		}
+		if primaryErr == nil {
+			primaryErr = err
+		}
+	}
+
+	return "", primaryErr
+}
+
+// VerifyBytes checks that the signature of the data was made by any of the keys
+func (k *keyRing) VerifyBytes(data, signature []byte) error {
+	var primaryErr error
+	for _, key := range k.keys {
+		err := key.VerifyBytes(data, signature)
+		if err == nil {
+			return nil
+		}
+		if primaryErr == nil {
+			primaryErr = err
+		}
+	}
+
+	return primaryErr
+}
+
+// Serialize writes all of the private keys, starting with the primary key, so that the key ring is read back as it is
+func (k *keyRing) Serialize(out io.Writer) error {
+	for _, key := range k.keys {
+		if err := key.Serialize(out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// signerVerifiers returns the verifiers for each of the signer's keys, starting with the key it signs with
+func signerVerifiers(s Signer) []Verifier {
+	if ring, ok := s.(KeyRing); ok {
+		return ring.Verifiers()
+	}
+
+	return []Verifier{s}
+}
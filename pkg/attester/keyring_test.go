@@ -0,0 +1,136 @@
+package attester
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestKeyRing returns the serialized keys of a new and an old signer, with the new key first
+func newTestKeyRing(t *testing.T) (Signer, Signer, []byte) {
+	newKey, err := NewSigner("new")
+	assert.NoError(t, err)
+	oldKey, err := NewSigner("old")
+	assert.NoError(t, err)
+
+	keys := &bytes.Buffer{}
+	assert.NoError(t, newKey.Serialize(keys))
+	assert.NoError(t, oldKey.Serialize(keys))
+
+	return newKey, oldKey, keys.Bytes()
+}
+
+func TestReadSigner_KeyRing(t *testing.T) {
+	assert := assert.New(t)
+
+	newKey, oldKey, keys := newTestKeyRing(t)
+	ring, err := ReadSigner(bytes.NewReader(keys))
+	assert.NoError(err)
+
+	// the first key is the primary key, which signs
+	assert.Equal(newKey.KeyID(), ring.KeyID())
+	signed, err := ring.Sign("payload")
+	assert.NoError(err)
+	_, err = newKey.Verify(signed)
+	assert.NoError(err)
+	_, err = oldKey.Verify(signed)
+	assert.Error(err)
+
+	signature, err := ring.SignBytes([]byte("payload"))
+	assert.NoError(err)
+	assert.NoError(newKey.VerifyBytes([]byte("payload"), signature))
+
+	// signatures by either key are accepted
+	for _, key := range []Signer{newKey, oldKey} {
+		signed, err := key.Sign("payload")
+		assert.NoError(err)
+		payload, err := ring.Verify(signed)
+		assert.NoError(err)
+		assert.Equal("payload", payload)
+
+		signature, err := key.SignBytes([]byte("payload"))
+		assert.NoError(err)
+		assert.NoError(ring.VerifyBytes([]byte("payload"), signature))
+	}
+
+	other, err := NewSigner("other")
+	assert.NoError(err)
+	signed, err = other.Sign("payload")
+	assert.NoError(err)
+	_, err = ring.Verify(signed)
+	assert.Error(err)
+
+	verifiers := ring.(KeyRing).Verifiers()
+	assert.Len(verifiers, 2)
+	assert.Equal(newKey.KeyID(), verifiers[0].KeyID())
+	assert.Equal(oldKey.KeyID(), verifiers[1].KeyID())
+
+	// the public key is the primary key's
+	publicKey := &bytes.Buffer{}
+	assert.NoError(SerializePublicKey(ring, publicKey))
+	verifier, err := ReadVerifier(publicKey)
+	assert.NoError(err)
+	assert.Equal(newKey.KeyID(), verifier.KeyID())
+}
+
+func TestReadSigner_KeyRingWithPassphrase(t *testing.T) {
+	assert := assert.New(t)
+
+	newKey, oldKey, keys := newTestKeyRing(t)
+	ring, err := ReadSigner(bytes.NewReader(keys))
+	assert.NoError(err)
+
+	encrypted := &bytes.Buffer{}
+	assert.NoError(SerializeWithPassphrase(ring, encrypted, []byte("passphrase")))
+	_, err = ReadSigner(bytes.NewReader(encrypted.Bytes()))
+	assert.Error(err)
+
+	read, err := ReadSignerWithPassphrase(bytes.NewReader(encrypted.Bytes()), []byte("passphrase"))
+	assert.NoError(err)
+	assert.Equal(newKey.KeyID(), read.KeyID())
+	assert.Equal(oldKey.KeyID(), read.(KeyRing).Verifiers()[1].KeyID())
+}
+
+func TestReadSigner_SingleKey(t *testing.T) {
+	key, err := NewSigner("single")
+	assert.NoError(t, err)
+	buf := &bytes.Buffer{}
+	assert.NoError(t, key.Serialize(buf))
+
+	read, err := ReadSigner(buf)
+	assert.NoError(t, err)
+	assert.IsType(t, &signer{}, read)
+}
+
+func TestAttester_VerifyKeyRing(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	policy, err := NewPolicy(ctx, "transition", "package transition\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	newKey, oldKey, keys := newTestKeyRing(t)
+	ring, err := ReadSigner(bytes.NewReader(keys))
+	assert.NoError(err)
+
+	// attestations signed by the old key before the transition are still accepted
+	previous := NewAttester("transition", policy, oldKey)
+	before, err := previous.Attest(ctx, &AttestRequest{ResourceURI: "image@sha256:1"})
+	assert.NoError(err)
+
+	att := NewAttester("transition", policy, ring)
+	after, err := att.Attest(ctx, &AttestRequest{ResourceURI: "image@sha256:2"})
+	assert.NoError(err)
+	assert.Equal(newKey.KeyID(), after.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId())
+
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: before.Attestation}))
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: after.Attestation}))
+
+	// attestations by keys outside the key ring aren't
+	other, err := NewSigner("other")
+	assert.NoError(err)
+	untrusted, err := NewAttester("transition", policy, other).Attest(ctx, &AttestRequest{ResourceURI: "image@sha256:3"})
+	assert.NoError(err)
+	assert.Error(att.Verify(ctx, &VerifyRequest{Occurrence: untrusted.Attestation}))
+}
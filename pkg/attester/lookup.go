@@ -0,0 +1,122 @@
+package attester
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+)
+
+var (
+	// ErrAttesterNotFound is returned by FindAttester when no attester with the name is loaded
+	ErrAttesterNotFound = errors.New("attester not found")
+
+	// ErrAmbiguousAttester is returned by FindAttester when attesters in several namespaces have the name and no
+	// namespace is given
+	ErrAmbiguousAttester = errors.New("attesters in several namespaces have the name")
+)
+
+// StoredAttestation is an attestation stored for a subject, with the payload that its signature covers
+type StoredAttestation struct {
+	Attester   string     `json:"attester"`
+	Subject    string     `json:"subject"`
+	Occurrence string     `json:"occurrence,omitempty"`
+	NoteName   string     `json:"noteName"`
+	KeyID      string     `json:"keyId"`
+	Payload    string     `json:"payload"`
+	Signature  string     `json:"signature"`
+	CreateTime *time.Time `json:"createTime,omitempty"`
+}
+
+// verifierAttester is implemented by attesters that expose the verifier for their key
+type verifierAttester interface {
+	verifier() Verifier
+}
+
+func (a *attester) verifier() Verifier {
+	return a.signers.Signer()
+}
+
+// FindAttestation returns the most recent of the occurrences that is an attestation by the attester for the subject,
+// or nil if there isn't one
+func FindAttestation(ctx context.Context, att Attester, subject string, occurrences []*grafeas.Occurrence) *StoredAttestation {
+	var found *StoredAttestation
+	for _, occ := range occurrences {
+		if occ.GetAttestation() == nil || occ.GetResource().GetUri() != subject {
+			continue
+		}
+		if err := att.Verify(ctx, &VerifyRequest{Occurrence: occ, Occurrences: occurrences}); err != nil {
+			continue
+		}
+
+		stored := newStoredAttestation(att, occ)
+		if found == nil || (stored.CreateTime != nil && (found.CreateTime == nil || stored.CreateTime.After(*found.CreateTime))) {
+			found = stored
+		}
+	}
+
+	return found
+}
+
+// newStoredAttestation describes an attestation occurrence created by the attester
+func newStoredAttestation(att Attester, occ *grafeas.Occurrence) *StoredAttestation {
+	signed := occ.GetAttestation().GetAttestation().GetPgpSignedAttestation()
+	stored := &StoredAttestation{
+		Attester:   att.String(),
+		Subject:    occ.GetResource().GetUri(),
+		Occurrence: occ.GetName(),
+		NoteName:   occ.GetNoteName(),
+		KeyID:      signed.GetPgpKeyId(),
+		Signature:  signed.GetSignature(),
+	}
+
+	if va, ok := att.(verifierAttester); ok {
+		if payload, err := verifyAttestationSignature(va.verifier(), signed.GetSignature()); err == nil {
+			stored.Payload = payload
+		}
+	}
+
+	if occ.GetCreateTime() != nil {
+		if createTime, err := ptypes.Timestamp(occ.GetCreateTime()); err == nil {
+			stored.CreateTime = &createTime
+		}
+	}
+
+	return stored
+}
+
+// FindAttester returns the namespace/name key and the loaded attester with the name, in the namespace if one is given.
+// The name must be unique across namespaces if no namespace is given.
+func FindAttester(lister Lister, name, namespace string) (string, Attester, error) {
+	attesters := lister.ListAttesters()
+
+	if namespace != "" {
+		key := namespace + "/" + name
+		att, ok := attesters[key]
+		if !ok {
+			return "", nil, ErrAttesterNotFound
+		}
+		return key, att, nil
+	}
+
+	var foundKey string
+	var found Attester
+	for key, att := range attesters {
+		if !strings.HasSuffix(key, "/"+name) {
+			continue
+		}
+		if found != nil {
+			return "", nil, ErrAmbiguousAttester
+		}
+		foundKey, found = key, att
+	}
+
+	if found == nil {
+		return "", nil, ErrAttesterNotFound
+	}
+
+	return foundKey, found, nil
+}
@@ -0,0 +1,67 @@
+package attester
+
+import (
+	common "github.com/grafeas/grafeas/proto/v1beta1/common_go_proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	policyPhaseParse   = "parse"
+	policyPhaseCompile = "compile"
+	policyPhaseEval    = "eval"
+
+	attestationResultAttested   = "attested"
+	attestationResultViolations = "violations"
+	attestationResultError      = "error"
+)
+
+var (
+	policyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "rode_policy_duration_seconds",
+		Help: "Duration of policy parse, compile and evaluation per policy",
+	}, []string{"policy", "phase"})
+
+	attestedSubjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rode_attester_attested_subjects",
+		Help: "Number of subjects each attester currently has a valid attestation for",
+	}, []string{"attester"})
+
+	evalCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_policy_eval_cache_requests_total",
+		Help: "Number of policy evaluations that were found in the evaluation cache (hit) or had to be evaluated (miss)",
+	}, []string{"policy", "result"})
+
+	policyEvalSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_policy_eval_seconds_total",
+		Help: "Cumulative time each attester has spent evaluating its policy",
+	}, []string{"attester"})
+
+	policyEvalThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_policy_eval_throttled_total",
+		Help: "Number of times each attester was throttled for exceeding its policy evaluation budget",
+	}, []string{"attester"})
+
+	attestations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rode_attestations_total",
+		Help: "Number of resources evaluated by each attester, by the kind of occurrence that triggered the evaluation and its result",
+	}, []string{"attester", "kind", "result"})
+)
+
+// metricKind returns the occurrence kind to label metrics with. Kinds that aren't known note kinds are counted together,
+// so that the label only has a bounded set of values.
+func metricKind(kind string) string {
+	if kind == "" {
+		return common.NoteKind_NOTE_KIND_UNSPECIFIED.String()
+	}
+
+	if _, ok := common.NoteKind_value[kind]; !ok {
+		return "UNKNOWN"
+	}
+
+	return kind
+}
+
+func init() {
+	metrics.Registry.MustRegister(policyDuration, attestedSubjects, evalCacheRequests, policyEvalSeconds, policyEvalThrottled, attestations)
+}
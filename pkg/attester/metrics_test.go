@@ -0,0 +1,38 @@
+package attester
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMetricKind(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("VULNERABILITY", metricKind("VULNERABILITY"))
+	assert.Equal("NOTE_KIND_UNSPECIFIED", metricKind(""))
+	assert.Equal("UNKNOWN", metricKind("sha256:b88ca0f4a7fe"))
+}
+
+func TestAttestWrapper_CountsAttestationsByKind(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := NewPolicy(ctx, "counted", "package counted\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("counted")
+	assert.NoError(err)
+
+	client := &fakeOccurrenceClient{}
+	wrapper := NewAttestWrapper(logf.NullLogger{}, client, client, fakeAttesterLister{
+		"default/counted": NewAttester("default/counted", policy, signer),
+	}, nil, nil, nil, nil, nil).(*attestWrapper)
+
+	assert.NoError(wrapper.attestResource(ctx, "image@sha256:aa", "DISCOVERY", false))
+	assert.NoError(wrapper.attestResource(ctx, "image@sha256:bb", "DISCOVERY", false))
+	assert.NoError(wrapper.attestResource(ctx, "image@sha256:cc", "BUILD", false))
+
+	assert.Equal(float64(2), testutil.ToFloat64(attestations.WithLabelValues("default/counted", "DISCOVERY", attestationResultAttested)))
+	assert.Equal(float64(1), testutil.ToFloat64(attestations.WithLabelValues("default/counted", "BUILD", attestationResultAttested)))
+}
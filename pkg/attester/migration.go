@@ -0,0 +1,100 @@
+package attester
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// MigrationPhasePending means the migration window hasn't started, so only the old policy's violations block
+	MigrationPhasePending = "Pending"
+	// MigrationPhaseWindow means only the violations reported by both the old and new policies block
+	MigrationPhaseWindow = "Window"
+	// MigrationPhaseComplete means the migration window has ended, so only the new policy's violations block
+	MigrationPhaseComplete = "Complete"
+)
+
+// PolicyMigration evaluates an old policy alongside the attester's policy while migrating between them
+type PolicyMigration struct {
+	OldPolicy Policy
+	Start     time.Time
+	End       time.Time
+}
+
+// MigrationOutcome reports the violations of each policy during a migration, and the phase that decided which of them
+// blocked the attestation
+type MigrationOutcome struct {
+	Phase         string   `json:"phase"`
+	OldViolations []string `json:"oldViolations,omitempty"`
+	NewViolations []string `json:"newViolations,omitempty"`
+}
+
+// WithPolicyMigration evaluates the old policy of the migration alongside the attester's policy until the migration
+// window ends
+func WithPolicyMigration(migration *PolicyMigration) AttesterOption {
+	return func(a *attester) {
+		a.migration = migration
+	}
+}
+
+// Phase returns the phase of the migration at the given time
+func (m *PolicyMigration) Phase(now time.Time) string {
+	if now.Before(m.Start) {
+		return MigrationPhasePending
+	}
+	if now.Before(m.End) {
+		return MigrationPhaseWindow
+	}
+
+	return MigrationPhaseComplete
+}
+
+// evaluate evaluates the old policy and combines its evaluation with the new policy's for the current phase. The
+// result embedded in the attestation comes from whichever policy is blocking.
+func (m *PolicyMigration) evaluate(ctx context.Context, input interface{}, evaluation *Evaluation, now time.Time) *Evaluation {
+	phase := m.Phase(now)
+	if phase == MigrationPhaseComplete {
+		evaluation.Migration = &MigrationOutcome{
+			Phase:         phase,
+			NewViolations: violationMessages(evaluation.Violations),
+		}
+		return evaluation
+	}
+
+	old := m.OldPolicy.EvaluateResult(ctx, input)
+	combined := &Evaluation{
+		Result: evaluation.Result,
+		Migration: &MigrationOutcome{
+			Phase:         phase,
+			OldViolations: violationMessages(old.Violations),
+			NewViolations: violationMessages(evaluation.Violations),
+		},
+	}
+
+	if phase == MigrationPhasePending {
+		combined.Violations = old.Violations
+		combined.Result = old.Result
+		return combined
+	}
+
+	oldMessages := make(map[string]bool)
+	for _, msg := range combined.Migration.OldViolations {
+		oldMessages[msg] = true
+	}
+	for _, v := range evaluation.Violations {
+		if oldMessages[v.Msg] {
+			combined.Violations = append(combined.Violations, v)
+		}
+	}
+
+	return combined
+}
+
+func violationMessages(violations []*Violation) []string {
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.Msg)
+	}
+
+	return messages
+}
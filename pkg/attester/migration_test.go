@@ -0,0 +1,133 @@
+package attester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	migrationOldPolicy = `
+package migration
+
+violation[{"msg":"critical vulnerability"}]{
+	input.critical
+}
+`
+	migrationNewPolicy = `
+package migration
+
+violation[{"msg":"critical vulnerability"}]{
+	input.critical
+}
+
+violation[{"msg":"high vulnerability"}]{
+	input.high
+}
+`
+)
+
+func TestPolicyMigration_Phase(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	m := &PolicyMigration{Start: now, End: now.Add(time.Hour)}
+
+	assert.Equal(MigrationPhasePending, m.Phase(now.Add(-time.Second)))
+	assert.Equal(MigrationPhaseWindow, m.Phase(now))
+	assert.Equal(MigrationPhaseWindow, m.Phase(now.Add(59*time.Minute)))
+	assert.Equal(MigrationPhaseComplete, m.Phase(now.Add(time.Hour)))
+}
+
+func TestAttester_PolicyMigration(t *testing.T) {
+	ctx := context.Background()
+
+	oldPolicy, err := NewPolicy(ctx, "migration", migrationOldPolicy, false)
+	assert.NoError(t, err)
+	newPolicy, err := NewPolicy(ctx, "migration", migrationNewPolicy, false)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		start, end time.Duration
+		input      map[string]interface{}
+		phase      string
+		violations []string
+	}{
+		{"pending ignores new violations", time.Hour, 2 * time.Hour, map[string]interface{}{"high": true}, MigrationPhasePending, nil},
+		{"pending blocks old violations", time.Hour, 2 * time.Hour, map[string]interface{}{"critical": true}, MigrationPhasePending, []string{"critical vulnerability"}},
+		{"window ignores violations of one policy", -time.Hour, time.Hour, map[string]interface{}{"high": true}, MigrationPhaseWindow, nil},
+		{"window blocks violations of both policies", -time.Hour, time.Hour, map[string]interface{}{"critical": true, "high": true}, MigrationPhaseWindow, []string{"critical vulnerability"}},
+		{"complete blocks new violations", -2 * time.Hour, -time.Hour, map[string]interface{}{"high": true}, MigrationPhaseComplete, []string{"high vulnerability"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+			now := time.Now()
+
+			m := &PolicyMigration{
+				OldPolicy: oldPolicy,
+				Start:     now.Add(tc.start),
+				End:       now.Add(tc.end),
+			}
+
+			evaluation := m.evaluate(ctx, tc.input, newPolicy.EvaluateResult(ctx, tc.input), now)
+			assert.Equal(tc.violations, violationMessages(evaluation.Violations))
+			assert.Equal(tc.phase, evaluation.Migration.Phase)
+			assert.Equal(violationMessages(newPolicy.Evaluate(ctx, tc.input)), evaluation.Migration.NewViolations)
+			if tc.phase != MigrationPhaseComplete {
+				assert.Equal(violationMessages(oldPolicy.Evaluate(ctx, tc.input)), evaluation.Migration.OldViolations)
+			}
+		})
+	}
+}
+
+func TestAttester_AttestReportsPolicyMigration(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	oldPolicy, err := NewPolicy(ctx, "migration", "package migration\nviolation[{\"msg\":\"unscanned\"}]{\n\tnot input.occurrences[0]\n}", false)
+	assert.NoError(err)
+	newPolicy, err := NewPolicy(ctx, "migration", "package migration\nviolation[{\"msg\":\"unscanned\"}]{\n\tnot input.occurrences[0]\n}\nviolation[{\"msg\":\"unsigned\"}]{\n\ttrue\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("migration")
+	assert.NoError(err)
+
+	a := NewAttester("migration", newPolicy, signer, WithPolicyMigration(&PolicyMigration{
+		OldPolicy: oldPolicy,
+		Start:     time.Now().Add(-time.Hour),
+		End:       time.Now().Add(time.Hour),
+	}))
+
+	_, err = a.Attest(ctx, &AttestRequest{ResourceURI: "harbor.liatr.io/app@sha256:abc"})
+	vErr, ok := err.(ViolationError)
+	assert.True(ok)
+	assert.Equal([]string{"unscanned"}, violationMessages(vErr.Violations))
+	assert.Equal(&MigrationOutcome{
+		Phase:         MigrationPhaseWindow,
+		OldViolations: []string{"unscanned"},
+		NewViolations: []string{"unscanned", "unsigned"},
+	}, vErr.Migration)
+}
+
+func TestNewDecision_PolicyMigration(t *testing.T) {
+	assert := assert.New(t)
+	migration := &MigrationOutcome{
+		Phase:         MigrationPhaseWindow,
+		OldViolations: []string{"critical vulnerability"},
+		NewViolations: []string{"critical vulnerability", "high vulnerability"},
+	}
+
+	decision := newDecision("migration", "harbor.liatr.io/app@sha256:abc", nil, ViolationError{
+		Violations: []*Violation{{Msg: "critical vulnerability"}},
+		Migration:  migration,
+	})
+	assert.False(decision.Attested)
+	assert.Equal(migration, decision.Migration)
+
+	decision = newDecision("migration", "harbor.liatr.io/app@sha256:abc", &AttestResponse{Migration: migration}, nil)
+	assert.True(decision.Attested)
+	assert.Equal(migration, decision.Migration)
+}
@@ -0,0 +1,133 @@
+package attester
+
+import (
+	"fmt"
+	"strings"
+
+	attestation "github.com/grafeas/grafeas/proto/v1beta1/attestation_go_proto"
+	common "github.com/grafeas/grafeas/proto/v1beta1/common_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+)
+
+const projectID = "rode"
+
+// Notes names the Grafeas notes an attester stores its attestations under, which are a default note and a note for
+// each occurrence kind that gets one, named after the default note
+type Notes struct {
+	// Project is the Grafeas project the notes are created in
+	Project string
+
+	// ID is the ID of the default note
+	ID string
+
+	// Description is the short description of the default note
+	Description string
+
+	// Authority is the human readable name of the notes' attestation authority. Defaults to the ID of each note.
+	Authority string
+}
+
+// DefaultNotes returns the notes of an attester that doesn't configure them, which are named after the attester in the
+// rode project
+func DefaultNotes(attester string) Notes {
+	return Notes{
+		Project:     projectID,
+		ID:          strings.ReplaceAll(attester, "/", "."),
+		Description: fmt.Sprintf("Attestations by %s", attester),
+	}
+}
+
+// NoteID returns the ID of the note for attestations of the occurrence kind, or of the default note when the kind is
+// empty
+func (n Notes) NoteID(kind string) string {
+	if kind == "" {
+		return n.ID
+	}
+
+	return fmt.Sprintf("%s.%s", n.ID, strings.ToLower(kind))
+}
+
+// NoteName returns the full name of the note with NoteID
+func (n Notes) NoteName(kind string) string {
+	return fmt.Sprintf("projects/%s/notes/%s", n.Project, n.NoteID(kind))
+}
+
+// noteKind returns the occurrence kind of the note with the name, which is empty for the default note, and whether the
+// note is one of the notes
+func (n Notes) noteKind(noteName string) (string, bool) {
+	defaultName := n.NoteName("")
+	if noteName == defaultName {
+		return "", true
+	}
+	if !strings.HasPrefix(noteName, defaultName+".") {
+		return "", false
+	}
+
+	return strings.ToUpper(strings.TrimPrefix(noteName, defaultName+".")), true
+}
+
+// Note creates the note for attestations of the occurrence kind
+func (n Notes) Note(kind string) *grafeas.Note {
+	description := n.Description
+	if kind != "" {
+		description = fmt.Sprintf("%s for %s occurrences", description, kind)
+	}
+	authority := n.Authority
+	if authority == "" {
+		authority = n.NoteID(kind)
+	}
+
+	return &grafeas.Note{
+		Name:             n.NoteName(kind),
+		ShortDescription: description,
+		Type: &grafeas.Note_AttestationAuthority{
+			AttestationAuthority: &attestation.Authority{
+				Hint: &attestation.Authority_Hint{
+					HumanReadableName: authority,
+				},
+			},
+		},
+	}
+}
+
+// NoteID returns the ID of the attester's default note for attestations of the occurrence kind, or of its default note
+// when the kind is empty
+func NoteID(attester, kind string) string {
+	return DefaultNotes(attester).NoteID(kind)
+}
+
+// NoteName returns the full name of the note with NoteID
+func NoteName(attester, kind string) string {
+	return DefaultNotes(attester).NoteName(kind)
+}
+
+// NewAttestationNote creates the attester's default note for attestations of the occurrence kind
+func NewAttestationNote(attester, kind string) *grafeas.Note {
+	return DefaultNotes(attester).Note(kind)
+}
+
+// OccurrenceKind returns the kind of the occurrence, falling back to the type of its details when the kind isn't set
+func OccurrenceKind(occurrence *grafeas.Occurrence) string {
+	if occurrence.GetKind() != common.NoteKind_NOTE_KIND_UNSPECIFIED {
+		return occurrence.GetKind().String()
+	}
+
+	switch occurrence.GetDetails().(type) {
+	case *grafeas.Occurrence_Vulnerability:
+		return common.NoteKind_VULNERABILITY.String()
+	case *grafeas.Occurrence_Build:
+		return common.NoteKind_BUILD.String()
+	case *grafeas.Occurrence_DerivedImage:
+		return common.NoteKind_IMAGE.String()
+	case *grafeas.Occurrence_Installation:
+		return common.NoteKind_PACKAGE.String()
+	case *grafeas.Occurrence_Deployment:
+		return common.NoteKind_DEPLOYMENT.String()
+	case *grafeas.Occurrence_Discovered:
+		return common.NoteKind_DISCOVERY.String()
+	case *grafeas.Occurrence_Attestation:
+		return common.NoteKind_ATTESTATION.String()
+	}
+
+	return ""
+}
@@ -0,0 +1,93 @@
+package attester
+
+import (
+	"testing"
+
+	common "github.com/grafeas/grafeas/proto/v1beta1/common_go_proto"
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoteName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("projects/rode/notes/default.scan", NoteName("default/scan", ""))
+	assert.Equal("projects/rode/notes/default.scan.vulnerability", NoteName("default/scan", "VULNERABILITY"))
+}
+
+func TestNotes(t *testing.T) {
+	assert := assert.New(t)
+
+	// the default notes are named after the attester
+	defaults := DefaultNotes("default/scan")
+	assert.Equal(Notes{Project: "rode", ID: "default.scan", Description: "Attestations by default/scan"}, defaults)
+	assert.Equal(NoteName("default/scan", "BUILD"), defaults.NoteName("BUILD"))
+	assert.Equal("default.scan.build", defaults.Note("BUILD").GetAttestationAuthority().GetHint().GetHumanReadableName())
+
+	notes := Notes{Project: "security", ID: "scan-approved", Description: "Approved scans", Authority: "Security team"}
+	assert.Equal("projects/security/notes/scan-approved", notes.NoteName(""))
+	assert.Equal("projects/security/notes/scan-approved.vulnerability", notes.NoteName("VULNERABILITY"))
+
+	note := notes.Note("VULNERABILITY")
+	assert.Equal("projects/security/notes/scan-approved.vulnerability", note.Name)
+	assert.Equal("Approved scans for VULNERABILITY occurrences", note.ShortDescription)
+	assert.Equal("Security team", note.GetAttestationAuthority().GetHint().GetHumanReadableName())
+
+	kind, ok := notes.noteKind("projects/security/notes/scan-approved.vulnerability")
+	assert.True(ok)
+	assert.Equal("VULNERABILITY", kind)
+	_, ok = notes.noteKind(defaults.NoteName(""))
+	assert.False(ok)
+}
+
+func TestAttester_AttestWithNotes(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := NewPolicy(ctx, "notes", "package notes\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("notes")
+	assert.NoError(err)
+
+	notes := Notes{Project: "security", ID: "scan-approved"}
+	att := NewAttester("default/notes", policy, signer, WithNotes(notes), WithNoteKinds([]string{"BUILD"}))
+	assert.Equal(notes, NotesOf(att))
+
+	res, err := att.Attest(ctx, &AttestRequest{ResourceURI: "image", Kind: "BUILD"})
+	assert.NoError(err)
+	assert.Equal("projects/security/notes/scan-approved.build", res.Attestation.NoteName)
+
+	// attesters without configured notes use the default notes
+	assert.Equal(DefaultNotes("default/other"), NotesOf(NewAttester("default/other", policy, signer)))
+}
+
+func TestOccurrenceKind(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("BUILD", OccurrenceKind(&grafeas.Occurrence{Kind: common.NoteKind_BUILD}))
+	assert.Equal("DISCOVERY", OccurrenceKind(&grafeas.Occurrence{
+		Details: &grafeas.Occurrence_Discovered{Discovered: &discovery.Details{}},
+	}))
+	assert.Equal("", OccurrenceKind(&grafeas.Occurrence{}))
+}
+
+func TestAttester_AttestNoteKinds(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := NewPolicy(ctx, "notes", "package notes\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("notes")
+	assert.NoError(err)
+
+	att := NewAttester("default/notes", policy, signer, WithNoteKinds([]string{"VULNERABILITY"}))
+
+	for kind, noteName := range map[string]string{
+		"VULNERABILITY": "projects/rode/notes/default.notes.vulnerability",
+		"DISCOVERY":     "projects/rode/notes/default.notes",
+		"":              "projects/rode/notes/default.notes",
+	} {
+		res, err := att.Attest(ctx, &AttestRequest{ResourceURI: "image", Kind: kind})
+		assert.NoError(err)
+		assert.Equal(noteName, res.Attestation.NoteName, kind)
+	}
+}
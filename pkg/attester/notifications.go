@@ -0,0 +1,96 @@
+package attester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/protobuf/jsonpb"
+	common "github.com/grafeas/grafeas/proto/v1beta1/common_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+)
+
+// OccurrenceNotificationPath is the path that notifications of occurrences stored in Grafeas are posted to
+const OccurrenceNotificationPath = "/v1/notifications/occurrences"
+
+// ResourceAttester attests resources whose occurrences are already stored, such as by scanners writing to Grafeas
+// directly rather than through a collector
+type ResourceAttester interface {
+	// AttestResource evaluates the resource with each attester, queueing it when attestation is asynchronous. The kind is
+	// the kind of occurrence that triggered the attestation.
+	AttestResource(ctx context.Context, resourceURI, kind string) error
+}
+
+// OccurrenceNotification is the body of a notification of occurrences stored in Grafeas
+type OccurrenceNotification struct {
+	// Occurrences are the stored occurrences in the JSON encoding of the Grafeas API. Only their kind and resource are
+	// used, the occurrences of each resource are read from Grafeas when it's attested.
+	Occurrences []json.RawMessage `json:"occurrences"`
+}
+
+type occurrenceNotificationHandler struct {
+	log      logr.Logger
+	attester ResourceAttester
+}
+
+// NewOccurrenceNotificationHandler creates a handler that serves POST /v1/notifications/occurrences, attesting the
+// resource of each occurrence in the OccurrenceNotification once. Attestations are skipped, so that storing an
+// attestation and being notified of it doesn't attest the resource again.
+func NewOccurrenceNotificationHandler(log logr.Logger, attester ResourceAttester) http.Handler {
+	return &occurrenceNotificationHandler{
+		log,
+		attester,
+	}
+}
+
+func (h *occurrenceNotificationHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := &OccurrenceNotification{}
+	if err := json.NewDecoder(request.Body).Decode(body); err != nil {
+		http.Error(writer, fmt.Sprintf("invalid notification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// each resource is attested once, for the kind of its first occurrence
+	var resources []string
+	kinds := make(map[string]string)
+	for i, raw := range body.Occurrences {
+		occ := &grafeas.Occurrence{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(raw), occ); err != nil {
+			http.Error(writer, fmt.Sprintf("invalid occurrence %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		if occ.GetResource().GetUri() == "" {
+			http.Error(writer, fmt.Sprintf("occurrence %d has no resource uri", i), http.StatusBadRequest)
+			return
+		}
+
+		kind := OccurrenceKind(occ)
+		if kind == common.NoteKind_ATTESTATION.String() {
+			continue
+		}
+		if _, ok := kinds[occ.Resource.Uri]; !ok {
+			resources = append(resources, occ.Resource.Uri)
+			kinds[occ.Resource.Uri] = kind
+		}
+	}
+
+	for _, uri := range resources {
+		h.log.Info("Attesting resource of notified occurrences", "uri", uri, "kind", kinds[uri])
+		if err := h.attester.AttestResource(request.Context(), uri, kinds[uri]); err != nil {
+			h.log.Error(err, "Unable to attest resource of notified occurrences", "uri", uri)
+			http.Error(writer, fmt.Sprintf("unable to attest %s", uri), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,116 @@
+package attester
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// recordingResourceAttester records the resources it's asked to attest
+type recordingResourceAttester struct {
+	attested map[string]string
+	err      error
+}
+
+func (a *recordingResourceAttester) AttestResource(ctx context.Context, resourceURI, kind string) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	a.attested[resourceURI] = kind
+	return nil
+}
+
+func postNotification(handler http.Handler, method, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, OccurrenceNotificationPath, strings.NewReader(body)))
+	return recorder
+}
+
+func TestOccurrenceNotificationHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := &recordingResourceAttester{attested: make(map[string]string)}
+	handler := NewOccurrenceNotificationHandler(logf.NullLogger{}, recorder)
+
+	// each resource is attested once, and attestations don't trigger attesting their resource again
+	resp := postNotification(handler, http.MethodPost, `{"occurrences": [
+		{"name": "projects/rode/occurrences/1", "resource": {"uri": "image@sha256:1"}, "kind": "VULNERABILITY"},
+		{"name": "projects/rode/occurrences/2", "resource": {"uri": "image@sha256:1"}, "kind": "DISCOVERY"},
+		{"name": "projects/rode/occurrences/3", "resource": {"uri": "image@sha256:2"}, "discovered": {"discovered": {"analysisStatus": "FINISHED_SUCCESS"}}},
+		{"name": "projects/rode/occurrences/4", "resource": {"uri": "image@sha256:3"}, "kind": "ATTESTATION"}
+	]}`)
+	assert.Equal(http.StatusAccepted, resp.Code, resp.Body.String())
+	assert.Equal(map[string]string{
+		"image@sha256:1": "VULNERABILITY",
+		"image@sha256:2": "DISCOVERY",
+	}, recorder.attested)
+
+	resp = postNotification(handler, http.MethodGet, "")
+	assert.Equal(http.StatusMethodNotAllowed, resp.Code)
+	resp = postNotification(handler, http.MethodPost, "{")
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	resp = postNotification(handler, http.MethodPost, `{"occurrences": [{"kind": "VULNERABILITY"}]}`)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Contains(resp.Body.String(), "occurrence 0 has no resource uri")
+
+	recorder.err = errors.New("grafeas unavailable")
+	resp = postNotification(handler, http.MethodPost, `{"occurrences": [{"resource": {"uri": "image@sha256:4"}}]}`)
+	assert.Equal(http.StatusInternalServerError, resp.Code)
+}
+
+func TestOccurrenceNotificationHandler_AttestsStoredOccurrences(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	passing, err := NewPolicy(ctx, "passing", "package passing\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	failing, err := NewPolicy(ctx, "failing", "package failing\nviolation[{\"msg\":\"always\"}]{\n\ttrue\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("notified")
+	assert.NoError(err)
+	attesters := fakeAttesterLister{
+		"default/passing": NewAttester("default/passing", passing, signer),
+		"default/failing": NewAttester("default/failing", failing, signer),
+	}
+
+	// the scanner stored its occurrence in Grafeas itself, so rode only learns of it from the notification
+	client := &fakeOccurrenceClient{}
+	wrapper := NewAttestWrapper(logf.NullLogger{}, client, client, attesters, nil, nil, nil, nil, nil)
+	handler := NewOccurrenceNotificationHandler(logf.NullLogger{}, wrapper)
+	notification := `{"occurrences": [{"resource": {"uri": "image@sha256:1"}, "kind": "VULNERABILITY"}]}`
+
+	for i := 0; i < 2; i++ {
+		resp := postNotification(handler, http.MethodPost, notification)
+		assert.Equal(http.StatusAccepted, resp.Code, resp.Body.String())
+	}
+
+	// only the attester that the resource passes attests it, and being notified again doesn't attest it twice
+	assert.Len(client.occurrences, 1)
+	attestation := client.occurrences[0]
+	assert.Equal("image@sha256:1", attestation.GetResource().GetUri())
+	assert.NoError(attesters["default/passing"].Verify(ctx, &VerifyRequest{Occurrence: attestation}))
+
+	// resources are queued when attestation is asynchronous
+	dir, err := ioutil.TempDir("", "notifications")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	queue, err := NewAttestQueue(logf.NullLogger{}, dir)
+	assert.NoError(err)
+	wrapper = NewAttestWrapper(logf.NullLogger{}, client, client, attesters, nil, nil, nil, queue, nil)
+	handler = NewOccurrenceNotificationHandler(logf.NullLogger{}, wrapper)
+
+	resp := postNotification(handler, http.MethodPost, `{"occurrences": [{"resource": {"uri": "image@sha256:2"}, "kind": "VULNERABILITY"}]}`)
+	assert.Equal(http.StatusAccepted, resp.Code, resp.Body.String())
+	status, ok := queue.Status("image@sha256:2")
+	assert.True(ok)
+	assert.Equal(AttestStatePending, status.State)
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/go-logr/logr"
 	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/liatrio/rode/pkg/occurrence"
 )
 
@@ -14,6 +16,22 @@ type Lister interface {
 	ListAttesters() map[string]Attester
 }
 
+// ListOptions selects attesters by their namespace and labels
+type ListOptions struct {
+	// Namespace selects the attesters in the namespace. Attesters in every namespace are selected when it's empty.
+	Namespace string
+
+	// LabelSelector selects the attesters whose labels match it. Attesters are selected whatever their labels when it's
+	// nil.
+	LabelSelector *metav1.LabelSelector
+}
+
+// FilteredLister is a Lister that can also list the attesters selected by ListOptions
+type FilteredLister interface {
+	Lister
+	ListAttestersFiltered(opts ListOptions) (map[string]Attester, error)
+}
+
 type attestWrapper struct {
 	log logr.Logger
 
@@ -25,16 +43,58 @@ type attestWrapper struct {
 
 	// used to retieve all occurrences for a resource
 	occurrenceLister occurrence.Lister
+
+	// tracks the subjects attested by each attester
+	subjectTracker *SubjectTracker
+
+	// records the violations of each attester's most recent rejection
+	violationTracker *ViolationTracker
+
+	// records the outcome of each attestation
+	decisionLogger DecisionLogger
+
+	// attests resources asynchronously when set
+	queue *AttestQueue
+
+	// stores the attestations
+	sink AttestationSink
 }
 
-// NewAttestWrapper creates an Creator that also performs attestation
-func NewAttestWrapper(log logr.Logger, delegate occurrence.Creator, lister occurrence.Lister, attesterLister Lister) occurrence.Creator {
-	return &attestWrapper{
+// AttestWrapper is a Creator that also attests the resources of the occurrences it creates, and that can attest the
+// resources of occurrences that are already stored
+type AttestWrapper interface {
+	occurrence.Creator
+	ResourceAttester
+}
+
+// NewAttestWrapper creates an Creator that also performs attestation. When queue is set, resources are attested
+// asynchronously by the queue's workers rather than before CreateOccurrences returns. Attestations are stored in the
+// sink, or by the delegate when sink is nil.
+func NewAttestWrapper(log logr.Logger, delegate occurrence.Creator, lister occurrence.Lister, attesterLister Lister, subjectTracker *SubjectTracker, violationTracker *ViolationTracker, decisionLogger DecisionLogger, queue *AttestQueue, sink AttestationSink) AttestWrapper {
+	if sink == nil {
+		sink = NewGrafeasSink(delegate)
+	}
+
+	a := &attestWrapper{
 		log,
 		delegate,
 		attesterLister,
 		lister,
+		subjectTracker,
+		violationTracker,
+		decisionLogger,
+		queue,
+		sink,
+	}
+
+	if queue != nil {
+		// jobs are processed at least once, so an attester's existing attestation isn't stored again
+		queue.process = func(ctx context.Context, resourceURI, kind string) error {
+			return a.attestResource(ctx, resourceURI, kind, true)
+		}
 	}
+
+	return a
 }
 
 // CreateOccurrences will attempt attestation
@@ -55,33 +115,112 @@ func (a *attestWrapper) CreateOccurrences(ctx context.Context, occurrences ...*g
 		if !visited[uri] {
 			visited[uri] = true
 
-			// fetch existing occurrences for this resource
-			allOccurrences, err := a.occurrenceLister.ListOccurrences(ctx, uri)
+			kind := OccurrenceKind(o)
+			if a.queue != nil {
+				err = a.queue.Enqueue(uri, kind)
+			} else {
+				err = a.attestResource(ctx, uri, kind, false)
+			}
 			if err != nil {
-				return fmt.Errorf("Unable to attempt attestation for occurrence %v", err)
+				return err
 			}
+		}
+	}
+
+	return nil
+}
+
+// AttestResource attests a resource whose occurrences are already stored. Existing attestations aren't stored again.
+func (a *attestWrapper) AttestResource(ctx context.Context, uri, kind string) error {
+	if a.queue != nil {
+		return a.queue.Enqueue(uri, kind)
+	}
 
-			for _, att := range a.attesterLister.ListAttesters() {
-				resp, err := att.Attest(ctx, &AttestRequest{
-					ResourceURI: uri,
-					Occurrences: allOccurrences.GetOccurrences(),
-				})
-				if err != nil {
-					if vErr, ok := err.(ViolationError); ok {
-						a.log.Info("Attestion resulted in violations", "violations", vErr.Violations)
-					} else {
-						return fmt.Errorf("Unable to perform attestation for occurrence %v", err)
-					}
-				} else {
-					a.log.Info("Storing attestation for resource", "uri", uri)
-					err = a.occurrenceCreator.CreateOccurrences(ctx, resp.Attestation)
-					if err != nil {
-						return fmt.Errorf("Unable to store attestation for occurrence %v", err)
-					}
+	return a.attestResource(ctx, uri, kind, true)
+}
+
+// attestResource evaluates the resource with each attester and stores the attestations of those it passes. The kind is
+// the kind of occurrence that triggered the attestation. When skipExisting is true, an attestation isn't stored for an
+// attester that has already attested the resource.
+func (a *attestWrapper) attestResource(ctx context.Context, uri, kind string, skipExisting bool) error {
+	// fetch existing occurrences for this resource
+	allOccurrences, err := a.occurrenceLister.ListOccurrences(ctx, uri)
+	if err != nil {
+		return fmt.Errorf("Unable to attempt attestation for occurrence %v", err)
+	}
+
+	for name, att := range a.attesterLister.ListAttesters() {
+		resp, err := att.Attest(ctx, &AttestRequest{
+			ResourceURI: uri,
+			Occurrences: allOccurrences.GetOccurrences(),
+			Kind:        kind,
+		})
+		a.logDecision(newDecision(name, uri, resp, err))
+		recordAttestation(name, kind, err)
+		if err != nil {
+			if vErr, ok := err.(ViolationError); ok {
+				a.log.Info("Attestion resulted in violations", "violations", vErr.Violations)
+				if a.subjectTracker != nil {
+					a.subjectTracker.Rejected(name, uri)
+				}
+				if a.violationTracker != nil {
+					a.violationTracker.Rejected(name, uri, vErr.Violations)
 				}
+				continue
+			}
+
+			return fmt.Errorf("Unable to perform attestation for occurrence %v", err)
+		}
+
+		if skipExisting && hasAttestation(ctx, att, allOccurrences.GetOccurrences()) {
+			a.log.Info("Resource already attested", "uri", uri, "attester", name)
+		} else {
+			a.log.Info("Storing attestation for resource", "uri", uri)
+			err = a.sink.StoreAttestation(ctx, att, resp.Attestation)
+			if err != nil {
+				return fmt.Errorf("Unable to store attestation for occurrence %v", err)
 			}
 		}
+
+		if a.subjectTracker != nil {
+			a.subjectTracker.Attested(name, uri)
+		}
 	}
 
 	return nil
 }
+
+// recordAttestation counts the result of the attester evaluating a resource
+func recordAttestation(attester, kind string, err error) {
+	result := attestationResultAttested
+	if _, ok := err.(ViolationError); ok {
+		result = attestationResultViolations
+	} else if err != nil {
+		result = attestationResultError
+	}
+
+	attestations.WithLabelValues(attester, metricKind(kind), result).Inc()
+}
+
+// hasAttestation returns true if any of the occurrences is an attestation by the attester
+func hasAttestation(ctx context.Context, att Attester, occurrences []*grafeas.Occurrence) bool {
+	for _, occ := range occurrences {
+		if occ.GetAttestation() != nil && att.Verify(ctx, &VerifyRequest{Occurrence: occ, Occurrences: occurrences}) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logDecision records the decision if a decision logger is configured. Failing to record a decision doesn't fail the
+// attestation.
+func (a *attestWrapper) logDecision(decision *Decision) {
+	if a.decisionLogger == nil {
+		return
+	}
+
+	if err := a.decisionLogger.LogDecision(decision); err != nil {
+		a.log.Error(err, "Unable to log attestation decision", "attester", decision.Attester, "uri", decision.ResourceURI)
+	}
+}
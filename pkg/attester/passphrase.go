@@ -0,0 +1,185 @@
+package attester
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+const (
+	// packetTagPrivateKey and packetTagPrivateSubkey are the OpenPGP packet tags of secret keys and subkeys
+	packetTagPrivateKey    = 5
+	packetTagPrivateSubkey = 7
+
+	// s2kUsageSHA1 marks a secret key packet's key material as encrypted, followed by a SHA-1 hash of the plaintext
+	s2kUsageSHA1 = 254
+)
+
+// SerializeWithPassphrase writes the signer's private key to out like Serialize, encrypting the PGP private keys with
+// the passphrase so that they can only be read with ReadSignerWithPassphrase. The key isn't encrypted when the
+// passphrase is empty.
+func SerializeWithPassphrase(s Signer, out io.Writer, passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return s.Serialize(out)
+	}
+
+	if _, ok := s.(*ed25519Signer); ok {
+		return fmt.Errorf("passphrase-protected Ed25519 keys are not supported")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := s.Serialize(buf); err != nil {
+		return err
+	}
+
+	encrypted, err := encryptPrivateKeys(buf.Bytes(), passphrase)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(encrypted)
+	return err
+}
+
+// encryptPrivateKeys encrypts the key material of the secret key packets in the serialized key, as described in RFC 4880
+// section 5.5.3. The golang.org/x/crypto/openpgp package can decrypt keys but not encrypt them, so the packets it
+// serialized are rewritten with the key material encrypted by AES-256 with a key derived from the passphrase by the
+// iterated and salted S2K. The other packets are copied as they are.
+func encryptPrivateKeys(serialized, passphrase []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+
+	for rest := serialized; len(rest) > 0; {
+		tag, body, next, err := readPacket(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		if tag == packetTagPrivateKey || tag == packetTagPrivateSubkey {
+			body, err = encryptPrivateKeyPacket(rest[:len(rest)-len(next)], body, passphrase)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		writePacketHeader(out, tag, len(body))
+		out.Write(body)
+		rest = next
+	}
+
+	return out.Bytes(), nil
+}
+
+// encryptPrivateKeyPacket returns the body of the unencrypted secret key packet with its key material encrypted
+func encryptPrivateKeyPacket(raw, body, passphrase []byte) ([]byte, error) {
+	p, err := packet.Read(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := p.(*packet.PrivateKey)
+	if !ok || privateKey.Encrypted {
+		return nil, fmt.Errorf("expected an unencrypted private key packet")
+	}
+
+	// only the key types that rode generates are encrypted, which are the ones checked against GnuPG
+	switch privateKey.PubKeyAlgo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSASignOnly, packet.PubKeyAlgoECDSA:
+	default:
+		return nil, fmt.Errorf("passphrase-protected keys with public key algorithm %d are not supported", privateKey.PubKeyAlgo)
+	}
+
+	// the body starts with the public key, which is left in the clear
+	publicKey := &bytes.Buffer{}
+	if err := privateKey.PublicKey.Serialize(publicKey); err != nil {
+		return nil, err
+	}
+	_, publicBody, _, err := readPacket(publicKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// followed by the S2K usage, the key material and its two octet checksum
+	publicLength := len(publicBody)
+	if len(body) < publicLength+3 || body[publicLength] != 0 {
+		return nil, fmt.Errorf("private key %s has an unexpected format", privateKey.KeyIdString())
+	}
+	keyMaterial := body[publicLength+1 : len(body)-2]
+
+	encrypted := bytes.NewBuffer(append([]byte{}, publicBody...))
+	encrypted.WriteByte(s2kUsageSHA1)
+	encrypted.WriteByte(byte(packet.CipherAES256))
+
+	key := make([]byte, packet.CipherAES256.KeySize())
+	if err := s2k.Serialize(encrypted, key, rand.Reader, passphrase, &s2k.Config{Hash: crypto.SHA256}); err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	encrypted.Write(iv)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha1.Sum(keyMaterial)
+	plaintext := append(append([]byte{}, keyMaterial...), checksum[:]...)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCFBEncrypter(block, iv).XORKeyStream(ciphertext, plaintext)
+	encrypted.Write(ciphertext)
+
+	return encrypted.Bytes(), nil
+}
+
+// readPacket splits the first packet from the serialized packets, returning its tag and body and the packets after it.
+// Only the new packet format with a definite length is supported, which is the format the openpgp package writes.
+func readPacket(data []byte) (byte, []byte, []byte, error) {
+	if len(data) < 2 || data[0]&0xc0 != 0xc0 {
+		return 0, nil, nil, fmt.Errorf("unsupported OpenPGP packet header")
+	}
+	tag := data[0] & 0x3f
+
+	var length, headerLength int
+	switch first := int(data[1]); {
+	case first < 192:
+		length, headerLength = first, 2
+	case first < 224 && len(data) >= 3:
+		length, headerLength = (first-192)<<8+int(data[2])+192, 3
+	case first == 255 && len(data) >= 6:
+		length = int(data[2])<<24 | int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		headerLength = 6
+	default:
+		return 0, nil, nil, fmt.Errorf("unsupported OpenPGP packet length")
+	}
+
+	if len(data) < headerLength+length {
+		return 0, nil, nil, fmt.Errorf("truncated OpenPGP packet")
+	}
+
+	return tag, data[headerLength : headerLength+length], data[headerLength+length:], nil
+}
+
+// writePacketHeader writes a new format packet header for a packet with the tag and body length
+func writePacketHeader(out *bytes.Buffer, tag byte, length int) {
+	out.WriteByte(0xc0 | tag)
+	switch {
+	case length < 192:
+		out.WriteByte(byte(length))
+	case length < 8384:
+		length -= 192
+		out.WriteByte(byte(192 + length>>8))
+		out.WriteByte(byte(length))
+	default:
+		out.Write([]byte{255, byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length)})
+	}
+}
@@ -0,0 +1,73 @@
+package attester
+
+import (
+	"bytes"
+	"crypto/dsa"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// TestSerializeWithPassphrase_GnuPG checks that the keys encrypted by SerializeWithPassphrase can be imported and
+// unlocked by GnuPG, rather than only by the openpgp package they're read back with
+func TestSerializeWithPassphrase_GnuPG(t *testing.T) {
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	for _, keyType := range []rodev1alpha1.KeyType{rodev1alpha1.KeyTypePGPRSA, rodev1alpha1.KeyTypePGPECDSAP256} {
+		t.Run(string(keyType), func(t *testing.T) {
+			assert := assert.New(t)
+
+			home, err := ioutil.TempDir("", "gnupg")
+			assert.NoError(err)
+			defer os.RemoveAll(home)
+			defer exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+
+			run := func(stdin []byte, args ...string) error {
+				cmd := exec.Command(gpg, append([]string{"--homedir", home, "--batch", "--pinentry-mode", "loopback"}, args...)...)
+				cmd.Stdin = bytes.NewReader(stdin)
+				output, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Logf("gpg %v: %s", args, output)
+				}
+				return err
+			}
+
+			s, err := NewSignerWithKeyType("gnupg", keyType)
+			assert.NoError(err)
+			buf := new(bytes.Buffer)
+			assert.NoError(SerializeWithPassphrase(s, buf, []byte("correct horse")))
+			assert.NoError(run(buf.Bytes(), "--import"))
+
+			// signing unlocks the key, which only succeeds with the passphrase it was encrypted with
+			signature := filepath.Join(home, "message.sig")
+			assert.Error(run([]byte("hello world!"), "--passphrase", "wrong", "--local-user", s.KeyID(), "--output", signature, "--detach-sign"))
+			assert.NoError(run([]byte("hello world!"), "--passphrase", "correct horse", "--local-user", s.KeyID(), "--output", signature, "--detach-sign"))
+		})
+	}
+}
+
+func TestEncryptPrivateKeys_UnsupportedAlgorithm(t *testing.T) {
+	assert := assert.New(t)
+
+	key := &dsa.PrivateKey{}
+	assert.NoError(dsa.GenerateParameters(&key.Parameters, rand.Reader, dsa.L1024N160))
+	assert.NoError(dsa.GenerateKey(key, rand.Reader))
+	buf := new(bytes.Buffer)
+	assert.NoError(packet.NewDSAPrivateKey(time.Now(), key).Serialize(buf))
+
+	// keys of types that rode doesn't generate aren't encrypted rather than being encrypted in a way that's unchecked
+	_, err := encryptPrivateKeys(buf.Bytes(), []byte("correct horse"))
+	assert.EqualError(err, "passphrase-protected keys with public key algorithm 17 are not supported")
+}
@@ -0,0 +1,83 @@
+package attester
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"sigs.k8s.io/yaml"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// PayloadFormats returns the formats that occurrences can be sent to an attester in
+func PayloadFormats() []rodev1alpha1.PayloadFormat {
+	return []rodev1alpha1.PayloadFormat{rodev1alpha1.PayloadFormatJSON, rodev1alpha1.PayloadFormatYAML, rodev1alpha1.PayloadFormatProtobuf}
+}
+
+// WithPayloadFormat sets the format that occurrences are sent to the attester in, which is JSON by default
+func WithPayloadFormat(format rodev1alpha1.PayloadFormat) AttesterOption {
+	return func(a *attester) {
+		a.payloadFormat = format
+	}
+}
+
+// payloadFormatAttester is implemented by attesters that expose the format occurrences are sent to them in
+type payloadFormatAttester interface {
+	payloadFormatOf() rodev1alpha1.PayloadFormat
+}
+
+func (a *attester) payloadFormatOf() rodev1alpha1.PayloadFormat {
+	return a.payloadFormat
+}
+
+// PayloadFormatOf returns the format that occurrences are sent to the attester in
+func PayloadFormatOf(att Attester) rodev1alpha1.PayloadFormat {
+	if pa, ok := att.(payloadFormatAttester); ok && pa.payloadFormatOf() != "" {
+		return pa.payloadFormatOf()
+	}
+
+	return rodev1alpha1.PayloadFormatJSON
+}
+
+// DecodeOccurrence decodes an occurrence sent in a JSON request in the format. A JSON occurrence is the occurrence
+// itself, while YAML and protobuf occurrences are strings holding the YAML and the base64 of the binary encoding.
+func DecodeOccurrence(format rodev1alpha1.PayloadFormat, raw json.RawMessage) (*grafeas.Occurrence, error) {
+	occ := &grafeas.Occurrence{}
+
+	switch format {
+	case "", rodev1alpha1.PayloadFormatJSON:
+		if err := jsonpb.Unmarshal(bytes.NewReader(raw), occ); err != nil {
+			return nil, err
+		}
+	case rodev1alpha1.PayloadFormatYAML:
+		var payload string
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return nil, fmt.Errorf("yaml occurrence must be a string: %v", err)
+		}
+
+		converted, err := yaml.YAMLToJSON([]byte(payload))
+		if err != nil {
+			return nil, err
+		}
+		if err := jsonpb.Unmarshal(bytes.NewReader(converted), occ); err != nil {
+			return nil, err
+		}
+	case rodev1alpha1.PayloadFormatProtobuf:
+		var payload []byte
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return nil, fmt.Errorf("protobuf occurrence must be a base64 string: %v", err)
+		}
+
+		if err := proto.Unmarshal(payload, occ); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported payload format %q, must be one of %v", format, PayloadFormats())
+	}
+
+	return occ, nil
+}
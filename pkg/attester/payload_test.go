@@ -0,0 +1,100 @@
+package attester
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+func discoveryOccurrence(status discovery.Discovered_AnalysisStatus) *grafeas.Occurrence {
+	return &grafeas.Occurrence{
+		Name:     "projects/rode/occurrences/discovery",
+		Resource: &grafeas.Resource{Uri: "harbor.example.com/app@sha256:1234"},
+		Details: &grafeas.Occurrence_Discovered{
+			Discovered: &discovery.Details{
+				Discovered: &discovery.Discovered{AnalysisStatus: status},
+			},
+		},
+	}
+}
+
+// encodePayload encodes the occurrence as it's sent in a request in the format
+func encodePayload(t *testing.T, format rodev1alpha1.PayloadFormat, occ *grafeas.Occurrence) json.RawMessage {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, (&jsonpb.Marshaler{}).Marshal(buf, occ))
+
+	var payload interface{}
+	switch format {
+	case rodev1alpha1.PayloadFormatJSON:
+		return buf.Bytes()
+	case rodev1alpha1.PayloadFormatYAML:
+		converted, err := yaml.JSONToYAML(buf.Bytes())
+		assert.NoError(t, err)
+		payload = string(converted)
+	case rodev1alpha1.PayloadFormatProtobuf:
+		encoded, err := proto.Marshal(occ)
+		assert.NoError(t, err)
+		payload = encoded
+	}
+
+	raw, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	return raw
+}
+
+func TestDecodeOccurrence(t *testing.T) {
+	policy, err := NewPolicy(ctx, "payload", `
+package payload
+
+violation[{"msg":"analysis failed"}]{
+	input.occurrences[_].discovered.discovered.analysisStatus != "FINISHED_SUCCESS"
+}
+`, false)
+	assert.NoError(t, err)
+	signer, err := NewSigner("payload")
+	assert.NoError(t, err)
+
+	for _, format := range PayloadFormats() {
+		t.Run(string(format), func(t *testing.T) {
+			assert := assert.New(t)
+			att := NewAttester("payload", policy, signer, WithPayloadFormat(format))
+			assert.Equal(format, PayloadFormatOf(att))
+
+			for status, violations := range map[discovery.Discovered_AnalysisStatus][]string{
+				discovery.Discovered_FINISHED_SUCCESS: nil,
+				discovery.Discovered_FINISHED_FAILED:  {"analysis failed"},
+			} {
+				occ := discoveryOccurrence(status)
+				decoded, err := DecodeOccurrence(format, encodePayload(t, format, occ))
+				assert.NoError(err)
+				assert.True(proto.Equal(occ, decoded), "decoded %v", decoded)
+
+				decision, err := att.DryRun(ctx, &AttestRequest{ResourceURI: occ.Resource.Uri, Occurrences: []*grafeas.Occurrence{decoded}})
+				assert.NoError(err)
+				assert.Equal(violations, decision.Violations, fmt.Sprintf("status %s", status))
+			}
+		})
+	}
+
+	// the payload must be in the attester's format
+	_, err = DecodeOccurrence(rodev1alpha1.PayloadFormatProtobuf, encodePayload(t, rodev1alpha1.PayloadFormatJSON, discoveryOccurrence(discovery.Discovered_FINISHED_SUCCESS)))
+	assert.EqualError(t, err, "protobuf occurrence must be a base64 string: json: cannot unmarshal object into Go value of type []uint8")
+	_, err = DecodeOccurrence(rodev1alpha1.PayloadFormatYAML, json.RawMessage(`{"name":"occurrence"}`))
+	assert.Error(t, err)
+
+	_, err = DecodeOccurrence("cue", json.RawMessage(`"name: \"occurrence\""`))
+	assert.EqualError(t, err, `unsupported payload format "cue", must be one of [json yaml protobuf]`)
+
+	// attesters without a format are sent JSON
+	assert.Equal(t, rodev1alpha1.PayloadFormatJSON, PayloadFormatOf(NewAttester("payload", policy, signer)))
+}
@@ -1,43 +1,283 @@
 package attester
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
 	"github.com/open-policy-agent/opa/topdown"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 )
 
 type policy struct {
 	name     string
-	module   string
 	trace    bool
+	modules  map[string]*ast.Module
 	compiler *ast.Compiler
+	store    storage.Store
+	options  *policyOptions
+
+	// hasResult is set when the policy defines a result rule, which is evaluated along with its violations
+	hasResult bool
+
+	preparedMutex      sync.RWMutex
+	prepared           *rego.PreparedEvalQuery
+	preparedGeneration int
+
+	// hashes of the modules and data, identifying the policy's results in the eval cache
+	moduleHash string
+	dataHash   string
+
+	timingsMutex sync.Mutex
+	timings      PolicyTimings
 }
 
+// resultPassField is the field of a policy's result that must be true for the policy to pass
+const resultPassField = "pass"
+
 // Policy is the interface for managing policy
 type Policy interface {
 	Evaluate(context.Context, interface{}) []*Violation
+	EvaluateResult(context.Context, interface{}) *Evaluation
 	Serialize(out io.Writer) error
+	Timings() PolicyTimings
+	SetData(context.Context, map[string]interface{}) error
 }
 
-// NewPolicy creates a new policy
-func NewPolicy(name string, module string, trace bool) (Policy, error) {
-	compiler, err := ast.CompileModules(map[string]string{
-		fmt.Sprintf("%s.rego", name): module,
-	})
-	if err != nil {
+// Evaluation is the outcome of evaluating a policy. Result is the object returned by the policy's result rule, or nil
+// when the policy doesn't define one.
+type Evaluation struct {
+	Violations []*Violation
+	Result     map[string]interface{}
+
+	// Migration reports the evaluation of each policy when the attester is migrating between policies
+	Migration *MigrationOutcome
+}
+
+// PolicyTimings contains the duration of the policy's parse and compile, and its most recent evaluation
+type PolicyTimings struct {
+	Parse   time.Duration
+	Compile time.Duration
+	Eval    time.Duration
+}
+
+// Timed is implemented by types that record PolicyTimings
+type Timed interface {
+	Timings() PolicyTimings
+}
+
+// PolicyOption configures optional behavior of a policy
+type PolicyOption func(*policyOptions)
+
+type policyOptions struct {
+	timed       bool
+	partialEval bool
+	data        map[string]interface{}
+	evalCache   *EvalCache
+	query       string
+	traceLog    logr.Logger
+	evalTimeout time.Duration
+	builtins    []Builtin
+	regoVersion rodev1alpha1.RegoVersion
+}
+
+// WithTimings enables recording the duration of the parse, compile and evaluation of the policy
+func WithTimings(enabled bool) PolicyOption {
+	return func(o *policyOptions) {
+		o.timed = enabled
+	}
+}
+
+// WithPartialEval enables partially evaluating the policy against its static data once, so that only the input needs
+// to be evaluated for each occurrence. The policy falls back to full evaluation when the residual can't be prepared, and
+// while it's being prepared again after the data changes.
+func WithPartialEval(enabled bool) PolicyOption {
+	return func(o *policyOptions) {
+		o.partialEval = enabled
+	}
+}
+
+// WithData sets the static data available to the policy under `data`
+func WithData(data map[string]interface{}) PolicyOption {
+	return func(o *policyOptions) {
+		o.data = data
+	}
+}
+
+// WithEvalCache caches the results of evaluating the policy in the cache
+func WithEvalCache(cache *EvalCache) PolicyOption {
+	return func(o *policyOptions) {
+		o.evalCache = cache
+	}
+}
+
+// WithQuery evaluates the query instead of the violation rule in the package named after the policy. The query must be
+// a reference to a partial set rule defined by the policy, such as data.shared.strict_violation, whose members are the
+// violations. A result rule isn't evaluated with a query.
+func WithQuery(query string) PolicyOption {
+	return func(o *policyOptions) {
+		o.query = query
+	}
+}
+
+// WithTraceLogger logs the trace of each evaluation of a traced policy to the logger at debug level, rather than printing
+// it to stdout
+func WithTraceLogger(log logr.Logger) PolicyOption {
+	return func(o *policyOptions) {
+		o.traceLog = log
+	}
+}
+
+// WithEvalTimeout cancels evaluations of the policy that take longer than the timeout, including its partial
+// evaluation, so that a policy that doesn't terminate can't hang the caller. An evaluation that times out is reported
+// as a violation. Evaluations aren't limited when the timeout is 0.
+func WithEvalTimeout(timeout time.Duration) PolicyOption {
+	return func(o *policyOptions) {
+		o.evalTimeout = timeout
+	}
+}
+
+// WithRegoVersion parses the policy's modules as the version of the Rego syntax, which is v0 by default
+func WithRegoVersion(version rodev1alpha1.RegoVersion) PolicyOption {
+	return func(o *policyOptions) {
+		o.regoVersion = version
+	}
+}
+
+// RegoVersions returns the versions of the Rego syntax that policies can be written in
+func RegoVersions() []rodev1alpha1.RegoVersion {
+	return []rodev1alpha1.RegoVersion{rodev1alpha1.RegoVersionV0}
+}
+
+// CheckRegoVersion returns an error if policies can't be written in the version of the Rego syntax
+func CheckRegoVersion(version rodev1alpha1.RegoVersion) error {
+	switch version {
+	case "", rodev1alpha1.RegoVersionV0:
+		return nil
+	case rodev1alpha1.RegoVersionV1:
+		return fmt.Errorf("rego version %s requires OPA 1.0 or later, but the bundled OPA only parses rego version %s", version, rodev1alpha1.RegoVersionV0)
+	default:
+		return fmt.Errorf("unsupported rego version %q, must be one of %v", version, RegoVersions())
+	}
+}
+
+// NewPolicy creates a new policy. An error is returned if the context is done before the policy has been compiled.
+func NewPolicy(ctx context.Context, name string, module string, trace bool, opts ...PolicyOption) (Policy, error) {
+	return NewPolicyFromModules(ctx, name, map[string]string{fmt.Sprintf("%s.rego", name): module}, trace, opts...)
+}
+
+// NewPolicyFromModules creates a new policy from several modules, keyed by their filenames, that are compiled together.
+// Empty modules are ignored. Compiling the policy stops with the context's error once the context is done.
+func NewPolicyFromModules(ctx context.Context, name string, modules map[string]string, trace bool, opts ...PolicyOption) (Policy, error) {
+	options := &policyOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if err := CheckRegoVersion(options.regoVersion); err != nil {
 		return nil, err
 	}
-	return &policy{
-		name,
-		module,
-		trace,
-		compiler,
-	}, nil
+
+	data := options.data
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	p := &policy{
+		name:    name,
+		trace:   trace,
+		store:   inmem.NewFromObject(data),
+		options: options,
+	}
+
+	if options.evalCache != nil {
+		var err error
+		if p.moduleHash, err = hashJSON([]interface{}{modules, options.query}); err != nil {
+			return nil, err
+		}
+		if p.dataHash, err = hashJSON(data); err != nil {
+			return nil, err
+		}
+	}
+
+	filenames := make([]string, 0, len(modules))
+	for filename := range modules {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	p.modules = make(map[string]*ast.Module)
+
+	stop := p.startTimer(policyPhaseParse)
+	for _, filename := range filenames {
+		parsed, err := ast.ParseModule(filename, modules[filename])
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		// a module without any statements parses without an error
+		if parsed == nil {
+			continue
+		}
+
+		p.modules[filename] = parsed
+
+		for _, rule := range parsed.Rules {
+			if rule.Head.Name.Equal(ast.Var("result")) && options.query == "" {
+				p.hasResult = true
+			}
+		}
+	}
+	stop()
+
+	if len(p.modules) == 0 {
+		return nil, fmt.Errorf("policy is empty")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stop = p.startTimer(policyPhaseCompile)
+	compiler := ast.NewCompiler().WithBuiltins(builtinDecls(options.builtins))
+	compiler.Compile(p.modules)
+	stop()
+	if compiler.Failed() {
+		return nil, compiler.Errors
+	}
+
+	p.compiler = compiler
+
+	if options.query != "" {
+		if err := p.checkQuery(options.query); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.partialEval {
+		// an error here isn't fatal, the policy is fully evaluated instead
+		prepareCtx, cancel := p.withEvalTimeout(ctx)
+		_ = p.prepare(prepareCtx)
+		cancel()
+	}
+
+	// a partial evaluation that was cancelled is retried when the policy is evaluated, but the caller has given up
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
 }
 
 // ReadPolicy creates a signer from reader
@@ -46,39 +286,361 @@ func ReadPolicy(in io.Reader) (Policy, error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
-// Evaluate the policy
+// Evaluate the policy, returning its violations
 func (p *policy) Evaluate(context context.Context, input interface{}) []*Violation {
-	violations := make([]*Violation, 0)
+	return p.EvaluateResult(context, input).Violations
+}
+
+// EvaluateResult evaluates the policy, returning its violations and result. A policy that defines a result rule only
+// passes when the result is an object whose pass field is true, so a violation is added when it isn't.
+func (p *policy) EvaluateResult(ctx context.Context, input interface{}) *Evaluation {
+	cacheKey := p.cacheKey(input)
+	if cacheKey != "" {
+		if evaluation, ok := p.options.evalCache.get(p.name, cacheKey); ok {
+			return evaluation
+		}
+	}
+
+	evaluation := &Evaluation{
+		Violations: make([]*Violation, 0),
+	}
+
+	stop := p.startTimer(policyPhaseEval)
+	defer stop()
 
 	var tracer *topdown.BufferTracer
 	if p.trace {
 		tracer = topdown.NewBufferTracer()
 	}
-	rego := rego.New(
-		rego.Query(fmt.Sprintf("data.%s.violation", p.name)),
+
+	evalCtx, cancel := p.withEvalTimeout(ctx)
+	defer cancel()
+
+	rs, err := p.eval(evalCtx, input, tracer)
+	if err != nil {
+		if topdown.IsCancel(err) && evalCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			err = fmt.Errorf("policy evaluation timed out after %s", p.options.evalTimeout)
+		}
+		evaluation.Violations = append(evaluation.Violations, NewViolation(err))
+		cacheKey = ""
+	}
+	if p.trace {
+		p.printTrace(*tracer)
+	}
+
+	var result interface{}
+	for _, v := range rs {
+		for _, e := range v.Expressions {
+			values := e.Value
+			if p.hasResult {
+				document, _ := e.Value.(map[string]interface{})
+				values = document["violation"]
+				result = document["result"]
+			}
+
+			violations, _ := values.([]interface{})
+			for _, val := range violations {
+				evaluation.Violations = append(evaluation.Violations, NewViolation(val))
+			}
+		}
+	}
+
+	if p.hasResult && err == nil {
+		var ok bool
+		evaluation.Result, ok = result.(map[string]interface{})
+		if !ok {
+			evaluation.Violations = append(evaluation.Violations, NewViolation(fmt.Errorf("policy result must be an object")))
+		} else if pass, _ := evaluation.Result[resultPassField].(bool); !pass {
+			evaluation.Violations = append(evaluation.Violations, NewViolation(fmt.Errorf("policy result %s is not true", resultPassField)))
+		}
+	}
+
+	if cacheKey != "" {
+		p.options.evalCache.set(cacheKey, evaluation)
+	}
+
+	return evaluation
+}
+
+// withEvalTimeout returns a context that's cancelled once the policy's evaluation timeout has passed
+func (p *policy) withEvalTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.options.evalTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, p.options.evalTimeout)
+}
+
+// printTrace writes the trace of an evaluation to the trace logger, or to stdout if the policy doesn't have one
+func (p *policy) printTrace(trace []*topdown.Event) {
+	if p.options.traceLog == nil {
+		topdown.PrettyTrace(os.Stdout, trace)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	topdown.PrettyTrace(buf, trace)
+	p.options.traceLog.V(1).Info("Policy trace", "policy", p.name, "trace", buf.String())
+}
+
+// cacheKey returns the key of the input's result in the eval cache, or an empty string if the result isn't cached.
+// Traced evaluations aren't cached so that the trace is always printed.
+func (p *policy) cacheKey(input interface{}) string {
+	if p.options.evalCache == nil || p.trace {
+		return ""
+	}
+
+	inputHash, err := hashJSON(input)
+	if err != nil {
+		return ""
+	}
+
+	p.preparedMutex.RLock()
+	defer p.preparedMutex.RUnlock()
+
+	return fmt.Sprintf("%s/%s/%s", p.moduleHash, p.dataHash, inputHash)
+}
+
+// eval evaluates the input against the partially evaluated policy when enabled, otherwise against the full policy
+func (p *policy) eval(ctx context.Context, input interface{}, tracer *topdown.BufferTracer) (rego.ResultSet, error) {
+	p.preparedMutex.RLock()
+	prepared := p.prepared
+	p.preparedMutex.RUnlock()
+
+	if prepared != nil {
+		opts := []rego.EvalOption{rego.EvalInput(input)}
+		if tracer != nil {
+			opts = append(opts, rego.EvalTracer(tracer))
+		}
+
+		return prepared.Eval(ctx, opts...)
+	}
+
+	r := rego.New(p.regoOptions(
+		rego.Query(p.query()),
 		rego.Compiler(p.compiler),
+		rego.Store(p.store),
 		rego.Input(input),
 		rego.Tracer(tracer),
-	)
-	rs, err := rego.Eval(context)
+	)...)
+
+	return r.Eval(ctx)
+}
+
+// prepare partially evaluates the policy against its static data and prepares the residual for evaluation
+func (p *policy) prepare(ctx context.Context) error {
+	p.preparedMutex.RLock()
+	generation := p.preparedGeneration
+	p.preparedMutex.RUnlock()
+
+	// partial evaluation adds the residual to the compiler's modules, so it gets a compiler of its own
+	compiler := ast.NewCompiler().WithBuiltins(builtinDecls(p.options.builtins))
+	compiler.Compile(p.modules)
+	if compiler.Failed() {
+		return compiler.Errors
+	}
+
+	partial, err := rego.New(p.regoOptions(
+		rego.Query(p.query()),
+		rego.Compiler(compiler),
+		rego.Store(p.store),
+	)...).PartialResult(ctx)
 	if err != nil {
-		violations = append(violations, NewViolation(err))
+		return err
 	}
-	if p.trace {
-		topdown.PrettyTrace(os.Stdout, *tracer)
+
+	// the residual is prepared separately, since it would otherwise be evaluated without the custom builtins
+	prepared, err := partial.Rego(p.regoOptions()...).PrepareForEval(ctx)
+	if err != nil {
+		return err
 	}
 
-	if len(rs) > 0 {
-		for _, v := range rs {
-			for _, e := range v.Expressions {
-				for _, val := range e.Value.([]interface{}) {
-					violations = append(violations, NewViolation(val))
-				}
-			}
+	p.preparedMutex.Lock()
+	defer p.preparedMutex.Unlock()
+
+	// the data changed while preparing, so the residual is already stale
+	if generation != p.preparedGeneration {
+		return fmt.Errorf("policy data changed during partial evaluation")
+	}
+	p.prepared = &prepared
+
+	return nil
+}
+
+// regoOptions adds the implementations of the policy's custom builtins to the options
+func (p *policy) regoOptions(opts ...func(*rego.Rego)) []func(*rego.Rego) {
+	for _, builtin := range p.options.builtins {
+		opts = append(opts, builtin.Function)
+	}
+
+	return opts
+}
+
+// SetData replaces the static data available to the policy. Any partially evaluated residual is discarded, so the
+// policy is fully evaluated until the residual has been prepared against the new data.
+func (p *policy) SetData(ctx context.Context, data map[string]interface{}) error {
+	err := storage.Txn(ctx, p.store, storage.WriteParams, func(txn storage.Transaction) error {
+		return p.store.Write(ctx, txn, storage.ReplaceOp, storage.Path{}, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	dataHash := ""
+	if p.options.evalCache != nil {
+		if dataHash, err = hashJSON(data); err != nil {
+			return err
 		}
 	}
 
-	return violations
+	p.preparedMutex.Lock()
+	p.prepared = nil
+	p.preparedGeneration++
+	p.dataHash = dataHash
+	p.preparedMutex.Unlock()
+
+	if p.options.partialEval {
+		go func() {
+			ctx, cancel := p.withEvalTimeout(context.Background())
+			defer cancel()
+			_ = p.prepare(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// checkQuery returns an error if the query doesn't refer to a partial set rule defined by the compiled policy
+func (p *policy) checkQuery(query string) error {
+	ref, err := ast.ParseRef(query)
+	if err != nil {
+		return fmt.Errorf("policy query %q is not a reference: %v", query, err)
+	}
+	if !ref.HasPrefix(ast.DefaultRootRef) {
+		return fmt.Errorf("policy query %q must refer to a rule under data", query)
+	}
+
+	rules := p.compiler.GetRulesExact(ref)
+	if len(rules) == 0 {
+		return fmt.Errorf("policy query %q does not refer to a rule defined by the policy", query)
+	}
+	for _, rule := range rules {
+		if rule.Head.DocKind() != ast.PartialSetDoc {
+			return fmt.Errorf("policy query %q must refer to a partial set rule of violations", query)
+		}
+	}
+
+	return nil
+}
+
+// PolicyVersion returns the version of a policy compiled from the modules with the query, which changes whenever any of
+// them change
+func PolicyVersion(modules map[string]string, query string) string {
+	// maps are encoded with sorted keys, so the version doesn't depend on the order of the modules
+	hash, err := hashJSON([]interface{}{modules, query})
+	if err != nil {
+		return ""
+	}
+
+	return hash[:16]
+}
+
+// checkRules returns an error if the policy's default query isn't defined by any of its rules, in which case the
+// policy never has violations and attests every resource. Policies with a query are checked by checkQuery instead.
+func (p *policy) checkRules() error {
+	if p.options.query != "" || p.hasResult {
+		return nil
+	}
+
+	if len(p.compiler.GetRulesExact(ast.MustParseRef(p.query()))) == 0 {
+		return fmt.Errorf("policy doesn't define a violation rule in package %s, so it would attest every resource", p.name)
+	}
+
+	return nil
+}
+
+// CheckRules returns an error describing why the policy can never have violations, which is when the rule it evaluates
+// isn't defined or the bodies of all of the rule's definitions are false, so that it attests every resource. The
+// policy still compiles and can be evaluated. Policies that define a result aren't checked, since their result decides
+// whether they pass.
+func CheckRules(p Policy) error {
+	compiled, ok := p.(*policy)
+	if !ok || compiled.hasResult {
+		return nil
+	}
+
+	if err := compiled.checkRules(); err != nil {
+		return err
+	}
+
+	rules := compiled.compiler.GetRulesExact(ast.MustParseRef(compiled.query()))
+	for _, rule := range rules {
+		if !isFalse(rule.Body) {
+			return nil
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("the bodies of the policy's %s rules are false, so it would attest every resource", compiled.query())
+}
+
+// isFalse returns true if the body is the single expression false
+func isFalse(body ast.Body) bool {
+	if len(body) != 1 || body[0].Negated {
+		return false
+	}
+
+	term, ok := body[0].Terms.(*ast.Term)
+	return ok && term.Value.Compare(ast.Boolean(false)) == 0
+}
+
+// query returns the policy's query if it has one, otherwise its violations, or the whole package document when the
+// policy also defines a result
+func (p *policy) query() string {
+	if p.options.query != "" {
+		return p.options.query
+	}
+
+	if p.hasResult {
+		return fmt.Sprintf("data.%s", p.name)
+	}
+
+	return fmt.Sprintf("data.%s.violation", p.name)
+}
+
+// Timings returns the durations recorded for the policy. They are only recorded when the policy was created WithTimings.
+func (p *policy) Timings() PolicyTimings {
+	p.timingsMutex.Lock()
+	defer p.timingsMutex.Unlock()
+
+	return p.timings
+}
+
+// startTimer starts timing a phase of the policy, returning a func that records the duration when called
+func (p *policy) startTimer(phase string) func() {
+	if !p.options.timed {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		duration := time.Since(start)
+		policyDuration.WithLabelValues(p.name, phase).Observe(duration.Seconds())
+
+		p.timingsMutex.Lock()
+		defer p.timingsMutex.Unlock()
+
+		switch phase {
+		case policyPhaseParse:
+			p.timings.Parse = duration
+		case policyPhaseCompile:
+			p.timings.Compile = duration
+		case policyPhaseEval:
+			p.timings.Eval = duration
+		}
+	}
 }
 
 func (p *policy) Serialize(out io.Writer) error {
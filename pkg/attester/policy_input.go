@@ -0,0 +1,133 @@
+package attester
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	packag "github.com/grafeas/grafeas/proto/v1beta1/package_go_proto"
+	vulnerability "github.com/grafeas/grafeas/proto/v1beta1/vulnerability_go_proto"
+)
+
+// VulnerabilityCounts counts the vulnerability occurrences of a resource by their effective severity, or by their
+// severity when they don't have an effective severity. It's the vulnerabilities field of a policy's input.
+type VulnerabilityCounts struct {
+	Total       int `json:"total"`
+	Critical    int `json:"critical"`
+	High        int `json:"high"`
+	Medium      int `json:"medium"`
+	Low         int `json:"low"`
+	Minimal     int `json:"minimal"`
+	Unspecified int `json:"unspecified"`
+
+	// Fixable is the number of vulnerabilities with a fixed version of an affected package
+	Fixable int `json:"fixable"`
+}
+
+// add counts the vulnerability
+func (c *VulnerabilityCounts) add(details *vulnerability.Details) {
+	c.Total++
+
+	severity := details.GetEffectiveSeverity()
+	if severity == vulnerability.Severity_SEVERITY_UNSPECIFIED {
+		severity = details.GetSeverity()
+	}
+	switch severity {
+	case vulnerability.Severity_CRITICAL:
+		c.Critical++
+	case vulnerability.Severity_HIGH:
+		c.High++
+	case vulnerability.Severity_MEDIUM:
+		c.Medium++
+	case vulnerability.Severity_LOW:
+		c.Low++
+	case vulnerability.Severity_MINIMAL:
+		c.Minimal++
+	default:
+		c.Unspecified++
+	}
+
+	for _, issue := range details.GetPackageIssue() {
+		if fixed := issue.GetFixedLocation(); fixed != nil && fixed.GetVersion().GetKind() != packag.Version_MAXIMUM {
+			c.Fixable++
+			break
+		}
+	}
+}
+
+// BuildProvenance is the provenance of one of a resource's build occurrences. The builds field of a policy's input
+// lists them.
+type BuildProvenance struct {
+	ID             string            `json:"id"`
+	ProjectID      string            `json:"projectId"`
+	Creator        string            `json:"creator"`
+	BuilderVersion string            `json:"builderVersion"`
+	TriggerID      string            `json:"triggerId"`
+	LogsURI        string            `json:"logsUri"`
+	BuildOptions   map[string]string `json:"buildOptions"`
+
+	// SourceURI is where the build's source was stored, and Revision is the git revision it was built from, when known
+	SourceURI string `json:"sourceUri"`
+	Revision  string `json:"revision"`
+
+	// Artifacts are the IDs of the artifacts the build produced, such as image digests
+	Artifacts []string `json:"artifacts"`
+
+	// CreateTime, StartTime and EndTime are RFC 3339 timestamps, empty when unknown
+	CreateTime string `json:"createTime"`
+	StartTime  string `json:"startTime"`
+	EndTime    string `json:"endTime"`
+}
+
+// newBuildProvenance returns the provenance of the build occurrence
+func newBuildProvenance(occurrence *grafeas.Occurrence) BuildProvenance {
+	provenance := occurrence.GetBuild().GetProvenance()
+
+	artifacts := make([]string, 0, len(provenance.GetBuiltArtifacts()))
+	for _, artifact := range provenance.GetBuiltArtifacts() {
+		artifacts = append(artifacts, artifact.GetId())
+	}
+
+	return BuildProvenance{
+		ID:             provenance.GetId(),
+		ProjectID:      provenance.GetProjectId(),
+		Creator:        provenance.GetCreator(),
+		BuilderVersion: provenance.GetBuilderVersion(),
+		TriggerID:      provenance.GetTriggerId(),
+		LogsURI:        provenance.GetLogsUri(),
+		BuildOptions:   provenance.GetBuildOptions(),
+		SourceURI:      provenance.GetSourceProvenance().GetArtifactStorageSourceUri(),
+		Revision:       provenance.GetSourceProvenance().GetContext().GetGit().GetRevisionId(),
+		Artifacts:      artifacts,
+		CreateTime:     formatTimestamp(provenance.GetCreateTime()),
+		StartTime:      formatTimestamp(provenance.GetStartTime()),
+		EndTime:        formatTimestamp(provenance.GetEndTime()),
+	}
+}
+
+// formatTimestamp returns the timestamp in RFC 3339 format, or an empty string if it isn't set or valid
+func formatTimestamp(ts *timestamp.Timestamp) string {
+	t, err := ptypes.Timestamp(ts)
+	if ts == nil || err != nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// newOccurrenceInput returns an input without occurrences. The builds are an empty list rather than null, so that
+// policies can count them. The occurrences are left null, as they always have been for resources without occurrences.
+func newOccurrenceInput() *occurrenceInput {
+	return &occurrenceInput{Builds: make([]BuildProvenance, 0)}
+}
+
+// addSummary adds the occurrence to the summaries of the input's occurrences
+func (oi *occurrenceInput) addSummary(occurrence *grafeas.Occurrence) {
+	switch {
+	case occurrence.GetVulnerability() != nil:
+		oi.Vulnerabilities.add(occurrence.GetVulnerability())
+	case occurrence.GetBuild() != nil:
+		oi.Builds = append(oi.Builds, newBuildProvenance(occurrence))
+	}
+}
@@ -0,0 +1,176 @@
+package attester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	build "github.com/grafeas/grafeas/proto/v1beta1/build_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	packag "github.com/grafeas/grafeas/proto/v1beta1/package_go_proto"
+	provenance "github.com/grafeas/grafeas/proto/v1beta1/provenance_go_proto"
+	source "github.com/grafeas/grafeas/proto/v1beta1/source_go_proto"
+	vulnerability "github.com/grafeas/grafeas/proto/v1beta1/vulnerability_go_proto"
+	"github.com/stretchr/testify/assert"
+)
+
+var enrichedInputPolicy = `
+package enriched
+
+violation[{"msg":"critical vulnerabilities found"}]{
+	input.vulnerabilities.critical > 0
+}
+
+violation[{"msg":"fixable high vulnerabilities found"}]{
+	input.vulnerabilities.high > 0
+	input.vulnerabilities.fixable > 0
+}
+
+violation[{"msg":"not built by the trusted builder"}]{
+	count(input.builds) == 0
+}
+
+violation[{"msg":"not built by the trusted builder"}]{
+	input.builds[_].creator != "ci@example.com"
+}
+
+violation[{"msg":"vulnerability not scored"}]{
+	input.occurrences[_].vulnerability.cvssScore == 0
+}
+`
+
+func vulnerabilityOccurrence(severity, effective vulnerability.Severity, fixed bool) *grafeas.Occurrence {
+	issue := &vulnerability.PackageIssue{}
+	if fixed {
+		issue.FixedLocation = &vulnerability.VulnerabilityLocation{Version: &packag.Version{Kind: packag.Version_NORMAL, Name: "1.2.3"}}
+	} else {
+		issue.FixedLocation = &vulnerability.VulnerabilityLocation{Version: &packag.Version{Kind: packag.Version_MAXIMUM}}
+	}
+
+	return &grafeas.Occurrence{
+		Details: &grafeas.Occurrence_Vulnerability{
+			Vulnerability: &vulnerability.Details{
+				Severity:          severity,
+				EffectiveSeverity: effective,
+				CvssScore:         5,
+				PackageIssue:      []*vulnerability.PackageIssue{issue},
+			},
+		},
+	}
+}
+
+func buildOccurrence(creator string) *grafeas.Occurrence {
+	created, _ := ptypes.TimestampProto(time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	return &grafeas.Occurrence{
+		Details: &grafeas.Occurrence_Build{
+			Build: &build.Details{
+				Provenance: &provenance.BuildProvenance{
+					Id:             "build-1",
+					ProjectId:      "rode",
+					Creator:        creator,
+					BuilderVersion: "2.0",
+					CreateTime:     created,
+					BuiltArtifacts: []*provenance.Artifact{{Id: "harbor.example.com/app@sha256:1234"}},
+					SourceProvenance: &provenance.Source{
+						ArtifactStorageSourceUri: "gs://builds/source.tgz",
+						Context: &source.SourceContext{
+							Context: &source.SourceContext_Git{Git: &source.GitSourceContext{RevisionId: "abc123"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestOccurrenceInput_Summaries(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	input := newOccurrenceInput()
+	for _, o := range []*grafeas.Occurrence{
+		vulnerabilityOccurrence(vulnerability.Severity_CRITICAL, vulnerability.Severity_SEVERITY_UNSPECIFIED, true),
+		vulnerabilityOccurrence(vulnerability.Severity_CRITICAL, vulnerability.Severity_LOW, false),
+		vulnerabilityOccurrence(vulnerability.Severity_SEVERITY_UNSPECIFIED, vulnerability.Severity_SEVERITY_UNSPECIFIED, false),
+		buildOccurrence("ci@example.com"),
+	} {
+		assert.NoError(input.addOccurrence(ctx, o, nil))
+	}
+
+	// the effective severity is counted when it's set, otherwise the severity
+	assert.Equal(VulnerabilityCounts{Total: 3, Critical: 1, Low: 1, Unspecified: 1, Fixable: 1}, input.Vulnerabilities)
+	assert.Equal([]BuildProvenance{{
+		ID:             "build-1",
+		ProjectID:      "rode",
+		Creator:        "ci@example.com",
+		BuilderVersion: "2.0",
+		SourceURI:      "gs://builds/source.tgz",
+		Revision:       "abc123",
+		Artifacts:      []string{"harbor.example.com/app@sha256:1234"},
+		CreateTime:     "2020-03-01T12:00:00Z",
+	}}, input.Builds)
+
+	// the raw occurrences are still in the input
+	assert.Len(input.Occurrences, 4)
+}
+
+func TestAttester_EnrichedInput(t *testing.T) {
+	ctx := context.Background()
+
+	policy, err := NewPolicy(ctx, "enriched", enrichedInputPolicy, false)
+	assert.NoError(t, err)
+	signer, err := NewSigner("enriched")
+	assert.NoError(t, err)
+	att := NewAttester("enriched", policy, signer)
+
+	testCases := []struct {
+		name        string
+		occurrences []*grafeas.Occurrence
+		violations  []string
+	}{
+		{
+			name: "pass",
+			occurrences: []*grafeas.Occurrence{
+				vulnerabilityOccurrence(vulnerability.Severity_HIGH, vulnerability.Severity_SEVERITY_UNSPECIFIED, false),
+				buildOccurrence("ci@example.com"),
+			},
+		},
+		{
+			name: "critical vulnerability",
+			occurrences: []*grafeas.Occurrence{
+				vulnerabilityOccurrence(vulnerability.Severity_LOW, vulnerability.Severity_CRITICAL, false),
+				buildOccurrence("ci@example.com"),
+			},
+			violations: []string{"critical vulnerabilities found"},
+		},
+		{
+			name: "fixable high vulnerability",
+			occurrences: []*grafeas.Occurrence{
+				vulnerabilityOccurrence(vulnerability.Severity_HIGH, vulnerability.Severity_SEVERITY_UNSPECIFIED, true),
+				buildOccurrence("ci@example.com"),
+			},
+			violations: []string{"fixable high vulnerabilities found"},
+		},
+		{
+			name:        "untrusted builder",
+			occurrences: []*grafeas.Occurrence{buildOccurrence("laptop@example.com")},
+			violations:  []string{"not built by the trusted builder"},
+		},
+		{
+			name:        "no build",
+			occurrences: []*grafeas.Occurrence{},
+			violations:  []string{"not built by the trusted builder"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decision, err := att.DryRun(ctx, &AttestRequest{ResourceURI: "harbor.example.com/app@sha256:1234", Occurrences: tc.occurrences})
+			assert.NoError(t, err)
+
+			assert.ElementsMatch(t, tc.violations, decision.Violations)
+		})
+	}
+}
@@ -3,9 +3,13 @@ package attester
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 )
 
 func TestClient_Evaluate(t *testing.T) {
@@ -22,7 +26,7 @@ violation[{"msg": "v2"}] {
 	input.a = "z"
 }
 `
-	c, err := NewPolicy("mytest", module, true)
+	c, err := NewPolicy(ctx, "mytest", module, true)
 	assert.NoError(err)
 
 	input := map[string]string{
@@ -211,7 +215,7 @@ func TestClient_EvaluateGrafeas(t *testing.T) {
 
 func evalAttenstationRego(occurrencesJSON string) []*Violation {
 	ctx := context.Background()
-	c, err := NewPolicy("default_attester", attenstationRego, true)
+	c, err := NewPolicy(ctx, "default_attester", attenstationRego, true)
 	if err != nil {
 		panic(err)
 	}
@@ -222,3 +226,442 @@ func evalAttenstationRego(occurrencesJSON string) []*Violation {
 	}
 	return c.Evaluate(ctx, listOccurrences)
 }
+
+func TestPolicy_Timings(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	untimed, err := NewPolicy(ctx, "default_attester", attenstationRego, false)
+	assert.NoError(err)
+	untimed.Evaluate(ctx, map[string]interface{}{})
+	assert.Equal(PolicyTimings{}, untimed.Timings())
+
+	timed, err := NewPolicy(ctx, "default_attester", attenstationRego, false, WithTimings(true))
+	assert.NoError(err)
+	assert.NotZero(timed.Timings().Parse)
+	assert.NotZero(timed.Timings().Compile)
+	assert.Zero(timed.Timings().Eval)
+
+	timed.Evaluate(ctx, map[string]interface{}{})
+	assert.NotZero(timed.Timings().Eval)
+}
+
+var thresholdRego = `
+package threshold
+violation[{"msg":"too many high vulnerabilities"}]{
+	count([v | v := input.occurrences[_].vulnerability.severity; v == "HIGH"]) > data.limits.high
+}
+`
+
+func TestPolicy_PartialEval(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	full, err := NewPolicy(ctx, "default_attester", attenstationRego, false)
+	assert.NoError(err)
+	partial, err := NewPolicy(ctx, "default_attester", attenstationRego, false, WithPartialEval(true))
+	assert.NoError(err)
+
+	for _, occurrencesJSON := range []string{emptyOccurrences, successDiscoveryOccurrences, failDiscoveryOccurrences, highVuln, lowVuln} {
+		input := make(map[string]interface{})
+		assert.NoError(json.Unmarshal([]byte(occurrencesJSON), &input))
+
+		assert.Equal(full.Evaluate(ctx, input), partial.Evaluate(ctx, input))
+	}
+}
+
+func TestPolicy_SetData(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	input := make(map[string]interface{})
+	assert.NoError(json.Unmarshal([]byte(highVuln), &input))
+
+	p, err := NewPolicy(ctx, "threshold", thresholdRego, false, WithPartialEval(true), WithData(map[string]interface{}{
+		"limits": map[string]interface{}{"high": 0},
+	}))
+	assert.NoError(err)
+	assert.Len(p.Evaluate(ctx, input), 1)
+
+	err = p.SetData(ctx, map[string]interface{}{
+		"limits": map[string]interface{}{"high": 1},
+	})
+	assert.NoError(err)
+
+	// evaluated in full until the residual has been prepared against the new data, and partially afterwards
+	assert.Empty(p.Evaluate(ctx, input))
+	assert.Eventually(func() bool {
+		p.(*policy).preparedMutex.RLock()
+		defer p.(*policy).preparedMutex.RUnlock()
+
+		return p.(*policy).prepared != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(p.Evaluate(ctx, input))
+}
+
+func BenchmarkPolicy_Evaluate(b *testing.B) {
+	benchmarkPolicyEvaluate(b, false)
+}
+
+func BenchmarkPolicy_EvaluatePartial(b *testing.B) {
+	benchmarkPolicyEvaluate(b, true)
+}
+
+func benchmarkPolicyEvaluate(b *testing.B, partialEval bool) {
+	ctx := context.Background()
+
+	p, err := NewPolicy(ctx, "default_attester", attenstationRego, false, WithPartialEval(partialEval))
+	if err != nil {
+		b.Fatal(err)
+	}
+	input := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(highVuln), &input); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Evaluate(ctx, input)
+	}
+}
+
+func TestPolicy_EvalCache(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	input := make(map[string]interface{})
+	assert.NoError(json.Unmarshal([]byte(highVuln), &input))
+
+	cache := NewEvalCache(time.Minute)
+	p, err := NewPolicy(ctx, "threshold", thresholdRego, false, WithEvalCache(cache), WithData(map[string]interface{}{
+		"limits": map[string]interface{}{"high": 0},
+	}))
+	assert.NoError(err)
+
+	first := p.Evaluate(ctx, input)
+	assert.Len(first, 1)
+	second := p.Evaluate(ctx, input)
+	assert.Len(second, 1)
+	assert.Same(first[0], second[0], "second evaluation should be cached")
+
+	// changing the data invalidates the cached results
+	err = p.SetData(ctx, map[string]interface{}{
+		"limits": map[string]interface{}{"high": 1},
+	})
+	assert.NoError(err)
+	assert.Empty(p.Evaluate(ctx, input))
+
+	// a different policy sharing the cache doesn't get the threshold policy's results
+	other, err := NewPolicy(ctx, "default_attester", attenstationRego, false, WithEvalCache(cache))
+	assert.NoError(err)
+	assert.Len(other.Evaluate(ctx, input), 1)
+	assert.Equal("high vulnerability found", other.Evaluate(ctx, input)[0].Msg)
+
+	expired := NewEvalCache(time.Nanosecond)
+	p, err = NewPolicy(ctx, "default_attester", attenstationRego, false, WithEvalCache(expired))
+	assert.NoError(err)
+	first = p.Evaluate(ctx, input)
+	time.Sleep(time.Millisecond)
+	second = p.Evaluate(ctx, input)
+	assert.False(first[0] == second[0], "expired result should be evaluated again")
+}
+
+var resultRego = `
+package scored
+
+default result = {"pass": false, "score": 0}
+
+result = {"pass": score >= 50, "score": score, "category": "vulnerability", "advice": advice} {
+	score := 100 - 25 * count([v | v := input.occurrences[_].vulnerability; v.severity == "HIGH"])
+	advice := "upgrade the base image"
+}
+
+violation[{"msg": "analysis failed"}] {
+	input.occurrences[_].discovered.discovered.analysisStatus == "FINISHED_FAILED"
+}
+`
+
+func TestPolicy_EvaluateResult(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	full, err := NewPolicy(ctx, "scored", resultRego, false)
+	assert.NoError(err)
+	partial, err := NewPolicy(ctx, "scored", resultRego, false, WithPartialEval(true))
+	assert.NoError(err)
+
+	for _, p := range []Policy{full, partial} {
+		input := make(map[string]interface{})
+		assert.NoError(json.Unmarshal([]byte(highVuln), &input))
+
+		evaluation := p.EvaluateResult(ctx, input)
+		assert.Empty(evaluation.Violations)
+		assert.Equal(true, evaluation.Result["pass"])
+		assert.Equal("vulnerability", evaluation.Result["category"])
+		assert.Equal(json.Number("75"), evaluation.Result["score"])
+
+		input = make(map[string]interface{})
+		assert.NoError(json.Unmarshal([]byte(failDiscoveryOccurrences), &input))
+		assert.Equal([]string{"analysis failed"}, violationMessages(p.Evaluate(ctx, input)))
+	}
+
+	failing, err := NewPolicy(ctx, "failing", "package failing\nresult = {\"pass\": false, \"score\": 10}\nviolation[{\"msg\": \"never\"}] { false }", false)
+	assert.NoError(err)
+	evaluation := failing.EvaluateResult(ctx, map[string]interface{}{})
+	assert.Equal([]string{"policy result pass is not true"}, violationMessages(evaluation.Violations))
+	assert.Equal(json.Number("10"), evaluation.Result["score"])
+
+	invalid, err := NewPolicy(ctx, "invalid", "package invalid\nresult = \"pass\"\nviolation[{\"msg\": \"never\"}] { false }", false)
+	assert.NoError(err)
+	assert.Equal([]string{"policy result must be an object"}, violationMessages(invalid.Evaluate(ctx, map[string]interface{}{})))
+}
+
+var sharedRego = `
+package shared
+
+strict_violation[{"msg": "vulnerability found"}] {
+	severity := input.occurrences[_].vulnerability.severity
+	severity != "LOW"
+}
+
+lenient_violation[{"msg": "critical vulnerability found"}] {
+	input.occurrences[_].vulnerability.severity == "CRITICAL"
+}
+
+allow {
+	count(strict_violation) == 0
+}
+`
+
+func TestPolicy_Query(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	input := map[string]interface{}{
+		"occurrences": []interface{}{
+			map[string]interface{}{"vulnerability": map[string]interface{}{"severity": "HIGH"}},
+		},
+	}
+
+	for _, partialEval := range []bool{false, true} {
+		// two attesters share the module, each evaluating a different rule
+		strict, err := NewPolicy(ctx, "strict", sharedRego, false, WithQuery("data.shared.strict_violation"), WithPartialEval(partialEval))
+		assert.NoError(err)
+		lenient, err := NewPolicy(ctx, "lenient", sharedRego, false, WithQuery("data.shared.lenient_violation"), WithPartialEval(partialEval))
+		assert.NoError(err)
+
+		violations := strict.Evaluate(ctx, input)
+		assert.Len(violations, 1)
+		assert.Equal("vulnerability found", violations[0].Msg)
+		assert.Empty(lenient.Evaluate(ctx, input))
+	}
+
+	// policies with the same name and module but different queries don't share cached results
+	cache := NewEvalCache(time.Minute)
+	strict, err := NewPolicy(ctx, "shared", sharedRego, false, WithQuery("data.shared.strict_violation"), WithEvalCache(cache))
+	assert.NoError(err)
+	lenient, err := NewPolicy(ctx, "shared", sharedRego, false, WithQuery("data.shared.lenient_violation"), WithEvalCache(cache))
+	assert.NoError(err)
+	assert.Len(strict.Evaluate(ctx, input), 1)
+	assert.Empty(lenient.Evaluate(ctx, input))
+}
+
+func TestPolicy_InvalidQuery(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		err   string
+	}{
+		"not a reference":   {"1 + 1", "is not a reference"},
+		"not data":          {"input.occurrences", "must refer to a rule under data"},
+		"undefined rule":    {"data.shared.missing_violation", "does not refer to a rule defined by the policy"},
+		"other package":     {"data.other.strict_violation", "does not refer to a rule defined by the policy"},
+		"package":           {"data.shared", "does not refer to a rule defined by the policy"},
+		"within a rule":     {"data.shared.strict_violation.msg", "does not refer to a rule defined by the policy"},
+		"complete document": {"data.shared.allow", "must refer to a partial set rule of violations"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPolicy(ctx, "shared", sharedRego, false, WithQuery(tc.query))
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	tests := map[string]struct {
+		module string
+		query  string
+		err    string
+	}{
+		"violation rules": {module: `package rules
+violation[{"msg":"failed"}]{
+	input.occurrences[_].failed
+}`},
+		"no rules": {module: `package rules
+allow = true`, err: "doesn't define a violation rule in package rules"},
+		"false rules": {module: `package rules
+violation[{"msg":"never"}]{
+	false
+}`, err: "the bodies of the policy's data.rules.violation rules are false"},
+		"some false rules": {module: `package rules
+violation[{"msg":"never"}]{
+	false
+}
+violation[{"msg":"failed"}]{
+	input.occurrences[_].failed
+}`},
+		"false query": {module: sharedRego + `
+never_violation[{"msg":"never"}]{
+	false
+}`, query: "data.shared.never_violation", err: "the bodies of the policy's data.shared.never_violation rules are false"},
+		"query": {module: sharedRego, query: "data.shared.strict_violation"},
+		"result": {module: `package rules
+result = {"pass": true}`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// the policies compile whether or not they have rules
+			p, err := NewPolicy(ctx, "rules", tc.module, false, WithQuery(tc.query))
+			assert.NoError(t, err)
+
+			err = CheckRules(p)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+func TestNewPolicyFromModules(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	modules := map[string]string{
+		"lib.rego": `
+package lib
+
+blocking(severity) {
+	severity == "HIGH"
+}
+`,
+		"main.rego": `
+package modular
+
+import data.lib
+
+violation[{"msg": "blocking vulnerability found"}] {
+	lib.blocking(input.occurrences[_].vulnerability.severity)
+}
+`,
+		"empty.rego": "",
+	}
+
+	p, err := NewPolicyFromModules(ctx, "modular", modules, false)
+	assert.NoError(err)
+
+	input := make(map[string]interface{})
+	assert.NoError(json.Unmarshal([]byte(highVuln), &input))
+	violations := p.Evaluate(ctx, input)
+	assert.Len(violations, 1)
+	assert.Equal("blocking vulnerability found", violations[0].Msg)
+
+	// the modules are compiled together, so a module can't be compiled without those it depends on
+	_, err = NewPolicyFromModules(ctx, "modular", map[string]string{"main.rego": modules["main.rego"]}, false)
+	assert.Error(err)
+
+	_, err = NewPolicyFromModules(ctx, "modular", map[string]string{"lib.rego": modules["lib.rego"], "broken.rego": "package broken\nviolation[{"}, false)
+	assert.Error(err)
+	assert.Contains(err.Error(), "broken.rego")
+
+	_, err = NewPolicyFromModules(ctx, "modular", map[string]string{"empty.rego": ""}, false)
+	assert.EqualError(err, "policy is empty")
+	_, err = NewPolicyFromModules(ctx, "modular", nil, false)
+	assert.EqualError(err, "policy is empty")
+}
+
+func TestPolicy_EvalTimeout(t *testing.T) {
+	// the policy iterates over every triple of occurrences, which takes far longer than the timeout
+	module := `
+package slow
+
+violation[{"msg": "never"}] {
+	count([1 | input.occurrences[_]; input.occurrences[_]; input.occurrences[_]]) < 0
+}
+`
+	occurrences := make([]interface{}, 2000)
+	for i := range occurrences {
+		occurrences[i] = i
+	}
+	input := map[string]interface{}{"occurrences": occurrences}
+
+	for _, partialEval := range []bool{false, true} {
+		t.Run(fmt.Sprintf("partialEval=%t", partialEval), func(t *testing.T) {
+			assert := assert.New(t)
+
+			p, err := NewPolicy(ctx, "slow", module, false, WithEvalTimeout(100*time.Millisecond), WithPartialEval(partialEval))
+			assert.NoError(err)
+
+			start := time.Now()
+			violations := p.Evaluate(context.Background(), input)
+			assert.True(time.Since(start) < 5*time.Second, "the evaluation wasn't cancelled")
+			assert.Len(violations, 1)
+			assert.Equal("policy evaluation timed out after 100ms", violations[0].Msg)
+		})
+	}
+}
+
+func TestNewPolicy_Cancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, partialEval := range []bool{false, true} {
+		policy, err := NewPolicy(cancelled, "cancelled", "package cancelled\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false, WithPartialEval(partialEval))
+		assert.Equal(context.Canceled, err)
+		assert.Nil(policy)
+	}
+}
+
+func TestNewPolicy_RegoVersion(t *testing.T) {
+	v0 := "package versioned\nviolation[{\"msg\":\"untrusted\"}]{\n\tinput.untrusted\n}"
+	v1 := "package versioned\nviolation contains {\"msg\":\"untrusted\"} if {\n\tinput.untrusted\n}"
+
+	testCases := []struct {
+		name    string
+		module  string
+		version rodev1alpha1.RegoVersion
+		err     string
+	}{
+		{name: "v0 by default", module: v0},
+		{name: "v0", module: v0, version: rodev1alpha1.RegoVersionV0},
+		{name: "v1 syntax parsed as v0", module: v1, version: rodev1alpha1.RegoVersionV0, err: "rego_parse_error"},
+		{name: "v1", module: v1, version: rodev1alpha1.RegoVersionV1, err: "rego version v1 requires OPA 1.0 or later, but the bundled OPA only parses rego version v0"},
+		{name: "unknown", module: v0, version: "v2", err: `unsupported rego version "v2", must be one of [v0]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			policy, err := NewPolicy(context.Background(), "versioned", tc.module, false, WithRegoVersion(tc.version))
+			if tc.err != "" {
+				assert.Error(err)
+				if err != nil {
+					assert.Contains(err.Error(), tc.err)
+				}
+				return
+			}
+
+			assert.NoError(err)
+			assert.Len(policy.Evaluate(context.Background(), map[string]interface{}{"untrusted": true}), 1)
+			assert.Empty(policy.Evaluate(context.Background(), map[string]interface{}{"untrusted": false}))
+		})
+	}
+}
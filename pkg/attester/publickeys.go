@@ -0,0 +1,80 @@
+package attester
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PublicKeyName returns the key that the attester's public key is stored under in the public keys ConfigMap. ConfigMap
+// keys can't contain a slash, and namespaces can't contain a dot, so the namespace and name are joined with a dot.
+func PublicKeyName(attester types.NamespacedName) string {
+	return attester.Namespace + "." + attester.Name
+}
+
+// PublishPublicKey stores the attester's armored public key in the ConfigMap, creating the ConfigMap if it doesn't
+// exist, so that verifiers can read every attester's key from a single place
+func PublishPublicKey(ctx context.Context, c client.Client, configMap, attester types.NamespacedName, publicKey string) error {
+	return updatePublicKeys(ctx, c, configMap, func(data map[string]string) bool {
+		if data[PublicKeyName(attester)] == publicKey {
+			return false
+		}
+
+		data[PublicKeyName(attester)] = publicKey
+		return true
+	})
+}
+
+// UnpublishPublicKey removes the attester's public key from the ConfigMap
+func UnpublishPublicKey(ctx context.Context, c client.Client, configMap, attester types.NamespacedName) error {
+	return updatePublicKeys(ctx, c, configMap, func(data map[string]string) bool {
+		if _, ok := data[PublicKeyName(attester)]; !ok {
+			return false
+		}
+
+		delete(data, PublicKeyName(attester))
+		return true
+	})
+}
+
+// updatePublicKeys applies the change to the data of the latest ConfigMap, writing it if the change returns true. Since
+// every attester's key is stored in the same ConfigMap, conflicting updates are retried with the latest ConfigMap.
+func updatePublicKeys(ctx context.Context, c client.Client, configMap types.NamespacedName, change func(map[string]string) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing := &corev1.ConfigMap{}
+		err := c.Get(ctx, configMap, existing)
+		if errors.IsNotFound(err) {
+			created := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: configMap.Namespace, Name: configMap.Name},
+				Data:       make(map[string]string),
+			}
+			if !change(created.Data) {
+				return nil
+			}
+
+			err = c.Create(ctx, created)
+			if errors.IsAlreadyExists(err) {
+				// another attester created it first, so the change is retried on the ConfigMap it created
+				return errors.NewConflict(corev1.Resource("configmaps"), configMap.Name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if existing.Data == nil {
+			existing.Data = make(map[string]string)
+		}
+		if !change(existing.Data) {
+			return nil
+		}
+
+		return c.Update(ctx, existing)
+	})
+}
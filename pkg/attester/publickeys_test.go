@@ -0,0 +1,86 @@
+package attester
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// optimisticClient rejects updates of ConfigMaps that were changed since they were read, as the API server does, which
+// the fake client doesn't
+type optimisticClient struct {
+	client.Client
+	mutex sync.Mutex
+}
+
+func (c *optimisticClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if updated, ok := obj.(*corev1.ConfigMap); ok {
+		current := &corev1.ConfigMap{}
+		if err := c.Client.Get(ctx, types.NamespacedName{Namespace: updated.Namespace, Name: updated.Name}, current); err != nil {
+			return err
+		}
+		if current.ResourceVersion != updated.ResourceVersion {
+			return errors.NewConflict(corev1.Resource("configmaps"), updated.Name, fmt.Errorf("the object has been modified"))
+		}
+	}
+
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *optimisticClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestPublishPublicKey(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	c := &optimisticClient{Client: newEscrowTestClient()}
+	configMap := types.NamespacedName{Namespace: "rode", Name: "attester-public-keys"}
+	publicKeys := func() map[string]string {
+		cm := &corev1.ConfigMap{}
+		assert.NoError(c.Get(ctx, configMap, cm))
+		return cm.Data
+	}
+
+	// attesters publishing their keys at the same time don't overwrite each other's keys
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			attester := types.NamespacedName{Namespace: "default", Name: fmt.Sprintf("attester%d", i)}
+			assert.NoError(PublishPublicKey(ctx, c, configMap, attester, fmt.Sprintf("key%d", i)))
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(publicKeys(), 5)
+	assert.Equal("key3", publicKeys()["default.attester3"])
+
+	attester := types.NamespacedName{Namespace: "default", Name: "attester0"}
+	assert.NoError(PublishPublicKey(ctx, c, configMap, attester, "rotated"))
+	assert.Equal("rotated", publicKeys()["default.attester0"])
+
+	assert.NoError(UnpublishPublicKey(ctx, c, configMap, attester))
+	assert.NotContains(publicKeys(), "default.attester0")
+	assert.Len(publicKeys(), 4)
+
+	// removing a key that isn't published, or from a ConfigMap that doesn't exist, does nothing
+	assert.NoError(UnpublishPublicKey(ctx, c, configMap, attester))
+	assert.NoError(UnpublishPublicKey(ctx, c, types.NamespacedName{Namespace: "rode", Name: "missing"}, attester))
+	assert.Error(c.Get(ctx, types.NamespacedName{Namespace: "rode", Name: "missing"}, &corev1.ConfigMap{}))
+}
@@ -0,0 +1,312 @@
+package attester
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// AttestStatePending means the resource is waiting to be attested
+	AttestStatePending = "Pending"
+	// AttestStateRetrying means attesting the resource failed and will be retried
+	AttestStateRetrying = "Retrying"
+	// AttestStateCompleted means the resource was evaluated and any attestations were stored
+	AttestStateCompleted = "Completed"
+
+	jobFileSuffix   = ".json"
+	maxRetryBackoff = 5 * time.Minute
+
+	// completedStatusRetention is how long the status of a completed job can be queried for
+	completedStatusRetention = time.Hour
+)
+
+// AttestStatus describes the progress of attesting a resource asynchronously
+type AttestStatus struct {
+	ResourceURI string    `json:"resourceUri"`
+	State       string    `json:"state"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// attestJob is the durable record of a resource waiting to be attested
+type attestJob struct {
+	ResourceURI string `json:"resourceUri"`
+	Kind        string `json:"kind,omitempty"`
+	Sequence    int64  `json:"sequence"`
+}
+
+// AttestQueue attests resources asynchronously. Jobs are persisted to a directory until they complete, so they are
+// retried after a restart, and failed jobs are retried with a backoff. Jobs are processed at least once, so processing
+// must be idempotent.
+type AttestQueue struct {
+	log     logr.Logger
+	dir     string
+	process func(ctx context.Context, resourceURI, kind string) error
+	jobs    chan string
+
+	mutex    sync.Mutex
+	sequence int64
+	queued   map[string]bool
+	statuses map[string]*AttestStatus
+}
+
+// NewAttestQueue creates a queue that persists jobs in dir, loading any jobs left from a previous run
+func NewAttestQueue(log logr.Logger, dir string) (*AttestQueue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	q := &AttestQueue{
+		log:      log,
+		dir:      dir,
+		jobs:     make(chan string, 1024),
+		sequence: time.Now().UnixNano(),
+		queued:   make(map[string]bool),
+		statuses: make(map[string]*AttestStatus),
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+jobFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		job, err := readAttestJob(file)
+		if err != nil {
+			log.Error(err, "Unable to read attestation job", "file", file)
+			continue
+		}
+
+		q.setStatus(job.ResourceURI, AttestStatePending, nil)
+		q.push(job.ResourceURI)
+	}
+
+	return q, nil
+}
+
+// Enqueue persists a job to attest the resource and queues it for a worker. The kind is the kind of occurrence that
+// triggered the attestation.
+func (q *AttestQueue) Enqueue(resourceURI, kind string) error {
+	q.mutex.Lock()
+	q.sequence++
+	err := q.writeJob(&attestJob{ResourceURI: resourceURI, Kind: kind, Sequence: q.sequence})
+	if err == nil {
+		q.setStatusLocked(resourceURI, AttestStatePending, nil)
+	}
+	q.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	q.push(resourceURI)
+
+	return nil
+}
+
+// Status returns the status of the most recent job for the resource
+func (q *AttestQueue) Status(resourceURI string) (*AttestStatus, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	status, ok := q.statuses[resourceURI]
+	if !ok {
+		return nil, false
+	}
+
+	copied := *status
+	return &copied, true
+}
+
+// Start runs the workers until stop is closed
+func (q *AttestQueue) Start(workers int, stop <-chan struct{}) {
+	for i := 0; i < workers; i++ {
+		go q.work(stop)
+	}
+}
+
+func (q *AttestQueue) work(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case resourceURI := <-q.jobs:
+			q.processJob(resourceURI)
+		}
+	}
+}
+
+func (q *AttestQueue) processJob(resourceURI string) {
+	q.mutex.Lock()
+	delete(q.queued, resourceURI)
+	job, err := readAttestJob(q.jobPath(resourceURI))
+	if status, ok := q.statuses[resourceURI]; ok && err == nil {
+		status.Attempts++
+	}
+	q.mutex.Unlock()
+
+	if err != nil {
+		// the job was completed by an earlier run of a duplicate
+		if os.IsNotExist(err) {
+			return
+		}
+
+		q.log.Error(err, "Unable to read attestation job", "uri", resourceURI)
+		return
+	}
+
+	err = q.process(context.Background(), resourceURI, job.Kind)
+	if err != nil {
+		status := q.setStatus(resourceURI, AttestStateRetrying, err)
+		backoff := retryBackoff(status.Attempts)
+		q.log.Error(err, "Unable to attest resource, retrying", "uri", resourceURI, "attempts", status.Attempts, "backoff", backoff)
+
+		time.AfterFunc(backoff, func() {
+			q.push(resourceURI)
+		})
+		return
+	}
+
+	q.completeJob(job)
+}
+
+// completeJob removes the job, unless the resource was enqueued again while the job was being processed
+func (q *AttestQueue) completeJob(job *attestJob) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	current, err := readAttestJob(q.jobPath(job.ResourceURI))
+	if err == nil && current.Sequence != job.Sequence {
+		return
+	}
+
+	if err := os.Remove(q.jobPath(job.ResourceURI)); err != nil && !os.IsNotExist(err) {
+		q.log.Error(err, "Unable to remove attestation job", "uri", job.ResourceURI)
+	}
+
+	q.setStatusLocked(job.ResourceURI, AttestStateCompleted, nil)
+
+	for resourceURI, status := range q.statuses {
+		if status.State == AttestStateCompleted && time.Since(status.UpdatedAt) > completedStatusRetention {
+			delete(q.statuses, resourceURI)
+		}
+	}
+}
+
+// push queues the resource for a worker, unless it's already queued
+func (q *AttestQueue) push(resourceURI string) {
+	q.mutex.Lock()
+	if q.queued[resourceURI] {
+		q.mutex.Unlock()
+		return
+	}
+	q.queued[resourceURI] = true
+	q.mutex.Unlock()
+
+	select {
+	case q.jobs <- resourceURI:
+	default:
+		go func() {
+			q.jobs <- resourceURI
+		}()
+	}
+}
+
+func (q *AttestQueue) setStatus(resourceURI, state string, err error) *AttestStatus {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.setStatusLocked(resourceURI, state, err)
+}
+
+func (q *AttestQueue) setStatusLocked(resourceURI, state string, err error) *AttestStatus {
+	status, ok := q.statuses[resourceURI]
+	if !ok || (state == AttestStatePending && status.State == AttestStateCompleted) {
+		status = &AttestStatus{ResourceURI: resourceURI}
+		q.statuses[resourceURI] = status
+	}
+
+	status.State = state
+	status.UpdatedAt = time.Now().UTC()
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	return status
+}
+
+// writeJob writes the job to a temporary file before renaming it, so that a partially written job is never loaded
+func (q *AttestQueue) writeJob(job *attestJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(q.dir, "job-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), q.jobPath(job.ResourceURI))
+}
+
+// jobPath returns the path of the job for the resource. Resource URIs aren't valid file names, so they're hashed.
+func (q *AttestQueue) jobPath(resourceURI string) string {
+	hash := sha256.Sum256([]byte(resourceURI))
+	return filepath.Join(q.dir, hex.EncodeToString(hash[:])+jobFileSuffix)
+}
+
+func readAttestJob(path string) (*attestJob, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &attestJob{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("invalid attestation job %s: %v", strings.TrimSuffix(filepath.Base(path), jobFileSuffix), err)
+	}
+
+	return job, nil
+}
+
+func retryBackoff(attempts int) time.Duration {
+	backoff := time.Second
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+
+	return backoff
+}
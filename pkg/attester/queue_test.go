@@ -0,0 +1,148 @@
+package attester
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestAttestQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "queue")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	q, err := NewAttestQueue(logf.NullLogger{}, dir)
+	assert.NoError(err)
+
+	var mutex sync.Mutex
+	calls := make(map[string]int)
+	q.process = func(ctx context.Context, resourceURI, kind string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		calls[resourceURI]++
+		if resourceURI == "flaky" && calls[resourceURI] == 1 {
+			return fmt.Errorf("grafeas unavailable")
+		}
+		return nil
+	}
+
+	assert.NoError(q.Enqueue("stable", ""))
+	assert.NoError(q.Enqueue("flaky", ""))
+
+	status, ok := q.Status("stable")
+	assert.True(ok)
+	assert.Equal(AttestStatePending, status.State)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	q.Start(2, stop)
+
+	for _, resourceURI := range []string{"stable", "flaky"} {
+		assert.Eventually(func() bool {
+			status, _ := q.Status(resourceURI)
+			return status.State == AttestStateCompleted
+		}, 5*time.Second, 10*time.Millisecond, resourceURI)
+	}
+
+	status, _ = q.Status("flaky")
+	assert.Equal(2, status.Attempts)
+
+	_, ok = q.Status("unknown")
+	assert.False(ok)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(err)
+	assert.Empty(files)
+}
+
+func TestAttestQueue_LoadsPendingJobs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "queue")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// jobs enqueued before a restart are processed by the next queue
+	q, err := NewAttestQueue(logf.NullLogger{}, dir)
+	assert.NoError(err)
+	assert.NoError(q.Enqueue("pending", "DISCOVERY"))
+
+	processed := make(chan string, 1)
+	q, err = NewAttestQueue(logf.NullLogger{}, dir)
+	assert.NoError(err)
+	q.process = func(ctx context.Context, resourceURI, kind string) error {
+		processed <- resourceURI + " " + kind
+		return nil
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	q.Start(1, stop)
+
+	select {
+	case job := <-processed:
+		assert.Equal("pending DISCOVERY", job)
+	case <-time.After(5 * time.Second):
+		assert.Fail("pending job wasn't processed")
+	}
+}
+
+type fakeOccurrenceClient struct {
+	mutex       sync.Mutex
+	occurrences []*grafeas.Occurrence
+}
+
+func (c *fakeOccurrenceClient) ListOccurrences(ctx context.Context, resourceURI string) (*grafeas.ListOccurrencesResponse, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return &grafeas.ListOccurrencesResponse{Occurrences: append([]*grafeas.Occurrence{}, c.occurrences...)}, nil
+}
+
+func (c *fakeOccurrenceClient) CreateOccurrences(ctx context.Context, occurrences ...*grafeas.Occurrence) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.occurrences = append(c.occurrences, occurrences...)
+	return nil
+}
+
+type fakeAttesterLister map[string]Attester
+
+func (l fakeAttesterLister) ListAttesters() map[string]Attester {
+	return l
+}
+
+func TestAttestWrapper_AttestResourceSkipsExisting(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := NewPolicy(ctx, "async", "package async\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("async")
+	assert.NoError(err)
+
+	client := &fakeOccurrenceClient{}
+	wrapper := NewAttestWrapper(logf.NullLogger{}, client, client, fakeAttesterLister{
+		"default/async": NewAttester("default/async", policy, signer),
+	}, nil, nil, nil, nil, nil).(*attestWrapper)
+
+	// processing the same resource again, as happens when a job is retried, doesn't store a second attestation
+	for i := 0; i < 2; i++ {
+		assert.NoError(wrapper.attestResource(ctx, "image", "", true))
+	}
+	assert.Len(client.occurrences, 1)
+
+	assert.NoError(wrapper.attestResource(ctx, "image", "", false))
+	assert.Len(client.occurrences, 2)
+}
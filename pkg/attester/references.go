@@ -0,0 +1,87 @@
+package attester
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// DataReference is a reference in a policy to a document under data
+type DataReference struct {
+	Ref  string
+	Line int
+}
+
+func (r DataReference) String() string {
+	return fmt.Sprintf("%s (line %d)", r.Ref, r.Line)
+}
+
+// FindDataReferences returns the references in the policy module to the data document at path, such as
+// data.limits.high. References to a document that contains the path, or with a variable in place of part of the path,
+// are included since they may read the document.
+func FindDataReferences(name, module, path string) ([]DataReference, error) {
+	if !strings.HasPrefix(path, ast.DefaultRootDocument.String()) {
+		path = fmt.Sprintf("%s.%s", ast.DefaultRootDocument, path)
+	}
+
+	target, err := ast.ParseRef(path)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := fmt.Sprintf("%s.rego", name)
+	parsed, err := ast.ParseModule(filename, module)
+	if err != nil {
+		return nil, err
+	}
+
+	// compiling resolves imports and package-relative references to full references
+	compiler := ast.NewCompiler()
+	compiler.Compile(map[string]*ast.Module{
+		filename: parsed,
+	})
+	if compiler.Failed() {
+		return nil, compiler.Errors
+	}
+
+	references := make([]DataReference, 0)
+	seen := make(map[string]bool)
+	// only the rules are walked, since the package and imports are paths rather than reads of documents
+	for _, rule := range compiler.Modules[filename].Rules {
+		ast.WalkRefs(rule, func(ref ast.Ref) bool {
+			if !ref[0].Equal(ast.DefaultRootDocument) || !refMayRead(ref, target) {
+				return false
+			}
+
+			reference := DataReference{Ref: ref.String()}
+			if ref[0].Location != nil {
+				reference.Line = ref[0].Location.Row
+			}
+
+			key := reference.String()
+			if !seen[key] {
+				seen[key] = true
+				references = append(references, reference)
+			}
+
+			return false
+		})
+	}
+
+	return references, nil
+}
+
+// refMayRead returns true if evaluating ref may read the document at target
+func refMayRead(ref, target ast.Ref) bool {
+	for i := 0; i < len(ref) && i < len(target); i++ {
+		if _, ok := ref[i].Value.(ast.Var); ok && i > 0 {
+			continue
+		}
+		if !ref[i].Equal(target[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,46 @@
+package attester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDataReferences(t *testing.T) {
+	assert := assert.New(t)
+
+	module := `
+package refs
+
+import data.exceptions
+
+violation[{"msg":"too many high vulnerabilities"}]{
+	count([v | v := input.occurrences[_].vulnerability.severity; v == "HIGH"]) > data.limits.high
+}
+violation[{"msg":"critical vulnerability found"}]{
+	input.occurrences[_].vulnerability.severity == "CRITICAL"
+	not exceptions[input.resource]
+}
+violation[{"msg":"limit exceeded"}]{
+	data.limits[severity] < count(input.occurrences)
+}
+`
+
+	refs, err := FindDataReferences("refs", module, "data.limits.high")
+	assert.NoError(err)
+	assert.Len(refs, 2)
+	assert.Equal("data.limits.high", refs[0].Ref)
+	assert.Equal(7, refs[0].Line)
+
+	refs, err = FindDataReferences("refs", module, "exceptions")
+	assert.NoError(err)
+	assert.Len(refs, 1)
+	assert.Contains(refs[0].Ref, "data.exceptions")
+
+	refs, err = FindDataReferences("refs", module, "data.unused")
+	assert.NoError(err)
+	assert.Empty(refs)
+
+	_, err = FindDataReferences("refs", "package refs\nviolation[", "data.limits")
+	assert.Error(err)
+}
@@ -0,0 +1,62 @@
+package attester
+
+import "sync"
+
+// SignerProvider provides the signer an attester currently signs with
+type SignerProvider interface {
+	Signer() Signer
+}
+
+type staticSigner struct {
+	signer Signer
+}
+
+func (s staticSigner) Signer() Signer {
+	return s.signer
+}
+
+// RotatingSigner is a SignerProvider whose signer can be replaced while attesters are using it, so that a rotated key
+// is used for new signatures without recreating the attesters
+type RotatingSigner struct {
+	mutex  sync.RWMutex
+	signer Signer
+}
+
+// NewRotatingSigner creates a RotatingSigner that provides the signer until it's rotated
+func NewRotatingSigner(signer Signer) *RotatingSigner {
+	return &RotatingSigner{
+		signer: signer,
+	}
+}
+
+// Signer returns the current signer
+func (r *RotatingSigner) Signer() Signer {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.signer
+}
+
+// Rotate replaces the signer. Attestations that are being signed when the signer is rotated are signed with either the
+// old or the new signer, never a mix of the two.
+func (r *RotatingSigner) Rotate(signer Signer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.signer = signer
+}
+
+// WithSignerProvider signs attestations with the provider's current signer rather than the signer the attester was
+// created with
+func WithSignerProvider(provider SignerProvider) AttesterOption {
+	return func(a *attester) {
+		a.signers = provider
+	}
+}
+
+// WithPreviousVerifiers also accepts attestations signed by the keys the attester's key was rotated from
+func WithPreviousVerifiers(verifiers []Verifier) AttesterOption {
+	return func(a *attester) {
+		a.previousVerifiers = verifiers
+	}
+}
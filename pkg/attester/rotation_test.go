@@ -0,0 +1,40 @@
+package attester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttester_RotatingSigner(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	uri := "harbor.liatr.io/rode/app@sha256:b88ca0f4a7fe2c67fc8bdb67ad405f418ca0be6aec442c79ae32253138da2dd5"
+
+	policy, err := NewPolicy(ctx, "rotation", "package rotation\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	oldSigner, err := NewSigner("rotation")
+	assert.NoError(err)
+	newSigner, err := NewSigner("rotation")
+	assert.NoError(err)
+
+	provider := NewRotatingSigner(oldSigner)
+	att := NewAttester("default/rotation", policy, nil, WithSignerProvider(provider))
+
+	before, err := att.Attest(ctx, &AttestRequest{ResourceURI: uri})
+	assert.NoError(err)
+	assert.Equal(oldSigner.KeyID(), before.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId())
+
+	provider.Rotate(newSigner)
+	assert.Equal(newSigner, provider.Signer())
+
+	after, err := att.Attest(ctx, &AttestRequest{ResourceURI: uri})
+	assert.NoError(err)
+	assert.Equal(newSigner.KeyID(), after.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation().GetPgpKeyId())
+	assert.NoError(VerifyAttestation(newSigner, after.Attestation, ""))
+	assert.Error(VerifyAttestation(oldSigner, after.Attestation, ""))
+
+	assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: after.Attestation}))
+	assert.Error(att.Verify(ctx, &VerifyRequest{Occurrence: before.Attestation}))
+}
@@ -3,8 +3,13 @@ package attester
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -14,59 +19,374 @@ import (
 // The name parameter is used to name the secret and the namespace parameter
 // is used to designate which namespace the secret is created in
 // The function returns a signer object to be used by the reconcile loop.
-func NewSecret(ctx context.Context, attester *rodev1alpha1.Attester, client client.Client, namespacedName types.NamespacedName) (Signer, error) {
-	// Create a new signer
-	signer, err := NewSigner(namespacedName.String())
+// If the attester has key escrow configured, the private key is escrowed before the secret is created.
+// The private key is encrypted with the passphrase unless it's empty.
+// If the secret already exists, such as when a reconcile is retried after the secret was created, the key in it is
+// returned rather than generating a new key, so that the attestations made with it stay valid.
+func NewSecret(ctx context.Context, attester *rodev1alpha1.Attester, client client.Client, namespacedName types.NamespacedName, passphrase []byte) (Signer, error) {
+	if signer, err := existingSigner(ctx, client, namespacedName, attester.Spec.GetPgpSecretKey(), passphrase); err != nil || signer != nil {
+		return signer, err
+	}
+
+	signer, signerData, err := newSignerData(ctx, attester.Spec, namespacedName.String(), passphrase)
 	if err != nil {
 		return nil, err
 	}
 
-	var buffer []byte
-	buf := bytes.NewBuffer(buffer)
+	signerSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespacedName.Namespace,
+			Name:        namespacedName.Name,
+			Labels:      map[string]string{SecretSignerLabel: "true"},
+			Annotations: make(map[string]string),
+		},
+		Data: map[string][]byte{attester.Spec.GetPgpSecretKey(): signerData},
+	}
+
+	if namespacedName.Namespace == attester.Namespace {
+		// the attester is the secret's controller, so the secret is garbage collected with the attester even if the
+		// finalizer doesn't get to delete it
+		signerSecret.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(attester, rodev1alpha1.GroupVersion.WithKind("Attester"))}
+	} else {
+		// owner references can't cross namespaces, so the attester that controls the secret is recorded in an annotation
+		signerSecret.Annotations[SecretControllerAnnotation] = secretController(attester)
+	}
 
-	// signer.Serialize writes the public and private keys to a buffer object buf
-	err = signer.Serialize(buf)
+	// the shares are written before the key so that an escrowed attester never has a key that wasn't escrowed
+	if attester.Spec.KeyEscrow != nil {
+		escrowSecrets, err := EscrowKey(ctx, attester, client, signerData)
+		if err != nil {
+			return nil, err
+		}
+
+		signerSecret.Annotations[SecretEscrowAnnotation] = EscrowDescription(attester.Spec.KeyEscrow.Threshold, escrowSecrets)
+	}
+
+	err = client.Create(ctx, signerSecret)
+	if errors.IsAlreadyExists(err) {
+		// the secret was created since it was read, so the key that won is used rather than the one just generated
+		existing, getErr := existingSigner(ctx, client, namespacedName, attester.Spec.GetPgpSecretKey(), passphrase)
+		if getErr != nil || existing != nil {
+			return existing, getErr
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// buf writes the private and public key to the signerData string
-	signerData := buf.Bytes()
+	return signer, nil
+}
+
+// existingSigner reads the signer from the key in the secret, returning nil without an error if the secret doesn't
+// exist. A secret that exists without a valid key is an error, since it's never overwritten.
+func existingSigner(ctx context.Context, c client.Client, namespacedName types.NamespacedName, key string, passphrase []byte) (Signer, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, namespacedName, secret); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s already exists without key %s", namespacedName, key)
+	}
+
+	signer, err := ReadSignerWithPassphrase(bytes.NewReader(data), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s already exists with an invalid key: %v", namespacedName, err)
+	}
+
+	return signer, nil
+}
+
+// NewClusterSecret creates the secret holding a new signing key for the cluster attester, in the namespace given by
+// namespacedName. The cluster attester is the secret's controller, so the secret is garbage collected with it.
+func NewClusterSecret(ctx context.Context, attester *rodev1alpha1.ClusterAttester, c client.Client, namespacedName types.NamespacedName) (Signer, error) {
+	spec := attester.Spec.AttesterSpec()
+	signer, signerData, err := newSignerData(ctx, spec, namespacedName.String(), nil)
+	if err != nil {
+		return nil, err
+	}
 
-	// we create the secret with an annotation to let rode know that it owns the secret
 	signerSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:       namespacedName.Namespace,
 			Name:            namespacedName.Name,
-			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(attester, rodev1alpha1.GroupVersion.WithKind("Attester"))},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(attester, rodev1alpha1.GroupVersion.WithKind("ClusterAttester"))},
 		},
-		Data: map[string][]byte{"keys": signerData},
+		Data: map[string][]byte{spec.GetPgpSecretKey(): signerData},
 	}
 
-	err = client.Create(ctx, signerSecret)
-	if err != nil {
+	if err := c.Create(ctx, signerSecret); err != nil {
 		return nil, err
 	}
 
 	return signer, nil
 }
 
+// newSignerData generates a key for the spec and serializes it, encrypting the private key with the passphrase unless
+// it's empty. Generating a large key can take a while, so the context's error is returned if it's done by the time the
+// key has been generated.
+func newSignerData(ctx context.Context, spec rodev1alpha1.AttesterSpec, name string, passphrase []byte) (Signer, []byte, error) {
+	options, err := NewSignerOptions(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer, err := NewSignerWithOptions(name, spec.KeyType, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// SerializeWithPassphrase writes the public and private keys to the buffer
+	buf := &bytes.Buffer{}
+	if err := SerializeWithPassphrase(signer, buf, passphrase); err != nil {
+		return nil, nil, err
+	}
+
+	return signer, buf.Bytes(), nil
+}
+
 // DeleteSecret uses the kubernetes client library to delete a named secret resource.
 // The name and namespace parameters are used to find the secret
-// The function returns an err if the deletion fails
-func DeleteSecret(ctx context.Context, attester *rodev1alpha1.Attester, c client.Client, namespacedName types.NamespacedName) error {
+// The function returns whether the secret was deleted, since secrets the attester doesn't control are kept, and an err
+// if the deletion fails. A secret that's already been deleted isn't an error.
+func DeleteSecret(ctx context.Context, attester *rodev1alpha1.Attester, c client.Client, namespacedName types.NamespacedName) (bool, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, namespacedName, secret)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !ControlsSecret(attester, secret) {
+		return false, nil
+	}
+
+	if err := c.Delete(ctx, secret); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+const (
+	// SecretDeleteAfterAnnotation records when a secret that was released by a deleted attester is deleted
+	SecretDeleteAfterAnnotation = "rode.liatr.io/delete-after"
+
+	// SecretAttesterAnnotation records the name of the deleted attester that released a secret
+	SecretAttesterAnnotation = "rode.liatr.io/attester"
+
+	// SecretSignerLabel marks the secrets holding the keys of the attesters that control them, so that the controller
+	// only watches those secrets rather than every secret in the cluster
+	SecretSignerLabel = "rode.liatr.io/signer"
+
+	// SecretReleasedLabel marks the secrets released by deleted attesters, so that they can be listed by label
+	SecretReleasedLabel = "rode.liatr.io/released"
+
+	// SecretRotatedAtAnnotation records when the key in a secret was last rotated
+	SecretRotatedAtAnnotation = "rode.liatr.io/key-rotated-at"
+
+	// SecretControllerAnnotation records the namespace, name and UID of the attester that controls a secret in another
+	// namespace than its own, which can't have an owner reference to the attester
+	SecretControllerAnnotation = "rode.liatr.io/controller"
+
+	// NoFinalizerAnnotation stops the controller from adding its finalizer to an attester when it's set to "true", so
+	// that deleting the attester doesn't wait for the controller. The attester's secret is deleted by the garbage
+	// collector through its owner reference instead.
+	NoFinalizerAnnotation = "rode.liatr.io/no-finalizer"
+)
+
+// secretController returns the value of the SecretControllerAnnotation for secrets the attester controls
+func secretController(attester *rodev1alpha1.Attester) string {
+	return fmt.Sprintf("%s/%s/%s", attester.Namespace, attester.Name, attester.UID)
+}
+
+// ControlsSecret returns whether the attester controls the secret, either as its controller or, for secrets in another
+// namespace, through the SecretControllerAnnotation
+func ControlsSecret(attester *rodev1alpha1.Attester, secret *corev1.Secret) bool {
+	if secret.Namespace == attester.Namespace {
+		return metav1.IsControlledBy(secret, attester)
+	}
+
+	return secret.Annotations[SecretControllerAnnotation] == secretController(attester)
+}
+
+// SecretControllerName returns the namespace and name of the attester that controls the secret through the
+// SecretControllerAnnotation, if it has one
+func SecretControllerName(secret *corev1.Secret) (types.NamespacedName, bool) {
+	parts := strings.SplitN(secret.Annotations[SecretControllerAnnotation], "/", 3)
+	if len(parts) != 3 {
+		return types.NamespacedName{}, false
+	}
+
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+}
+
+// KeyCreatedAt returns when the key in the secret was generated, which is when it was last rotated or otherwise when
+// the secret was created
+func KeyCreatedAt(secret *corev1.Secret) time.Time {
+	if rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[SecretRotatedAtAnnotation]); err == nil {
+		return rotatedAt
+	}
+
+	return secret.CreationTimestamp.Time
+}
+
+// RotateSecret replaces the key in the attester's secret with a newly generated key, escrowing it if the attester
+// escrows its keys, and annotates the secret with the time it was rotated. Secrets that aren't controlled by the
+// attester aren't rotated. The new private key is encrypted with the passphrase unless it's empty.
+func RotateSecret(ctx context.Context, attester *rodev1alpha1.Attester, c client.Client, secret *corev1.Secret, now time.Time, passphrase []byte) (Signer, error) {
+	if !ControlsSecret(attester, secret) {
+		return nil, fmt.Errorf("secret %s/%s is not controlled by the attester", secret.Namespace, secret.Name)
+	}
+
+	signer, signerData, err := newSignerData(ctx, attester.Spec, fmt.Sprintf("%s/%s", attester.Namespace, attester.Name), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+
+	if attester.Spec.KeyEscrow != nil {
+		escrowSecrets, err := EscrowKey(ctx, attester, c, signerData)
+		if err != nil {
+			return nil, err
+		}
+		secret.Annotations[SecretEscrowAnnotation] = EscrowDescription(attester.Spec.KeyEscrow.Threshold, escrowSecrets)
+	}
+
+	secret.Data[attester.Spec.GetPgpSecretKey()] = signerData
+	secret.Annotations[SecretRotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+
+	if err := c.Update(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	return signer, nil
+}
+
+// ReleaseSecret schedules the deletion of the attester's secret rather than deleting it immediately. The attester's
+// owner reference is removed so that the secret isn't garbage collected with the attester, and the secret is annotated
+// with the attester and the time after which it can be deleted. Secrets that aren't controlled by the attester are left
+// untouched.
+func ReleaseSecret(ctx context.Context, attester *rodev1alpha1.Attester, c client.Client, namespacedName types.NamespacedName, deleteAfter time.Time) error {
 	secret := &corev1.Secret{}
 	err := c.Get(ctx, namespacedName, secret)
 	if err != nil {
 		return err
 	}
 
-	if metav1.IsControlledBy(secret, attester) {
-		err = c.Delete(ctx, secret)
+	if !ControlsSecret(attester, secret) {
+		return nil
+	}
+
+	ownerReferences := make([]metav1.OwnerReference, 0)
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID != attester.UID {
+			ownerReferences = append(ownerReferences, ref)
+		}
+	}
+	secret.OwnerReferences = ownerReferences
+
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	delete(secret.Annotations, SecretControllerAnnotation)
+	secret.Annotations[SecretDeleteAfterAnnotation] = deleteAfter.UTC().Format(time.RFC3339)
+	secret.Annotations[SecretAttesterAnnotation] = attester.Name
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	secret.Labels[SecretReleasedLabel] = "true"
+	delete(secret.Labels, SecretSignerLabel)
+
+	return c.Update(ctx, secret)
+}
+
+// LabelSecret adds the SecretSignerLabel to a secret the attester controls that doesn't have it yet, such as a secret
+// created before the label was added. It returns whether the secret was labeled.
+func LabelSecret(ctx context.Context, attester *rodev1alpha1.Attester, c client.Client, secret *corev1.Secret) (bool, error) {
+	if !ControlsSecret(attester, secret) || secret.Labels[SecretSignerLabel] == "true" {
+		return false, nil
+	}
+
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	secret.Labels[SecretSignerLabel] = "true"
+
+	if err := c.Update(ctx, secret); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReclaimSecret cancels the scheduled deletion of a secret released by an attester with the same name, making the
+// attester its owner again. It returns whether the secret was reclaimed.
+func ReclaimSecret(ctx context.Context, attester *rodev1alpha1.Attester, c client.Client, secret *corev1.Secret) (bool, error) {
+	if _, ok := secret.Annotations[SecretDeleteAfterAnnotation]; !ok || secret.Annotations[SecretAttesterAnnotation] != attester.Name {
+		return false, nil
+	}
+
+	delete(secret.Annotations, SecretDeleteAfterAnnotation)
+	delete(secret.Annotations, SecretAttesterAnnotation)
+	delete(secret.Labels, SecretReleasedLabel)
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	secret.Labels[SecretSignerLabel] = "true"
+	if secret.Namespace == attester.Namespace {
+		secret.OwnerReferences = append(secret.OwnerReferences, *metav1.NewControllerRef(attester, rodev1alpha1.GroupVersion.WithKind("Attester")))
+	} else {
+		secret.Annotations[SecretControllerAnnotation] = secretController(attester)
+	}
+
+	if err := c.Update(ctx, secret); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteReleasedSecrets deletes the secrets released by the named attester whose grace period has passed. It returns
+// how long until the next of the remaining secrets can be deleted, or 0 if none remain.
+func DeleteReleasedSecrets(ctx context.Context, c client.Client, attester types.NamespacedName, now time.Time) (time.Duration, error) {
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets, client.InNamespace(attester.Namespace)); err != nil {
+		return 0, err
+	}
+
+	var next time.Duration
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Annotations[SecretAttesterAnnotation] != attester.Name {
+			continue
+		}
+
+		deleteAfter, err := time.Parse(time.RFC3339, secret.Annotations[SecretDeleteAfterAnnotation])
 		if err != nil {
-			return err
+			continue
+		}
+
+		if remaining := deleteAfter.Sub(now); remaining > 0 {
+			if next == 0 || remaining < next {
+				next = remaining
+			}
+			continue
+		}
+
+		if err := c.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return 0, err
 		}
 	}
 
-	return nil
+	return next, nil
 }
@@ -0,0 +1,404 @@
+package attester
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp/packet"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+func TestNewSecret_KeyTypes(t *testing.T) {
+	keyTypes := []rodev1alpha1.KeyType{"", rodev1alpha1.KeyTypePGPRSA, rodev1alpha1.KeyTypePGPECDSAP256, rodev1alpha1.KeyTypeEd25519}
+
+	for _, keyType := range keyTypes {
+		t.Run(string(keyType), func(t *testing.T) {
+			assert := assert.New(t)
+			ctx := context.Background()
+
+			att := &rodev1alpha1.Attester{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "keytype"},
+				Spec:       rodev1alpha1.AttesterSpec{KeyType: keyType},
+			}
+			c := newEscrowTestClient(att)
+			name := types.NamespacedName{Namespace: "default", Name: "keytype"}
+
+			generated, err := NewSecret(ctx, att, c, name, nil)
+			assert.NoError(err)
+
+			secret := &corev1.Secret{}
+			assert.NoError(c.Get(ctx, name, secret))
+
+			read, err := ReadSigner(bytes.NewReader(secret.Data["keys"]))
+			assert.NoError(err)
+			assert.Equal(generated.KeyID(), read.KeyID())
+
+			signed, err := read.Sign("payload")
+			assert.NoError(err)
+			payload, err := generated.Verify(signed)
+			assert.NoError(err)
+			assert.Equal("payload", payload)
+
+			publicKey := &bytes.Buffer{}
+			assert.NoError(SerializePublicKey(read, publicKey))
+			verifier, err := ReadVerifier(publicKey)
+			assert.NoError(err)
+			assert.Equal(generated.KeyID(), verifier.KeyID())
+			payload, err = verifier.Verify(signed)
+			assert.NoError(err)
+			assert.Equal("payload", payload)
+
+			other, err := NewSignerWithKeyType("other", keyType)
+			assert.NoError(err)
+			_, err = other.Verify(signed)
+			assert.Error(err)
+		})
+	}
+}
+
+func TestNewSignerWithKeyType_Unsupported(t *testing.T) {
+	_, err := NewSignerWithKeyType("dsa", "pgp-dsa")
+	assert.Error(t, err)
+}
+
+// signatureHash returns the hash of the detached signature
+func signatureHash(t *testing.T, signature []byte) crypto.Hash {
+	p, err := packet.Read(bytes.NewReader(signature))
+	assert.NoError(t, err)
+	sig, ok := p.(*packet.Signature)
+	assert.True(t, ok)
+
+	return sig.Hash
+}
+
+func TestNewSecret_SignerOptions(t *testing.T) {
+	tests := map[string]struct {
+		spec rodev1alpha1.AttesterSpec
+		// bits is the size of the RSA key, or 0 for other keys
+		bits uint16
+		hash crypto.Hash
+	}{
+		"defaults": {rodev1alpha1.AttesterSpec{}, 2048, crypto.SHA256},
+		"rsa": {rodev1alpha1.AttesterSpec{
+			PgpKeyBits:       3072,
+			PgpHashAlgorithm: rodev1alpha1.HashAlgorithmSHA512,
+		}, 3072, crypto.SHA512},
+		"ecdsa": {rodev1alpha1.AttesterSpec{
+			KeyType:          rodev1alpha1.KeyTypePGPECDSAP256,
+			PgpHashAlgorithm: rodev1alpha1.HashAlgorithmSHA384,
+		}, 0, crypto.SHA384},
+		"insecure": {rodev1alpha1.AttesterSpec{
+			PgpKeyBits:                 1024,
+			PgpHashAlgorithm:           rodev1alpha1.HashAlgorithmSHA1,
+			AllowInsecurePgpParameters: true,
+		}, 1024, crypto.SHA1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx := context.Background()
+
+			att := &rodev1alpha1.Attester{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "options"},
+				Spec:       tc.spec,
+			}
+			c := newEscrowTestClient(att)
+			name := types.NamespacedName{Namespace: "default", Name: "options"}
+
+			generated, err := NewSecret(ctx, att, c, name, nil)
+			assert.NoError(err)
+
+			// the key read back from the secret has the requested size, and still signs with the requested hash
+			secret := &corev1.Secret{}
+			assert.NoError(c.Get(ctx, name, secret))
+			read, err := ReadSigner(bytes.NewReader(secret.Data["keys"]))
+			assert.NoError(err)
+
+			for _, s := range []Signer{generated, read} {
+				if tc.bits != 0 {
+					bits, err := s.(*signer).entity.PrimaryKey.BitLength()
+					assert.NoError(err)
+					assert.Equal(tc.bits, bits)
+				}
+
+				signature, err := s.SignBytes([]byte("payload"))
+				assert.NoError(err)
+				assert.Equal(tc.hash, signatureHash(t, signature))
+				assert.NoError(generated.VerifyBytes([]byte("payload"), signature))
+			}
+		})
+	}
+}
+
+func TestNewSecret_InsecureSignerOptions(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "insecure"},
+		Spec:       rodev1alpha1.AttesterSpec{PgpHashAlgorithm: rodev1alpha1.HashAlgorithmSHA1},
+	}
+	c := newEscrowTestClient(att)
+	name := types.NamespacedName{Namespace: "default", Name: "insecure"}
+
+	_, err := NewSecret(ctx, att, c, name, nil)
+	assert.EqualError(err, "hash algorithm SHA1 is insecure, set allowInsecurePgpParameters to allow it")
+
+	// the secret isn't created with a key that doesn't meet the requirements
+	assert.Error(c.Get(ctx, name, &corev1.Secret{}))
+}
+
+func TestReadVerifier_Ed25519(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSignerWithKeyType("verifier", rodev1alpha1.KeyTypeEd25519)
+	assert.NoError(err)
+	signed, err := s.Sign("payload")
+	assert.NoError(err)
+
+	der, err := x509.MarshalPKIXPublicKey(s.(*ed25519Signer).privateKey.Public().(ed25519.PublicKey))
+	assert.NoError(err)
+	verifier, err := ReadVerifier(bytes.NewReader(pem.EncodeToMemory(&pem.Block{Type: pemPublicKeyType, Bytes: der})))
+	assert.NoError(err)
+	assert.Equal(s.KeyID(), verifier.KeyID())
+
+	payload, err := verifier.Verify(signed)
+	assert.NoError(err)
+	assert.Equal("payload", payload)
+
+	// the envelope's payload can't be changed without invalidating the signature
+	envelope := &ed25519Envelope{}
+	decoded, err := base64.StdEncoding.DecodeString(signed)
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal(decoded, envelope))
+	envelope.Payload = []byte("forged")
+	forged, err := json.Marshal(envelope)
+	assert.NoError(err)
+	_, err = verifier.Verify(base64.StdEncoding.EncodeToString(forged))
+	assert.Error(err)
+}
+
+func TestNewSecret_OwnerReference(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "owned", UID: "owned-uid"},
+	}
+	c := newEscrowTestClient(att)
+	name := types.NamespacedName{Namespace: "default", Name: "owned"}
+
+	_, err := NewSecret(ctx, att, c, name, nil)
+	assert.NoError(err)
+
+	secret := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, name, secret))
+	assert.True(metav1.IsControlledBy(secret, att))
+	assert.Equal("true", secret.Labels[SecretSignerLabel])
+
+	isController := true
+	assert.Equal([]metav1.OwnerReference{
+		{
+			APIVersion:         "rode.liatr.io/v1alpha1",
+			Kind:               "Attester",
+			Name:               "owned",
+			UID:                "owned-uid",
+			Controller:         &isController,
+			BlockOwnerDeletion: &isController,
+		},
+	}, secret.OwnerReferences)
+
+	// rotating the key keeps the secret owned by the attester
+	_, err = RotateSecret(ctx, att, c, secret, time.Now(), nil)
+	assert.NoError(err)
+	rotated := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, name, rotated))
+	assert.True(metav1.IsControlledBy(rotated, att))
+}
+
+func TestNewSecret_Idempotent(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "retried", UID: "retried-uid"},
+	}
+	c := newEscrowTestClient(att)
+	name := types.NamespacedName{Namespace: "default", Name: "retried"}
+
+	first, err := NewSecret(ctx, att, c, name, nil)
+	assert.NoError(err)
+	created := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, name, created))
+
+	// calling it again, as a retried reconcile would, reuses the key rather than generating another
+	second, err := NewSecret(ctx, att, c, name, nil)
+	assert.NoError(err)
+	assert.Equal(first.KeyID(), second.KeyID())
+
+	unchanged := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, name, unchanged))
+	assert.Equal(created.Data, unchanged.Data)
+	assert.Equal(created.ResourceVersion, unchanged.ResourceVersion)
+
+	// a secret without a valid key is never overwritten
+	invalid := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "invalid"},
+		Data:       map[string][]byte{att.Spec.GetPgpSecretKey(): []byte("not a key")},
+	}
+	assert.NoError(c.Create(ctx, invalid))
+	_, err = NewSecret(ctx, att, c, types.NamespacedName{Namespace: "default", Name: "invalid"}, nil)
+	assert.Error(err)
+
+	assert.NoError(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "invalid"}, invalid))
+	assert.Equal([]byte("not a key"), invalid.Data[att.Spec.GetPgpSecretKey()])
+}
+
+func TestNewSecret_Cancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cancelled", UID: "cancelled-uid"},
+	}
+	c := newEscrowTestClient(att)
+	name := types.NamespacedName{Namespace: "default", Name: "cancelled"}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the key that was generated isn't stored once the caller has given up
+	signer, err := NewSecret(cancelled, att, c, name, nil)
+	assert.Equal(context.Canceled, err)
+	assert.Nil(signer)
+	assert.Error(c.Get(context.Background(), name, &corev1.Secret{}))
+}
+
+func TestNewSecret_OtherNamespace(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "remote", UID: "remote-uid"},
+	}
+	other := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "remote", UID: "other-uid"},
+	}
+	c := newEscrowTestClient(att)
+	name := types.NamespacedName{Namespace: "keys", Name: "remote"}
+
+	_, err := NewSecret(ctx, att, c, name, nil)
+	assert.NoError(err)
+
+	// owner references can't cross namespaces, so the attester is recorded in an annotation instead
+	secret := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, name, secret))
+	assert.Empty(secret.OwnerReferences)
+	assert.Equal("default/remote/remote-uid", secret.Annotations[SecretControllerAnnotation])
+	assert.Equal("true", secret.Labels[SecretSignerLabel])
+	assert.True(ControlsSecret(att, secret))
+	assert.False(ControlsSecret(other, secret))
+	controller, ok := SecretControllerName(secret)
+	assert.True(ok)
+	assert.Equal(types.NamespacedName{Namespace: "default", Name: "remote"}, controller)
+
+	// a secret with the same name in the attester's namespace isn't controlled through the annotation
+	local := secret.DeepCopy()
+	local.Namespace = "default"
+	assert.False(ControlsSecret(att, local))
+
+	_, err = RotateSecret(ctx, att, c, secret, time.Now(), nil)
+	assert.NoError(err)
+	_, err = RotateSecret(ctx, other, c, secret, time.Now(), nil)
+	assert.Error(err)
+
+	deleted, err := DeleteSecret(ctx, other, c, name)
+	assert.NoError(err)
+	assert.False(deleted)
+	deleted, err = DeleteSecret(ctx, att, c, name)
+	assert.NoError(err)
+	assert.True(deleted)
+}
+
+func TestLabelSecret(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unlabeled", UID: "unlabeled-uid"},
+	}
+	owned := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "owned",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(att, rodev1alpha1.GroupVersion.WithKind("Attester"))},
+		},
+	}
+	unowned := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unowned"}}
+	c := newEscrowTestClient(att, owned, unowned)
+
+	// a secret created before the label was added is labeled once
+	assert.NoError(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "owned"}, owned))
+	labeled, err := LabelSecret(ctx, att, c, owned)
+	assert.NoError(err)
+	assert.True(labeled)
+	labeled, err = LabelSecret(ctx, att, c, owned)
+	assert.NoError(err)
+	assert.False(labeled)
+
+	stored := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "owned"}, stored))
+	assert.Equal("true", stored.Labels[SecretSignerLabel])
+
+	// secrets the attester doesn't control aren't labeled
+	labeled, err = LabelSecret(ctx, att, c, unowned)
+	assert.NoError(err)
+	assert.False(labeled)
+	stored = &corev1.Secret{}
+	assert.NoError(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "unowned"}, stored))
+	assert.NotContains(stored.Labels, SecretSignerLabel)
+}
+
+func TestNewSecret_PgpSecretKey(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "layout", UID: "layout-uid"},
+		Spec:       rodev1alpha1.AttesterSpec{PgpSecretKey: "privateKey"},
+	}
+	c := newEscrowTestClient(att)
+	name := types.NamespacedName{Namespace: "default", Name: "layout"}
+
+	generated, err := NewSecret(ctx, att, c, name, nil)
+	assert.NoError(err)
+
+	secret := &corev1.Secret{}
+	assert.NoError(c.Get(ctx, name, secret))
+	assert.NotContains(secret.Data, "keys")
+	read, err := ReadSigner(bytes.NewReader(secret.Data["privateKey"]))
+	assert.NoError(err)
+	assert.Equal(generated.KeyID(), read.KeyID())
+
+	// the rotated key replaces the key under the same name
+	rotated, err := RotateSecret(ctx, att, c, secret, time.Now(), nil)
+	assert.NoError(err)
+	assert.NoError(c.Get(ctx, name, secret))
+	assert.Len(secret.Data, 1)
+	read, err = ReadSigner(bytes.NewReader(secret.Data["privateKey"]))
+	assert.NoError(err)
+	assert.Equal(rotated.KeyID(), read.KeyID())
+}
@@ -3,54 +3,396 @@ package attester
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
+	"fmt"
 	"io"
 	"io/ioutil"
 
 	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
 	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 )
 
+// signingHash is the hash used for generated keys and signatures unless another is chosen
+const signingHash = crypto.SHA256
+
+// minimumSecureRSABits is the smallest RSA key that's generated without AllowInsecurePgpParameters
+const minimumSecureRSABits = 2048
+
+// hashAlgorithms are the hashes that PGP keys can be generated to sign with
+var hashAlgorithms = map[rodev1alpha1.HashAlgorithm]crypto.Hash{
+	rodev1alpha1.HashAlgorithmSHA1:   crypto.SHA1,
+	rodev1alpha1.HashAlgorithmSHA256: crypto.SHA256,
+	rodev1alpha1.HashAlgorithmSHA384: crypto.SHA384,
+	rodev1alpha1.HashAlgorithmSHA512: crypto.SHA512,
+}
+
 type signer struct {
 	entity *openpgp.Entity
+	// hash is the hash that signatures are made with
+	hash crypto.Hash
+}
+
+// SignerOptions are the parameters of a generated PGP key. The defaults are used for the fields that aren't set.
+type SignerOptions struct {
+	// RSABits is the size of a generated RSA key, 2048 by default
+	RSABits int
+
+	// Hash is the hash the key signs with, SHA-256 by default. It's stored in the key as its preferred hash, so
+	// signers read from the key sign with it too.
+	Hash crypto.Hash
+}
+
+// NewSignerOptions returns the options for the attester's generated key, returning an error if they're insecure and
+// the attester doesn't allow insecure parameters, or if they don't apply to its key type
+func NewSignerOptions(spec rodev1alpha1.AttesterSpec) (SignerOptions, error) {
+	options := SignerOptions{RSABits: spec.PgpKeyBits}
+
+	if spec.PgpHashAlgorithm != "" {
+		hash, ok := hashAlgorithms[spec.PgpHashAlgorithm]
+		if !ok {
+			return options, fmt.Errorf("unsupported hash algorithm %q", spec.PgpHashAlgorithm)
+		}
+		options.Hash = hash
+	}
+
+	if spec.PgpKeyBits != 0 && spec.KeyType != "" && spec.KeyType != rodev1alpha1.KeyTypePGPRSA {
+		return options, fmt.Errorf("pgpKeyBits only applies to keys of type %s", rodev1alpha1.KeyTypePGPRSA)
+	}
+	if spec.PgpHashAlgorithm != "" && spec.KeyType == rodev1alpha1.KeyTypeEd25519 {
+		return options, fmt.Errorf("pgpHashAlgorithm doesn't apply to keys of type %s", rodev1alpha1.KeyTypeEd25519)
+	}
+
+	if !spec.AllowInsecurePgpParameters {
+		if options.RSABits != 0 && options.RSABits < minimumSecureRSABits {
+			return options, fmt.Errorf("RSA key size %d is below the minimum of %d, set allowInsecurePgpParameters to allow it", options.RSABits, minimumSecureRSABits)
+		}
+		if options.Hash == crypto.SHA1 {
+			return options, fmt.Errorf("hash algorithm %s is insecure, set allowInsecurePgpParameters to allow it", spec.PgpHashAlgorithm)
+		}
+	}
+
+	return options, nil
+}
+
+// hash returns the hash that the options sign with
+func (o SignerOptions) hash() crypto.Hash {
+	if o.Hash == 0 {
+		return signingHash
+	}
+
+	return o.Hash
 }
 
 // Signer is the interface for managing gpg signing
 type Signer interface {
+	Verifier
 	Sign(string) (string, error)
+	// SignBytes returns a detached signature of the data, such as the signature in a DSSE envelope
+	SignBytes(data []byte) ([]byte, error)
+	Serialize(out io.Writer) error
+}
+
+// Verifier is the interface for verifying gpg signatures with a public key
+type Verifier interface {
 	Verify(string) (string, error)
+	// VerifyBytes checks a detached signature of the data made by SignBytes
+	VerifyBytes(data, signature []byte) error
 	KeyID() string
-	Serialize(out io.Writer) error
 }
 
 // NewSigner creates a new signer
 func NewSigner(name string) (Signer, error) {
+	return newRSASigner(name, SignerOptions{})
+}
+
+// newRSASigner creates a signer with a PGP key whose primary key is an RSA key
+func newRSASigner(name string, options SignerOptions) (Signer, error) {
 	config := &packet.Config{
-		DefaultHash: crypto.SHA256,
+		DefaultHash: options.hash(),
+		RSABits:     options.RSABits,
 	}
 	entity, err := openpgp.NewEntity(name, "", "", config)
 	if err != nil {
 		return nil, err
 	}
+	err = checkFIPSEntity(entity, config.DefaultHash)
+	if err != nil {
+		return nil, err
+	}
+	return &signer{
+		entity: entity,
+		hash:   config.DefaultHash,
+	}, nil
+}
+
+// NewSignerWithKeyType creates a new signer with a key of the type, or an RSA PGP key if the type is empty
+func NewSignerWithKeyType(name string, keyType rodev1alpha1.KeyType) (Signer, error) {
+	return NewSignerWithOptions(name, keyType, SignerOptions{})
+}
+
+// NewSignerWithOptions creates a new signer with a key of the type generated with the options, or an RSA PGP key if
+// the type is empty. The options don't apply to Ed25519 keys.
+func NewSignerWithOptions(name string, keyType rodev1alpha1.KeyType, options SignerOptions) (Signer, error) {
+	switch keyType {
+	case "", rodev1alpha1.KeyTypePGPRSA:
+		return newRSASigner(name, options)
+	case rodev1alpha1.KeyTypePGPECDSAP256:
+		return newECDSASigner(name, elliptic.P256(), options)
+	case rodev1alpha1.KeyTypeEd25519:
+		return newEd25519Signer()
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", keyType)
+}
+
+// newECDSASigner creates a signer with a PGP key whose primary key is an ECDSA key on the curve. It's built the same
+// way as openpgp.NewEntity builds RSA keys, without the encryption subkey that signing doesn't need.
+func newECDSASigner(name string, curve elliptic.Curve, options SignerOptions) (Signer, error) {
+	config := &packet.Config{
+		DefaultHash: options.hash(),
+	}
+
+	uid := packet.NewUserId(name, "", "")
+	if uid == nil {
+		return nil, fmt.Errorf("user id %q contains invalid characters", name)
+	}
+
+	key, err := ecdsa.GenerateKey(curve, config.Random())
+	if err != nil {
+		return nil, err
+	}
+
+	creationTime := config.Now()
+	entity := &openpgp.Entity{
+		PrimaryKey: packet.NewECDSAPublicKey(creationTime, &key.PublicKey),
+		PrivateKey: packet.NewECDSAPrivateKey(creationTime, key),
+		Identities: make(map[string]*openpgp.Identity),
+	}
+
+	isPrimaryID := true
+	entity.Identities[uid.Id] = &openpgp.Identity{
+		Name:   uid.Id,
+		UserId: uid,
+		SelfSignature: &packet.Signature{
+			CreationTime: creationTime,
+			SigType:      packet.SigTypePositiveCert,
+			PubKeyAlgo:   packet.PubKeyAlgoECDSA,
+			Hash:         config.Hash(),
+			IsPrimaryId:  &isPrimaryID,
+			FlagsValid:   true,
+			FlagSign:     true,
+			FlagCertify:  true,
+			IssuerKeyId:  &entity.PrimaryKey.KeyId,
+		},
+	}
+	err = entity.Identities[uid.Id].SelfSignature.SignUserId(uid.Id, entity.PrimaryKey, entity.PrivateKey, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// without a preferred hash, signing falls back to RIPEMD-160, which isn't available
+	hashID, _ := s2k.HashToHashId(config.DefaultHash)
+	entity.Identities[uid.Id].SelfSignature.PreferredHash = []uint8{hashID}
+
+	err = checkFIPSEntity(entity, config.DefaultHash)
+	if err != nil {
+		return nil, err
+	}
 	return &signer{
-		entity,
+		entity: entity,
+		hash:   config.DefaultHash,
 	}, nil
 }
 
 // ReadSigner creates a signer from reader
 func ReadSigner(in io.Reader) (Signer, error) {
-	entity, err := openpgp.ReadEntity(packet.NewReader(in))
+	return ReadSignerWithPassphrase(in, nil)
+}
+
+// ReadSignerWithPassphrase creates a signer from a reader containing an armored or binary PGP private key, or a PEM
+// encoded Ed25519 private key. If the PGP private key is encrypted, it is decrypted in memory with the passphrase. When
+// the reader contains several PGP private keys, the signer is a KeyRing that signs with the first of them.
+func ReadSignerWithPassphrase(in io.Reader, passphrase []byte) (Signer, error) {
+	key, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(key); block != nil && block.Type == pemPrivateKeyType {
+		if len(passphrase) > 0 {
+			return nil, fmt.Errorf("passphrase-protected Ed25519 keys are not supported")
+		}
+		return readEd25519Signer(block)
+	}
+
+	var keyReader io.Reader = bytes.NewReader(key)
+	if bytes.HasPrefix(bytes.TrimSpace(key), []byte("-----BEGIN")) {
+		block, err := armor.Decode(keyReader)
+		if err != nil {
+			return nil, err
+		}
+		keyReader = block.Body
+	}
+
+	// the key may be followed by more keys, which are verified with but not signed with
+	packets := packet.NewReader(keyReader)
+	var keys []*signer
+	for {
+		entity, err := openpgp.ReadEntity(packets)
+		if err == io.EOF && len(keys) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = decryptEntity(entity, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := preferredHash(entity)
+		err = checkFIPSEntity(entity, hash)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, &signer{
+			entity: entity,
+			hash:   hash,
+		})
+	}
+
+	return newKeyRing(keys), nil
+}
+
+// preferredHash returns the hash the entity's primary identity prefers, which is the hash it was generated to sign
+// with, or the default hash if it doesn't prefer one that keys can be generated with
+func preferredHash(entity *openpgp.Entity) crypto.Hash {
+	var identity *openpgp.Identity
+	for _, id := range entity.Identities {
+		if id.SelfSignature == nil {
+			continue
+		}
+		if identity == nil || (id.SelfSignature.IsPrimaryId != nil && *id.SelfSignature.IsPrimaryId) {
+			identity = id
+		}
+	}
+	if identity == nil || len(identity.SelfSignature.PreferredHash) == 0 {
+		return signingHash
+	}
+
+	hash, ok := s2k.HashIdToHash(identity.SelfSignature.PreferredHash[0])
+	if !ok {
+		return signingHash
+	}
+	for _, allowed := range hashAlgorithms {
+		if hash == allowed {
+			return hash
+		}
+	}
+
+	return signingHash
+}
+
+// decryptEntity decrypts the entity's private keys in place, returning an error if they are encrypted and the
+// passphrase is missing or incorrect
+func decryptEntity(entity *openpgp.Entity, passphrase []byte) error {
+	privateKeys := make([]*packet.PrivateKey, 0)
+	if entity.PrivateKey != nil {
+		privateKeys = append(privateKeys, entity.PrivateKey)
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil {
+			privateKeys = append(privateKeys, subkey.PrivateKey)
+		}
+	}
+
+	for _, privateKey := range privateKeys {
+		if !privateKey.Encrypted {
+			continue
+		}
+
+		if len(passphrase) == 0 {
+			return fmt.Errorf("private key %s is encrypted but no passphrase was provided", privateKey.KeyIdString())
+		}
+
+		if err := privateKey.Decrypt(passphrase); err != nil {
+			return fmt.Errorf("unable to decrypt private key %s, the passphrase may be incorrect: %v", privateKey.KeyIdString(), err)
+		}
+	}
+
+	return nil
+}
+
+// ReadVerifier creates a verifier from a reader containing an armored or binary PGP public key, or a PEM encoded
+// Ed25519 public key
+func ReadVerifier(in io.Reader) (Verifier, error) {
+	key, err := ioutil.ReadAll(in)
 	if err != nil {
 		return nil, err
 	}
+
+	if block, _ := pem.Decode(key); block != nil && block.Type == pemPublicKeyType {
+		return readEd25519Verifier(block)
+	}
+
+	var entities openpgp.EntityList
+	if bytes.HasPrefix(bytes.TrimSpace(key), []byte("-----BEGIN")) {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(key))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("no public key found")
+	}
+
 	return &signer{
-		entity,
+		entity: entities[0],
+		hash:   preferredHash(entities[0]),
 	}, nil
 }
 
+// SerializePublicKey writes the public key of the verifier in a form that ReadVerifier reads, as an armored PGP public
+// key or a PEM encoded Ed25519 public key
+func SerializePublicKey(v Verifier, out io.Writer) error {
+	switch s := v.(type) {
+	case *keyRing:
+		return SerializePublicKey(s.signer, out)
+	case *signer:
+		w, err := armor.Encode(out, openpgp.PublicKeyType, nil)
+		if err != nil {
+			return err
+		}
+		if err := s.entity.Serialize(w); err != nil {
+			return err
+		}
+		return w.Close()
+	case *ed25519Signer:
+		der, err := x509.MarshalPKIXPublicKey(s.publicKey)
+		if err != nil {
+			return err
+		}
+		return pem.Encode(out, &pem.Block{Type: pemPublicKeyType, Bytes: der})
+	}
+
+	return fmt.Errorf("unable to serialize public key of %T", v)
+}
+
 func (s *signer) Sign(message string) (string, error) {
 	buf := new(bytes.Buffer)
-	writer, err := openpgp.Sign(buf, s.entity, nil, nil)
+	writer, err := openpgp.Sign(buf, s.entity, nil, &packet.Config{DefaultHash: s.hash})
 	if err != nil {
 		return "", err
 	}
@@ -94,9 +436,32 @@ func (s *signer) Verify(signedMessage string) (string, error) {
 	} else if message.SignatureError != nil {
 		return "", message.SignatureError
 	}
+	// a message signed by an unknown key has no signature error, since its signature isn't checked at all
+	if !message.IsSigned || message.SignedBy == nil {
+		return "", fmt.Errorf("message is not signed by key %s", s.KeyID())
+	}
 	return string(b), nil
 }
 
+// SignBytes returns a binary OpenPGP signature of the data
+func (s *signer) SignBytes(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := openpgp.DetachSign(buf, s.entity, bytes.NewReader(data), &packet.Config{DefaultHash: s.hash}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *signer) VerifyBytes(data, signature []byte) error {
+	var entities openpgp.EntityList = []*openpgp.Entity{
+		s.entity,
+	}
+
+	_, err := openpgp.CheckDetachedSignature(entities, bytes.NewReader(data), bytes.NewReader(signature))
+	return err
+}
+
 func (s *signer) KeyID() string {
 	return s.entity.PrimaryKey.KeyIdString()
 }
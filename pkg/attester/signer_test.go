@@ -1,9 +1,15 @@
 package attester
 
 import (
+	"bytes"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
 )
 
 func TestSigner(t *testing.T) {
@@ -27,3 +33,113 @@ func TestSigner(t *testing.T) {
 	keyID := signer.KeyID()
 	assert.NotEmpty(keyID)
 }
+
+func TestReadVerifier(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSigner("foo")
+	assert.NoError(err)
+
+	signedMessage, err := s.Sign("hello world!")
+	assert.NoError(err)
+
+	buf := new(bytes.Buffer)
+	armorWriter, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	assert.NoError(err)
+	assert.NoError(s.(*signer).entity.Serialize(armorWriter))
+	assert.NoError(armorWriter.Close())
+
+	verifier, err := ReadVerifier(buf)
+	assert.NoError(err)
+	assert.Equal(s.KeyID(), verifier.KeyID())
+
+	verifiedMessage, err := verifier.Verify(signedMessage)
+	assert.NoError(err)
+	assert.Equal("hello world!", verifiedMessage)
+
+	_, err = ReadVerifier(bytes.NewBufferString("not a key"))
+	assert.Error(err)
+}
+
+func TestReadSignerWithPassphrase(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := ioutil.ReadFile("../../test/pgp_encrypted_key.asc")
+	assert.NoError(err)
+
+	_, err = ReadSigner(bytes.NewReader(key))
+	assert.Error(err, "encrypted key without a passphrase")
+
+	_, err = ReadSignerWithPassphrase(bytes.NewReader(key), []byte("wrong"))
+	assert.Error(err, "encrypted key with the wrong passphrase")
+
+	s, err := ReadSignerWithPassphrase(bytes.NewReader(key), []byte("correct horse"))
+	assert.NoError(err)
+
+	signedMessage, err := s.Sign("hello world!")
+	assert.NoError(err)
+
+	verifiedMessage, err := s.Verify(signedMessage)
+	assert.NoError(err)
+	assert.Equal("hello world!", verifiedMessage)
+}
+
+func TestReadSignerWithPassphrase_Unencrypted(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSigner("foo")
+	assert.NoError(err)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(s.Serialize(buf))
+
+	_, err = ReadSignerWithPassphrase(buf, []byte("unused"))
+	assert.NoError(err)
+}
+
+func TestSerializeWithPassphrase(t *testing.T) {
+	for _, keyType := range []rodev1alpha1.KeyType{rodev1alpha1.KeyTypePGPRSA, rodev1alpha1.KeyTypePGPECDSAP256} {
+		t.Run(string(keyType), func(t *testing.T) {
+			assert := assert.New(t)
+
+			s, err := NewSignerWithKeyType("encrypted", keyType)
+			assert.NoError(err)
+
+			buf := new(bytes.Buffer)
+			assert.NoError(SerializeWithPassphrase(s, buf, []byte("correct horse")))
+			key := buf.Bytes()
+
+			_, err = ReadSigner(bytes.NewReader(key))
+			assert.Error(err, "encrypted key without a passphrase")
+			assert.Contains(err.Error(), "no passphrase was provided")
+
+			_, err = ReadSignerWithPassphrase(bytes.NewReader(key), []byte("wrong"))
+			assert.Error(err, "encrypted key with the wrong passphrase")
+			assert.Contains(err.Error(), "the passphrase may be incorrect")
+
+			read, err := ReadSignerWithPassphrase(bytes.NewReader(key), []byte("correct horse"))
+			assert.NoError(err)
+			assert.Equal(s.KeyID(), read.KeyID())
+
+			signedMessage, err := read.Sign("hello world!")
+			assert.NoError(err)
+			verifiedMessage, err := s.Verify(signedMessage)
+			assert.NoError(err)
+			assert.Equal("hello world!", verifiedMessage)
+		})
+	}
+}
+
+func TestSerializeWithPassphrase_Unsupported(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSignerWithKeyType("ed25519", rodev1alpha1.KeyTypeEd25519)
+	assert.NoError(err)
+	assert.Error(SerializeWithPassphrase(s, new(bytes.Buffer), []byte("correct horse")))
+
+	// keys aren't encrypted without a passphrase
+	buf := new(bytes.Buffer)
+	assert.NoError(SerializeWithPassphrase(s, buf, nil))
+	_, err = ReadSigner(buf)
+	assert.NoError(err)
+}
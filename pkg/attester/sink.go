@@ -0,0 +1,119 @@
+package attester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/liatrio/rode/pkg/occurrence"
+)
+
+// DefaultAttestationSink is the sink attestations are stored in unless another is configured
+const DefaultAttestationSink = "grafeas"
+
+// AttestationSink stores the attestations created by attesters
+type AttestationSink interface {
+	StoreAttestation(ctx context.Context, att Attester, attestation *grafeas.Occurrence) error
+}
+
+// AttestationSinkFactory creates a sink. The creator stores occurrences in Grafeas.
+type AttestationSinkFactory func(creator occurrence.Creator) AttestationSink
+
+var attestationSinks = map[string]AttestationSinkFactory{
+	DefaultAttestationSink: func(creator occurrence.Creator) AttestationSink {
+		return NewGrafeasSink(creator)
+	},
+	"stdout": func(occurrence.Creator) AttestationSink {
+		return NewWriterSink(os.Stdout)
+	},
+}
+
+// RegisterAttestationSink makes a sink available to NewAttestationSink by name, replacing any sink with the same name
+func RegisterAttestationSink(name string, factory AttestationSinkFactory) {
+	attestationSinks[name] = factory
+}
+
+// AttestationSinks returns the names of the registered sinks
+func AttestationSinks() []string {
+	var names []string
+	for name := range attestationSinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewAttestationSink creates the registered sink with the name
+func NewAttestationSink(name string, creator occurrence.Creator) (AttestationSink, error) {
+	factory, ok := attestationSinks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown attestation sink %q, must be one of %v", name, AttestationSinks())
+	}
+
+	return factory(creator), nil
+}
+
+type grafeasSink struct {
+	creator occurrence.Creator
+}
+
+// NewGrafeasSink creates a sink that stores attestations as occurrences in Grafeas. If the attestation's note doesn't
+// exist, because it was deleted after the attester was reconciled, the note is created when the creator can create
+// notes and the attestation is stored again.
+func NewGrafeasSink(creator occurrence.Creator) AttestationSink {
+	return &grafeasSink{
+		creator,
+	}
+}
+
+func (s *grafeasSink) StoreAttestation(ctx context.Context, att Attester, attestation *grafeas.Occurrence) error {
+	err := s.creator.CreateOccurrences(ctx, attestation)
+	notes, ok := s.creator.(occurrence.NoteCreator)
+	if status.Code(err) != codes.NotFound || !ok {
+		return err
+	}
+
+	attesterNotes := NotesOf(att)
+	kind, ok := attesterNotes.noteKind(attestation.GetNoteName())
+	if !ok {
+		return err
+	}
+	if err := notes.CreateNote(ctx, attesterNotes.NoteID(kind), attesterNotes.Note(kind)); err != nil {
+		return fmt.Errorf("unable to create note %s: %v", attestation.GetNoteName(), err)
+	}
+
+	return s.creator.CreateOccurrences(ctx, attestation)
+}
+
+type writerSink struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewWriterSink creates a sink that writes each attestation, with its payload and signature, to out as a line of JSON
+func NewWriterSink(out io.Writer) AttestationSink {
+	return &writerSink{
+		out: out,
+	}
+}
+
+func (s *writerSink) StoreAttestation(ctx context.Context, att Attester, attestation *grafeas.Occurrence) error {
+	line, err := json.Marshal(newStoredAttestation(att, attestation))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.out.Write(append(line, '\n'))
+	return err
+}
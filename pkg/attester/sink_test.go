@@ -0,0 +1,132 @@
+package attester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/liatrio/rode/pkg/occurrence"
+	"github.com/liatrio/rode/pkg/test"
+)
+
+func TestWriterSink(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	policy, err := NewPolicy(ctx, "sink", "package sink\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("sink")
+	assert.NoError(err)
+
+	out := &bytes.Buffer{}
+	client := &fakeOccurrenceClient{}
+	wrapper := NewAttestWrapper(logf.NullLogger{}, client, client, fakeAttesterLister{
+		"default/sink": NewAttester("default/sink", policy, signer, WithStage("dev")),
+	}, nil, nil, nil, nil, NewWriterSink(out)).(*attestWrapper)
+
+	assert.NoError(wrapper.attestResource(ctx, "image", "DISCOVERY", false))
+	assert.NoError(wrapper.attestResource(ctx, "other", "DISCOVERY", false))
+
+	// the attestations are written rather than stored in grafeas
+	assert.Empty(client.occurrences)
+
+	decoder := json.NewDecoder(out)
+	for _, subject := range []string{"image", "other"} {
+		stored := &StoredAttestation{}
+		assert.NoError(decoder.Decode(stored))
+		assert.Equal("default/sink", stored.Attester)
+		assert.Equal(subject, stored.Subject)
+		assert.Equal(signer.KeyID(), stored.KeyID)
+		assert.Equal(subject+"\nstage=dev", stored.Payload)
+
+		payload, err := signer.Verify(stored.Signature)
+		assert.NoError(err)
+		assert.Equal(stored.Payload, payload)
+	}
+	assert.False(decoder.More())
+}
+
+func TestNewAttestationSink(t *testing.T) {
+	assert := assert.New(t)
+	client := &fakeOccurrenceClient{}
+
+	sink, err := NewAttestationSink(DefaultAttestationSink, client)
+	assert.NoError(err)
+	assert.IsType(&grafeasSink{}, sink)
+
+	sink, err = NewAttestationSink("stdout", client)
+	assert.NoError(err)
+	assert.IsType(&writerSink{}, sink)
+
+	_, err = NewAttestationSink("kafka", client)
+	assert.Error(err)
+
+	RegisterAttestationSink("discard", func(occurrence.Creator) AttestationSink {
+		return NewWriterSink(&bytes.Buffer{})
+	})
+	defer delete(attestationSinks, "discard")
+	assert.Contains(AttestationSinks(), "discard")
+
+	_, err = NewAttestationSink("discard", client)
+	assert.NoError(err)
+}
+
+func TestGrafeasSink_CreatesMissingNote(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	fake, conn, stop, err := test.StartFakeGrafeas()
+	assert.NoError(err)
+	defer stop()
+	client := occurrence.NewGrafeasClientWithConn(logf.NullLogger{}, conn)
+
+	policy, err := NewPolicy(ctx, "sink", "package sink\nviolation[{\"msg\":\"never\"}]{\n\tfalse\n}", false)
+	assert.NoError(err)
+	signer, err := NewSigner("sink")
+	assert.NoError(err)
+	att := NewAttester("default/sink", policy, signer, WithNoteKinds([]string{"DISCOVERY"}))
+	wrapper := NewAttestWrapper(logf.NullLogger{}, client, client, fakeAttesterLister{
+		"default/sink": att,
+	}, nil, nil, nil, nil, NewGrafeasSink(client)).(*attestWrapper)
+
+	// the note is created when the attestation is stored, and again once it has been deleted
+	noteName := NoteName("default/sink", "DISCOVERY")
+	assert.NoError(wrapper.attestResource(ctx, "image", "DISCOVERY", false))
+	assert.True(proto.Equal(NewAttestationNote("default/sink", "DISCOVERY"), fake.Note(noteName)))
+
+	_, err = fake.DeleteNote(ctx, &grafeas.DeleteNoteRequest{Name: noteName})
+	assert.NoError(err)
+	assert.NoError(wrapper.attestResource(ctx, "other", "DISCOVERY", false))
+	assert.NotNil(fake.Note(noteName))
+
+	attestations := fake.Occurrences()
+	assert.Len(attestations, 2)
+	for i, subject := range []string{"image", "other"} {
+		assert.Equal(subject, attestations[i].Resource.Uri)
+		assert.Equal(noteName, attestations[i].NoteName)
+		assert.NoError(att.Verify(ctx, &VerifyRequest{Occurrence: attestations[i]}))
+	}
+
+	// notes that aren't the attester's aren't created
+	res, err := att.Attest(ctx, &AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+	res.Attestation.NoteName = "projects/rode/notes/unrelated"
+	res.Attestation.CreateTime = ptypes.TimestampNow()
+	assert.Error(NewGrafeasSink(client).StoreAttestation(ctx, att, res.Attestation))
+	assert.Nil(fake.Note("projects/rode/notes/unrelated"))
+
+	// the notes of attesters that configure them are created in their project
+	custom := NewAttester("default/sink", policy, signer, WithNotes(Notes{Project: "security", ID: "approved"}))
+	res, err = custom.Attest(ctx, &AttestRequest{ResourceURI: "image"})
+	assert.NoError(err)
+	res.Attestation.CreateTime = ptypes.TimestampNow()
+	assert.NoError(NewGrafeasSink(client).StoreAttestation(ctx, custom, res.Attestation))
+	assert.NotNil(fake.Note("projects/security/notes/approved"))
+}
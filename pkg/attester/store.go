@@ -0,0 +1,55 @@
+package attester
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// SignerStore stores the signing keys of attesters, such as in Kubernetes secrets or in Vault
+type SignerStore interface {
+	// CreateSigner generates a key for the attester and stores it, encrypting the private key with the passphrase
+	// unless it's empty. The key that's already stored is returned instead if there is one, so that the attestations
+	// made with it stay valid.
+	CreateSigner(ctx context.Context, attester *rodev1alpha1.Attester, passphrase []byte) (Signer, error)
+
+	// ReadSigner reads the attester's key, returning nil without an error if the store doesn't hold one
+	ReadSigner(ctx context.Context, attester *rodev1alpha1.Attester, passphrase []byte) (Signer, error)
+
+	// DeleteSigner deletes the attester's key, returning whether it was deleted, since keys that were stored for
+	// another attester are kept. A key that's already been deleted isn't an error.
+	DeleteSigner(ctx context.Context, attester *rodev1alpha1.Attester) (bool, error)
+}
+
+type secretStore struct {
+	client client.Client
+}
+
+// NewSecretStore creates a signer store that keeps each attester's key in the Kubernetes secret named by its PgpSecret,
+// with NewSecret and DeleteSecret
+func NewSecretStore(c client.Client) SignerStore {
+	return &secretStore{c}
+}
+
+func (s *secretStore) CreateSigner(ctx context.Context, attester *rodev1alpha1.Attester, passphrase []byte) (Signer, error) {
+	return NewSecret(ctx, attester, s.client, secretName(attester), passphrase)
+}
+
+func (s *secretStore) ReadSigner(ctx context.Context, attester *rodev1alpha1.Attester, passphrase []byte) (Signer, error) {
+	return existingSigner(ctx, s.client, secretName(attester), attester.Spec.GetPgpSecretKey(), passphrase)
+}
+
+func (s *secretStore) DeleteSigner(ctx context.Context, attester *rodev1alpha1.Attester) (bool, error) {
+	return DeleteSecret(ctx, attester, s.client, secretName(attester))
+}
+
+// secretName returns the name of the secret holding the attester's generated key
+func secretName(attester *rodev1alpha1.Attester) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: attester.GetPgpSecretNamespace(),
+		Name:      attester.GetPgpSecret(),
+	}
+}
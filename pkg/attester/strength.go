@@ -0,0 +1,107 @@
+package attester
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// KeyStrengthPolicy is the minimum strength of the keys a signer can be loaded with
+type KeyStrengthPolicy struct {
+	// MinRSABits is the smallest RSA key size accepted
+	MinRSABits int
+
+	// MinECDSABits is the smallest ECDSA curve size accepted
+	MinECDSABits int
+
+	// AllowDSA accepts DSA keys, which are deprecated
+	AllowDSA bool
+}
+
+// DefaultKeyStrengthPolicy rejects RSA keys smaller than 2048 bits, curves smaller than P-256 and DSA keys
+var DefaultKeyStrengthPolicy = KeyStrengthPolicy{
+	MinRSABits:   2048,
+	MinECDSABits: 256,
+}
+
+// WeakKeyError is returned when a key doesn't meet the key strength policy
+type WeakKeyError struct {
+	KeyID  string
+	Reason string
+}
+
+func (e WeakKeyError) Error() string {
+	return fmt.Sprintf("key %s is too weak: %s", e.KeyID, e.Reason)
+}
+
+// Check returns a WeakKeyError if any of the signer's keys that can sign don't meet the policy. Signers that weren't
+// created by this package aren't checked.
+func (p KeyStrengthPolicy) Check(s Signer) error {
+	switch pgpSigner := s.(type) {
+	case *signer:
+		return p.checkEntity(pgpSigner.entity)
+	case *keyRing:
+		for _, key := range pgpSigner.keys {
+			if err := p.checkEntity(key.entity); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p KeyStrengthPolicy) checkEntity(entity *openpgp.Entity) error {
+	keys := []*packet.PublicKey{entity.PrimaryKey}
+	for _, subkey := range entity.Subkeys {
+		keys = append(keys, subkey.PublicKey)
+	}
+
+	for _, key := range keys {
+		if key == nil || !key.PubKeyAlgo.CanSign() {
+			continue
+		}
+
+		if reason := p.weakness(key); reason != "" {
+			return WeakKeyError{
+				KeyID:  key.KeyIdString(),
+				Reason: reason,
+			}
+		}
+	}
+
+	return nil
+}
+
+// weakness describes why the key doesn't meet the policy, or is empty if it does
+func (p KeyStrengthPolicy) weakness(key *packet.PublicKey) string {
+	switch key.PubKeyAlgo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSASignOnly:
+		bits, err := key.BitLength()
+		if err != nil {
+			return err.Error()
+		}
+		if int(bits) < p.MinRSABits {
+			return fmt.Sprintf("RSA key size %d is below the minimum of %d", bits, p.MinRSABits)
+		}
+	case packet.PubKeyAlgoECDSA:
+		publicKey, ok := key.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return "unable to determine the ECDSA curve"
+		}
+		params := publicKey.Curve.Params()
+		if params.BitSize < p.MinECDSABits {
+			return fmt.Sprintf("ECDSA curve %s is below the minimum size of %d bits", params.Name, p.MinECDSABits)
+		}
+	case packet.PubKeyAlgoDSA:
+		if !p.AllowDSA {
+			return "DSA keys are deprecated"
+		}
+	default:
+		return fmt.Sprintf("public key algorithm %v is not supported", key.PubKeyAlgo)
+	}
+
+	return ""
+}
@@ -0,0 +1,78 @@
+package attester
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// readRSASigner generates an RSA key of the given size and loads a signer from it as if it were imported
+func readRSASigner(t *testing.T, bits int) Signer {
+	entity, err := openpgp.NewEntity("imported", "", "", &packet.Config{DefaultHash: signingHash, RSABits: bits})
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, entity.SerializePrivate(buf, nil))
+
+	s, err := ReadSigner(buf)
+	assert.NoError(t, err)
+	return s
+}
+
+func TestKeyStrengthPolicy_RSA(t *testing.T) {
+	assert := assert.New(t)
+
+	weak := readRSASigner(t, 1024)
+	err := DefaultKeyStrengthPolicy.Check(weak)
+	assert.IsType(WeakKeyError{}, err)
+	assert.Equal(weak.KeyID(), err.(WeakKeyError).KeyID)
+	assert.Contains(err.Error(), "RSA key size 1024 is below the minimum of 2048")
+
+	assert.NoError(DefaultKeyStrengthPolicy.Check(readRSASigner(t, 2048)))
+	assert.NoError(KeyStrengthPolicy{MinRSABits: 1024}.Check(weak))
+
+	strong, err := NewSigner("generated")
+	assert.NoError(err)
+	assert.NoError(KeyStrengthPolicy{MinRSABits: 2048}.Check(strong))
+	assert.Error(KeyStrengthPolicy{MinRSABits: 3072}.Check(strong))
+}
+
+func TestKeyStrengthPolicy_ECDSA(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+	entity := &openpgp.Entity{PrimaryKey: packet.NewECDSAPublicKey(time.Now(), &key.PublicKey)}
+
+	assert.NoError(DefaultKeyStrengthPolicy.checkEntity(entity))
+
+	err = KeyStrengthPolicy{MinRSABits: 2048, MinECDSABits: 384}.checkEntity(entity)
+	assert.IsType(WeakKeyError{}, err)
+	assert.Contains(err.Error(), "ECDSA curve P-256 is below the minimum size of 384 bits")
+}
+
+func TestKeyStrengthPolicy_Subkeys(t *testing.T) {
+	assert := assert.New(t)
+
+	strong := readRSASigner(t, 2048).(*signer).entity
+	weak := readRSASigner(t, 1024).(*signer).entity
+
+	// a weak signing subkey fails the check even when the primary key is strong
+	strong.Subkeys = append(strong.Subkeys, openpgp.Subkey{PublicKey: weak.PrimaryKey})
+	assert.Error(DefaultKeyStrengthPolicy.checkEntity(strong))
+}
+
+func TestKeyStrengthPolicy_DSA(t *testing.T) {
+	assert := assert.New(t)
+
+	key := &packet.PublicKey{PubKeyAlgo: packet.PubKeyAlgoDSA}
+	assert.Equal("DSA keys are deprecated", DefaultKeyStrengthPolicy.weakness(key))
+	assert.Empty(KeyStrengthPolicy{AllowDSA: true}.weakness(key))
+}
@@ -0,0 +1,87 @@
+package attester
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SubjectTracker tracks which subjects each attester currently has a valid attestation for. A subject is added when
+// it's attested and removed when a later evaluation of the subject results in violations.
+type SubjectTracker struct {
+	mutex    sync.RWMutex
+	subjects map[string]map[string]bool
+
+	// OnChange is called with the attester name whenever the subjects for that attester change
+	OnChange func(attester string)
+}
+
+// NewSubjectTracker creates a new SubjectTracker
+func NewSubjectTracker() *SubjectTracker {
+	return &SubjectTracker{
+		subjects: make(map[string]map[string]bool),
+	}
+}
+
+// Attested records that the attester attested the subject
+func (t *SubjectTracker) Attested(attester, subject string) {
+	t.update(attester, func(subjects map[string]bool) bool {
+		if subjects[subject] {
+			return false
+		}
+
+		subjects[subject] = true
+		return true
+	})
+}
+
+// Rejected records that the subject no longer meets the attester's policy
+func (t *SubjectTracker) Rejected(attester, subject string) {
+	t.update(attester, func(subjects map[string]bool) bool {
+		if !subjects[subject] {
+			return false
+		}
+
+		delete(subjects, subject)
+		return true
+	})
+}
+
+// Remove forgets all subjects for the attester
+func (t *SubjectTracker) Remove(attester string) {
+	t.mutex.Lock()
+	delete(t.subjects, attester)
+	t.mutex.Unlock()
+
+	attestedSubjects.DeleteLabelValues(attester)
+}
+
+// Count returns the number of subjects the attester has attested
+func (t *SubjectTracker) Count(attester string) int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return len(t.subjects[attester])
+}
+
+func (t *SubjectTracker) update(attester string, updateFunc func(map[string]bool) bool) {
+	t.mutex.Lock()
+	subjects, ok := t.subjects[attester]
+	if !ok {
+		subjects = make(map[string]bool)
+		t.subjects[attester] = subjects
+	}
+	changed := updateFunc(subjects)
+	count := len(subjects)
+	t.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	attestedSubjects.With(prometheus.Labels{"attester": attester}).Set(float64(count))
+
+	if t.OnChange != nil {
+		t.OnChange(attester)
+	}
+}
@@ -0,0 +1,34 @@
+package attester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	var changes []string
+	tracker := NewSubjectTracker()
+	tracker.OnChange = func(attester string) {
+		changes = append(changes, attester)
+	}
+
+	tracker.Attested("default/foo", "image-a")
+	tracker.Attested("default/foo", "image-a")
+	tracker.Attested("default/foo", "image-b")
+	tracker.Attested("default/bar", "image-a")
+	assert.Equal(2, tracker.Count("default/foo"))
+	assert.Equal(1, tracker.Count("default/bar"))
+
+	tracker.Rejected("default/foo", "image-a")
+	tracker.Rejected("default/foo", "image-c")
+	assert.Equal(1, tracker.Count("default/foo"))
+
+	tracker.Remove("default/foo")
+	assert.Equal(0, tracker.Count("default/foo"))
+	assert.Equal(1, tracker.Count("default/bar"))
+
+	assert.Equal([]string{"default/foo", "default/foo", "default/bar", "default/foo"}, changes)
+}
@@ -0,0 +1,199 @@
+package attester
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// transformRule is the rule a Rego input transform must define
+const transformRule = "transform"
+
+// InputTransform transforms an occurrence, as the document the policy would otherwise see, into the policy's input
+type InputTransform interface {
+	Transform(ctx context.Context, occurrence map[string]interface{}) (map[string]interface{}, error)
+}
+
+// NewInputTransform creates the transform described by the spec, returning an error if it's invalid
+func NewInputTransform(name string, spec *rodev1alpha1.InputTransform) (InputTransform, error) {
+	if spec.Rego != "" && len(spec.Mapping) > 0 {
+		return nil, fmt.Errorf("input transform must set only one of rego or mapping")
+	}
+
+	if spec.Rego != "" {
+		return NewRegoTransform(name, spec.Rego)
+	}
+	if len(spec.Mapping) > 0 {
+		return NewMappingTransform(spec.Mapping)
+	}
+
+	return nil, fmt.Errorf("input transform must set rego or mapping")
+}
+
+// WithInputTransform transforms each occurrence before it's added to the policy's input
+func WithInputTransform(transform InputTransform) AttesterOption {
+	return func(a *attester) {
+		a.transform = transform
+	}
+}
+
+type regoTransform struct {
+	prepared rego.PreparedEvalQuery
+}
+
+// NewRegoTransform creates a transform from a Rego module that defines a transform rule. The rule is evaluated with
+// the occurrence as input.
+func NewRegoTransform(name string, module string) (InputTransform, error) {
+	filename := fmt.Sprintf("%s-transform.rego", name)
+	parsed, err := ast.ParseModule(filename, module)
+	if err != nil {
+		return nil, err
+	}
+
+	defined := false
+	for _, rule := range parsed.Rules {
+		if rule.Head.Name.Equal(ast.Var(transformRule)) {
+			defined = true
+		}
+	}
+	if !defined {
+		return nil, fmt.Errorf("input transform must define a %s rule", transformRule)
+	}
+
+	compiler := ast.NewCompiler()
+	compiler.Compile(map[string]*ast.Module{filename: parsed})
+	if compiler.Failed() {
+		return nil, compiler.Errors
+	}
+
+	prepared, err := rego.New(
+		rego.Query(fmt.Sprintf("%s.%s", parsed.Package.Path, transformRule)),
+		rego.Compiler(compiler),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &regoTransform{
+		prepared,
+	}, nil
+}
+
+func (t *regoTransform) Transform(ctx context.Context, occurrence map[string]interface{}) (map[string]interface{}, error) {
+	rs, err := t.prepared.Eval(ctx, rego.EvalInput(occurrence))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return nil, fmt.Errorf("input transform is undefined for the occurrence")
+	}
+
+	transformed, ok := rs[0].Expressions[0].Value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("input transform must be an object")
+	}
+
+	return transformed, nil
+}
+
+type mappingTransform struct {
+	fields []string
+	paths  map[string][]string
+}
+
+// NewMappingTransform creates a transform that builds a document from the values at paths in the occurrence. Fields
+// and paths are dot separated, so fields can be nested, and path segments that are numbers index arrays.
+func NewMappingTransform(mapping map[string]string) (InputTransform, error) {
+	t := &mappingTransform{
+		paths: make(map[string][]string),
+	}
+
+	for field, path := range mapping {
+		if err := validatePath(field); err != nil {
+			return nil, fmt.Errorf("invalid input transform field %q: %v", field, err)
+		}
+		if err := validatePath(path); err != nil {
+			return nil, fmt.Errorf("invalid input transform path %q for field %q: %v", path, field, err)
+		}
+
+		t.fields = append(t.fields, field)
+		t.paths[field] = strings.Split(path, ".")
+	}
+
+	// a field that's a prefix of another would be overwritten depending on the order they were set in
+	sort.Strings(t.fields)
+	for i := 1; i < len(t.fields); i++ {
+		if strings.HasPrefix(t.fields[i], t.fields[i-1]+".") {
+			return nil, fmt.Errorf("input transform field %q conflicts with %q", t.fields[i], t.fields[i-1])
+		}
+	}
+
+	return t, nil
+}
+
+func (t *mappingTransform) Transform(ctx context.Context, occurrence map[string]interface{}) (map[string]interface{}, error) {
+	transformed := make(map[string]interface{})
+	for _, field := range t.fields {
+		value, ok := lookupPath(occurrence, t.paths[field])
+		if !ok {
+			continue
+		}
+
+		document := transformed
+		segments := strings.Split(field, ".")
+		for _, segment := range segments[:len(segments)-1] {
+			next, ok := document[segment].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				document[segment] = next
+			}
+			document = next
+		}
+		document[segments[len(segments)-1]] = value
+	}
+
+	return transformed, nil
+}
+
+func validatePath(path string) error {
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return fmt.Errorf("empty path segment")
+		}
+	}
+
+	return nil
+}
+
+// lookupPath returns the value at the path in the document
+func lookupPath(document interface{}, path []string) (interface{}, bool) {
+	value := document
+	for _, segment := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
@@ -0,0 +1,224 @@
+package attester
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	build "github.com/grafeas/grafeas/proto/v1beta1/build_go_proto"
+	common "github.com/grafeas/grafeas/proto/v1beta1/common_go_proto"
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	provenance "github.com/grafeas/grafeas/proto/v1beta1/provenance_go_proto"
+	vulnerability "github.com/grafeas/grafeas/proto/v1beta1/vulnerability_go_proto"
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+const normalizeTransform = `
+package normalize
+
+transform = {"source": "scanner", "severity": input.vulnerability.severity} {
+	input.kind == "VULNERABILITY"
+}
+
+transform = {"source": "discovery", "passed": passed} {
+	input.kind == "DISCOVERY"
+	passed := input.discovered.discovered.analysisStatus == "FINISHED_SUCCESS"
+}
+
+transform = {"source": "build", "builder": input.build.provenance.projectId} {
+	input.kind == "BUILD"
+}
+`
+
+// normalizedPolicy only understands the documents produced by normalizeTransform
+const normalizedPolicy = `
+package normalized
+
+violation[{"msg": "critical vulnerability"}] {
+	input.occurrences[_].severity == "CRITICAL"
+}
+
+violation[{"msg": "scan failed"}] {
+	input.occurrences[_].passed == false
+}
+
+violation[{"msg": "untrusted builder"}] {
+	o := input.occurrences[_]
+	o.source == "build"
+	o.builder != "trusted"
+}
+`
+
+func transformTestOccurrences() []*grafeas.Occurrence {
+	return []*grafeas.Occurrence{
+		{
+			Name: "projects/rode/occurrences/vulnerability",
+			Kind: common.NoteKind_VULNERABILITY,
+			Details: &grafeas.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_CRITICAL},
+			},
+		},
+		{
+			Name: "projects/rode/occurrences/discovery",
+			Kind: common.NoteKind_DISCOVERY,
+			Details: &grafeas.Occurrence_Discovered{
+				Discovered: &discovery.Details{
+					Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_FINISHED_FAILED},
+				},
+			},
+		},
+		{
+			Name: "projects/rode/occurrences/build",
+			Kind: common.NoteKind_BUILD,
+			Details: &grafeas.Occurrence_Build{
+				Build: &build.Details{Provenance: &provenance.BuildProvenance{Id: "1", ProjectId: "laptop"}},
+			},
+		},
+	}
+}
+
+func TestRegoTransform(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	transform, err := NewInputTransform("normalize", &rodev1alpha1.InputTransform{Rego: normalizeTransform})
+	assert.NoError(err)
+
+	input := new(occurrenceInput)
+	for _, o := range transformTestOccurrences() {
+		assert.NoError(input.addOccurrence(ctx, o, transform))
+	}
+
+	assert.Equal([]map[string]interface{}{
+		{"source": "scanner", "severity": "CRITICAL"},
+		{"source": "discovery", "passed": false},
+		{"source": "build", "builder": "laptop"},
+	}, input.Occurrences)
+
+	// the transform must be defined for every occurrence
+	err = input.addOccurrence(ctx, &grafeas.Occurrence{Name: "projects/rode/occurrences/image", Kind: common.NoteKind_IMAGE}, transform)
+	assert.Error(err)
+	assert.Contains(err.Error(), "projects/rode/occurrences/image")
+}
+
+func TestMappingTransform(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	transform, err := NewInputTransform("mapping", &rodev1alpha1.InputTransform{Mapping: map[string]string{
+		"severity":        "vulnerability.severity",
+		"scan.status":     "discovered.discovered.analysisStatus",
+		"scan.kind":       "kind",
+		"builder":         "build.provenance.projectId",
+		"firstCommand":    "build.provenance.commands.0.name",
+		"missing.ignored": "vulnerability.cvssScore",
+	}})
+	assert.NoError(err)
+
+	occurrences := transformTestOccurrences()
+	occurrences[2].GetBuild().Provenance.Commands = []*provenance.Command{{Name: "docker build"}}
+
+	var transformed []map[string]interface{}
+	for _, o := range occurrences {
+		input := new(occurrenceInput)
+		assert.NoError(input.addOccurrence(ctx, o, transform))
+		transformed = append(transformed, input.Occurrences[0])
+	}
+
+	assert.Equal([]map[string]interface{}{
+		{"severity": "CRITICAL", "scan": map[string]interface{}{"kind": "VULNERABILITY"}},
+		{"scan": map[string]interface{}{"kind": "DISCOVERY", "status": "FINISHED_FAILED"}},
+		{"scan": map[string]interface{}{"kind": "BUILD"}, "builder": "laptop", "firstCommand": "docker build"},
+	}, transformed)
+}
+
+func TestNewInputTransform_Invalid(t *testing.T) {
+	tests := map[string]*rodev1alpha1.InputTransform{
+		"empty":              {},
+		"both":               {Rego: normalizeTransform, Mapping: map[string]string{"severity": "vulnerability.severity"}},
+		"parse error":        {Rego: "package broken\ntransform = {"},
+		"no transform rule":  {Rego: "package notransform\nresult = {}"},
+		"compile error":      {Rego: "package unsafe\ntransform = {\"x\": x} { input.y }"},
+		"empty path segment": {Mapping: map[string]string{"severity": "vulnerability..severity"}},
+		"empty field":        {Mapping: map[string]string{"": "kind"}},
+		"conflicting fields": {Mapping: map[string]string{"scan": "kind", "scan.status": "discovered.discovered.analysisStatus"}},
+	}
+
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewInputTransform("invalid", spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAttester_InputTransform(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	policy, err := NewPolicy(ctx, "normalized", normalizedPolicy, false)
+	assert.NoError(err)
+	signer, err := NewSigner("normalized")
+	assert.NoError(err)
+	transform, err := NewRegoTransform("normalize", normalizeTransform)
+	assert.NoError(err)
+
+	att := NewAttester("default/normalized", policy, signer, WithInputTransform(transform))
+	_, err = att.Attest(ctx, &AttestRequest{ResourceURI: "image", Occurrences: transformTestOccurrences()})
+	assert.IsType(ViolationError{}, err)
+	assert.ElementsMatch([]string{"critical vulnerability", "scan failed", "untrusted builder"}, violationMessages(err.(ViolationError).Violations))
+
+	// without the transform the policy doesn't understand the occurrences
+	violations, err := NewAttester("default/normalized", policy, signer).Evaluate(ctx, &AttestRequest{ResourceURI: "image", Occurrences: transformTestOccurrences()})
+	assert.NoError(err)
+	assert.Empty(violations)
+}
+
+func TestValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	v := NewValidator(logf.NullLogger{}, nil)
+	assert.NoError(t, v.InjectDecoder(decoder))
+
+	tests := map[string]struct {
+		transform *rodev1alpha1.InputTransform
+		allowed   bool
+	}{
+		"no transform":      {nil, true},
+		"valid rego":        {&rodev1alpha1.InputTransform{Rego: normalizeTransform}, true},
+		"valid mapping":     {&rodev1alpha1.InputTransform{Mapping: map[string]string{"severity": "vulnerability.severity"}}, true},
+		"invalid rego":      {&rodev1alpha1.InputTransform{Rego: "package broken\ntransform = {"}, false},
+		"invalid mapping":   {&rodev1alpha1.InputTransform{Mapping: map[string]string{"severity": "."}}, false},
+		"rego and mapping":  {&rodev1alpha1.InputTransform{Rego: normalizeTransform, Mapping: map[string]string{"a": "b"}}, false},
+		"neither specified": {&rodev1alpha1.InputTransform{}, false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "normalized"},
+				Spec:       rodev1alpha1.AttesterSpec{Policy: normalizedPolicy, InputTransform: tc.transform},
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Object: runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(tc.allowed, resp.Allowed, resp.Result.Message)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package attester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/auth"
+)
+
+// ValidatorPath is the path the validator is served on by the webhook server
+const ValidatorPath = "/validate-v1alpha1-attester"
+
+// +kubebuilder:webhook:path=/validate-v1alpha1-attester,mutating=false,failurePolicy=fail,groups=rode.liatr.io,resources=attesters,verbs=create;update,versions=v1alpha1,name=vattester.rode.liatr.io
+
+// Validator validates Attesters when they're admitted
+type Validator interface {
+	admission.Handler
+	admission.DecoderInjector
+}
+
+type validator struct {
+	log        logr.Logger
+	authorizer auth.Authorizer
+	builtins   []Builtin
+	decoder    *admission.Decoder
+}
+
+// NewValidator creates a validator that rejects Attesters whose policy doesn't compile or has no violation rule, that
+// have an invalid input transform, an invalid secret name, that reference both a generated and an imported key or that
+// require more signatures than they have co-signers.
+// Attesters with a secret in another namespace are rejected unless the authorizer allows the user admitting them to
+// get and create secrets in that namespace, and always when the authorizer is nil. Policies are compiled with the
+// custom builtins, which should be the same builtins the controller compiles them with.
+func NewValidator(log logr.Logger, authorizer auth.Authorizer, builtins ...Builtin) Validator {
+	return &validator{
+		log,
+		authorizer,
+		builtins,
+		nil,
+	}
+}
+
+// SetupValidatorWithManager registers a validator with the manager's webhook server, which authorizes access to secrets
+// in other namespaces with SubjectAccessReviews
+func SetupValidatorWithManager(mgr manager.Manager, log logr.Logger, builtins ...Builtin) {
+	validator := NewValidator(log, auth.NewKubernetesAuthorizer(mgr.GetClient()), builtins...)
+	mgr.GetWebhookServer().Register(ValidatorPath, &webhook.Admission{Handler: validator})
+}
+
+func (v *validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	att := &rodev1alpha1.Attester{}
+	err := v.decoder.Decode(req, att)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// attesters being deleted are admitted so that their finalizer can be removed, even if they were created before a
+	// check was added
+	if att.DeletionTimestamp != nil {
+		return admission.Allowed("")
+	}
+
+	if att.Spec.PgpSecret != "" {
+		if errs := validation.IsDNS1123Subdomain(att.Spec.PgpSecret); len(errs) > 0 {
+			v.log.Info("rejecting attester with an invalid secret name", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "pgpSecret", att.Spec.PgpSecret)
+			return admission.Denied(fmt.Sprintf("pgpSecret %q is not a valid secret name: %s", att.Spec.PgpSecret, strings.Join(errs, ", ")))
+		}
+	}
+
+	if att.Spec.PgpSecretKey != "" {
+		if errs := validation.IsConfigMapKey(att.Spec.PgpSecretKey); len(errs) > 0 {
+			v.log.Info("rejecting attester with an invalid secret key", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "pgpSecretKey", att.Spec.PgpSecretKey)
+			return admission.Denied(fmt.Sprintf("pgpSecretKey %q is not a valid secret key: %s", att.Spec.PgpSecretKey, strings.Join(errs, ", ")))
+		}
+		if att.Spec.PgpSecretRef != nil {
+			v.log.Info("rejecting attester with a secret key for an imported key", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+			return admission.Denied("pgpSecretKey can't be set with pgpSecretRef, set pgpSecretRef.key instead")
+		}
+	}
+
+	if att.Spec.PgpSecretRef != nil && att.Spec.PgpSecret != "" {
+		v.log.Info("rejecting attester with both a generated and an imported key", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+		return admission.Denied("pgpSecret and pgpSecretRef can't both be set")
+	}
+
+	if att.Spec.VaultPath != "" {
+		if field := secretOnlyField(att.Spec); field != "" {
+			v.log.Info("rejecting attester with a key in Vault and a secret option", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "field", field)
+			return admission.Denied(fmt.Sprintf("%s can't be set with vaultPath, the key is stored in Vault rather than a secret", field))
+		}
+	}
+
+	if att.Spec.KeyType == rodev1alpha1.KeyTypeEd25519 && att.Spec.PgpPassphraseSecretRef != nil {
+		v.log.Info("rejecting attester with a passphrase for an Ed25519 key", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+		return admission.Denied("pgpPassphraseSecretRef can't be set with keyType ed25519, passphrase-protected Ed25519 keys are not supported")
+	}
+
+	if _, err := NewSignerOptions(att.Spec); err != nil {
+		v.log.Info("rejecting attester with invalid key parameters", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "error", err.Error())
+		return admission.Denied(fmt.Sprintf("invalid key parameters: %v", err))
+	}
+
+	if att.Spec.RequiredSignatures > 1+len(att.Spec.CoSigners) {
+		v.log.Info("rejecting attester requiring more signatures than it has signers", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "requiredSignatures", att.Spec.RequiredSignatures)
+		return admission.Denied(fmt.Sprintf("requiredSignatures %d is more than the attester and its %d co-signers can provide", att.Spec.RequiredSignatures, len(att.Spec.CoSigners)))
+	}
+
+	if namespace := att.Spec.PgpSecretNamespace; namespace != "" && namespace != att.Namespace {
+		if response, denied := v.checkSecretNamespace(ctx, req, att); denied {
+			return response
+		}
+	}
+
+	// without a finalizer, the secret is only deleted through its owner reference as soon as the attester is deleted
+	if att.Annotations[NoFinalizerAnnotation] == "true" {
+		if namespace := att.Spec.PgpSecretNamespace; namespace != "" && namespace != att.Namespace {
+			v.log.Info("rejecting attester without a finalizer with a secret in another namespace", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+			return admission.Denied(fmt.Sprintf("%s can't be set with a pgpSecretNamespace other than the attester's namespace, the secret is only deleted by the finalizer", NoFinalizerAnnotation))
+		}
+		if grace := att.Spec.SecretDeletionGracePeriod; grace != nil && grace.Duration > 0 {
+			v.log.Info("rejecting attester without a finalizer with a deletion grace period", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+			return admission.Denied(fmt.Sprintf("%s can't be set with secretDeletionGracePeriod, the secret is only released by the finalizer", NoFinalizerAnnotation))
+		}
+	}
+
+	if att.Spec.PolicyConfigMapRef != nil && att.Spec.Policy != "" {
+		v.log.Info("rejecting attester with both an inline policy and a policy ConfigMap", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+		return admission.Denied("policy and policyConfigMapRef can't both be set")
+	}
+
+	if len(att.Spec.Policies) > 0 && (att.Spec.PolicyConfigMapRef != nil || att.Spec.Policy != "") {
+		v.log.Info("rejecting attester with policy modules and another policy", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+		return admission.Denied("policies can't be set along with policy or policyConfigMapRef")
+	}
+
+	if att.Spec.PolicyGitRepository != nil && (att.Spec.Policy != "" || len(att.Spec.Policies) > 0 || att.Spec.PolicyConfigMapRef != nil) {
+		v.log.Info("rejecting attester with a policy Git repository and another policy", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+		return admission.Denied("policyGitRepository can't be set along with policy, policies or policyConfigMapRef")
+	}
+
+	if repo := att.Spec.PolicyGitRepository; repo != nil {
+		if err := CheckPolicyRepositoryURL(repo.URL, PolicyRepositoryProtocols); err != nil {
+			v.log.Info("rejecting attester with a policy Git repository URL that can't be fetched", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "url", repo.URL)
+			return admission.Denied(err.Error())
+		}
+	}
+
+	if err := CheckRegoVersion(att.Spec.RegoVersion); err != nil {
+		v.log.Info("rejecting attester with an unsupported rego version", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "regoVersion", att.Spec.RegoVersion)
+		return admission.Denied(err.Error())
+	}
+
+	// the policies are compiled the same way as when the attester is reconciled, so a policy that's admitted compiles.
+	// A policy in a ConfigMap or a Git repository is compiled when the attester is reconciled, since it can change
+	// separately.
+	if att.Spec.PolicyConfigMapRef == nil && att.Spec.PolicyGitRepository == nil {
+		var compiled Policy
+		if len(att.Spec.Policies) > 0 {
+			compiled, err = NewPolicyFromModules(ctx, att.Name, att.Spec.Policies, false, WithQuery(att.Spec.PolicyQuery), WithRegoVersion(att.Spec.RegoVersion), WithBuiltins(v.builtins...))
+		} else if strings.TrimSpace(att.Spec.Policy) == "" {
+			v.log.Info("rejecting attester without a policy", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+			return admission.Denied("policy is empty, set policy, policies, policyConfigMapRef or policyGitRepository to the Rego policy that resources must pass")
+		} else {
+			compiled, err = NewPolicy(ctx, att.Name, att.Spec.Policy, false, WithQuery(att.Spec.PolicyQuery), WithRegoVersion(att.Spec.RegoVersion), WithBuiltins(v.builtins...))
+		}
+		if err != nil {
+			v.log.Info("rejecting attester with a policy that doesn't compile", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "error", err.Error())
+			return admission.Denied(fmt.Sprintf("policy does not compile: %v", err))
+		}
+		if p, ok := compiled.(*policy); ok {
+			if err := p.checkRules(); err != nil {
+				v.log.Info("rejecting attester with a policy without violations", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name))
+				return admission.Denied(fmt.Sprintf("%v, define violation rules in package %s or set policyQuery", err, att.Name))
+			}
+		}
+	}
+
+	if m := att.Spec.PolicyMigration; m != nil {
+		if _, err := NewPolicy(ctx, att.Name, m.OldPolicy, false, WithQuery(att.Spec.PolicyQuery), WithRegoVersion(att.Spec.RegoVersion), WithBuiltins(v.builtins...)); err != nil {
+			v.log.Info("rejecting attester with a migration policy that doesn't compile", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "error", err.Error())
+			return admission.Denied(fmt.Sprintf("policy being migrated from does not compile: %v", err))
+		}
+	}
+
+	if att.Spec.InputTransform != nil {
+		if _, err := NewInputTransform(att.Name, att.Spec.InputTransform); err != nil {
+			v.log.Info("rejecting attester with an invalid input transform", "attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "error", err.Error())
+			return admission.Denied(fmt.Sprintf("invalid input transform: %v", err))
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// checkSecretNamespace checks that an attester can use a secret in another namespace than its own, returning the
+// response that denies it if it can't. The user admitting the attester must be allowed to get and create secrets in
+// the namespace, so that attesters can't be used to read or overwrite secrets the user doesn't have access to.
+func (v *validator) checkSecretNamespace(ctx context.Context, req admission.Request, att *rodev1alpha1.Attester) (admission.Response, bool) {
+	namespace := att.Spec.PgpSecretNamespace
+	log := v.log.WithValues("attester", fmt.Sprintf("%s/%s", att.Namespace, att.Name), "pgpSecretNamespace", namespace)
+
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		log.Info("rejecting attester with an invalid secret namespace")
+		return admission.Denied(fmt.Sprintf("pgpSecretNamespace %q is not a valid namespace: %s", namespace, strings.Join(errs, ", "))), true
+	}
+	if att.Spec.PgpSecretRef != nil {
+		log.Info("rejecting attester with a secret namespace for an imported key")
+		return admission.Denied("pgpSecretNamespace can't be set with pgpSecretRef, imported keys are read from the attester's namespace"), true
+	}
+	if grace := att.Spec.SecretDeletionGracePeriod; grace != nil && grace.Duration > 0 {
+		log.Info("rejecting attester with a deletion grace period for a secret in another namespace")
+		return admission.Denied("secretDeletionGracePeriod can't be set with a pgpSecretNamespace other than the attester's namespace"), true
+	}
+	if v.authorizer == nil {
+		log.Info("rejecting attester with a secret in another namespace")
+		return admission.Denied(fmt.Sprintf("pgpSecretNamespace %s is not the attester's namespace, and secrets in other namespaces can't be authorized", namespace)), true
+	}
+
+	for _, verb := range []string{"get", "create"} {
+		err := v.authorizer.Authorize(ctx, &req.UserInfo, &authorizationv1.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      verb,
+			Resource:  "secrets",
+		})
+		if _, ok := err.(auth.ForbiddenError); ok {
+			log.Info("rejecting attester with a secret the user can't access", "user", req.UserInfo.Username, "verb", verb, "error", err.Error())
+			return admission.Denied(fmt.Sprintf("pgpSecretNamespace %s: user %s is not allowed to %s secrets in the namespace", namespace, req.UserInfo.Username, verb)), true
+		}
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err), true
+		}
+	}
+
+	return admission.Response{}, false
+}
+
+// secretOnlyField returns the first field of the spec that's set and only applies to a key stored in a secret, or an
+// empty string if there isn't one
+func secretOnlyField(spec rodev1alpha1.AttesterSpec) string {
+	switch {
+	case spec.PgpSecret != "":
+		return "pgpSecret"
+	case spec.PgpSecretKey != "":
+		return "pgpSecretKey"
+	case spec.PgpSecretNamespace != "":
+		return "pgpSecretNamespace"
+	case spec.PgpSecretRef != nil:
+		return "pgpSecretRef"
+	case spec.KeyEscrow != nil:
+		return "keyEscrow"
+	case spec.KeyRotation != nil:
+		return "keyRotation"
+	case spec.SecretDeletionGracePeriod != nil:
+		return "secretDeletionGracePeriod"
+	}
+
+	return ""
+}
+
+func (v *validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
@@ -0,0 +1,554 @@
+package attester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/auth"
+)
+
+func TestValidator_Policy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	v := NewValidator(logf.NullLogger{}, nil)
+	assert.NoError(t, v.InjectDecoder(decoder))
+
+	now := time.Now()
+	migration := func(oldPolicy string) *rodev1alpha1.PolicyMigration {
+		return &rodev1alpha1.PolicyMigration{
+			OldPolicy: oldPolicy,
+			Start:     metav1.NewTime(now),
+			End:       metav1.NewTime(now.Add(time.Hour)),
+		}
+	}
+
+	tests := map[string]struct {
+		policy    string
+		query     string
+		migration *rodev1alpha1.PolicyMigration
+		allowed   bool
+		message   string
+	}{
+		"valid rego":             {normalizedPolicy, "", nil, true, ""},
+		"syntax error":           {"package broken\nviolation[{\"msg\": \"x\"}] {", "", nil, false, "policy does not compile"},
+		"unsafe variable":        {"package broken\nviolation[{\"msg\": msg}] { true }", "", nil, false, "var msg is unsafe"},
+		"undefined function":     {"package broken\nviolation[{\"msg\": \"x\"}] { missing(input) }", "", nil, false, "undefined function missing"},
+		"empty policy":           {"", "", nil, false, "policy is empty"},
+		"valid query":            {sharedRego, "data.shared.strict_violation", nil, true, ""},
+		"undefined query":        {sharedRego, "data.shared.missing_violation", nil, false, "does not refer to a rule defined by the policy"},
+		"valid migration":        {normalizedPolicy, "", migration(normalizedPolicy), true, ""},
+		"invalid migration rego": {normalizedPolicy, "", migration("package old\nviolation[{"), false, "policy being migrated from does not compile"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "normalized"},
+				Spec:       rodev1alpha1.AttesterSpec{Policy: tc.policy, PolicyQuery: tc.query, PolicyMigration: tc.migration},
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(tc.allowed, resp.Allowed, resp.Result.Reason)
+			if !tc.allowed {
+				assert.Contains(string(resp.Result.Reason), tc.message)
+			}
+		})
+	}
+}
+
+func TestValidator_PgpSecretRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	v := NewValidator(logf.NullLogger{}, nil)
+	assert.NoError(t, v.InjectDecoder(decoder))
+
+	ref := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "external"}, Key: "keys"}
+
+	tests := map[string]struct {
+		pgpSecret    string
+		pgpSecretRef *corev1.SecretKeySelector
+		allowed      bool
+	}{
+		"generated key": {"generated", nil, true},
+		"imported key":  {"", ref, true},
+		"both":          {"generated", ref, false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "normalized"},
+				Spec:       rodev1alpha1.AttesterSpec{Policy: normalizedPolicy, PgpSecret: tc.pgpSecret, PgpSecretRef: tc.pgpSecretRef},
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(tc.allowed, resp.Allowed, resp.Result.Reason)
+		})
+	}
+}
+
+func TestValidator_PolicyConfigMapRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	v := NewValidator(logf.NullLogger{}, nil)
+	assert.NoError(t, v.InjectDecoder(decoder))
+
+	ref := &corev1.LocalObjectReference{Name: "policy"}
+
+	tests := map[string]struct {
+		policy             string
+		policyConfigMapRef *corev1.LocalObjectReference
+		allowed            bool
+	}{
+		"inline policy":    {normalizedPolicy, nil, true},
+		"policy ConfigMap": {"", ref, true},
+		"both":             {normalizedPolicy, ref, false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "normalized"},
+				Spec:       rodev1alpha1.AttesterSpec{Policy: tc.policy, PolicyConfigMapRef: tc.policyConfigMapRef},
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(tc.allowed, resp.Allowed, resp.Result.Reason)
+		})
+	}
+}
+
+func TestValidator_Policies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	v := NewValidator(logf.NullLogger{}, nil)
+	assert.NoError(t, v.InjectDecoder(decoder))
+
+	lib := "package lib\n\nunfinished(status) {\n\tstatus != \"FINISHED_SUCCESS\"\n}\n"
+	main := "package validated\n\nimport data.lib\n\nviolation[{\"msg\": \"analysis not finished\"}] {\n\tlib.unfinished(input.occurrences[_].discovered.discovered.analysisStatus)\n}\n"
+
+	tests := map[string]struct {
+		policies           map[string]string
+		policy             string
+		policyConfigMapRef *corev1.LocalObjectReference
+		allowed            bool
+		reason             string
+	}{
+		"modules":        {map[string]string{"lib.rego": lib, "main.rego": main}, "", nil, true, ""},
+		"missing import": {map[string]string{"main.rego": main}, "", nil, false, "main.rego"},
+		"syntax error":   {map[string]string{"lib.rego": "package lib\nunfinished(status) {", "main.rego": main}, "", nil, false, "lib.rego"},
+		"inline policy":  {map[string]string{"lib.rego": lib, "main.rego": main}, normalizedPolicy, nil, false, "policies can't be set"},
+		"policy ConfigMap": {
+			map[string]string{"lib.rego": lib, "main.rego": main}, "", &corev1.LocalObjectReference{Name: "policy"}, false, "policies can't be set",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "validated"},
+				Spec: rodev1alpha1.AttesterSpec{
+					Policy:             tc.policy,
+					Policies:           tc.policies,
+					PolicyConfigMapRef: tc.policyConfigMapRef,
+				},
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(tc.allowed, resp.Allowed, resp.Result.Reason)
+			if tc.reason != "" {
+				assert.Contains(string(resp.Result.Reason), tc.reason)
+			}
+		})
+	}
+}
+
+func TestValidator_Spec(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	v := NewValidator(logf.NullLogger{}, nil)
+	assert.NoError(t, v.InjectDecoder(decoder))
+
+	ref := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "external"}, Key: "keys"}
+	repo := &rodev1alpha1.PolicyGitRepository{URL: "https://example.com/policies.git"}
+	valid := rodev1alpha1.AttesterSpec{
+		Policy:       normalizedPolicy,
+		PgpSecret:    "normalized-keys.v1",
+		PgpSecretKey: "privateKey",
+	}
+	deleted := metav1.Now()
+
+	tests := map[string]struct {
+		mutate  func(spec *rodev1alpha1.AttesterSpec)
+		deleted *metav1.Time
+		reason  string
+	}{
+		"valid spec":               {func(spec *rodev1alpha1.AttesterSpec) {}, nil, ""},
+		"no policy":                {func(spec *rodev1alpha1.AttesterSpec) { spec.Policy = "" }, nil, "policy is empty, set policy, policies, policyConfigMapRef or policyGitRepository"},
+		"blank policy":             {func(spec *rodev1alpha1.AttesterSpec) { spec.Policy = " \n\t" }, nil, "policy is empty"},
+		"other package":            {func(spec *rodev1alpha1.AttesterSpec) { spec.Policy = sharedRego }, nil, "policy doesn't define a violation rule in package normalized"},
+		"no violation rule":        {func(spec *rodev1alpha1.AttesterSpec) { spec.Policy = "package normalized\n\nallowed = true\n" }, nil, "define violation rules in package normalized or set policyQuery"},
+		"uppercase secret name":    {func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecret = "Normalized" }, nil, `pgpSecret "Normalized" is not a valid secret name`},
+		"secret name with slash":   {func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecret = "default/keys" }, nil, `pgpSecret "default/keys" is not a valid secret name`},
+		"invalid secret key":       {func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecretKey = "private key" }, nil, `pgpSecretKey "private key" is not a valid secret key`},
+		"secret key with ref":      {func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecret = ""; spec.PgpSecretRef = ref }, nil, "set pgpSecretRef.key instead"},
+		"policy in git":            {func(spec *rodev1alpha1.AttesterSpec) { spec.Policy = ""; spec.PolicyGitRepository = repo }, nil, ""},
+		"policy in git and inline": {func(spec *rodev1alpha1.AttesterSpec) { spec.PolicyGitRepository = repo }, nil, "policyGitRepository can't be set along with policy"},
+		"policy in a local repository": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.Policy = ""
+			spec.PolicyGitRepository = &rodev1alpha1.PolicyGitRepository{URL: "file:///var/run/secrets"}
+		}, nil, "policy repository URL file:///var/run/secrets uses protocol file, must be one of [https ssh]"},
+		"policy in git over http": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.Policy = ""
+			spec.PolicyGitRepository = &rodev1alpha1.PolicyGitRepository{URL: "http://example.com/policies.git"}
+		}, nil, "uses protocol http"},
+		"deleted with no policy":   {func(spec *rodev1alpha1.AttesterSpec) { spec.Policy = "" }, &deleted, ""},
+		"deleted with bad secrets": {func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecret = "Normalized" }, &deleted, ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			spec := valid
+			tc.mutate(&spec)
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "normalized", DeletionTimestamp: tc.deleted},
+				Spec:       spec,
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(tc.reason == "", resp.Allowed, resp.Result.Reason)
+			if tc.reason != "" {
+				assert.Contains(string(resp.Result.Reason), tc.reason)
+			}
+		})
+	}
+}
+
+// secretsAuthorizer allows users to access secrets in the namespaces they're bound to, like RBAC role bindings
+type secretsAuthorizer map[string]map[string][]string
+
+func (a secretsAuthorizer) Authenticate(req *http.Request) (*authenticationv1.UserInfo, error) {
+	return nil, auth.ErrUnauthenticated
+}
+
+func (a secretsAuthorizer) Authorize(ctx context.Context, user *authenticationv1.UserInfo, resource *authorizationv1.ResourceAttributes) error {
+	if resource.Resource == "secrets" {
+		for _, verb := range a[user.Username][resource.Namespace] {
+			if verb == resource.Verb {
+				return nil
+			}
+		}
+	}
+
+	return auth.ForbiddenError{User: user.Username}
+}
+
+func TestValidator_SecretNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	authorizer := secretsAuthorizer{
+		"admin":  {"keys": {"get", "create"}},
+		"reader": {"keys": {"get"}},
+	}
+	ref := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "external"}, Key: "keys"}
+
+	tests := map[string]struct {
+		authorizer auth.Authorizer
+		user       string
+		mutate     func(spec *rodev1alpha1.AttesterSpec)
+		reason     string
+	}{
+		"default namespace":        {nil, "", func(spec *rodev1alpha1.AttesterSpec) {}, ""},
+		"same namespace":           {nil, "", func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecretNamespace = "default" }, ""},
+		"other without authorizer": {nil, "admin", func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecretNamespace = "keys" }, "secrets in other namespaces can't be authorized"},
+		"other with access":        {authorizer, "admin", func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecretNamespace = "keys" }, ""},
+		"other without create":     {authorizer, "reader", func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecretNamespace = "keys" }, "user reader is not allowed to create secrets in the namespace"},
+		"other without access":     {authorizer, "admin", func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecretNamespace = "vault" }, "user admin is not allowed to get secrets in the namespace"},
+		"invalid namespace":        {authorizer, "admin", func(spec *rodev1alpha1.AttesterSpec) { spec.PgpSecretNamespace = "Keys" }, `pgpSecretNamespace "Keys" is not a valid namespace`},
+		"other with imported key": {authorizer, "admin", func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpSecretNamespace = "keys"
+			spec.PgpSecret = ""
+			spec.PgpSecretRef = ref
+		}, "pgpSecretNamespace can't be set with pgpSecretRef"},
+		"other with grace period": {authorizer, "admin", func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpSecretNamespace = "keys"
+			spec.SecretDeletionGracePeriod = &metav1.Duration{Duration: time.Hour}
+		}, "secretDeletionGracePeriod can't be set"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v := NewValidator(logf.NullLogger{}, tc.authorizer)
+			assert.NoError(v.InjectDecoder(decoder))
+
+			spec := rodev1alpha1.AttesterSpec{Policy: normalizedPolicy, PgpSecret: "normalized"}
+			tc.mutate(&spec)
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "normalized"},
+				Spec:       spec,
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+				UserInfo:  authenticationv1.UserInfo{Username: tc.user},
+			}})
+			assert.Equal(tc.reason == "", resp.Allowed, resp.Result.Reason)
+			if tc.reason != "" {
+				assert.Contains(string(resp.Result.Reason), tc.reason)
+			}
+		})
+	}
+}
+
+func TestValidator_NoFinalizer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		annotation string
+		mutate     func(spec *rodev1alpha1.AttesterSpec)
+		reason     string
+	}{
+		"without finalizer": {"true", func(spec *rodev1alpha1.AttesterSpec) {}, ""},
+		"with grace period": {"false", func(spec *rodev1alpha1.AttesterSpec) {
+			spec.SecretDeletionGracePeriod = &metav1.Duration{Duration: time.Hour}
+		}, ""},
+		"without finalizer with grace period": {"true", func(spec *rodev1alpha1.AttesterSpec) {
+			spec.SecretDeletionGracePeriod = &metav1.Duration{Duration: time.Hour}
+		}, "rode.liatr.io/no-finalizer can't be set with secretDeletionGracePeriod"},
+		"without finalizer with other namespace": {"true", func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpSecretNamespace = "keys"
+		}, "rode.liatr.io/no-finalizer can't be set with a pgpSecretNamespace"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v := NewValidator(logf.NullLogger{}, secretsAuthorizer{"admin": {"keys": {"get", "create"}}})
+			assert.NoError(v.InjectDecoder(decoder))
+
+			spec := rodev1alpha1.AttesterSpec{Policy: normalizedPolicy, PgpSecret: "normalized"}
+			tc.mutate(&spec)
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta: metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "default",
+					Name:        "normalized",
+					Annotations: map[string]string{NoFinalizerAnnotation: tc.annotation},
+				},
+				Spec: spec,
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+				UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+			}})
+			assert.Equal(tc.reason == "", resp.Allowed, resp.Result.Reason)
+			if tc.reason != "" {
+				assert.Contains(string(resp.Result.Reason), tc.reason)
+			}
+		})
+	}
+}
+
+func TestValidator_Builtins(t *testing.T) {
+	assert := assert.New(t)
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(err)
+
+	raw, err := json.Marshal(&rodev1alpha1.Attester{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "allowlist"},
+		Spec:       rodev1alpha1.AttesterSpec{Policy: allowlistPolicy},
+	})
+	assert.NoError(err)
+	req := admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+
+	// policies calling a custom builtin are only admitted by a validator that has it
+	v := NewValidator(logf.NullLogger{}, nil, NewImageAllowlist(nil).Builtin())
+	assert.NoError(v.InjectDecoder(decoder))
+	resp := v.Handle(context.Background(), req)
+	assert.True(resp.Allowed, resp.Result.Reason)
+
+	v = NewValidator(logf.NullLogger{}, nil)
+	assert.NoError(v.InjectDecoder(decoder))
+	resp = v.Handle(context.Background(), req)
+	assert.False(resp.Allowed)
+	assert.Contains(string(resp.Result.Reason), "undefined function rode.image_allowed")
+}
+
+func TestValidator_SignerOptions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = rodev1alpha1.AddToScheme(scheme)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		mutate func(spec *rodev1alpha1.AttesterSpec)
+		reason string
+	}{
+		"defaults": {func(spec *rodev1alpha1.AttesterSpec) {}, ""},
+		"strong parameters": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpKeyBits = 3072
+			spec.PgpHashAlgorithm = rodev1alpha1.HashAlgorithmSHA384
+		}, ""},
+		"sha1": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpHashAlgorithm = rodev1alpha1.HashAlgorithmSHA1
+		}, "hash algorithm SHA1 is insecure"},
+		"small key": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpKeyBits = 1024
+		}, "RSA key size 1024 is below the minimum of 2048"},
+		"insecure parameters allowed": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpKeyBits = 1024
+			spec.PgpHashAlgorithm = rodev1alpha1.HashAlgorithmSHA1
+			spec.AllowInsecurePgpParameters = true
+		}, ""},
+		"key bits for an ECDSA key": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.KeyType = rodev1alpha1.KeyTypePGPECDSAP256
+			spec.PgpKeyBits = 4096
+		}, "pgpKeyBits only applies to keys of type pgp-rsa"},
+		"hash for an Ed25519 key": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.KeyType = rodev1alpha1.KeyTypeEd25519
+			spec.PgpHashAlgorithm = rodev1alpha1.HashAlgorithmSHA512
+		}, "pgpHashAlgorithm doesn't apply to keys of type ed25519"},
+		"passphrase for an Ed25519 key": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.KeyType = rodev1alpha1.KeyTypeEd25519
+			spec.PgpPassphraseSecretRef = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "passphrase"}, Key: "passphrase"}
+		}, "pgpPassphraseSecretRef can't be set with keyType ed25519"},
+		"required signatures with co-signers": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.RequiredSignatures = 2
+			spec.CoSigners = []corev1.SecretKeySelector{{LocalObjectReference: corev1.LocalObjectReference{Name: "security"}, Key: "public.asc"}}
+		}, ""},
+		"required signatures without enough co-signers": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.RequiredSignatures = 3
+			spec.CoSigners = []corev1.SecretKeySelector{{LocalObjectReference: corev1.LocalObjectReference{Name: "security"}, Key: "public.asc"}}
+		}, "requiredSignatures 3 is more than the attester and its 1 co-signers can provide"},
+		"key in vault": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpSecret = ""
+			spec.VaultPath = "rode/default/normalized"
+		}, ""},
+		"key in vault with a secret": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.VaultPath = "rode/default/normalized"
+		}, "pgpSecret can't be set with vaultPath"},
+		"key in vault with rotation": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.PgpSecret = ""
+			spec.VaultPath = "rode/default/normalized"
+			spec.KeyRotation = &rodev1alpha1.KeyRotation{RotateAfter: metav1.Duration{Duration: time.Hour}}
+		}, "keyRotation can't be set with vaultPath"},
+		"rego v0": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.RegoVersion = rodev1alpha1.RegoVersionV0
+		}, ""},
+		"rego v1": {func(spec *rodev1alpha1.AttesterSpec) {
+			spec.RegoVersion = rodev1alpha1.RegoVersionV1
+		}, "rego version v1 requires OPA 1.0 or later"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v := NewValidator(logf.NullLogger{}, secretsAuthorizer{})
+			assert.NoError(v.InjectDecoder(decoder))
+
+			spec := rodev1alpha1.AttesterSpec{Policy: normalizedPolicy, PgpSecret: "normalized"}
+			tc.mutate(&spec)
+			raw, err := json.Marshal(&rodev1alpha1.Attester{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rodev1alpha1.GroupVersion.String(), Kind: "Attester"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "normalized"},
+				Spec:       spec,
+			})
+			assert.NoError(err)
+
+			resp := v.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			}})
+			assert.Equal(tc.reason == "", resp.Allowed, resp.Result.Reason)
+			if tc.reason != "" {
+				assert.Contains(string(resp.Result.Reason), tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package attester
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+const (
+	// vaultKeyField is the field of the Vault secret that holds the base64 of the serialized key
+	vaultKeyField = "key"
+
+	// vaultControllerField is the field of the Vault secret that records the attester that the key was generated for,
+	// in the same format as the SecretControllerAnnotation
+	vaultControllerField = "controller"
+)
+
+type vaultStore struct {
+	address string
+	token   string
+	mount   string
+	client  *http.Client
+}
+
+// NewVaultStore creates a signer store that keeps each attester's key in the secret at its VaultPath, in the KV version
+// 2 secrets engine mounted at mount in the Vault server at address, authenticating with the token.
+func NewVaultStore(address, token, mount string, client *http.Client) SignerStore {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &vaultStore{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		client:  client,
+	}
+}
+
+func (s *vaultStore) CreateSigner(ctx context.Context, attester *rodev1alpha1.Attester, passphrase []byte) (Signer, error) {
+	if signer, err := s.ReadSigner(ctx, attester, passphrase); err != nil || signer != nil {
+		return signer, err
+	}
+
+	signer, signerData, err := newSignerData(ctx, attester.Spec, fmt.Sprintf("%s/%s", attester.Namespace, attester.Name), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	// cas 0 only writes the secret if it doesn't exist, so a key written since it was read isn't overwritten
+	body := map[string]interface{}{
+		"options": map[string]interface{}{"cas": 0},
+		"data": map[string]string{
+			vaultKeyField:        base64.StdEncoding.EncodeToString(signerData),
+			vaultControllerField: secretController(attester),
+		},
+	}
+
+	status, _, err := s.do(ctx, http.MethodPost, "data", attester.Spec.VaultPath, body)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusBadRequest {
+		// the secret was written since it was read, so the key that won is used rather than the one just generated
+		existing, err := s.ReadSigner(ctx, attester, passphrase)
+		if err != nil || existing != nil {
+			return existing, err
+		}
+	}
+	if status != http.StatusOK && status != http.StatusNoContent {
+		return nil, fmt.Errorf("unable to write vault secret %s: status %d", attester.Spec.VaultPath, status)
+	}
+
+	return signer, nil
+}
+
+func (s *vaultStore) ReadSigner(ctx context.Context, attester *rodev1alpha1.Attester, passphrase []byte) (Signer, error) {
+	data, err := s.read(ctx, attester.Spec.VaultPath)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	encoded, ok := data[vaultKeyField]
+	if !ok {
+		return nil, fmt.Errorf("vault secret %s already exists without field %s", attester.Spec.VaultPath, vaultKeyField)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("vault secret %s already exists with an invalid key: %v", attester.Spec.VaultPath, err)
+	}
+
+	signer, err := ReadSignerWithPassphrase(bytes.NewReader(key), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("vault secret %s already exists with an invalid key: %v", attester.Spec.VaultPath, err)
+	}
+
+	return signer, nil
+}
+
+func (s *vaultStore) DeleteSigner(ctx context.Context, attester *rodev1alpha1.Attester) (bool, error) {
+	data, err := s.read(ctx, attester.Spec.VaultPath)
+	if err != nil || data == nil {
+		return false, err
+	}
+
+	// keys generated for another attester, such as a previous attester with the same name, are kept
+	if data[vaultControllerField] != secretController(attester) {
+		return false, nil
+	}
+
+	// deleting the metadata deletes every version of the secret, so the key can't be undeleted
+	status, _, err := s.do(ctx, http.MethodDelete, "metadata", attester.Spec.VaultPath, nil)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if status != http.StatusOK && status != http.StatusNoContent {
+		return false, fmt.Errorf("unable to delete vault secret %s: status %d", attester.Spec.VaultPath, status)
+	}
+
+	return true, nil
+}
+
+// read returns the data of the latest version of the secret at the path, or nil if it doesn't exist or was deleted
+func (s *vaultStore) read(ctx context.Context, path string) (map[string]string, error) {
+	status, body, err := s.do(ctx, http.MethodGet, "data", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("unable to read vault secret %s: status %d", path, status)
+	}
+
+	secret := struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("unable to read vault secret %s: %v", path, err)
+	}
+
+	return secret.Data.Data, nil
+}
+
+// do sends a request to the KV API for the path, returning the response's status and body
+func (s *vaultStore) do(ctx context.Context, method, api, path string, body interface{}) (int, []byte, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s/%s/%s", s.address, s.mount, api, strings.Trim(path, "/")), reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", s.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return res.StatusCode, resBody, nil
+}
@@ -0,0 +1,122 @@
+package attester
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// fakeVault serves the parts of the KV version 2 API used by the vault store, from secrets kept in memory
+type fakeVault struct {
+	mutex   sync.Mutex
+	secrets map[string]map[string]string
+	writes  int
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		data, ok := v.secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		body := struct {
+			Options map[string]int    `json:"options"`
+			Data    map[string]string `json:"data"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if cas, ok := body.Options["cas"]; ok && cas == 0 && v.secrets[path] != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		v.secrets[path] = body.Data
+		v.writes++
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/"):
+		delete(v.secrets, strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestVaultStore(t *testing.T) {
+	assert := assert.New(t)
+
+	vault := &fakeVault{secrets: make(map[string]map[string]string)}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	store := NewVaultStore(server.URL+"/", "token", "secret", server.Client())
+	att := &rodev1alpha1.Attester{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vault", UID: "vault-uid"},
+		Spec:       rodev1alpha1.AttesterSpec{VaultPath: "rode/default/vault", KeyType: rodev1alpha1.KeyTypeEd25519},
+	}
+
+	signer, err := store.ReadSigner(ctx, att, nil)
+	assert.NoError(err)
+	assert.Nil(signer)
+
+	// the key is generated once, and the stored key is returned by later calls
+	created, err := store.CreateSigner(ctx, att, nil)
+	assert.NoError(err)
+	assert.Equal(1, vault.writes)
+
+	again, err := store.CreateSigner(ctx, att, nil)
+	assert.NoError(err)
+	assert.Equal(created.KeyID(), again.KeyID())
+	assert.Equal(1, vault.writes)
+
+	read, err := store.ReadSigner(ctx, att, nil)
+	assert.NoError(err)
+	assert.Equal(created.KeyID(), read.KeyID())
+
+	// a key stored for another attester with the same name isn't deleted
+	recreated := att.DeepCopy()
+	recreated.UID = "recreated-uid"
+	deleted, err := store.DeleteSigner(ctx, recreated)
+	assert.NoError(err)
+	assert.False(deleted)
+	assert.Contains(vault.secrets, "rode/default/vault")
+
+	deleted, err = store.DeleteSigner(ctx, att)
+	assert.NoError(err)
+	assert.True(deleted)
+	assert.NotContains(vault.secrets, "rode/default/vault")
+
+	deleted, err = store.DeleteSigner(ctx, att)
+	assert.NoError(err)
+	assert.False(deleted)
+
+	// a secret at the path that isn't a key is never overwritten
+	vault.secrets["rode/default/vault"] = map[string]string{"password": "hunter2"}
+	_, err = store.CreateSigner(ctx, att, nil)
+	assert.EqualError(err, "vault secret rode/default/vault already exists without field key")
+
+	// requests without a valid token fail
+	_, err = NewVaultStore(server.URL, "invalid", "secret", server.Client()).ReadSigner(ctx, att, nil)
+	assert.EqualError(err, "unable to read vault secret rode/default/vault: status 403")
+}
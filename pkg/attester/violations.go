@@ -0,0 +1,97 @@
+package attester
+
+import (
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+const (
+	// MaxLastViolations is the number of violation messages recorded for an attester's most recent rejection
+	MaxLastViolations = 10
+
+	// MaxViolationMessageLength is the length that recorded violation messages are truncated to
+	MaxViolationMessageLength = 256
+)
+
+// ViolationTracker records the violations of the most recent evaluation by each attester that rejected a resource
+type ViolationTracker struct {
+	mutex sync.RWMutex
+	last  map[string]*rodev1alpha1.LastViolations
+	now   func() time.Time
+
+	// OnChange is called with the attester name whenever violations are recorded for that attester
+	OnChange func(attester string)
+}
+
+// NewViolationTracker creates a new ViolationTracker
+func NewViolationTracker() *ViolationTracker {
+	return &ViolationTracker{
+		last: make(map[string]*rodev1alpha1.LastViolations),
+		now:  time.Now,
+	}
+}
+
+// Rejected records the violations that the attester rejected the subject for, replacing the attester's previous
+// violations. Only the first MaxLastViolations messages are kept, each truncated to MaxViolationMessageLength.
+func (t *ViolationTracker) Rejected(attester, subject string, violations []*Violation) {
+	last := &rodev1alpha1.LastViolations{
+		ResourceURI: subject,
+		// the status is stored with a precision of seconds, so the time is truncated to compare equal once stored
+		Time:     metav1.NewTime(t.now().Truncate(time.Second)),
+		Messages: make([]string, 0, len(violations)),
+	}
+	for _, v := range violations {
+		if len(last.Messages) == MaxLastViolations {
+			last.Omitted++
+			continue
+		}
+		last.Messages = append(last.Messages, truncateMessage(v.Msg))
+	}
+
+	t.mutex.Lock()
+	t.last[attester] = last
+	t.mutex.Unlock()
+
+	if t.OnChange != nil {
+		t.OnChange(attester)
+	}
+}
+
+// Remove forgets the violations recorded for the attester
+func (t *ViolationTracker) Remove(attester string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.last, attester)
+}
+
+// Last returns the violations of the attester's most recent rejection, or nil if it hasn't rejected a resource
+func (t *ViolationTracker) Last(attester string) *rodev1alpha1.LastViolations {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if last, ok := t.last[attester]; ok {
+		return last.DeepCopy()
+	}
+
+	return nil
+}
+
+func truncateMessage(msg string) string {
+	if len(msg) <= MaxViolationMessageLength {
+		return msg
+	}
+
+	// messages are cut on a rune boundary so that they remain valid UTF-8
+	cut := MaxViolationMessageLength - len("...")
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+
+	return msg[:cut] + "..."
+}
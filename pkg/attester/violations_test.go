@@ -0,0 +1,102 @@
+package attester
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestViolationTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	var changes []string
+	tracker := NewViolationTracker()
+	tracker.OnChange = func(attester string) {
+		changes = append(changes, attester)
+	}
+	now := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	assert.Nil(tracker.Last("default/foo"))
+
+	violations := make([]*Violation, 0, MaxLastViolations+2)
+	for i := 0; i < MaxLastViolations+2; i++ {
+		violations = append(violations, &Violation{Msg: fmt.Sprintf("violation %d", i)})
+	}
+	tracker.Rejected("default/foo", "image-a", violations)
+
+	last := tracker.Last("default/foo")
+	assert.Equal("image-a", last.ResourceURI)
+	assert.Equal(now.Truncate(time.Second), last.Time.Time)
+	assert.Len(last.Messages, MaxLastViolations)
+	assert.Equal("violation 0", last.Messages[0])
+	assert.Equal(fmt.Sprintf("violation %d", MaxLastViolations-1), last.Messages[MaxLastViolations-1])
+	assert.Equal(2, last.Omitted)
+
+	// the returned violations are a copy
+	last.Messages[0] = "changed"
+	assert.Equal("violation 0", tracker.Last("default/foo").Messages[0])
+
+	// a later rejection replaces the violations, and long messages are truncated on a rune boundary
+	long := strings.Repeat("é", MaxViolationMessageLength)
+	tracker.Rejected("default/foo", "image-b", []*Violation{{Msg: long}})
+	last = tracker.Last("default/foo")
+	assert.Equal("image-b", last.ResourceURI)
+	assert.Len(last.Messages, 1)
+	assert.Zero(last.Omitted)
+	assert.True(len(last.Messages[0]) <= MaxViolationMessageLength)
+	assert.True(utf8.ValidString(last.Messages[0]))
+	assert.True(strings.HasSuffix(last.Messages[0], "é..."))
+
+	tracker.Rejected("default/bar", "image-a", violations[:1])
+	tracker.Remove("default/foo")
+	assert.Nil(tracker.Last("default/foo"))
+	assert.Equal([]string{"violation 0"}, tracker.Last("default/bar").Messages)
+
+	assert.Equal([]string{"default/foo", "default/foo", "default/bar"}, changes)
+}
+
+func TestAttestWrapper_RecordsViolations(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := NewPolicy(ctx, "violations", `
+package violations
+
+violation[{"msg": sprintf("occurrence %d failed", [i])}] {
+	input.occurrences[i].discovered.discovered.analysisStatus != "FINISHED_SUCCESS"
+}
+`, false)
+	assert.NoError(err)
+	signer, err := NewSigner("violations")
+	assert.NoError(err)
+
+	client := &fakeOccurrenceClient{}
+	for i := 0; i < MaxLastViolations+1; i++ {
+		client.occurrences = append(client.occurrences, &grafeas.Occurrence{
+			Resource: &grafeas.Resource{Uri: "image"},
+			Details: &grafeas.Occurrence_Discovered{Discovered: &discovery.Details{
+				Discovered: &discovery.Discovered{AnalysisStatus: discovery.Discovered_FINISHED_FAILED},
+			}},
+		})
+	}
+
+	tracker := NewViolationTracker()
+	wrapper := NewAttestWrapper(logf.NullLogger{}, client, client, fakeAttesterLister{
+		"default/violations": NewAttester("default/violations", policy, signer),
+	}, nil, tracker, nil, nil, nil).(*attestWrapper)
+
+	assert.NoError(wrapper.attestResource(ctx, "image", "DISCOVERY", false))
+
+	last := tracker.Last("default/violations")
+	assert.Equal("image", last.ResourceURI)
+	assert.Len(last.Messages, MaxLastViolations)
+	assert.Contains(last.Messages, "occurrence 0 failed")
+	assert.Equal(1, last.Omitted)
+}
@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+// flushInterval is how often buffered records are written to the file
+const flushInterval = 5 * time.Second
+
+// FileLog writes attestation decisions to a file as JSON lines. Writes are buffered, and the file is rotated once it
+// reaches its maximum size, keeping a limited number of the rotated files.
+type FileLog struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex  sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewFileLog opens the audit log at path, appending to it if it already exists. Rotated files are named path.1 through
+// path.<maxBackups>, with path.1 the most recent.
+func NewFileLog(path string, maxSize int64, maxBackups int) (*FileLog, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("audit log max size must be greater than 0")
+	}
+
+	l := &FileLog{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	go l.flushPeriodically()
+
+	return l, nil
+}
+
+// LogDecision appends the decision to the audit log
+func (l *FileLog) LogDecision(decision *attester.Decision) error {
+	return l.write(decision)
+}
+
+// Flush writes any buffered records to the file
+func (l *FileLog) Flush() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.writer.Flush()
+}
+
+// Close flushes any buffered records and closes the file
+func (l *FileLog) Close() error {
+	close(l.stop)
+	<-l.done
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.writer.Flush(); err != nil {
+		_ = l.file.Close()
+		return err
+	}
+
+	return l.file.Close()
+}
+
+func (l *FileLog) write(record interface{}) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.writer.Write(line)
+	l.size += int64(n)
+
+	return err
+}
+
+func (l *FileLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	l.file = file
+	l.writer = bufio.NewWriter(file)
+	l.size = info.Size()
+
+	return nil
+}
+
+// rotate closes the current file, shifts the rotated files along by one, removing the oldest, and opens a new file
+func (l *FileLog) rotate() error {
+	if err := l.writer.Flush(); err != nil {
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	if l.maxBackups > 0 {
+		_ = os.Remove(l.backupPath(l.maxBackups))
+		for i := l.maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(l.backupPath(i), l.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(l.path, l.backupPath(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return err
+	}
+
+	return l.open()
+}
+
+func (l *FileLog) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
+
+func (l *FileLog) flushPeriodically() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = l.Flush()
+		case <-l.stop:
+			return
+		}
+	}
+}
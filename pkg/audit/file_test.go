@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func TestFileLog_Rotate(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	l, err := NewFileLog(path, 200, 2)
+	assert.NoError(err)
+
+	for i := 0; i < 20; i++ {
+		err = l.LogDecision(&attester.Decision{
+			Attester:    "default/attester",
+			ResourceURI: fmt.Sprintf("image-%d", i),
+			Attested:    true,
+		})
+		assert.NoError(err)
+	}
+	assert.NoError(l.Close())
+
+	files, err := filepath.Glob(path + "*")
+	assert.NoError(err)
+	assert.ElementsMatch([]string{path, path + ".1", path + ".2"}, files)
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		assert.NoError(err)
+		assert.True(info.Size() <= 200, "%s is larger than the max size", file)
+	}
+
+	// the most recent decision is in the current file
+	decisions := readDecisions(t, path)
+	assert.NotEmpty(decisions)
+	assert.Equal("image-19", decisions[len(decisions)-1].ResourceURI)
+}
+
+func TestFileLog_Append(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	for i := 0; i < 2; i++ {
+		l, err := NewFileLog(path, 1024*1024, 1)
+		assert.NoError(err)
+		assert.NoError(l.LogDecision(&attester.Decision{ResourceURI: fmt.Sprintf("image-%d", i), Violations: []string{"failed"}}))
+		assert.NoError(l.Close())
+	}
+
+	decisions := readDecisions(t, path)
+	assert.Len(decisions, 2)
+	assert.Equal("image-0", decisions[0].ResourceURI)
+	assert.Equal([]string{"failed"}, decisions[1].Violations)
+}
+
+func readDecisions(t *testing.T, path string) []*attester.Decision {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	decisions := make([]*attester.Decision, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		decision := &attester.Decision{}
+		if err := json.Unmarshal(scanner.Bytes(), decision); err != nil {
+			t.Fatal(err)
+		}
+		decisions = append(decisions, decision)
+	}
+
+	return decisions
+}
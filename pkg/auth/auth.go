@@ -0,0 +1,109 @@
+// Package auth authenticates and authorizes requests to rode's HTTP API with the Kubernetes API server, so that access
+// to the API is granted with RBAC like access to the underlying resources.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// ErrUnauthenticated is returned when a request doesn't carry a valid bearer token
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ForbiddenError is returned when the user isn't allowed to access a resource
+type ForbiddenError struct {
+	User   string
+	Reason string
+}
+
+func (e ForbiddenError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("user %s is forbidden", e.User)
+	}
+
+	return fmt.Sprintf("user %s is forbidden: %s", e.User, e.Reason)
+}
+
+// Authorizer authorizes requests to access resources
+type Authorizer interface {
+	// Authenticate returns the user that the request's bearer token belongs to
+	Authenticate(req *http.Request) (*authenticationv1.UserInfo, error)
+
+	// Authorize checks that the user is allowed to access the resource
+	Authorize(ctx context.Context, user *authenticationv1.UserInfo, resource *authorizationv1.ResourceAttributes) error
+}
+
+type kubernetesAuthorizer struct {
+	client client.Client
+}
+
+// NewKubernetesAuthorizer creates an Authorizer that authenticates bearer tokens with a TokenReview and authorizes users
+// with a SubjectAccessReview
+func NewKubernetesAuthorizer(c client.Client) Authorizer {
+	return &kubernetesAuthorizer{
+		c,
+	}
+}
+
+func (a *kubernetesAuthorizer) Authenticate(req *http.Request) (*authenticationv1.UserInfo, error) {
+	header := req.Header.Get("Authorization")
+	if !strings.HasPrefix(strings.ToLower(header), "bearer ") {
+		return nil, ErrUnauthenticated
+	}
+
+	token := strings.TrimSpace(header[len("bearer "):])
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: token,
+		},
+	}
+	if err := a.client.Create(req.Context(), review); err != nil {
+		return nil, err
+	}
+
+	if !review.Status.Authenticated {
+		return nil, ErrUnauthenticated
+	}
+
+	return &review.Status.User, nil
+}
+
+func (a *kubernetesAuthorizer) Authorize(ctx context.Context, user *authenticationv1.UserInfo, resource *authorizationv1.ResourceAttributes) error {
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               user.Username,
+			UID:                user.UID,
+			Groups:             user.Groups,
+			Extra:              extra,
+			ResourceAttributes: resource,
+		},
+	}
+	if err := a.client.Create(ctx, review); err != nil {
+		return err
+	}
+
+	if !review.Status.Allowed {
+		return ForbiddenError{User: user.Username, Reason: review.Status.Reason}
+	}
+
+	return nil
+}
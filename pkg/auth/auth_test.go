@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// reviewClient answers reviews like the API server would for a single valid token and a single allowed user
+type reviewClient struct {
+	client.Client
+	reviews []*authorizationv1.SubjectAccessReview
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		if review.Spec.Token == "valid-token" {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{
+				Username: "system:serviceaccount:ci:pipeline",
+				Groups:   []string{"system:serviceaccounts"},
+				Extra:    map[string]authenticationv1.ExtraValue{"scope": {"read"}},
+			}
+		}
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		c.reviews = append(c.reviews, review)
+		review.Status.Allowed = review.Spec.User == "system:serviceaccount:ci:pipeline" && review.Spec.ResourceAttributes.Namespace == "default"
+		if !review.Status.Allowed {
+			review.Status.Reason = "no RBAC policy matched"
+		}
+		return nil
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func newReviewClient() *reviewClient {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+
+	return &reviewClient{Client: fake.NewFakeClientWithScheme(s)}
+}
+
+func TestKubernetesAuthorizer_Authenticate(t *testing.T) {
+	assert := assert.New(t)
+	a := NewKubernetesAuthorizer(newReviewClient())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	_, err := a.Authenticate(req)
+	assert.Equal(ErrUnauthenticated, err)
+
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	_, err = a.Authenticate(req)
+	assert.Equal(ErrUnauthenticated, err)
+
+	req.Header.Set("Authorization", "Bearer invalid-token")
+	_, err = a.Authenticate(req)
+	assert.Equal(ErrUnauthenticated, err)
+
+	req.Header.Set("Authorization", "Bearer valid-token")
+	user, err := a.Authenticate(req)
+	assert.NoError(err)
+	assert.Equal("system:serviceaccount:ci:pipeline", user.Username)
+}
+
+func TestKubernetesAuthorizer_Authorize(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	c := newReviewClient()
+	a := NewKubernetesAuthorizer(c)
+
+	user := &authenticationv1.UserInfo{
+		Username: "system:serviceaccount:ci:pipeline",
+		Groups:   []string{"system:serviceaccounts"},
+		Extra:    map[string]authenticationv1.ExtraValue{"scope": {"read"}},
+	}
+	resource := &authorizationv1.ResourceAttributes{Namespace: "default", Verb: "get", Group: "rode.liatr.io", Resource: "attesters", Name: "image-scan"}
+
+	assert.NoError(a.Authorize(ctx, user, resource))
+	assert.Equal(user.Groups, c.reviews[0].Spec.Groups)
+	assert.Equal(authorizationv1.ExtraValue{"read"}, c.reviews[0].Spec.Extra["scope"])
+
+	resource.Namespace = "prod"
+	err := a.Authorize(ctx, user, resource)
+	assert.IsType(ForbiddenError{}, err)
+	assert.Contains(err.Error(), "no RBAC policy matched")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/go-logr/logr"
 
 	"github.com/liatrio/rode/pkg/occurrence"
+	"github.com/liatrio/rode/pkg/registry"
 
 	"github.com/liatrio/rode/pkg/attester"
 
@@ -33,74 +34,50 @@ type enforcer struct {
 	attesterLister   attester.Lister
 	occurrenceLister occurrence.Lister
 	client           client.Client
+	digestResolver   registry.Resolver
 	decoder          *admission.Decoder
 }
 
-// NewEnforcer creates an enforcer
-func NewEnforcer(log logr.Logger, attesterLister attester.Lister, occurrenceLister occurrence.Lister, c client.Client) Enforcer {
+// NewEnforcer creates an enforcer. When digestResolver is set, the image of each container is resolved to its current
+// digest, and only attestations for that digest are accepted.
+func NewEnforcer(log logr.Logger, attesterLister attester.Lister, occurrenceLister occurrence.Lister, c client.Client, digestResolver registry.Resolver) Enforcer {
 	return &enforcer{
 		log,
 		attesterLister,
 		occurrenceLister,
 		c,
+		digestResolver,
 		nil,
 	}
 }
 
-func (e *enforcer) AddEnforcerAttesters(ctx context.Context, enforcerAttesters map[string]attester.Attester, namespace string) error {
-	// get all attesters
-	attesters := e.attesterLister.ListAttesters()
+// listGates returns a gate for each Enforcer in the namespace and each ClusterEnforcer that enforces the namespace
+func (e *enforcer) listGates(ctx context.Context, namespace string) ([]*gate, error) {
+	gates := make([]*gate, 0)
 
-	// get enforcers
 	enforcers := &rodev1alpha1.EnforcerList{}
 	err := e.client.List(ctx, enforcers, client.InNamespace(namespace))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, enforcer := range enforcers.Items {
-		for _, enforcerAttester := range enforcer.Spec.Attesters {
-			a, attesterExists := attesters[enforcerAttester.String()]
-			if !attesterExists {
-				return fmt.Errorf("enforcer %s/%s requires attester %s which does not exist", enforcer.Namespace, enforcer.Name, enforcerAttester.String())
-			}
-
-			_, enforcerAttesterExists := enforcerAttesters[enforcerAttester.String()]
-			if !enforcerAttesterExists {
-				enforcerAttesters[enforcerAttester.String()] = a
-			}
-		}
+		gates = append(gates, newGate(fmt.Sprintf("enforcer %s/%s", enforcer.Namespace, enforcer.Name), enforcer.Spec.Attesters, enforcer.Spec.RequiredWeight))
 	}
 
-	return nil
-}
-
-func (e *enforcer) AddClusterEnforcerAttesters(ctx context.Context, enforcerAttesters map[string]attester.Attester, namespace string) error {
-	// get all attesters
-	attesters := e.attesterLister.ListAttesters()
-
 	clusterEnforcers := &rodev1alpha1.ClusterEnforcerList{}
-	err := e.client.List(ctx, clusterEnforcers)
+	err = e.client.List(ctx, clusterEnforcers)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, clusterEnforcer := range clusterEnforcers.Items {
 		if clusterEnforcer.EnforcesNamespace(namespace) {
-			for _, clusterEnforcerAttester := range clusterEnforcer.Spec.Attesters {
-				a, attesterExists := attesters[clusterEnforcerAttester.String()]
-				if !attesterExists {
-					return fmt.Errorf("cluster enforcer %s/%s requires attester %s which does not exist", clusterEnforcer.Namespace, clusterEnforcer.Name, clusterEnforcerAttester.String())
-				}
-
-				_, enforcerAttesterExists := enforcerAttesters[clusterEnforcerAttester.String()]
-				if !enforcerAttesterExists {
-					enforcerAttesters[clusterEnforcerAttester.String()] = a
-				}
-			}
+			gates = append(gates, newGate(fmt.Sprintf("cluster enforcer %s/%s", clusterEnforcer.Namespace, clusterEnforcer.Name), clusterEnforcer.Spec.Attesters, clusterEnforcer.Spec.RequiredWeight))
 		}
 	}
-	return nil
+
+	return gates, nil
 }
 
 func (e *enforcer) Handle(ctx context.Context, req admission.Request) admission.Response {
@@ -112,18 +89,17 @@ func (e *enforcer) Handle(ctx context.Context, req admission.Request) admission.
 
 	e.log.Info("handling enforcement request", "pod", fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
 
-	enforcerAttesters := make(map[string]attester.Attester)
-
-	err = e.AddEnforcerAttesters(ctx, enforcerAttesters, pod.Namespace)
+	gates, err := e.listGates(ctx, pod.Namespace)
 	if err != nil {
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	err = e.AddClusterEnforcerAttesters(ctx, enforcerAttesters, pod.Namespace)
-	if err != nil {
-		return admission.Errored(http.StatusInternalServerError, err)
+	if len(gates) == 0 {
+		return admission.Allowed("")
 	}
 
+	attesters := e.attesterLister.ListAttesters()
+
 	for _, container := range pod.Spec.Containers {
 		occurrenceList, err := e.occurrenceLister.ListOccurrences(ctx, container.Image) // probably have to convert to sha256 here
 		if err != nil {
@@ -132,17 +108,60 @@ func (e *enforcer) Handle(ctx context.Context, req admission.Request) admission.
 
 		e.log.Info("ListOccurrances", "occurrences", occurrenceList.Occurrences)
 
-		for _, enforcerAttester := range enforcerAttesters {
-			attested := false
-			for _, occ := range occurrenceList.GetOccurrences() {
-				if err = enforcerAttester.Verify(ctx, &attester.VerifyRequest{Occurrence: occ}); err == nil {
-					attested = true
-					break
+		// resolve the image to the digest it currently refers to, so that a tag that has moved to a different image
+		// doesn't match the attestations for the previous image
+		digest := ""
+		if e.digestResolver != nil {
+			digest, err = e.digestResolver.ResolveDigest(ctx, container.Image)
+			if err != nil {
+				return admission.Errored(http.StatusInternalServerError, fmt.Errorf("unable to resolve digest for %s: %v", container.Image, err))
+			}
+		}
+
+		// cache the verification result for each attester and stage, since an attester may be part of multiple gates
+		verified := make(map[string]bool)
+		attested := func(ea *rodev1alpha1.EnforcerAttester) bool {
+			key := ea.Description()
+			if result, ok := verified[key]; ok {
+				return result
+			}
+
+			verified[key] = false
+			if a, ok := attesters[ea.String()]; ok {
+				for _, occ := range occurrenceList.GetOccurrences() {
+					if digest != "" && registry.Digest(occ.GetResource().GetUri()) != digest {
+						continue
+					}
+					if err := a.Verify(ctx, &attester.VerifyRequest{Occurrence: occ, Stage: ea.Stage, Occurrences: occurrenceList.GetOccurrences()}); err == nil {
+						verified[key] = true
+						break
+					}
 				}
 			}
 
-			if !attested {
-				return admission.Denied(fmt.Sprintf("unable to find attestation for %s", enforcerAttester.String()))
+			return verified[key]
+		}
+
+		loaded := func(ea *rodev1alpha1.EnforcerAttester) bool {
+			_, ok := attesters[ea.String()]
+			return ok
+		}
+
+		for _, g := range gates {
+			result := g.evaluate(attested).markNotFound(loaded)
+			e.log.Info("evaluated gate", "gate", g.name, "image", container.Image, "passed", result.passed, "failed", result.failed)
+			for _, name := range result.notFound {
+				e.log.Info("gate requires an attester that isn't loaded, counting it as failed", "gate", g.name, "attester", name)
+			}
+
+			if !result.allowed() {
+				e.log.Info("denying pod", "gate", g.name, "image", container.Image, "result", result.String())
+
+				rejection := newRejection(ctx, container.Image, result, attesters, occurrenceList.GetOccurrences())
+				response := admission.Denied(rejection.String())
+				response.Result.Message = rejection.String()
+				response.Result.Details = rejection.details()
+				return response
 			}
 		}
 	}
@@ -0,0 +1,84 @@
+package enforcer
+
+import (
+	"fmt"
+	"strings"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+// gate is a set of weighted attesters from an Enforcer or ClusterEnforcer. An image passes the gate when the combined
+// weight of the attesters that attested it meets the required weight.
+type gate struct {
+	name           string
+	attesters      []*rodev1alpha1.EnforcerAttester
+	requiredWeight int
+}
+
+// gateResult describes which attesters passed or failed a gate
+type gateResult struct {
+	gate            *gate
+	weight          int
+	passed          []string
+	failed          []string
+	failedAttesters []*rodev1alpha1.EnforcerAttester
+	// notFound are the failed attesters that aren't loaded, rather than having not attested the image
+	notFound []string
+}
+
+func newGate(name string, attesters []*rodev1alpha1.EnforcerAttester, requiredWeight int) *gate {
+	if requiredWeight <= 0 {
+		for _, a := range attesters {
+			requiredWeight += a.GetWeight()
+		}
+	}
+
+	return &gate{
+		name,
+		attesters,
+		requiredWeight,
+	}
+}
+
+// evaluate checks each attester in the gate with the attested func, which returns true if the attester has attested
+// the image for the attester's stage
+func (g *gate) evaluate(attested func(a *rodev1alpha1.EnforcerAttester) bool) *gateResult {
+	result := &gateResult{gate: g}
+
+	for _, a := range g.attesters {
+		if attested(a) {
+			result.weight += a.GetWeight()
+			result.passed = append(result.passed, a.Description())
+		} else {
+			result.failed = append(result.failed, a.Description())
+			result.failedAttesters = append(result.failedAttesters, a)
+		}
+	}
+
+	return result
+}
+
+// markNotFound records which of the failed attesters aren't loaded, with the found func returning true for the attesters
+// that are
+func (r *gateResult) markNotFound(found func(a *rodev1alpha1.EnforcerAttester) bool) *gateResult {
+	for _, a := range r.failedAttesters {
+		if !found(a) {
+			r.notFound = append(r.notFound, a.Description())
+		}
+	}
+
+	return r
+}
+
+func (r *gateResult) allowed() bool {
+	return r.weight >= r.gate.requiredWeight
+}
+
+func (r *gateResult) String() string {
+	notFound := ""
+	if len(r.notFound) > 0 {
+		notFound = fmt.Sprintf(", not found: [%s]", strings.Join(r.notFound, ", "))
+	}
+
+	return fmt.Sprintf("%s requires attestation weight %d but got %d (passed: [%s], failed: [%s]%s)", r.gate.name, r.gate.requiredWeight, r.weight, strings.Join(r.passed, ", "), strings.Join(r.failed, ", "), notFound)
+}
@@ -0,0 +1,76 @@
+package enforcer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+)
+
+func TestGate_Evaluate(t *testing.T) {
+	assert := assert.New(t)
+
+	attesters := []*rodev1alpha1.EnforcerAttester{
+		{Namespace: "default", Name: "build"},
+		{Namespace: "default", Name: "test"},
+		{Namespace: "default", Name: "scan", Weight: 2},
+	}
+	attestedBy := func(names ...string) func(*rodev1alpha1.EnforcerAttester) bool {
+		return func(a *rodev1alpha1.EnforcerAttester) bool {
+			for _, n := range names {
+				if n == a.Description() {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	all := newGate("enforcer default/all", attesters, 0)
+	assert.Equal(4, all.requiredWeight)
+	assert.True(all.evaluate(attestedBy("default/build", "default/test", "default/scan")).allowed())
+	assert.False(all.evaluate(attestedBy("default/build", "default/test")).allowed())
+
+	weighted := newGate("enforcer default/weighted", attesters, 3)
+	assert.True(weighted.evaluate(attestedBy("default/build", "default/scan")).allowed())
+	assert.False(weighted.evaluate(attestedBy("default/build", "default/test")).allowed())
+
+	result := weighted.evaluate(attestedBy("default/scan"))
+	assert.False(result.allowed())
+	assert.Equal([]string{"default/scan"}, result.passed)
+	assert.Equal([]string{"default/build", "default/test"}, result.failed)
+	assert.Contains(result.String(), "requires attestation weight 3 but got 2")
+
+	staged := newGate("enforcer default/prod", []*rodev1alpha1.EnforcerAttester{
+		{Namespace: "default", Name: "build", Stage: "prod"},
+	}, 0)
+	assert.False(staged.evaluate(attestedBy("default/build")).allowed())
+
+	result = staged.evaluate(attestedBy("default/build (stage prod)"))
+	assert.True(result.allowed())
+	assert.Equal([]string{"default/build (stage prod)"}, result.passed)
+}
+
+func TestGateResult_MarkNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	g := newGate("enforcer default/missing", []*rodev1alpha1.EnforcerAttester{
+		{Namespace: "default", Name: "build"},
+		{Namespace: "default", Name: "deleted"},
+	}, 0)
+	loaded := func(a *rodev1alpha1.EnforcerAttester) bool {
+		return a.Name != "deleted"
+	}
+
+	// attesters that aren't loaded fail the gate, and are called out as not found
+	result := g.evaluate(func(*rodev1alpha1.EnforcerAttester) bool { return false }).markNotFound(loaded)
+	assert.False(result.allowed())
+	assert.Equal([]string{"default/build", "default/deleted"}, result.failed)
+	assert.Equal([]string{"default/deleted"}, result.notFound)
+	assert.Contains(result.String(), "failed: [default/build, default/deleted], not found: [default/deleted])")
+
+	result = g.evaluate(func(*rodev1alpha1.EnforcerAttester) bool { return false }).markNotFound(func(*rodev1alpha1.EnforcerAttester) bool { return true })
+	assert.Empty(result.notFound)
+	assert.NotContains(result.String(), "not found")
+}
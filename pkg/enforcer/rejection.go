@@ -0,0 +1,93 @@
+package enforcer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+// maxRejectionReasons is the number of violations listed for each attester that failed
+const maxRejectionReasons = 3
+
+// rejection explains to the user why an image was denied by a gate
+type rejection struct {
+	image  string
+	gate   string
+	causes []metav1.StatusCause
+}
+
+// newRejection lists the attesters that failed the gate along with their top violations for the image's occurrences
+func newRejection(ctx context.Context, image string, result *gateResult, attesters map[string]attester.Attester, occurrences []*grafeas.Occurrence) *rejection {
+	r := &rejection{
+		image: image,
+		gate:  result.gate.name,
+	}
+
+	for _, ea := range result.failedAttesters {
+		r.causes = append(r.causes, metav1.StatusCause{
+			Type:    metav1.CauseType("AttestationMissing"),
+			Field:   ea.Description(),
+			Message: rejectionReasons(ctx, image, attesters[ea.String()], occurrences),
+		})
+	}
+
+	return r
+}
+
+// rejectionReasons returns the top violations of the attester's policy. When the policy passes, the image hasn't been
+// attested yet or was attested for a different stage.
+func rejectionReasons(ctx context.Context, image string, a attester.Attester, occurrences []*grafeas.Occurrence) string {
+	if a == nil {
+		return "attester not found"
+	}
+
+	violations, err := a.Evaluate(ctx, &attester.AttestRequest{
+		ResourceURI: image,
+		Occurrences: occurrences,
+	})
+	if err != nil || len(violations) == 0 {
+		return "no valid attestation"
+	}
+
+	reasons := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, v := range violations {
+		if v.Msg == "" || seen[v.Msg] {
+			continue
+		}
+		seen[v.Msg] = true
+		reasons = append(reasons, v.Msg)
+	}
+
+	if len(reasons) == 0 {
+		return "policy violated"
+	}
+
+	if len(reasons) > maxRejectionReasons {
+		return fmt.Sprintf("%s (and %d more)", strings.Join(reasons[:maxRejectionReasons], ", "), len(reasons)-maxRejectionReasons)
+	}
+
+	return strings.Join(reasons, ", ")
+}
+
+func (r *rejection) String() string {
+	attesters := make([]string, 0)
+	for _, cause := range r.causes {
+		attesters = append(attesters, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+	}
+
+	return fmt.Sprintf("image %s is not attested as required by %s: %s", r.image, r.gate, strings.Join(attesters, "; "))
+}
+
+// details returns the failed attesters as the details of the admission response status
+func (r *rejection) details() *metav1.StatusDetails {
+	return &metav1.StatusDetails{
+		Name:   r.image,
+		Causes: r.causes,
+	}
+}
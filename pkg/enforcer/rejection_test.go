@@ -0,0 +1,59 @@
+package enforcer
+
+import (
+	"context"
+	"testing"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	vulnerability "github.com/grafeas/grafeas/proto/v1beta1/vulnerability_go_proto"
+	"github.com/stretchr/testify/assert"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+func TestNewRejection(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	policy, err := attester.NewPolicy(ctx, "scan", `
+package scan
+violation[{"msg":"critical vulnerability found"}]{
+	input.occurrences[_].vulnerability.severity == "CRITICAL"
+}
+violation[{"msg":"high vulnerability found"}]{
+	input.occurrences[_].vulnerability.severity == "HIGH"
+}
+`, false)
+	assert.NoError(err)
+	signer, err := attester.NewSigner("scan")
+	assert.NoError(err)
+
+	attesters := map[string]attester.Attester{
+		"default/scan": attester.NewAttester("default/scan", policy, signer),
+	}
+	occurrences := []*grafeas.Occurrence{
+		{
+			Resource: &grafeas.Resource{Uri: "app"},
+			Details: &grafeas.Occurrence_Vulnerability{
+				Vulnerability: &vulnerability.Details{Severity: vulnerability.Severity_CRITICAL},
+			},
+		},
+	}
+
+	g := newGate("enforcer default/prod", []*rodev1alpha1.EnforcerAttester{
+		{Namespace: "default", Name: "scan"},
+		{Namespace: "default", Name: "build"},
+	}, 0)
+	result := g.evaluate(func(*rodev1alpha1.EnforcerAttester) bool {
+		return false
+	})
+
+	rejection := newRejection(ctx, "app", result, attesters, occurrences)
+	assert.Equal("image app is not attested as required by enforcer default/prod: default/scan: critical vulnerability found; default/build: attester not found", rejection.String())
+	assert.Len(rejection.details().Causes, 2)
+	assert.Equal("default/scan", rejection.details().Causes[0].Field)
+
+	rejection = newRejection(ctx, "app", result, attesters, nil)
+	assert.Contains(rejection.String(), "default/scan: no valid attestation")
+}
@@ -0,0 +1,229 @@
+package intoto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	layoutType = "layout"
+	linkType   = "link"
+
+	linkSuffix = ".link"
+)
+
+// Metablock is a signed piece of in-toto metadata, either a layout or a link
+type Metablock struct {
+	Signed     json.RawMessage `json:"signed"`
+	Signatures []Signature     `json:"signatures"`
+}
+
+// Signature is a signature of the canonical JSON encoding of a metablock's signed metadata
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Key is a public key in the in-toto key format. Ed25519 keys are hex encoded, and RSA keys are PEM encoded.
+type Key struct {
+	KeyID               string   `json:"keyid"`
+	KeyType             string   `json:"keytype"`
+	Scheme              string   `json:"scheme"`
+	KeyIDHashAlgorithms []string `json:"keyid_hash_algorithms,omitempty"`
+	KeyVal              KeyVal   `json:"keyval"`
+}
+
+// KeyVal holds the encoded value of a key
+type KeyVal struct {
+	Public  string `json:"public"`
+	Private string `json:"private,omitempty"`
+}
+
+// Layout defines the steps of a supply chain, the functionaries that are trusted to carry them out, and the rules
+// that the artifacts of each step must follow
+type Layout struct {
+	Type    string         `json:"_type"`
+	Expires string         `json:"expires"`
+	Readme  string         `json:"readme,omitempty"`
+	Keys    map[string]Key `json:"keys"`
+	Steps   []Step         `json:"steps"`
+	Inspect []Inspection   `json:"inspect"`
+}
+
+// Step is a step of the supply chain that is carried out by a functionary, who records it in a link
+type Step struct {
+	Name              string     `json:"name"`
+	ExpectedMaterials [][]string `json:"expected_materials"`
+	ExpectedProducts  [][]string `json:"expected_products"`
+	PubKeys           []string   `json:"pubkeys"`
+	ExpectedCommand   []string   `json:"expected_command,omitempty"`
+	Threshold         int        `json:"threshold"`
+}
+
+// Inspection is a command that is run on the client during verification
+type Inspection struct {
+	Name              string     `json:"name"`
+	ExpectedMaterials [][]string `json:"expected_materials"`
+	ExpectedProducts  [][]string `json:"expected_products"`
+	Run               []string   `json:"run"`
+}
+
+// Link records the materials and products of a step as it was carried out
+type Link struct {
+	Type        string                 `json:"_type"`
+	Name        string                 `json:"name"`
+	Materials   map[string]HashObj     `json:"materials"`
+	Products    map[string]HashObj     `json:"products"`
+	Byproducts  map[string]interface{} `json:"byproducts,omitempty"`
+	Command     []string               `json:"command,omitempty"`
+	Environment map[string]interface{} `json:"environment,omitempty"`
+}
+
+// HashObj maps hash algorithms to the hex encoded digest of an artifact
+type HashObj map[string]string
+
+// LoadMetablock reads signed layout or link metadata from a file
+func LoadMetablock(path string) (*Metablock, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Metablock{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid metadata %s: %v", path, err)
+	}
+
+	return m, nil
+}
+
+// LoadLinks reads the signed links in the directory, which are the files named with a .link suffix
+func LoadLinks(dir string) ([]*Metablock, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+linkSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	links := make([]*Metablock, 0, len(paths))
+	for _, path := range paths {
+		link, err := LoadMetablock(path)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// LoadKey reads a public key in the in-toto key format from a file
+func LoadKey(path string) (Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key := Key{}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return Key{}, fmt.Errorf("invalid key %s: %v", path, err)
+	}
+	if key.KeyID == "" {
+		return Key{}, fmt.Errorf("invalid key %s: missing keyid", path)
+	}
+
+	return key, nil
+}
+
+// Layout decodes the metablock's layout
+func (m *Metablock) Layout() (*Layout, error) {
+	layout := &Layout{}
+	if err := json.Unmarshal(m.Signed, layout); err != nil {
+		return nil, err
+	}
+	if layout.Type != layoutType {
+		return nil, fmt.Errorf("metadata is a %q, not a layout", layout.Type)
+	}
+
+	return layout, nil
+}
+
+// Link decodes the metablock's link
+func (m *Metablock) Link() (*Link, error) {
+	link := &Link{}
+	if err := json.Unmarshal(m.Signed, link); err != nil {
+		return nil, err
+	}
+	if link.Type != linkType {
+		return nil, fmt.Errorf("metadata is a %q, not a link", link.Type)
+	}
+
+	return link, nil
+}
+
+// VerifySignature checks that the metablock was signed by the key
+func (m *Metablock) VerifySignature(key Key) error {
+	message, err := canonicalJSON(m.Signed)
+	if err != nil {
+		return err
+	}
+
+	for _, signature := range m.Signatures {
+		if signature.KeyID != key.KeyID {
+			continue
+		}
+
+		sig, err := hex.DecodeString(signature.Sig)
+		if err != nil {
+			return fmt.Errorf("invalid signature by key %s: %v", key.KeyID, err)
+		}
+
+		return verifySignature(key, message, sig)
+	}
+
+	return fmt.Errorf("no signature by key %s", key.KeyID)
+}
+
+func verifySignature(key Key, message, sig []byte) error {
+	switch key.Scheme {
+	case "ed25519":
+		public, err := hex.DecodeString(key.KeyVal.Public)
+		if err != nil || len(public) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid ed25519 key %s", key.KeyID)
+		}
+		if !ed25519.Verify(ed25519.PublicKey(public), message, sig) {
+			return fmt.Errorf("invalid signature by key %s", key.KeyID)
+		}
+	case "rsassa-pss-sha256":
+		block, _ := pem.Decode([]byte(key.KeyVal.Public))
+		if block == nil {
+			return fmt.Errorf("invalid rsa key %s", key.KeyID)
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("invalid rsa key %s: %v", key.KeyID, err)
+		}
+		public, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key %s is not an rsa key", key.KeyID)
+		}
+		hash := sha256.Sum256(message)
+		if err := rsa.VerifyPSS(public, crypto.SHA256, hash[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}); err != nil {
+			return fmt.Errorf("invalid signature by key %s", key.KeyID)
+		}
+	default:
+		return fmt.Errorf("unsupported signature scheme %q of key %s", key.Scheme, key.KeyID)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package intoto
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// verifyArtifactRules applies the rules in order to the artifacts, which are the materials or products of the link.
+// Each rule consumes the artifacts it matches, so later rules only apply to the artifacts that are left. Artifacts
+// that no rule consumes are allowed.
+func verifyArtifactRules(rules [][]string, artifacts map[string]HashObj, link *Link, links map[string]*Link) error {
+	queue := make(map[string]bool)
+	for name := range artifacts {
+		queue[name] = true
+	}
+
+	for _, rule := range rules {
+		if len(rule) == 0 {
+			return fmt.Errorf("empty artifact rule")
+		}
+
+		switch strings.ToUpper(rule[0]) {
+		case "MATCH":
+			match, err := parseMatchRule(rule)
+			if err != nil {
+				return err
+			}
+
+			destination, ok := links[match.step]
+			if !ok {
+				return fmt.Errorf("rule %v refers to unknown step %s", rule, match.step)
+			}
+			destinationArtifacts := destination.Materials
+			if match.products {
+				destinationArtifacts = destination.Products
+			}
+
+			consume(queue, func(name string) bool {
+				relative, ok := trimPrefix(name, match.sourcePrefix)
+				if !ok || !matchPattern(match.pattern, relative) {
+					return false
+				}
+
+				hashes, ok := destinationArtifacts[joinPrefix(match.destinationPrefix, relative)]
+				return ok && reflect.DeepEqual(hashes, artifacts[name])
+			})
+		case "CREATE", "DELETE", "MODIFY", "ALLOW", "DISALLOW", "REQUIRE":
+			if len(rule) != 2 {
+				return fmt.Errorf("invalid artifact rule %v", rule)
+			}
+			pattern := rule[1]
+
+			switch strings.ToUpper(rule[0]) {
+			case "CREATE":
+				consume(queue, func(name string) bool {
+					_, material := link.Materials[name]
+					return matchPattern(pattern, name) && !material
+				})
+			case "DELETE":
+				consume(queue, func(name string) bool {
+					_, product := link.Products[name]
+					return matchPattern(pattern, name) && !product
+				})
+			case "MODIFY":
+				consume(queue, func(name string) bool {
+					material, isMaterial := link.Materials[name]
+					product, isProduct := link.Products[name]
+					return matchPattern(pattern, name) && isMaterial && isProduct && !reflect.DeepEqual(material, product)
+				})
+			case "ALLOW":
+				consume(queue, func(name string) bool {
+					return matchPattern(pattern, name)
+				})
+			case "DISALLOW":
+				for _, name := range sortedNames(queue) {
+					if matchPattern(pattern, name) {
+						return fmt.Errorf("artifact %s is disallowed by rule %v", name, rule)
+					}
+				}
+			case "REQUIRE":
+				if _, ok := artifacts[pattern]; !ok {
+					return fmt.Errorf("artifact %s is required by rule %v", pattern, rule)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown artifact rule %v", rule)
+		}
+	}
+
+	return nil
+}
+
+type matchRule struct {
+	pattern           string
+	sourcePrefix      string
+	products          bool
+	destinationPrefix string
+	step              string
+}
+
+// parseMatchRule parses a rule of the form MATCH <pattern> [IN <prefix>] WITH (MATERIALS|PRODUCTS) [IN <prefix>] FROM <step>
+func parseMatchRule(rule []string) (*matchRule, error) {
+	invalid := fmt.Errorf("invalid artifact rule %v", rule)
+	tokens := rule[1:]
+	match := &matchRule{}
+
+	next := func(keyword string) (string, bool) {
+		if len(tokens) >= 2 && strings.ToUpper(tokens[0]) == keyword {
+			value := tokens[1]
+			tokens = tokens[2:]
+			return value, true
+		}
+		return "", false
+	}
+
+	if len(tokens) == 0 {
+		return nil, invalid
+	}
+	match.pattern = tokens[0]
+	tokens = tokens[1:]
+
+	match.sourcePrefix, _ = next("IN")
+
+	artifactType, ok := next("WITH")
+	if !ok {
+		return nil, invalid
+	}
+	switch strings.ToUpper(artifactType) {
+	case "MATERIALS":
+	case "PRODUCTS":
+		match.products = true
+	default:
+		return nil, invalid
+	}
+
+	match.destinationPrefix, _ = next("IN")
+
+	if match.step, ok = next("FROM"); !ok || len(tokens) > 0 {
+		return nil, invalid
+	}
+
+	return match, nil
+}
+
+// consume removes the artifacts that match from the queue
+func consume(queue map[string]bool, matches func(name string) bool) {
+	for _, name := range sortedNames(queue) {
+		if matches(name) {
+			delete(queue, name)
+		}
+	}
+}
+
+func sortedNames(queue map[string]bool) []string {
+	names := make([]string, 0, len(queue))
+	for name := range queue {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// matchPattern matches the artifact name against a shell-style pattern, where * also matches path separators
+func matchPattern(pattern, name string) bool {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	matched, err := regexp.MatchString(expr.String(), name)
+	return err == nil && matched
+}
+
+// trimPrefix removes the directory prefix from the artifact name, returning false if the name isn't under the prefix
+func trimPrefix(name, prefix string) (string, bool) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return name, true
+	}
+
+	if !strings.HasPrefix(name, prefix+"/") {
+		return "", false
+	}
+
+	return strings.TrimPrefix(name, prefix+"/"), true
+}
+
+func joinPrefix(prefix, name string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "/" + name
+}
@@ -0,0 +1,125 @@
+package intoto
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Verify checks the supply chain recorded in the links against the layout. The layout must be signed by every layout
+// key and not have expired, each step must have links signed by at least its threshold of functionaries, and the
+// materials and products of each step must follow its artifact rules. Inspections aren't run, so layouts with
+// inspections aren't supported.
+func Verify(layoutBlock *Metablock, layoutKeys []Key, linkBlocks []*Metablock, now time.Time) error {
+	if len(layoutKeys) == 0 {
+		return fmt.Errorf("at least one layout key is required")
+	}
+
+	for _, key := range layoutKeys {
+		if err := layoutBlock.VerifySignature(key); err != nil {
+			return fmt.Errorf("layout signature: %v", err)
+		}
+	}
+
+	layout, err := layoutBlock.Layout()
+	if err != nil {
+		return err
+	}
+
+	expires, err := time.Parse(time.RFC3339, layout.Expires)
+	if err != nil {
+		return fmt.Errorf("invalid layout expiry %q: %v", layout.Expires, err)
+	}
+	if !now.Before(expires) {
+		return fmt.Errorf("layout expired at %s", layout.Expires)
+	}
+
+	if len(layout.Inspect) > 0 {
+		return fmt.Errorf("layout inspections aren't supported")
+	}
+
+	links, err := verifyLinks(layout, linkBlocks)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range layout.Steps {
+		link := links[step.Name]
+		if err := verifyArtifactRules(step.ExpectedMaterials, link.Materials, link, links); err != nil {
+			return fmt.Errorf("step %s materials: %v", step.Name, err)
+		}
+		if err := verifyArtifactRules(step.ExpectedProducts, link.Products, link, links); err != nil {
+			return fmt.Errorf("step %s products: %v", step.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// verifyLinks returns a link for each step of the layout, after checking that each step has links signed by at least
+// its threshold of functionaries and that those links agree on the step's artifacts
+func verifyLinks(layout *Layout, linkBlocks []*Metablock) (map[string]*Link, error) {
+	// the links for each step, by the functionary that signed them
+	signed := make(map[string]map[string]*Link)
+
+	for _, block := range linkBlocks {
+		link, err := block.Link()
+		if err != nil {
+			return nil, err
+		}
+
+		step := layout.step(link.Name)
+		if step == nil {
+			continue
+		}
+
+		for _, keyID := range step.PubKeys {
+			key, ok := layout.Keys[keyID]
+			if !ok || block.VerifySignature(key) != nil {
+				continue
+			}
+
+			if signed[step.Name] == nil {
+				signed[step.Name] = make(map[string]*Link)
+			}
+			signed[step.Name][keyID] = link
+		}
+	}
+
+	links := make(map[string]*Link)
+	for _, step := range layout.Steps {
+		threshold := step.Threshold
+		if threshold < 1 {
+			threshold = 1
+		}
+
+		if len(signed[step.Name]) < threshold {
+			return nil, fmt.Errorf("step %s requires links signed by %d of its functionaries, found %d", step.Name, threshold, len(signed[step.Name]))
+		}
+
+		for _, keyID := range step.PubKeys {
+			link, ok := signed[step.Name][keyID]
+			if !ok {
+				continue
+			}
+
+			if first, ok := links[step.Name]; !ok {
+				links[step.Name] = link
+			} else if !reflect.DeepEqual(first.Materials, link.Materials) || !reflect.DeepEqual(first.Products, link.Products) {
+				return nil, fmt.Errorf("step %s has links with different artifacts", step.Name)
+			}
+		}
+	}
+
+	return links, nil
+}
+
+func (l *Layout) step(name string) *Step {
+	for i := range l.Steps {
+		if l.Steps[i].Name == name {
+			return &l.Steps[i]
+		}
+	}
+
+	return nil
+}
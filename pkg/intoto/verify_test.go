@@ -0,0 +1,189 @@
+package intoto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the samples in testdata were signed by keys generated from these seeds
+var (
+	ownerSeed     = []byte("rode in-toto sample layout owner")
+	developerSeed = []byte("rode in-toto sample developer!!!")
+	builderSeed   = []byte("rode in-toto sample build server")
+)
+
+var now = time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	layout, keys, links := loadSamples(t)
+	assert.NoError(Verify(layout, keys, links, now))
+}
+
+func TestVerify_Failures(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, tc := range map[string]struct {
+		modify   func(layout map[string]interface{}, links map[string]map[string]interface{})
+		expected string
+	}{
+		"expired layout": {
+			modify: func(layout map[string]interface{}, links map[string]map[string]interface{}) {
+				layout["expires"] = "2020-01-01T00:00:00Z"
+			},
+			expected: "layout expired",
+		},
+		"inspections": {
+			modify: func(layout map[string]interface{}, links map[string]map[string]interface{}) {
+				layout["inspect"] = []interface{}{map[string]interface{}{"name": "untar", "run": []interface{}{"tar", "xf", "app.tar"}}}
+			},
+			expected: "inspections aren't supported",
+		},
+		"untrusted functionary": {
+			modify: func(layout map[string]interface{}, links map[string]map[string]interface{}) {
+				steps := layout["steps"].([]interface{})
+				steps[1].(map[string]interface{})["pubkeys"] = []interface{}{sampleKey(developerSeed).KeyID}
+			},
+			expected: "step build requires links signed by 1 of its functionaries, found 0",
+		},
+		"disallowed product": {
+			modify: func(layout map[string]interface{}, links map[string]map[string]interface{}) {
+				products := links["clone"]["products"].(map[string]interface{})
+				products["secrets.txt"] = map[string]interface{}{"sha256": "aa"}
+			},
+			expected: "step clone products: artifact secrets.txt is disallowed",
+		},
+		"unmatched material": {
+			modify: func(layout map[string]interface{}, links map[string]map[string]interface{}) {
+				materials := links["build"]["materials"].(map[string]interface{})
+				materials["src/main.go"] = map[string]interface{}{"sha256": "bb"}
+			},
+			expected: "step build materials: artifact src/main.go is disallowed",
+		},
+	} {
+		layoutBlock, keys, linkBlocks := loadSamples(t)
+
+		layout := decodeSigned(t, layoutBlock)
+		links := make(map[string]map[string]interface{})
+		for _, block := range linkBlocks {
+			link := decodeSigned(t, block)
+			links[link["name"].(string)] = link
+		}
+
+		tc.modify(layout, links)
+
+		layoutBlock = signSample(t, layout, ownerSeed)
+		linkBlocks = []*Metablock{
+			signSample(t, links["clone"], developerSeed),
+			signSample(t, links["build"], builderSeed),
+		}
+
+		err := Verify(layoutBlock, keys, linkBlocks, now)
+		if assert.Error(err, name) {
+			assert.Contains(err.Error(), tc.expected, name)
+		}
+	}
+}
+
+func TestVerify_TamperedLink(t *testing.T) {
+	assert := assert.New(t)
+
+	layout, keys, links := loadSamples(t)
+	links[0].Signed = json.RawMessage(string(links[0].Signed[:len(links[0].Signed)-1]) + `,"command":["rm"]}`)
+
+	assert.Error(Verify(layout, keys, links, now))
+	assert.Error(Verify(layout, []Key{sampleKey(developerSeed)}, links, now))
+}
+
+func TestMatchPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(matchPattern("*", "src/main.go"))
+	assert.True(matchPattern("src/*.go", "src/pkg/main.go"))
+	assert.True(matchPattern("?.txt", "a.txt"))
+	assert.True(matchPattern("[ab].txt", "b.txt"))
+	assert.False(matchPattern("[!ab].txt", "b.txt"))
+	assert.False(matchPattern("src/*", "README.md"))
+	assert.False(matchPattern("a.txt", "aatxt"))
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	canonical, err := canonicalJSON([]byte(`{"b": [1, true, null], "a": "quote \" back \\ newline \n"}`))
+	assert.NoError(err)
+	assert.Equal("{\"a\":\"quote \\\" back \\\\ newline \n\",\"b\":[1,true,null]}", string(canonical))
+
+	_, err = canonicalJSON([]byte(`{"a": 1.5}`))
+	assert.Error(err)
+}
+
+func loadSamples(t *testing.T) (*Metablock, []Key, []*Metablock) {
+	layout, err := LoadMetablock(filepath.Join("testdata", "root.layout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadKey(filepath.Join("testdata", "owner.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	links, err := LoadLinks(filepath.Join("testdata", "links"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return layout, []Key{key}, links
+}
+
+func decodeSigned(t *testing.T, block *Metablock) map[string]interface{} {
+	signed := make(map[string]interface{})
+	if err := json.Unmarshal(block.Signed, &signed); err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+// sampleKey returns the public key generated from the seed, identified by a hash of its canonical encoding
+func sampleKey(seed []byte) Key {
+	key := Key{
+		KeyType:             "ed25519",
+		Scheme:              "ed25519",
+		KeyIDHashAlgorithms: []string{"sha256", "sha512"},
+		KeyVal:              KeyVal{Public: hex.EncodeToString(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))},
+	}
+
+	data, _ := json.Marshal(key)
+	canonical, _ := canonicalJSON(data)
+	hash := sha256.Sum256(canonical)
+	key.KeyID = hex.EncodeToString(hash[:])
+
+	return key
+}
+
+func signSample(t *testing.T, signed interface{}, seed []byte) *Metablock {
+	data, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := canonicalJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Metablock{
+		Signed: canonical,
+		Signatures: []Signature{{
+			KeyID: sampleKey(seed).KeyID,
+			Sig:   hex.EncodeToString(ed25519.Sign(ed25519.NewKeyFromSeed(seed), canonical)),
+		}},
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
 
@@ -17,17 +19,20 @@ import (
 )
 
 type grafeasClient struct {
-	log                logr.Logger
-	client             grafeas.GrafeasV1Beta1Client
-	projectClient      project.ProjectsClient
-	projectID          string
-	projectInitialized bool
+	log           logr.Logger
+	client        grafeas.GrafeasV1Beta1Client
+	projectClient project.ProjectsClient
+	projectID     string
+
+	mutex               sync.Mutex
+	initializedProjects map[string]bool
 }
 
 // GrafeasClient handle into grafeas
 type GrafeasClient interface {
 	Creator
 	Lister
+	NoteCreator
 }
 
 // NewGrafeasClient creates a new client
@@ -41,17 +46,18 @@ func NewGrafeasClient(log logr.Logger, tlsConfig *tls.Config, endpoint string) (
 		return nil, err
 	}
 
-	client := grafeas.NewGrafeasV1Beta1Client(conn)
-	projectClient := project.NewProjectsClient(conn)
-	c := &grafeasClient{
-		log,
-		client,
-		projectClient,
-		"projects/rode",
-		false,
-	}
+	return NewGrafeasClientWithConn(log, conn), nil
+}
 
-	return c, nil
+// NewGrafeasClientWithConn creates a new client that uses an existing connection to Grafeas
+func NewGrafeasClientWithConn(log logr.Logger, conn *grpc.ClientConn) GrafeasClient {
+	return &grafeasClient{
+		log:                 log,
+		client:              grafeas.NewGrafeasV1Beta1Client(conn),
+		projectClient:       project.NewProjectsClient(conn),
+		projectID:           "projects/rode",
+		initializedProjects: make(map[string]bool),
+	}
 }
 
 // ListOccurrences will get the occurence for a resource
@@ -87,7 +93,7 @@ func (c *grafeasClient) CreateOccurrences(ctx context.Context, occurrences ...*g
 		return nil
 	}
 
-	err := c.initProject(ctx)
+	err := c.initProject(ctx, c.projectID)
 	if err != nil {
 		return err
 	}
@@ -99,23 +105,60 @@ func (c *grafeasClient) CreateOccurrences(ctx context.Context, occurrences ...*g
 	return err
 }
 
-func (c *grafeasClient) initProject(ctx context.Context) error {
-	if c.projectInitialized {
+// CreateNote will save the note in grafeas, in the project of the note's name, or in the client's project when the note
+// doesn't have a name. It succeeds if the note already exists, leaving the existing note as it is.
+func (c *grafeasClient) CreateNote(ctx context.Context, noteID string, note *grafeas.Note) error {
+	parent := c.projectID
+	if i := strings.Index(note.GetName(), "/notes/"); i > 0 && strings.HasPrefix(note.GetName(), "projects/") {
+		parent = note.GetName()[:i]
+	}
+
+	err := c.initProject(ctx, parent)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.CreateNote(ctx, &grafeas.CreateNoteRequest{
+		Parent: parent,
+		NoteId: noteID,
+		Note:   note,
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+	return err
+}
+
+// initProject creates the project unless it exists, once per project
+func (c *grafeasClient) initProject(ctx context.Context, projectID string) error {
+	c.mutex.Lock()
+	initialized := c.initializedProjects[projectID]
+	c.mutex.Unlock()
+	if initialized {
 		return nil
 	}
 
-	c.log.Info("Fetching project", "projectID", c.projectID)
+	c.log.Info("Fetching project", "projectID", projectID)
 	_, err := c.projectClient.GetProject(ctx, &project.GetProjectRequest{
-		Name: c.projectID,
+		Name: projectID,
 	})
 	if err != nil && status.Code(err) == codes.NotFound {
-		c.log.Info("Creating project", "ProjectID", c.projectID)
+		c.log.Info("Creating project", "ProjectID", projectID)
 		_, err = c.projectClient.CreateProject(ctx, &project.CreateProjectRequest{
 			Project: &project.Project{
-				Name: c.projectID,
+				Name: projectID,
 			},
 		})
+		if status.Code(err) == codes.AlreadyExists {
+			err = nil
+		}
 	}
-	c.projectInitialized = (err == nil)
-	return err
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.initializedProjects[projectID] = true
+	c.mutex.Unlock()
+	return nil
 }
@@ -0,0 +1,52 @@
+package occurrence
+
+import (
+	"context"
+	"testing"
+
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	project "github.com/grafeas/grafeas/proto/v1beta1/project_go_proto"
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/liatrio/rode/pkg/test"
+)
+
+func TestGrafeasClient(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	fake, conn, stop, err := test.StartFakeGrafeas()
+	assert.NoError(err)
+	defer stop()
+	c := NewGrafeasClientWithConn(logf.NullLogger{}, conn)
+
+	// the project is created before the first note, and creating a note that already exists succeeds
+	note := &grafeas.Note{Name: "projects/rode/notes/scanner", ShortDescription: "scanner"}
+	assert.NoError(c.CreateNote(ctx, "scanner", note))
+	assert.NoError(c.CreateNote(ctx, "scanner", note))
+	assert.Equal("scanner", fake.Note("projects/rode/notes/scanner").ShortDescription)
+
+	image := &grafeas.Occurrence{NoteName: "projects/rode/notes/scanner", Resource: &grafeas.Resource{Uri: "image"}}
+	other := &grafeas.Occurrence{NoteName: "projects/rode/notes/scanner", Resource: &grafeas.Resource{Uri: "other"}}
+	assert.NoError(c.CreateOccurrences(ctx, image, other))
+	assert.NoError(c.CreateOccurrences(ctx))
+	assert.Len(fake.Occurrences(), 2)
+
+	// occurrences of notes that don't exist are rejected
+	assert.Error(c.CreateOccurrences(ctx, &grafeas.Occurrence{NoteName: "projects/rode/notes/missing", Resource: &grafeas.Resource{Uri: "image"}}))
+
+	// notes are created in the project of their names, and a note that exists is left as it is rather than recreated
+	custom := &grafeas.Note{Name: "projects/security/notes/approved", ShortDescription: "approved"}
+	assert.NoError(c.CreateNote(ctx, "approved", custom))
+	assert.NoError(c.CreateNote(ctx, "approved", &grafeas.Note{Name: custom.Name, ShortDescription: "changed"}))
+	assert.Equal("approved", fake.Note("projects/security/notes/approved").ShortDescription)
+	_, err = fake.GetProject(ctx, &project.GetProjectRequest{Name: "projects/security"})
+	assert.NoError(err)
+	assert.NoError(c.CreateOccurrences(ctx, &grafeas.Occurrence{NoteName: custom.Name, Resource: &grafeas.Resource{Uri: "approved"}}))
+
+	resp, err := c.ListOccurrences(ctx, "image")
+	assert.NoError(err)
+	assert.Len(resp.Occurrences, 1)
+	assert.Equal("image", resp.Occurrences[0].Resource.Uri)
+}
@@ -0,0 +1,146 @@
+package occurrence
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+)
+
+// TimeRange limits occurrences to those created within a window. A zero Since or Until leaves that end of the window
+// open. The window includes Since and excludes Until.
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParseTimeRange parses the since and until parameters of a time range. Each is either an RFC 3339 timestamp, which must
+// include a timezone offset such as Z or +02:00, or a duration before now such as 36h or 7d. An empty value leaves that
+// end of the range open. The range is returned in UTC.
+func ParseTimeRange(since, until string, now time.Time) (*TimeRange, error) {
+	r := &TimeRange{}
+
+	var err error
+	if r.Since, err = parseTimeRangeBound("since", since, now); err != nil {
+		return nil, err
+	}
+	if r.Until, err = parseTimeRangeBound("until", until, now); err != nil {
+		return nil, err
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func parseTimeRangeBound(name, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	var ago time.Duration
+	var err error
+	if strings.HasSuffix(value, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(value, "d"))
+		ago = time.Duration(days) * 24 * time.Hour
+	} else {
+		ago, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: must be an RFC 3339 timestamp with a timezone offset or a duration such as 36h or 7d", name, value)
+	}
+	if ago < 0 {
+		return time.Time{}, fmt.Errorf("invalid %s %q: duration must not be negative", name, value)
+	}
+
+	return now.Add(-ago).UTC(), nil
+}
+
+// Validate returns an error if the range ends before it starts
+func (r *TimeRange) Validate() error {
+	if !r.Since.IsZero() && !r.Until.IsZero() && !r.Since.Before(r.Until) {
+		return fmt.Errorf("invalid time range: since %s must be before until %s", r.Since.Format(time.RFC3339), r.Until.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// IsZero returns whether the range is open at both ends
+func (r *TimeRange) IsZero() bool {
+	return r == nil || (r.Since.IsZero() && r.Until.IsZero())
+}
+
+// Contains returns whether the occurrence was created within the range. Occurrences without a creation time are only
+// within a range that is open at both ends.
+func (r *TimeRange) Contains(o *grafeas.Occurrence) bool {
+	if r.IsZero() {
+		return true
+	}
+
+	created, err := ptypes.Timestamp(o.GetCreateTime())
+	if err != nil {
+		return false
+	}
+
+	if !r.Since.IsZero() && created.Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && !created.Before(r.Until) {
+		return false
+	}
+
+	return true
+}
+
+// Filter returns the occurrences created within the range
+func (r *TimeRange) Filter(occurrences []*grafeas.Occurrence) []*grafeas.Occurrence {
+	if r.IsZero() {
+		return occurrences
+	}
+
+	filtered := make([]*grafeas.Occurrence, 0, len(occurrences))
+	for _, o := range occurrences {
+		if r.Contains(o) {
+			filtered = append(filtered, o)
+		}
+	}
+
+	return filtered
+}
+
+type timeRangeLister struct {
+	lister    Lister
+	timeRange *TimeRange
+}
+
+// NewTimeRangeLister creates a Lister that only lists the occurrences created within the time range, for bounding the
+// occurrences considered when evaluating resources in bulk
+func NewTimeRangeLister(lister Lister, timeRange *TimeRange) Lister {
+	return &timeRangeLister{
+		lister,
+		timeRange,
+	}
+}
+
+// ListOccurrences lists the occurrences for the resource that were created within the time range
+func (l *timeRangeLister) ListOccurrences(ctx context.Context, resourceURI string) (*grafeas.ListOccurrencesResponse, error) {
+	resp, err := l.lister.ListOccurrences(ctx, resourceURI)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grafeas.ListOccurrencesResponse{
+		Occurrences: l.timeRange.Filter(resp.GetOccurrences()),
+	}, nil
+}
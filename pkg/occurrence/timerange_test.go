@@ -0,0 +1,81 @@
+package occurrence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	r, err := ParseTimeRange("7d", "", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2020, 3, 3, 12, 0, 0, 0, time.UTC), r.Since)
+	assert.True(r.Until.IsZero())
+
+	r, err = ParseTimeRange("2020-03-01T00:00:00+02:00", "36h", now)
+	assert.NoError(err)
+	assert.Equal(time.Date(2020, 2, 29, 22, 0, 0, 0, time.UTC), r.Since)
+	assert.Equal(time.Date(2020, 3, 9, 0, 0, 0, 0, time.UTC), r.Until)
+	assert.Equal(time.UTC, r.Since.Location())
+
+	r, err = ParseTimeRange("", "", now)
+	assert.NoError(err)
+	assert.True(r.IsZero())
+
+	for _, invalid := range [][2]string{
+		{"2020-03-01T00:00:00", ""},
+		{"yesterday", ""},
+		{"-1h", ""},
+		{"1h", "2h"},
+		{"2020-03-01T00:00:00Z", "2020-03-01T00:00:00Z"},
+	} {
+		_, err := ParseTimeRange(invalid[0], invalid[1], now)
+		assert.Error(err, "since %s until %s", invalid[0], invalid[1])
+	}
+}
+
+func TestTimeRangeLister(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	lister := &fakeLister{
+		occurrences: []*grafeas.Occurrence{
+			newTimeRangeOccurrence("old", now.Add(-48*time.Hour)),
+			newTimeRangeOccurrence("recent", now.Add(-time.Hour)),
+			newTimeRangeOccurrence("until", now),
+			{Name: "unknown"},
+		},
+	}
+
+	r, err := ParseTimeRange("1d", "2020-03-10T12:00:00Z", now)
+	assert.NoError(err)
+
+	resp, err := NewTimeRangeLister(lister, r).ListOccurrences(context.Background(), "image")
+	assert.NoError(err)
+	assert.Len(resp.Occurrences, 1)
+	assert.Equal("recent", resp.Occurrences[0].Name)
+
+	resp, err = NewTimeRangeLister(lister, &TimeRange{}).ListOccurrences(context.Background(), "image")
+	assert.NoError(err)
+	assert.Len(resp.Occurrences, 4)
+}
+
+type fakeLister struct {
+	occurrences []*grafeas.Occurrence
+}
+
+func (l *fakeLister) ListOccurrences(ctx context.Context, resourceURI string) (*grafeas.ListOccurrencesResponse, error) {
+	return &grafeas.ListOccurrencesResponse{Occurrences: l.occurrences}, nil
+}
+
+func newTimeRangeOccurrence(name string, created time.Time) *grafeas.Occurrence {
+	createTime, _ := ptypes.TimestampProto(created)
+	return &grafeas.Occurrence{Name: name, CreateTime: createTime}
+}
@@ -0,0 +1,38 @@
+package occurrence
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+
+	"github.com/go-logr/logr"
+)
+
+// NewGrafeasTLSConfig creates the TLS config for connecting to Grafeas from the TLS_CLIENT_CERT, TLS_CLIENT_KEY
+// and TLS_CA_CERT environment variables
+func NewGrafeasTLSConfig(log logr.Logger) (*tls.Config, error) {
+	clientCert, err := tls.LoadX509KeyPair(os.Getenv("TLS_CLIENT_CERT"), os.Getenv("TLS_CLIENT_KEY"))
+	if err != nil {
+		log.Error(err, "Unable to load client cert")
+		return nil, err
+	}
+
+	cf, err := ioutil.ReadFile(os.Getenv("TLS_CA_CERT"))
+	if err != nil {
+		log.Error(err, "Unable to load CA cert")
+		return nil, err
+	}
+
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(cf)
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      caCertPool,
+		//InsecureSkipVerify: true,
+	}
+	tlsConfig.BuildNameToCertificate()
+
+	return tlsConfig, nil
+}
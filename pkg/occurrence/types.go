@@ -15,3 +15,8 @@ type Lister interface {
 type Creator interface {
 	CreateOccurrences(context.Context, ...*grafeas.Occurrence) error
 }
+
+// NoteCreator implements the creation of notes
+type NoteCreator interface {
+	CreateNote(ctx context.Context, noteID string, note *grafeas.Note) error
+}
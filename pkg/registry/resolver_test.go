@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	assert := assert.New(t)
+
+	for image, expected := range map[string]Reference{
+		"nginx":                              {Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+		"liatrio/rode:v1":                    {Registry: "docker.io", Repository: "liatrio/rode", Tag: "v1"},
+		"harbor.liatr.io/rode/app:1.0":       {Registry: "harbor.liatr.io", Repository: "rode/app", Tag: "1.0"},
+		"localhost:5000/app":                 {Registry: "localhost:5000", Repository: "app", Tag: "latest"},
+		"harbor.liatr.io/rode/app@sha256:ab": {Registry: "harbor.liatr.io", Repository: "rode/app", Digest: "sha256:ab"},
+		"harbor.liatr.io/rode/app:1.0@sha256:ab": {
+			Registry: "harbor.liatr.io", Repository: "rode/app", Tag: "1.0", Digest: "sha256:ab",
+		},
+	} {
+		ref, err := ParseReference(image)
+		assert.NoError(err, image)
+		assert.Equal(expected, *ref, image)
+	}
+
+	_, err := ParseReference("")
+	assert.Error(err)
+}
+
+func TestResolver_ResolveDigest(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal("repository:rode/app:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token":"anonymous"}`)
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/rode/app/manifests/1.0":
+			assert.Equal(http.MethodHead, r.Method)
+			w.Header().Set("Docker-Content-Digest", "sha256:current")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := NewResolver(server.Client())
+
+	digest, err := resolver.ResolveDigest(ctx, host+"/rode/app:1.0")
+	assert.NoError(err)
+	assert.Equal("sha256:current", digest)
+
+	digest, err = resolver.ResolveDigest(ctx, host+"/rode/app@sha256:pinned")
+	assert.NoError(err)
+	assert.Equal("sha256:pinned", digest)
+
+	_, err = resolver.ResolveDigest(ctx, host+"/rode/app:missing")
+	assert.Error(err)
+}
+
+func TestDigest(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("sha256:ab", Digest("harbor.liatr.io/rode/app:1.0@sha256:ab"))
+	assert.Equal("", Digest("harbor.liatr.io/rode/app:1.0"))
+}
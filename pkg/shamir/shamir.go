@@ -0,0 +1,151 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8). A secret is split into shares such that any
+// threshold of them can recover it, while fewer reveal nothing about it.
+//
+// Each byte of the secret is the constant term of its own random polynomial of degree threshold-1. A share is the
+// polynomials evaluated at the share's x coordinate, followed by the x coordinate itself.
+package shamir
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+const maxShares = 255
+
+// Split splits the secret into the given number of shares, any threshold of which recover the secret
+func Split(secret []byte, shares, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret must not be empty")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if shares < threshold {
+		return nil, fmt.Errorf("shares must be at least the threshold")
+	}
+	if shares > maxShares {
+		return nil, fmt.Errorf("shares must be at most %d", maxShares)
+	}
+
+	out := make([][]byte, shares)
+	for i := range out {
+		out[i] = make([]byte, len(secret)+1)
+		out[i][len(secret)] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	for b, value := range secret {
+		coefficients[0] = value
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+
+		for i := range out {
+			out[i][b] = evaluate(coefficients, byte(i+1))
+		}
+	}
+
+	return out, nil
+}
+
+// Combine recovers the secret from shares created by Split. At least the threshold number of shares must be given, or
+// the result is meaningless; shares don't record the threshold so this can't be detected.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("at least 2 shares are required")
+	}
+
+	length := len(shares[0])
+	if length < 2 {
+		return nil, fmt.Errorf("invalid share length %d", length)
+	}
+
+	xs := make([]byte, len(shares))
+	seen := make(map[byte]bool)
+	for i, share := range shares {
+		if len(share) != length {
+			return nil, fmt.Errorf("shares have different lengths")
+		}
+
+		x := share[length-1]
+		if x == 0 {
+			return nil, fmt.Errorf("invalid share %d", i)
+		}
+		if seen[x] {
+			return nil, fmt.Errorf("duplicate share %d", x)
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	secret := make([]byte, length-1)
+	ys := make([]byte, len(shares))
+	for b := range secret {
+		for i, share := range shares {
+			ys[i] = share[b]
+		}
+		secret[b] = interpolateAtZero(xs, ys)
+	}
+
+	return secret, nil
+}
+
+// evaluate evaluates the polynomial with the given coefficients, lowest degree first, at x
+func evaluate(coefficients []byte, x byte) byte {
+	result := byte(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = add(mul(result, x), coefficients[i])
+	}
+
+	return result
+}
+
+// interpolateAtZero returns the value at 0 of the polynomial through the points, using Lagrange interpolation
+func interpolateAtZero(xs, ys []byte) byte {
+	result := byte(0)
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			// x_j / (x_j - x_i), where subtraction is addition in GF(2^8)
+			basis = mul(basis, div(xs[j], add(xs[j], xs[i])))
+		}
+		result = add(result, mul(ys[i], basis))
+	}
+
+	return result
+}
+
+func add(a, b byte) byte {
+	return a ^ b
+}
+
+// mul multiplies in GF(2^8) modulo the AES polynomial x^8 + x^4 + x^3 + x + 1, without branching on the operands
+func mul(a, b byte) byte {
+	result := byte(0)
+	for i := 0; i < 8; i++ {
+		result ^= a & -(b & 1)
+		carry := -(a >> 7)
+		a = (a << 1) ^ (0x1b & carry)
+		b >>= 1
+	}
+
+	return result
+}
+
+// div divides in GF(2^8). b must not be 0.
+func div(a, b byte) byte {
+	return mul(a, inverse(b))
+}
+
+// inverse returns the multiplicative inverse of a as a^254
+func inverse(a byte) byte {
+	result := byte(1)
+	for i := 0; i < 254; i++ {
+		result = mul(result, a)
+	}
+
+	return result
+}
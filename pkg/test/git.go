@@ -0,0 +1,64 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// GitRepository is a bare Git repository that files are pushed to from a clone of it, for testing components that
+// pull from Git
+type GitRepository struct {
+	// URL is the path of the bare repository, which git fetches from
+	URL string
+
+	t     testing.TB
+	clone string
+}
+
+// NewGitRepository creates a bare repository, with main as its default branch, and a clone of it in the directory
+func NewGitRepository(t testing.TB, dir string) *GitRepository {
+	repo := &GitRepository{URL: filepath.Join(dir, "repository.git"), t: t, clone: filepath.Join(dir, "clone")}
+	repo.git("", "init", "--quiet", "--bare", repo.URL)
+	repo.git("", "--git-dir", repo.URL, "symbolic-ref", "HEAD", "refs/heads/main")
+	repo.git("", "clone", "--quiet", repo.URL, repo.clone)
+
+	return repo
+}
+
+// Push writes the files to the clone, commits them to the branch and pushes the branch, returning the commit
+func (r *GitRepository) Push(branch string, files map[string]string) string {
+	for name, content := range files {
+		file := filepath.Join(r.clone, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+
+	r.git(r.clone, "checkout", "--quiet", "-B", branch)
+	r.git(r.clone, "add", "--all")
+	r.git(r.clone, "commit", "--quiet", "--allow-empty", "-m", "Update "+branch)
+	r.git(r.clone, "push", "--quiet", "--force", "origin", branch)
+
+	return strings.TrimSpace(r.git(r.clone, "rev-parse", "HEAD"))
+}
+
+func (r *GitRepository) git(dir string, args ...string) string {
+	r.t.Helper()
+
+	args = append([]string{"-c", "user.name=rode", "-c", "user.email=rode@liatr.io", "-c", "commit.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+	}
+
+	return string(out)
+}
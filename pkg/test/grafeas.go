@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	project "github.com/grafeas/grafeas/proto/v1beta1/project_go_proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// FakeGrafeas is an in-memory Grafeas server. Only the calls that rode makes are implemented, and like Grafeas it
+// rejects occurrences of notes or projects that don't exist.
+type FakeGrafeas struct {
+	grafeas.GrafeasV1Beta1Server
+	project.ProjectsServer
+
+	mutex       sync.Mutex
+	projects    map[string]bool
+	notes       map[string]*grafeas.Note
+	occurrences []*grafeas.Occurrence
+}
+
+// StartFakeGrafeas serves a FakeGrafeas over an in-memory listener, returning it with a connection to it and a function
+// that stops the server
+func StartFakeGrafeas() (*FakeGrafeas, *grpc.ClientConn, func(), error) {
+	fake := &FakeGrafeas{
+		projects: make(map[string]bool),
+		notes:    make(map[string]*grafeas.Note),
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	grafeas.RegisterGrafeasV1Beta1Server(server, fake)
+	project.RegisterProjectsServer(server, fake)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	if err != nil {
+		server.Stop()
+		return nil, nil, nil, err
+	}
+
+	return fake, conn, func() {
+		_ = conn.Close()
+		server.Stop()
+	}, nil
+}
+
+// Note returns the note with the name, or nil if it doesn't exist
+func (f *FakeGrafeas) Note(name string) *grafeas.Note {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.notes[name]
+}
+
+// Occurrences returns the occurrences that have been created
+func (f *FakeGrafeas) Occurrences() []*grafeas.Occurrence {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]*grafeas.Occurrence{}, f.occurrences...)
+}
+
+func (f *FakeGrafeas) GetProject(ctx context.Context, req *project.GetProjectRequest) (*project.Project, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.projects[req.Name] {
+		return nil, status.Errorf(codes.NotFound, "project %q not found", req.Name)
+	}
+
+	return &project.Project{Name: req.Name}, nil
+}
+
+func (f *FakeGrafeas) CreateProject(ctx context.Context, req *project.CreateProjectRequest) (*project.Project, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.projects[req.Project.Name] {
+		return nil, status.Errorf(codes.AlreadyExists, "project %q already exists", req.Project.Name)
+	}
+	f.projects[req.Project.Name] = true
+
+	return req.Project, nil
+}
+
+func (f *FakeGrafeas) CreateNote(ctx context.Context, req *grafeas.CreateNoteRequest) (*grafeas.Note, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.projects[req.Parent] {
+		return nil, status.Errorf(codes.NotFound, "project %q not found", req.Parent)
+	}
+
+	name := req.Parent + "/notes/" + req.NoteId
+	if _, ok := f.notes[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "note %q already exists", name)
+	}
+	f.notes[name] = req.Note
+
+	return req.Note, nil
+}
+
+func (f *FakeGrafeas) DeleteNote(ctx context.Context, req *grafeas.DeleteNoteRequest) (*empty.Empty, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, ok := f.notes[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "note %q not found", req.Name)
+	}
+	delete(f.notes, req.Name)
+
+	return &empty.Empty{}, nil
+}
+
+func (f *FakeGrafeas) BatchCreateOccurrences(ctx context.Context, req *grafeas.BatchCreateOccurrencesRequest) (*grafeas.BatchCreateOccurrencesResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.projects[req.Parent] {
+		return nil, status.Errorf(codes.NotFound, "project %q not found", req.Parent)
+	}
+	for _, occ := range req.Occurrences {
+		if occ.NoteName != "" {
+			if _, ok := f.notes[occ.NoteName]; !ok {
+				return nil, status.Errorf(codes.NotFound, "note %q not found", occ.NoteName)
+			}
+		}
+	}
+	f.occurrences = append(f.occurrences, req.Occurrences...)
+
+	return &grafeas.BatchCreateOccurrencesResponse{Occurrences: req.Occurrences}, nil
+}
+
+func (f *FakeGrafeas) ListOccurrences(ctx context.Context, req *grafeas.ListOccurrencesRequest) (*grafeas.ListOccurrencesResponse, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return &grafeas.ListOccurrencesResponse{Occurrences: append([]*grafeas.Occurrence{}, f.occurrences...)}, nil
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+)
+
+// ExporterFactory creates a tracer provider that exports the spans it records. The logger is for the exporter's own
+// messages, and is where the log exporter writes spans.
+type ExporterFactory func(log logr.Logger) TracerProvider
+
+var exporters = map[string]ExporterFactory{
+	"log": NewLogTracerProvider,
+}
+
+// RegisterExporter makes an exporter, such as an adapter for an OpenTelemetry tracer provider, available to NewExporter
+// by name, replacing any exporter with the same name
+func RegisterExporter(name string, factory ExporterFactory) {
+	exporters[name] = factory
+}
+
+// Exporters returns the names of the registered exporters
+func Exporters() []string {
+	var names []string
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewExporter creates the tracer provider of the registered exporter with the name
+func NewExporter(name string, log logr.Logger) (TracerProvider, error) {
+	factory, ok := exporters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown trace exporter %q, must be one of %v", name, Exporters())
+	}
+
+	return factory(log), nil
+}
@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// NewLogTracerProvider creates a tracer provider that logs each span when it's ended, with its duration, attributes,
+// events and errors. Spans are logged with the IDs of their trace and parent span, so that the spans of a reconcile can
+// be pieced together from the log.
+func NewLogTracerProvider(log logr.Logger) TracerProvider {
+	return &logTracerProvider{log: log}
+}
+
+type logTracerProvider struct {
+	log logr.Logger
+}
+
+func (p *logTracerProvider) Tracer(name string) Tracer {
+	return &logTracer{log: p.log.WithValues("tracer", name)}
+}
+
+type logTracer struct {
+	log logr.Logger
+}
+
+func (t *logTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &logSpan{
+		log:        t.log,
+		name:       name,
+		traceID:    newID(16),
+		spanID:     newID(8),
+		start:      time.Now(),
+		attributes: make(map[string]interface{}),
+	}
+	if parent, ok := SpanFromContext(ctx).(*logSpan); ok {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	}
+	span.SetAttributes(attrs...)
+
+	return ContextWithSpan(ctx, span), span
+}
+
+type logSpan struct {
+	log      logr.Logger
+	name     string
+	traceID  string
+	spanID   string
+	parentID string
+	start    time.Time
+
+	mutex      sync.Mutex
+	attributes map[string]interface{}
+	events     []RecordedEvent
+	errors     []string
+	ended      bool
+}
+
+func (s *logSpan) SetAttributes(attrs ...Attribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, attr := range attrs {
+		s.attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *logSpan) AddEvent(name string, attrs ...Attribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	event := RecordedEvent{Name: name, Attributes: make(map[string]interface{})}
+	for _, attr := range attrs {
+		event.Attributes[attr.Key] = attr.Value
+	}
+	s.events = append(s.events, event)
+}
+
+func (s *logSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.errors = append(s.errors, err.Error())
+}
+
+func (s *logSpan) End() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ended {
+		return
+	}
+	s.ended = true
+
+	s.log.Info("Span ended", "span", s.name, "traceID", s.traceID, "spanID", s.spanID, "parentSpanID", s.parentID,
+		"duration", time.Since(s.start).String(), "attributes", s.attributes, "events", s.events, "errors", s.errors)
+}
+
+// newID returns a random ID of the given number of bytes, hex encoded like OpenTelemetry trace and span IDs
+func newID(size int) string {
+	id := make([]byte, size)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
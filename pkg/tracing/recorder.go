@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// RecordedSpan is a span that was ended while a Recorder was the tracer provider
+type RecordedSpan struct {
+	Tracer     string
+	Name       string
+	Parent     string
+	Attributes map[string]interface{}
+	Events     []RecordedEvent
+	Errors     []error
+}
+
+// RecordedEvent is an event that was added to a RecordedSpan
+type RecordedEvent struct {
+	Name       string
+	Attributes map[string]interface{}
+}
+
+// Recorder is a tracer provider that keeps the spans that are ended in memory, for testing instrumented components
+type Recorder struct {
+	mutex sync.Mutex
+	ended []RecordedSpan
+}
+
+// NewRecorder creates a Recorder without any spans
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Tracer returns a tracer whose spans are kept by the recorder once they're ended
+func (r *Recorder) Tracer(name string) Tracer {
+	return &recordingTracer{recorder: r, name: name}
+}
+
+// Spans returns the spans that have been ended, in the order they were ended
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]RecordedSpan(nil), r.ended...)
+}
+
+// Reset forgets the spans that have been ended
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.ended = nil
+}
+
+type recordingTracer struct {
+	recorder *Recorder
+	name     string
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &recordingSpan{
+		recorder: t.recorder,
+		span: RecordedSpan{
+			Tracer:     t.name,
+			Name:       name,
+			Attributes: make(map[string]interface{}),
+		},
+	}
+	if parent, ok := SpanFromContext(ctx).(*recordingSpan); ok {
+		span.span.Parent = parent.span.Name
+	}
+	span.SetAttributes(attrs...)
+
+	return ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	recorder *Recorder
+
+	mutex sync.Mutex
+	span  RecordedSpan
+	ended bool
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, attr := range attrs {
+		s.span.Attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) AddEvent(name string, attrs ...Attribute) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	event := RecordedEvent{Name: name, Attributes: make(map[string]interface{})}
+	for _, attr := range attrs {
+		event.Attributes[attr.Key] = attr.Value
+	}
+	s.span.Events = append(s.span.Events, event)
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.span.Errors = append(s.span.Errors, err)
+}
+
+func (s *recordingSpan) End() {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	span := s.span
+	s.mutex.Unlock()
+
+	s.recorder.mutex.Lock()
+	defer s.recorder.mutex.Unlock()
+	s.recorder.ended = append(s.recorder.ended, span)
+}
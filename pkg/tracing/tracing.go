@@ -0,0 +1,90 @@
+// Package tracing records spans of the work done by the controller. Its interfaces follow the OpenTelemetry trace API,
+// so that an OpenTelemetry tracer provider can be adapted to them, and spans aren't recorded unless a provider is set.
+package tracing
+
+import (
+	"context"
+)
+
+// Attribute is a key and value describing a span or an event
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is an operation being traced, which is ended once the operation is done
+type Span interface {
+	// SetAttributes adds the attributes to the span, replacing attributes with the same keys
+	SetAttributes(attrs ...Attribute)
+
+	// AddEvent records something that happened during the span, such as a condition transition
+	AddEvent(name string, attrs ...Attribute)
+
+	// RecordError records that the operation failed with the error
+	RecordError(err error)
+
+	// End ends the span
+	End()
+}
+
+// Tracer starts spans
+type Tracer interface {
+	// Start starts a span that's a child of the span in the context, if there is one, returning a context holding the
+	// new span
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// TracerProvider provides the tracers of instrumented components
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context holding the span, which is the parent of spans started with the context
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span in the context, or a span that doesn't record anything if there isn't one
+func SpanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+
+	return noopSpan{}
+}
+
+// NoopTracerProvider returns a tracer provider whose spans don't record anything
+func NoopTracerProvider() TracerProvider {
+	return noopTracerProvider{}
+}
+
+type noopTracerProvider struct{}
+
+func (noopTracerProvider) Tracer(string) Tracer {
+	return noopTracer{}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute)    {}
+func (noopSpan) AddEvent(string, ...Attribute) {}
+func (noopSpan) RecordError(error)             {}
+func (noopSpan) End()                          {}
@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoopTracerProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	spanCtx, span := NoopTracerProvider().Tracer("test").Start(ctx, "noop", String("key", "value"))
+	span.AddEvent("event")
+	span.RecordError(fmt.Errorf("failed"))
+	span.End()
+
+	// nothing is added to the context, and a context without a span has a span that doesn't record anything
+	assert.Equal(ctx, spanCtx)
+	assert.Equal(noopSpan{}, SpanFromContext(spanCtx))
+}
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := NewRecorder()
+	tracer := recorder.Tracer("test")
+
+	ctx, parent := tracer.Start(context.Background(), "parent", String("attester", "default/test"))
+	_, child := tracer.Start(ctx, "child", Int("modules", 2))
+	child.RecordError(nil)
+	child.RecordError(fmt.Errorf("failed"))
+	child.End()
+
+	SpanFromContext(ctx).AddEvent("ConditionTransition", String("to", "True"))
+	assert.Len(recorder.Spans(), 1)
+	parent.End()
+	parent.End()
+
+	assert.Equal([]RecordedSpan{
+		{
+			Tracer:     "test",
+			Name:       "child",
+			Parent:     "parent",
+			Attributes: map[string]interface{}{"modules": 2},
+			Errors:     []error{fmt.Errorf("failed")},
+		},
+		{
+			Tracer:     "test",
+			Name:       "parent",
+			Attributes: map[string]interface{}{"attester": "default/test"},
+			Events:     []RecordedEvent{{Name: "ConditionTransition", Attributes: map[string]interface{}{"to": "True"}}},
+		},
+	}, recorder.Spans())
+
+	recorder.Reset()
+	assert.Empty(recorder.Spans())
+}
+
+// capturingLogger keeps the key/value pairs of each line logged with it
+type capturingLogger struct {
+	values []interface{}
+	lines  *[]map[string]interface{}
+}
+
+func (l capturingLogger) Info(msg string, keysAndValues ...interface{}) {
+	line := map[string]interface{}{"msg": msg}
+	all := append(append([]interface{}{}, l.values...), keysAndValues...)
+	for i := 0; i+1 < len(all); i += 2 {
+		line[fmt.Sprint(all[i])] = all[i+1]
+	}
+	*l.lines = append(*l.lines, line)
+}
+
+func (l capturingLogger) Enabled() bool {
+	return true
+}
+
+func (l capturingLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.Info(msg, keysAndValues...)
+}
+
+func (l capturingLogger) V(int) logr.InfoLogger {
+	return l
+}
+
+func (l capturingLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return capturingLogger{values: append(append([]interface{}{}, l.values...), keysAndValues...), lines: l.lines}
+}
+
+func (l capturingLogger) WithName(string) logr.Logger {
+	return l
+}
+
+func TestLogTracerProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	lines := &[]map[string]interface{}{}
+	tracer := NewLogTracerProvider(capturingLogger{lines: lines}).Tracer("test")
+
+	ctx, parent := tracer.Start(context.Background(), "parent", String("attester", "default/test"))
+	_, child := tracer.Start(ctx, "child", Int("modules", 2))
+	child.RecordError(nil)
+	child.RecordError(fmt.Errorf("failed"))
+	child.End()
+	SpanFromContext(ctx).AddEvent("ConditionTransition", String("to", "True"))
+	parent.End()
+	parent.End()
+
+	// each span is logged once when it's ended, and the child is logged in the parent's trace
+	assert.Len(*lines, 2)
+	childLine, parentLine := (*lines)[0], (*lines)[1]
+	assert.Equal("test", childLine["tracer"])
+	assert.Equal("child", childLine["span"])
+	assert.Equal(map[string]interface{}{"modules": 2}, childLine["attributes"])
+	assert.Equal([]string{"failed"}, childLine["errors"])
+	assert.Equal(parentLine["traceID"], childLine["traceID"])
+	assert.Equal(parentLine["spanID"], childLine["parentSpanID"])
+	assert.Len(parentLine["traceID"], 32)
+	assert.Len(parentLine["spanID"], 16)
+
+	assert.Equal("parent", parentLine["span"])
+	assert.Equal("", parentLine["parentSpanID"])
+	assert.Equal([]RecordedEvent{{Name: "ConditionTransition", Attributes: map[string]interface{}{"to": "True"}}}, parentLine["events"])
+
+	// a new trace is started for spans without a parent
+	_, other := tracer.Start(context.Background(), "other")
+	other.End()
+	assert.NotEqual(parentLine["traceID"], (*lines)[2]["traceID"])
+}
+
+func TestNewExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	lines := &[]map[string]interface{}{}
+	provider, err := NewExporter("log", capturingLogger{lines: lines})
+	assert.NoError(err)
+	_, span := provider.Tracer("test").Start(context.Background(), "exported")
+	span.End()
+	assert.Len(*lines, 1)
+
+	_, err = NewExporter("missing", capturingLogger{lines: lines})
+	assert.EqualError(err, `unknown trace exporter "missing", must be one of [log]`)
+
+	// exporters such as OpenTelemetry adapters can be registered by name
+	recorder := NewRecorder()
+	RegisterExporter("recorder", func(logr.Logger) TracerProvider { return recorder })
+	defer delete(exporters, "recorder")
+	provider, err = NewExporter("recorder", capturingLogger{lines: lines})
+	assert.NoError(err)
+	assert.Equal(recorder, provider)
+	assert.Equal([]string{"log", "recorder"}, Exporters())
+}
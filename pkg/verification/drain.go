@@ -0,0 +1,96 @@
+package verification
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// Drainer tracks the requests being served so that shutting down waits for them, and the attestations they're signing,
+// to complete. It's added to the manager as a Runnable, which starts draining once the manager is stopped.
+type Drainer struct {
+	log     logr.Logger
+	timeout time.Duration
+
+	// mutex guards draining, so that a request can't start being tracked once the drain has started waiting
+	mutex    sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+	drained  chan struct{}
+}
+
+// NewDrainer creates a drainer that waits up to the timeout for requests in flight when it's stopped
+func NewDrainer(log logr.Logger, timeout time.Duration) *Drainer {
+	return &Drainer{
+		log:     log,
+		timeout: timeout,
+		drained: make(chan struct{}),
+	}
+}
+
+// Handler serves requests with the handler, tracking them until they complete. Requests are rejected with a 503 once
+// the drainer is draining, so that no attestations start while it waits for the ones in flight.
+func (d *Drainer) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !d.track() {
+			writer.Header().Set("Connection", "close")
+			http.Error(writer, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		defer d.inFlight.Done()
+
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+// track starts tracking a request, returning false if the drainer is draining
+func (d *Drainer) track() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.inFlight.Add(1)
+
+	return true
+}
+
+// Start blocks until stop is closed, then stops accepting requests and waits for the requests in flight to complete,
+// or for the timeout to pass
+func (d *Drainer) Start(stop <-chan struct{}) error {
+	<-stop
+	defer close(d.drained)
+
+	d.mutex.Lock()
+	d.draining = true
+	d.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+
+	d.log.Info("Draining in-flight requests", "timeout", d.timeout)
+	select {
+	case <-done:
+		d.log.Info("Drained in-flight requests")
+	case <-time.After(d.timeout):
+		d.log.Info("Timed out draining in-flight requests", "timeout", d.timeout)
+	}
+
+	return nil
+}
+
+// NeedLeaderElection returns false, since every replica serves requests and drains them when it's stopped
+func (d *Drainer) NeedLeaderElection() bool {
+	return false
+}
+
+// Drained returns a channel that's closed once the drainer has stopped waiting for requests in flight
+func (d *Drainer) Drained() <-chan struct{} {
+	return d.drained
+}
@@ -0,0 +1,128 @@
+package verification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/liatrio/rode/controllers"
+	"github.com/liatrio/rode/pkg/attester"
+)
+
+// blockingAuthorizer holds requests after authorizing them until it's released, so that they're in flight
+type blockingAuthorizer struct {
+	fakeAuthorizer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (a blockingAuthorizer) Authorize(ctx context.Context, user *authenticationv1.UserInfo, resource *authorizationv1.ResourceAttributes) error {
+	a.started <- struct{}{}
+	<-a.release
+
+	return a.fakeAuthorizer.Authorize(ctx, user, resource)
+}
+
+func postAttest(url, resource string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url+AttestPath, strings.NewReader(`{"attester":"verification","namespace":"default","resourceUri":"`+resource+`"}`))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer attester")
+
+	return http.DefaultClient.Do(req)
+}
+
+func TestDrainer(t *testing.T) {
+	assert := assert.New(t)
+
+	verification, signer := newTestAttester(t, "default/verification")
+	r := &controllers.AttesterReconciler{
+		Attesters: map[string]attester.Attester{"default/verification": verification},
+	}
+	authorizer := blockingAuthorizer{started: make(chan struct{}), release: make(chan struct{})}
+	drainer := NewDrainer(logf.NullLogger{}, time.Minute)
+	server := httptest.NewServer(drainer.Handler(NewHandler(logf.NullLogger{}, r, authorizer)))
+	defer server.Close()
+
+	// start an attestation, and stop the drainer while it's in flight
+	responses := make(chan *http.Response, 1)
+	go func() {
+		res, err := postAttest(server.URL, "image@sha256:1")
+		assert.NoError(err)
+		responses <- res
+	}()
+	<-authorizer.started
+
+	stop := make(chan struct{})
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- drainer.Start(stop)
+	}()
+	close(stop)
+
+	// new requests are rejected while draining, and the drain waits for the one in flight
+	assert.Eventually(func() bool {
+		drainer.mutex.Lock()
+		defer drainer.mutex.Unlock()
+		return drainer.draining
+	}, time.Second, 10*time.Millisecond)
+	rejected, err := postAttest(server.URL, "image@sha256:2")
+	assert.NoError(err)
+	rejected.Body.Close()
+	assert.Equal(http.StatusServiceUnavailable, rejected.StatusCode)
+	select {
+	case <-drainer.Drained():
+		assert.Fail("drained before the attestation in flight completed")
+	default:
+	}
+
+	// the attestation in flight completes and is signed
+	close(authorizer.release)
+	res := <-responses
+	defer res.Body.Close()
+	assert.Equal(http.StatusOK, res.StatusCode)
+	resp := &Response{}
+	assert.NoError(json.NewDecoder(res.Body).Decode(resp))
+	assert.True(resp.Attested)
+	payload, err := signer.Verify(resp.Signature)
+	assert.NoError(err)
+	assert.Contains(payload, "image@sha256:1")
+
+	assert.NoError(<-stopped)
+	<-drainer.Drained()
+}
+
+func TestDrainer_Timeout(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	drainer := NewDrainer(logf.NullLogger{}, 50*time.Millisecond)
+	handler := drainer.Handler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, AttestPath, nil))
+	<-started
+
+	// a request that doesn't complete only holds up the drain until the timeout
+	stop := make(chan struct{})
+	close(stop)
+	start := time.Now()
+	assert.NoError(drainer.Start(stop))
+	assert.True(time.Since(start) >= 50*time.Millisecond)
+	<-drainer.Drained()
+	assert.False(drainer.NeedLeaderElection())
+}
@@ -0,0 +1,164 @@
+// Package verification serves an HTTP API for attesting resources on demand, so that services outside the cluster can
+// have occurrences evaluated by an attester's policy and get back a signed attestation or the policy's violations.
+package verification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	authorizationv1 "k8s.io/api/authorization/v1"
+
+	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/auth"
+)
+
+// AttestPath is the path that the verification handler is served under
+const AttestPath = "/v1/attest"
+
+// Request is the body of a request to attest a resource
+type Request struct {
+	// Attester is the name of the attester, and Namespace its namespace when attesters in several namespaces share
+	// the name
+	Attester  string `json:"attester"`
+	Namespace string `json:"namespace,omitempty"`
+
+	ResourceURI string `json:"resourceUri"`
+
+	// Occurrences are the Grafeas occurrences of the resource that the attester's policy evaluates, in the attester's
+	// payload format: their JSON form by default, or strings holding their YAML or base64 protobuf encoding
+	Occurrences []json.RawMessage `json:"occurrences"`
+}
+
+// Response is the attester's decision on the resource, with the signature of its attestation if it was attested
+type Response struct {
+	Attester    string   `json:"attester"`
+	ResourceURI string   `json:"resourceUri"`
+	Attested    bool     `json:"attested"`
+	Violations  []string `json:"violations,omitempty"`
+	KeyID       string   `json:"keyId,omitempty"`
+	Signature   string   `json:"signature,omitempty"`
+}
+
+type handler struct {
+	log            logr.Logger
+	attesterLister attester.Lister
+	authorizer     auth.Authorizer
+}
+
+// NewHandler creates a handler that serves POST /v1/attest, evaluating the occurrences in the request with the
+// attester's policy. The attestation is signed and returned, but not stored, when the policy passes, and the violations
+// are returned with a 422 status when it fails. Since the caller provides the occurrences, callers must be allowed to
+// create the attestations subresource of the Attester.
+func NewHandler(log logr.Logger, attesterLister attester.Lister, authorizer auth.Authorizer) http.Handler {
+	return &handler{
+		log,
+		attesterLister,
+		authorizer,
+	}
+}
+
+func (h *handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := &Request{}
+	if err := json.NewDecoder(request.Body).Decode(body); err != nil {
+		http.Error(writer, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if body.Attester == "" || body.ResourceURI == "" {
+		http.Error(writer, "attester and resourceUri are required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.authorizer.Authenticate(request)
+	if err == auth.ErrUnauthenticated {
+		http.Error(writer, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.log.Error(err, "Unable to authenticate request")
+		http.Error(writer, "unable to authenticate request", http.StatusInternalServerError)
+		return
+	}
+
+	key, att, err := attester.FindAttester(h.attesterLister, body.Attester, body.Namespace)
+	if err == attester.ErrAttesterNotFound {
+		http.Error(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == attester.ErrAmbiguousAttester {
+		http.Error(writer, "attesters in several namespaces have the name, set the namespace", http.StatusConflict)
+		return
+	}
+	namespace := strings.SplitN(key, "/", 2)[0]
+
+	err = h.authorizer.Authorize(request.Context(), user, &authorizationv1.ResourceAttributes{
+		Namespace:   namespace,
+		Verb:        "create",
+		Group:       "rode.liatr.io",
+		Resource:    "attesters",
+		Subresource: "attestations",
+		Name:        body.Attester,
+	})
+	if _, ok := err.(auth.ForbiddenError); ok {
+		http.Error(writer, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		h.log.Error(err, "Unable to authorize request", "user", user.Username)
+		http.Error(writer, "unable to authorize request", http.StatusInternalServerError)
+		return
+	}
+
+	// the occurrences are decoded once the attester is found, since it decides their format
+	format := attester.PayloadFormatOf(att)
+	occurrences := make([]*grafeas.Occurrence, 0, len(body.Occurrences))
+	for i, raw := range body.Occurrences {
+		occ, err := attester.DecodeOccurrence(format, raw)
+		if err != nil {
+			http.Error(writer, fmt.Sprintf("invalid %s occurrence %d: %v", format, i, err), http.StatusBadRequest)
+			return
+		}
+		occurrences = append(occurrences, occ)
+	}
+
+	resp := &Response{
+		Attester:    key,
+		ResourceURI: body.ResourceURI,
+	}
+	status := http.StatusOK
+
+	res, err := att.Attest(request.Context(), &attester.AttestRequest{
+		ResourceURI: body.ResourceURI,
+		Occurrences: occurrences,
+	})
+	if violations, ok := err.(attester.ViolationError); ok {
+		for _, v := range violations.Violations {
+			resp.Violations = append(resp.Violations, v.Msg)
+		}
+		status = http.StatusUnprocessableEntity
+	} else if err != nil {
+		h.log.Error(err, "Unable to attest resource", "attester", key, "resourceUri", body.ResourceURI)
+		http.Error(writer, "unable to attest resource", http.StatusInternalServerError)
+		return
+	} else {
+		signed := res.Attestation.GetAttestation().GetAttestation().GetPgpSignedAttestation()
+		resp.Attested = true
+		resp.KeyID = signed.GetPgpKeyId()
+		resp.Signature = signed.GetSignature()
+	}
+
+	h.log.Info("Attested resource on request", "attester", key, "resourceUri", body.ResourceURI, "attested", resp.Attested, "user", user.Username)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(resp)
+}
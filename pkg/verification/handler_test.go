@@ -0,0 +1,182 @@
+package verification
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	discovery "github.com/grafeas/grafeas/proto/v1beta1/discovery_go_proto"
+	grafeas "github.com/grafeas/grafeas/proto/v1beta1/grafeas_go_proto"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	rodev1alpha1 "github.com/liatrio/rode/api/v1alpha1"
+	"github.com/liatrio/rode/controllers"
+	"github.com/liatrio/rode/pkg/attester"
+	"github.com/liatrio/rode/pkg/auth"
+)
+
+// fakeAuthorizer authenticates the "attester" token and allows it to create attestations in the default namespace
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Authenticate(req *http.Request) (*authenticationv1.UserInfo, error) {
+	if req.Header.Get("Authorization") != "Bearer attester" {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	return &authenticationv1.UserInfo{Username: "attester"}, nil
+}
+
+func (fakeAuthorizer) Authorize(ctx context.Context, user *authenticationv1.UserInfo, resource *authorizationv1.ResourceAttributes) error {
+	if resource.Namespace != "default" || resource.Verb != "create" || resource.Resource != "attesters" || resource.Subresource != "attestations" {
+		return auth.ForbiddenError{User: user.Username}
+	}
+
+	return nil
+}
+
+func newTestAttester(t *testing.T, key string, opts ...attester.AttesterOption) (attester.Attester, attester.Signer) {
+	policy, err := attester.NewPolicy(context.Background(), "verification", `
+package verification
+
+violation[{"msg":"analysis failed"}]{
+	input.occurrences[_].discovered.discovered.analysisStatus != "FINISHED_SUCCESS"
+}
+`, false)
+	assert.NoError(t, err)
+	signer, err := attester.NewSigner(key)
+	assert.NoError(t, err)
+
+	return attester.NewAttester(key, policy, signer, opts...), signer
+}
+
+func TestHandler(t *testing.T) {
+	verification, signer := newTestAttester(t, "default/verification")
+	other, _ := newTestAttester(t, "other/verification")
+	restricted, _ := newTestAttester(t, "restricted/restricted")
+	r := &controllers.AttesterReconciler{
+		Attesters: map[string]attester.Attester{
+			"default/verification":  verification,
+			"other/verification":    other,
+			"restricted/restricted": restricted,
+		},
+	}
+
+	server := httptest.NewServer(NewHandler(logf.NullLogger{}, r, fakeAuthorizer{}))
+	defer server.Close()
+
+	passing := `{"discovered":{"discovered":{"analysisStatus":"FINISHED_SUCCESS"}}}`
+	failing := `{"discovered":{"discovered":{"analysisStatus":"FINISHED_FAILED"}}}`
+	resource := "harbor.liatr.io/rode/app@sha256:b88ca0f4a7fe2c67fc8bdb67ad405f418ca0be6aec442c79ae32253138da2dd5"
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		token      string
+		status     int
+		violations []string
+	}{
+		{"attested", http.MethodPost, `{"attester":"verification","namespace":"default","resourceUri":"` + resource + `","occurrences":[` + passing + `]}`, "attester", http.StatusOK, nil},
+		{"rejected", http.MethodPost, `{"attester":"verification","namespace":"default","resourceUri":"` + resource + `","occurrences":[` + passing + `,` + failing + `]}`, "attester", http.StatusUnprocessableEntity, []string{"analysis failed"}},
+		{"unknown attester", http.MethodPost, `{"attester":"unknown","resourceUri":"` + resource + `"}`, "attester", http.StatusNotFound, nil},
+		{"ambiguous", http.MethodPost, `{"attester":"verification","resourceUri":"` + resource + `"}`, "attester", http.StatusConflict, nil},
+		{"forbidden", http.MethodPost, `{"attester":"restricted","resourceUri":"` + resource + `"}`, "attester", http.StatusForbidden, nil},
+		{"unauthenticated", http.MethodPost, `{"attester":"verification","namespace":"default","resourceUri":"` + resource + `"}`, "", http.StatusUnauthorized, nil},
+		{"missing resource", http.MethodPost, `{"attester":"verification","namespace":"default"}`, "attester", http.StatusBadRequest, nil},
+		{"invalid occurrence", http.MethodPost, `{"attester":"verification","namespace":"default","resourceUri":"` + resource + `","occurrences":[{"unknown":true}]}`, "attester", http.StatusBadRequest, nil},
+		{"get", http.MethodGet, "", "attester", http.StatusMethodNotAllowed, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			req, err := http.NewRequest(tc.method, server.URL+AttestPath, strings.NewReader(tc.body))
+			assert.NoError(err)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			assert.NoError(err)
+			defer res.Body.Close()
+			assert.Equal(tc.status, res.StatusCode)
+
+			if tc.status != http.StatusOK && tc.status != http.StatusUnprocessableEntity {
+				return
+			}
+
+			resp := &Response{}
+			assert.NoError(json.NewDecoder(res.Body).Decode(resp))
+			assert.Equal("default/verification", resp.Attester)
+			assert.Equal(resource, resp.ResourceURI)
+			assert.Equal(tc.violations, resp.Violations)
+			assert.Equal(tc.status == http.StatusOK, resp.Attested)
+
+			if resp.Attested {
+				assert.Equal(signer.KeyID(), resp.KeyID)
+				payload, err := signer.Verify(resp.Signature)
+				assert.NoError(err)
+				assert.Contains(payload, resource)
+			} else {
+				assert.Empty(resp.Signature)
+			}
+		})
+	}
+}
+
+func TestHandler_PayloadFormat(t *testing.T) {
+	verification, _ := newTestAttester(t, "default/verification", attester.WithPayloadFormat(rodev1alpha1.PayloadFormatProtobuf))
+	r := &controllers.AttesterReconciler{
+		Attesters: map[string]attester.Attester{"default/verification": verification},
+	}
+
+	server := httptest.NewServer(NewHandler(logf.NullLogger{}, r, fakeAuthorizer{}))
+	defer server.Close()
+
+	// the attester's occurrences are sent as base64 strings of their protobuf encoding
+	encode := func(status discovery.Discovered_AnalysisStatus) string {
+		encoded, err := proto.Marshal(&grafeas.Occurrence{
+			Details: &grafeas.Occurrence_Discovered{
+				Discovered: &discovery.Details{Discovered: &discovery.Discovered{AnalysisStatus: status}},
+			},
+		})
+		assert.NoError(t, err)
+		return `"` + base64.StdEncoding.EncodeToString(encoded) + `"`
+	}
+	resource := "harbor.liatr.io/rode/app@sha256:b88ca0f4a7fe2c67fc8bdb67ad405f418ca0be6aec442c79ae32253138da2dd5"
+
+	tests := []struct {
+		name        string
+		occurrences string
+		status      int
+	}{
+		{"attested", encode(discovery.Discovered_FINISHED_SUCCESS), http.StatusOK},
+		{"rejected", encode(discovery.Discovered_FINISHED_FAILED), http.StatusUnprocessableEntity},
+		{"json occurrence", `{"discovered":{"discovered":{"analysisStatus":"FINISHED_SUCCESS"}}}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			body := `{"attester":"verification","namespace":"default","resourceUri":"` + resource + `","occurrences":[` + tc.occurrences + `]}`
+			req, err := http.NewRequest(http.MethodPost, server.URL+AttestPath, strings.NewReader(body))
+			assert.NoError(err)
+			req.Header.Set("Authorization", "Bearer attester")
+
+			res, err := http.DefaultClient.Do(req)
+			assert.NoError(err)
+			defer res.Body.Close()
+			assert.Equal(tc.status, res.StatusCode)
+		})
+	}
+}